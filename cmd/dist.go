@@ -43,19 +43,27 @@ var (
 				// if force flag is false, use modification time of configuration file
 				configFileModTime = distgoConfigModTime()
 			}
-			return dist.Products(projectInfo, projectParam, configFileModTime, distgo.ToProductDistIDs(args), distDryRunFlagVal, cmd.OutOrStdout())
+			return dist.Products(projectInfo, projectParam, configFileModTime, distgo.ToProductDistIDs(args), dist.Options{
+				Parallel: distParallelFlagVal,
+				DryRun:   distDryRunFlagVal,
+				Explain:  distExplainFlagVal,
+			}, cmd.OutOrStdout())
 		},
 	}
 )
 
 var (
-	distDryRunFlagVal bool
-	distForceFlagVal  bool
+	distParallelFlagVal bool
+	distDryRunFlagVal   bool
+	distForceFlagVal    bool
+	distExplainFlagVal  bool
 )
 
 func init() {
+	distCmd.Flags().BoolVar(&distParallelFlagVal, "parallel", true, "create distributions in parallel")
 	distCmd.Flags().BoolVar(&distDryRunFlagVal, "dry-run", false, "print the operations that would be performed")
 	distCmd.Flags().BoolVar(&distForceFlagVal, "force", false, "create distribution outputs even if they are considered up-to-date")
+	distCmd.Flags().BoolVar(&distExplainFlagVal, "explain", false, "print the reason that products are built and distributions are created")
 
 	rootCmd.AddCommand(distCmd)
 }
@@ -0,0 +1,62 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v0
+
+import (
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+type Config struct {
+	// OSArchs specifies the GOOS and GOARCH pairs for which AppImages are created. The OS must be "linux". If blank,
+	// defaults to the GOOS and GOARCH of the host system at runtime.
+	OSArchs []osarch.OSArch `yaml:"os-archs,omitempty"`
+
+	// Runtimes maps an OS/Arch (for example, "linux-amd64") to the path of the AppImage runtime executable for that
+	// OS/Arch. The runtime is written at the start of the AppImage and is responsible for mounting the squashfs image
+	// that follows it and running "AppRun". A runtime must be specified for every OS/Arch in "os-archs". Relative paths
+	// are resolved relative to the project directory. The runtime is read from disk rather than downloaded, so it
+	// should be checked in or otherwise provided as part of the project.
+	Runtimes map[string]string `yaml:"runtimes,omitempty"`
+
+	// DesktopFile is the path to the desktop entry file for the application, which must have the extension ".desktop".
+	// The "Icon" key of its "[Desktop Entry]" group must be the name of the icon file without its extension. Relative
+	// paths are resolved relative to the project directory.
+	DesktopFile string `yaml:"desktop-file,omitempty"`
+
+	// Icon is the path to the icon for the application, which must have the extension ".png" or ".svg". The icon is
+	// written to the root of the AppImage along with a ".DirIcon" symlink to it. Relative paths are resolved relative
+	// to the project directory.
+	Icon string `yaml:"icon,omitempty"`
+
+	// Reproducible specifies that the modification time of all of the entries in the AppImage is set based on the
+	// SOURCE_DATE_EPOCH environment variable or the time of the HEAD commit of the project so that the AppImage is
+	// byte-for-byte identical for identical inputs. If false, the current time is used.
+	Reproducible bool `yaml:"reproducible,omitempty"`
+}
+
+func UpgradeConfig(cfgBytes []byte) ([]byte, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(cfgBytes, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal appimage dister v0 configuration")
+	}
+	return cfgBytes, nil
+}
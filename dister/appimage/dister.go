@@ -0,0 +1,291 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appimage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+const TypeName = "appimage" // distribution that consists of an AppImage for a specific OS/Architecture
+
+// elfMagic is the magic number at the start of every ELF file. The AppImage runtime must be an ELF executable.
+var elfMagic = []byte("\x7fELF")
+
+type Dister struct {
+	// OSArchs are the OS/Architectures for which AppImages are created. The OS of every entry must be "linux".
+	OSArchs []osarch.OSArch
+	// Runtimes maps the string representation of an OS/Arch to the path of the AppImage runtime executable for that
+	// OS/Arch. Relative paths are resolved relative to the project directory.
+	Runtimes map[string]string
+	// DesktopFile is the path to the desktop entry file of the application. Relative paths are resolved relative to the
+	// project directory.
+	DesktopFile string
+	// Icon is the path to the icon of the application. Relative paths are resolved relative to the project directory.
+	Icon string
+	// Reproducible specifies that the entries of the squashfs image use a fixed modification time.
+	Reproducible bool
+}
+
+func (d *Dister) TypeName() (string, error) {
+	return TypeName, nil
+}
+
+func (d *Dister) Artifacts(renderedName string) ([]string, error) {
+	var outPaths []string
+	for _, osArch := range d.OSArchs {
+		outPaths = append(outPaths, fmt.Sprintf("%s-%s.AppImage", renderedName, osArch.String()))
+	}
+	return outPaths, nil
+}
+
+func (d *Dister) PackagingExtension() (string, error) {
+	return "AppImage", nil
+}
+
+func (d *Dister) osArchFromArtifactPath(distID distgo.DistID, artifactPath string, productTaskOutputInfo distgo.ProductTaskOutputInfo) (osarch.OSArch, error) {
+	for _, osArch := range d.OSArchs {
+		if strings.HasSuffix(artifactPath, fmt.Sprintf("%s-%s.AppImage", productTaskOutputInfo.Product.DistOutputInfos.DistInfos[distID].DistNameTemplateRendered, osArch.String())) {
+			return osArch, nil
+		}
+	}
+	return osarch.OSArch{}, errors.Errorf("failed to determine OS/Arch for artifact with Path %s", artifactPath)
+}
+
+func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
+	projectDir := productTaskOutputInfo.Project.ProjectDir
+	for _, osArch := range d.OSArchs {
+		if osArch.OS != "linux" {
+			return nil, errors.Errorf("AppImages can only be created for linux, but OS/Arch %s was specified", osArch)
+		}
+		if _, err := d.runtime(projectDir, osArch); err != nil {
+			return nil, err
+		}
+		if err := disterutil.VerifyDistTargetSupported(osArch, productTaskOutputInfo); err != nil {
+			return nil, err
+		}
+	}
+	if err := verifyDesktopFile(resolvePath(projectDir, d.DesktopFile), resolvePath(projectDir, d.Icon)); err != nil {
+		return nil, err
+	}
+
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	outputPathsForOSArchs := make(map[string][]string)
+	for _, osArch := range d.OSArchs {
+		for _, currProductOutputInfo := range productTaskOutputInfo.AllProductOutputInfos() {
+			// copy executable for current product
+			dst, err := disterutil.CopyArtifactForOSArch(path.Join(distWorkDir, osArch.String()), productTaskOutputInfo.Project, currProductOutputInfo, osArch)
+			if err != nil {
+				return nil, err
+			}
+			outputPathsForOSArchs[osArch.String()] = append(outputPathsForOSArchs[osArch.String()], dst)
+		}
+	}
+	jsonBytes, err := json.Marshal(outputPathsForOSArchs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal outputPathsForOSArchs as JSON")
+	}
+	return jsonBytes, nil
+}
+
+func (d *Dister) GenerateDistArtifacts(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo, runDistResult []byte) error {
+	var outputPathsForOSArchs map[string][]string
+	if err := json.Unmarshal(runDistResult, &outputPathsForOSArchs); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal runDistResult JSON %s", string(runDistResult))
+	}
+
+	projectDir := productTaskOutputInfo.Project.ProjectDir
+	modTime := time.Now()
+	if d.Reproducible {
+		var err error
+		if modTime, err = distarchive.ReproducibleModTime(projectDir); err != nil {
+			return err
+		}
+	}
+
+	// all of the content of the dist work directory other than the per-OS/Arch executable directories is included at
+	// the root of the AppDir
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	rootFiles, err := disterutil.RootFiles(distWorkDir, d.OSArchs)
+	if err != nil {
+		return err
+	}
+	productExecutable := distgo.ExecutableName(productTaskOutputInfo.Product.BuildOutputInfo.BuildNameTemplateRendered, "linux")
+	for _, artifactPath := range productTaskOutputInfo.ProductDisterArtifactPaths()[distID] {
+		currOSArch, err := d.osArchFromArtifactPath(distID, artifactPath, productTaskOutputInfo)
+		if err != nil {
+			return err
+		}
+		runtimePath, err := d.runtime(projectDir, currOSArch)
+		if err != nil {
+			return err
+		}
+		appDir, err := d.appDir(projectDir, distWorkDir, rootFiles, outputPathsForOSArchs[currOSArch.String()], productExecutable)
+		if err != nil {
+			return err
+		}
+		if err := writeAppImage(artifactPath, runtimePath, appDir, modTime); err != nil {
+			return errors.Wrapf(err, "failed to create AppImage %s", artifactPath)
+		}
+	}
+	return nil
+}
+
+// appDir returns the root directory of the AppDir for an AppImage. The executables are placed in "usr/bin" and the
+// desktop file and icon are placed at the root of the AppDir along with the ".DirIcon" and "AppRun" symlinks. If the
+// dist work directory contains an "AppRun" file, it is used instead of the symlink to the executable of the product.
+func (d *Dister) appDir(projectDir, distWorkDir string, rootFiles, executablePaths []string, productExecutable string) (*squashfsNode, error) {
+	root := newSquashfsDir()
+	if err := root.add("AppRun", &squashfsNode{perm: 0777, target: path.Join("usr", "bin", productExecutable)}); err != nil {
+		return nil, err
+	}
+	iconName := path.Base(d.Icon)
+	if err := root.add(".DirIcon", &squashfsNode{perm: 0777, target: iconName}); err != nil {
+		return nil, err
+	}
+	if err := root.addPath(resolvePath(projectDir, d.DesktopFile), path.Base(d.DesktopFile)); err != nil {
+		return nil, err
+	}
+	if err := root.addPath(resolvePath(projectDir, d.Icon), iconName); err != nil {
+		return nil, err
+	}
+	for _, executablePath := range executablePaths {
+		if err := root.add(path.Join("usr", "bin", path.Base(executablePath)), &squashfsNode{perm: 0755, srcPath: executablePath}); err != nil {
+			return nil, err
+		}
+	}
+	for _, rootFile := range rootFiles {
+		if err := root.addPath(path.Join(distWorkDir, rootFile), rootFile); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// runtime returns the path to the AppImage runtime for the provided OS/Arch. Returns an error if no runtime is
+// configured for the OS/Arch or if the runtime is not an ELF file.
+func (d *Dister) runtime(projectDir string, osArch osarch.OSArch) (string, error) {
+	runtimePath, ok := d.Runtimes[osArch.String()]
+	if !ok || runtimePath == "" {
+		return "", errors.Errorf("no AppImage runtime is configured for OS/Arch %s", osArch)
+	}
+	runtimePath = resolvePath(projectDir, runtimePath)
+	f, err := os.Open(runtimePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open AppImage runtime for OS/Arch %s", osArch)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	magic := make([]byte, len(elfMagic))
+	if _, err := io.ReadFull(f, magic); err != nil || !bytes.Equal(magic, elfMagic) {
+		return "", errors.Errorf("AppImage runtime %s for OS/Arch %s is not an ELF executable", runtimePath, osArch)
+	}
+	return runtimePath, nil
+}
+
+// writeAppImage writes an AppImage that consists of the runtime followed by a squashfs image of the AppDir to the
+// provided path.
+func writeAppImage(artifactPath, runtimePath string, appDir *squashfsNode, modTime time.Time) (rErr error) {
+	runtime, err := os.Open(runtimePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open AppImage runtime")
+	}
+	defer func() {
+		_ = runtime.Close()
+	}()
+	f, err := os.OpenFile(artifactPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create file")
+	}
+	defer func() {
+		if err := f.Close(); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "failed to close file")
+		}
+	}()
+	if _, err := io.Copy(f, runtime); err != nil {
+		return errors.Wrapf(err, "failed to write AppImage runtime")
+	}
+	if err := writeSquashfs(f, appDir, modTime); err != nil {
+		return err
+	}
+	// ensure that the AppImage is executable even if the file already existed
+	return f.Chmod(0755)
+}
+
+// verifyDesktopFile verifies that the desktop file has a "[Desktop Entry]" group whose "Icon" key refers to the icon,
+// which is required for the AppImage to be integrated into desktop environments.
+func verifyDesktopFile(desktopFile, icon string) error {
+	if desktopFile == "" || !strings.HasSuffix(desktopFile, ".desktop") {
+		return errors.Errorf("a desktop file with the extension .desktop must be specified, but was %q", desktopFile)
+	}
+	iconExt := filepath.Ext(icon)
+	if iconExt != ".png" && iconExt != ".svg" {
+		return errors.Errorf("an icon with the extension .png or .svg must be specified, but was %q", icon)
+	}
+	if _, err := os.Stat(icon); err != nil {
+		return errors.Wrapf(err, "failed to stat icon")
+	}
+	content, err := ioutil.ReadFile(desktopFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read desktop file")
+	}
+	wantIcon := strings.TrimSuffix(filepath.Base(icon), iconExt)
+	inDesktopEntry := false
+	var icons []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inDesktopEntry = line == "[Desktop Entry]"
+			continue
+		}
+		if inDesktopEntry && strings.HasPrefix(line, "Icon=") {
+			icons = append(icons, strings.TrimPrefix(line, "Icon="))
+		}
+	}
+	if len(icons) != 1 || icons[0] != wantIcon {
+		return errors.Errorf("desktop file %s must specify Icon=%s in its [Desktop Entry] group to match the icon %s, but specified %v", desktopFile, wantIcon, filepath.Base(icon), icons)
+	}
+	return nil
+}
+
+func resolvePath(projectDir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(projectDir, p)
+}
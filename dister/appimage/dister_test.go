@@ -0,0 +1,79 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appimage
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyDesktopFile(t *testing.T) {
+	tmpDir, cleanup, err := dirs.TempDir("", "")
+	require.NoError(t, err)
+	defer cleanup()
+
+	icon := path.Join(tmpDir, "foo.png")
+	require.NoError(t, ioutil.WriteFile(icon, []byte("png"), 0644))
+
+	for i, tc := range []struct {
+		name      string
+		content   string
+		icon      string
+		wantError string
+	}{
+		{
+			name:    "valid desktop file",
+			content: "[Desktop Entry]\nName=Foo\nIcon=foo\n",
+			icon:    icon,
+		},
+		{
+			name:      "icon in other group is ignored",
+			content:   "[Desktop Entry]\nName=Foo\n[Desktop Action New]\nIcon=foo\n",
+			icon:      icon,
+			wantError: "desktop file " + path.Join(tmpDir, "foo.desktop") + " must specify Icon=foo in its [Desktop Entry] group to match the icon foo.png, but specified []",
+		},
+		{
+			name:      "icon must match",
+			content:   "[Desktop Entry]\nName=Foo\nIcon=bar\n",
+			icon:      icon,
+			wantError: "desktop file " + path.Join(tmpDir, "foo.desktop") + " must specify Icon=foo in its [Desktop Entry] group to match the icon foo.png, but specified [bar]",
+		},
+		{
+			name:      "icon extension must be supported",
+			content:   "[Desktop Entry]\nName=Foo\nIcon=foo\n",
+			icon:      path.Join(tmpDir, "foo.ico"),
+			wantError: `an icon with the extension .png or .svg must be specified, but was "` + path.Join(tmpDir, "foo.ico") + `"`,
+		},
+	} {
+		desktopFile := path.Join(tmpDir, "foo.desktop")
+		require.NoError(t, ioutil.WriteFile(desktopFile, []byte(tc.content), 0644), "Case %d: %s", i, tc.name)
+		err := verifyDesktopFile(desktopFile, tc.icon)
+		if tc.wantError == "" {
+			assert.NoError(t, err, "Case %d: %s", i, tc.name)
+		} else {
+			assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+		}
+	}
+}
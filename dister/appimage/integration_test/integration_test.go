@@ -0,0 +1,216 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration_test
+
+import (
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/gofiles"
+	"github.com/palantir/godel/framework/pluginapitester"
+	"github.com/palantir/godel/pkg/products/v2/products"
+	"github.com/palantir/pkg/specdir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distertester"
+)
+
+func TestAppImageDist(t *testing.T) {
+	const godelYML = `exclude:
+  names:
+    - "\\..+"
+    - "vendor"
+  paths:
+    - "godel"
+`
+
+	pluginPath, err := products.Bin("dist-plugin")
+	require.NoError(t, err)
+
+	distertester.RunAssetDistTest(t,
+		pluginapitester.NewPluginProvider(pluginPath),
+		nil,
+		[]distertester.TestCase{
+			{
+				Name: "appimage creates expected output",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+					{
+						RelPath: "appimage/runtime-x86_64",
+						Src:     "\x7fELF runtime",
+					},
+					{
+						RelPath: "appimage/foo.desktop",
+						Src: `[Desktop Entry]
+Type=Application
+Name=Foo
+Exec=foo
+Icon=foo
+Categories=Utility;
+`,
+					},
+					{
+						RelPath: "appimage/foo.svg",
+						Src:     `<svg xmlns="http://www.w3.org/2000/svg"/>`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: appimage
+        config:
+          os-archs:
+            - os: linux
+              arch: amd64
+          runtimes:
+            linux-amd64: appimage/runtime-x86_64
+          desktop-file: appimage/foo.desktop
+          icon: appimage/foo.svg
+`,
+				},
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/appimage/foo-1.0.0-linux-amd64.AppImage
+Finished creating appimage distribution for foo
+`
+				},
+				Validate: func(projectDir string) {
+					wantLayout := specdir.NewLayoutSpec(
+						specdir.Dir(specdir.LiteralName("1.0.0"), "",
+							specdir.Dir(specdir.LiteralName("appimage"), "",
+								specdir.Dir(specdir.LiteralName("foo-1.0.0"), "",
+									specdir.Dir(specdir.LiteralName("linux-amd64"), "",
+										specdir.File(specdir.LiteralName("foo"), ""),
+									),
+								),
+								specdir.File(specdir.LiteralName("foo-1.0.0-linux-amd64.AppImage"), ""),
+							),
+						), true,
+					)
+					assert.NoError(t, wantLayout.Validate(path.Join(projectDir, "out", "dist", "foo", "1.0.0"), nil))
+				},
+			},
+			{
+				Name: "appimage fails if no runtime is configured for OS/Arch",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: appimage
+        config:
+          os-archs:
+            - os: linux
+              arch: amd64
+          desktop-file: foo.desktop
+          icon: foo.png
+`,
+				},
+				WantError: true,
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/appimage/foo-1.0.0-linux-amd64.AppImage
+Error: dist failed for foo: no AppImage runtime is configured for OS/Arch linux-amd64
+`
+				},
+			},
+		},
+	)
+}
+
+func TestAppImageUpgradeConfig(t *testing.T) {
+	pluginPath, err := products.Bin("dist-plugin")
+	require.NoError(t, err)
+
+	pluginapitester.RunUpgradeConfigTest(t,
+		pluginapitester.NewPluginProvider(pluginPath),
+		nil,
+		[]pluginapitester.UpgradeConfigTestCase{
+			{
+				Name: `valid v0 config works`,
+				ConfigFiles: map[string]string{
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: appimage
+        config:
+          # comment
+          runtimes:
+            linux-amd64: appimage/runtime-x86_64
+          desktop-file: appimage/foo.desktop
+          icon: appimage/foo.svg
+`,
+				},
+				WantOutput: ``,
+				WantFiles: map[string]string{
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: appimage
+        config:
+          # comment
+          runtimes:
+            linux-amd64: appimage/runtime-x86_64
+          desktop-file: appimage/foo.desktop
+          icon: appimage/foo.svg
+`,
+				},
+			},
+		},
+	)
+}
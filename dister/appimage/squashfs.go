@@ -0,0 +1,540 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appimage
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The squashfs image is written in the squashfs 4.0 format with gzip (zlib) compression, which is supported by the
+// AppImage runtime. Only the subset of the format required for an AppDir is written: basic directory, file and symlink
+// inodes, no fragments (the last block of a file is a partial block), no extended attributes, no export table and all
+// entries are owned by uid/gid 0.
+const (
+	squashfsMagic          = 0x73717368
+	squashfsSuperblockSize = 96
+	squashfsBlockSize      = 1 << 17
+	squashfsBlockLog       = 17
+	squashfsMetadataSize   = 8192
+	squashfsPadding        = 4096
+
+	squashfsCompressionGzip = 1
+	squashfsFlagNoFragments = 0x0010
+	squashfsFlagNoXattrs    = 0x0200
+
+	squashfsUncompressedData     = 1 << 24
+	squashfsUncompressedMetadata = 1 << 15
+	squashfsInvalidTable         = 0xFFFFFFFFFFFFFFFF
+	squashfsInvalidFragment      = 0xFFFFFFFF
+
+	squashfsDirType     = 1
+	squashfsFileType    = 2
+	squashfsSymlinkType = 3
+
+	// squashfsMaxDirHeaderEntries is the maximum number of entries that can follow a single directory header.
+	squashfsMaxDirHeaderEntries = 256
+	// squashfsMaxNameLen is the maximum length of the name of an entry.
+	squashfsMaxNameLen = 256
+)
+
+// squashfsNode is a directory, regular file or symlink in a squashfs image.
+type squashfsNode struct {
+	perm     os.FileMode
+	children map[string]*squashfsNode // non-nil for directories
+	srcPath  string                   // path of the content of a regular file
+	target   string                   // target of a symlink
+
+	inodeNumber uint32
+	inodeRef    uint64
+	blocksStart uint64
+	blockSizes  []uint32
+	fileSize    uint64
+}
+
+func newSquashfsDir() *squashfsNode {
+	return &squashfsNode{
+		perm:     0755,
+		children: make(map[string]*squashfsNode),
+	}
+}
+
+func (n *squashfsNode) isDir() bool {
+	return n.children != nil
+}
+
+func (n *squashfsNode) inodeType() uint16 {
+	switch {
+	case n.isDir():
+		return squashfsDirType
+	case n.srcPath == "":
+		return squashfsSymlinkType
+	default:
+		return squashfsFileType
+	}
+}
+
+func (n *squashfsNode) sortedNames() []string {
+	var names []string
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// add adds the provided node at the provided slash-separated path relative to the receiver, which must be a directory.
+// Parent directories are created as needed and an existing node at the path is replaced.
+func (n *squashfsNode) add(relPath string, node *squashfsNode) error {
+	parts := strings.Split(path.Clean(relPath), "/")
+	dir := n
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := dir.children[part]
+		if !ok {
+			child = newSquashfsDir()
+			dir.children[part] = child
+		}
+		if !child.isDir() {
+			return errors.Errorf("cannot add %s: %s is not a directory", relPath, part)
+		}
+		dir = child
+	}
+	name := parts[len(parts)-1]
+	if len(name) > squashfsMaxNameLen {
+		return errors.Errorf("cannot add %s: name %s is longer than %d bytes", relPath, name, squashfsMaxNameLen)
+	}
+	if existing, ok := dir.children[name]; ok && existing.isDir() && node.isDir() {
+		// merge directories
+		existing.perm = node.perm
+		for childName, child := range node.children {
+			existing.children[childName] = child
+		}
+		return nil
+	}
+	dir.children[name] = node
+	return nil
+}
+
+// addPath adds the file, directory or symlink at srcPath at the provided path relative to the receiver. Directories are
+// added recursively and symlinks are not followed.
+func (n *squashfsNode) addPath(srcPath, relPath string) error {
+	fi, err := os.Lstat(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", srcPath)
+	}
+	switch {
+	case fi.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(srcPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read symlink %s", srcPath)
+		}
+		return n.add(relPath, &squashfsNode{perm: 0777, target: target})
+	case fi.IsDir():
+		dir := newSquashfsDir()
+		dir.perm = fi.Mode().Perm()
+		if err := n.add(relPath, dir); err != nil {
+			return err
+		}
+		fis, err := readDirNames(srcPath)
+		if err != nil {
+			return err
+		}
+		for _, name := range fis {
+			if err := n.addPath(filepath.Join(srcPath, name), path.Join(relPath, name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case fi.Mode().IsRegular():
+		return n.add(relPath, &squashfsNode{perm: fi.Mode().Perm(), srcPath: srcPath})
+	default:
+		return errors.Errorf("%s is not a regular file, directory or symlink", srcPath)
+	}
+}
+
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", dir)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list files in %s", dir)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// writeSquashfs writes a squashfs image of the provided root directory to f starting at the current offset of f. All of
+// the offsets in the image are relative to the start of the image, so the image can be appended to other content such
+// as the AppImage runtime.
+func writeSquashfs(f *os.File, root *squashfsNode, modTime time.Time) error {
+	base, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine offset of squashfs image")
+	}
+	w := &squashfsWriter{
+		w:       f,
+		modTime: uint32(modTime.Unix()),
+	}
+	// the superblock is written last because it contains the locations of the tables
+	if err := w.write(make([]byte, squashfsSuperblockSize)); err != nil {
+		return err
+	}
+	if err := w.writeData(root); err != nil {
+		return err
+	}
+
+	var inodeCount uint32
+	numberInodes(root, &inodeCount)
+	inodes := &squashfsMetadataWriter{}
+	dirs := &squashfsMetadataWriter{}
+	if err := w.writeDirInodes(root, inodeCount+1, inodes, dirs); err != nil {
+		return err
+	}
+
+	inodeTableStart := w.pos
+	if err := w.writeMetadata(inodes); err != nil {
+		return err
+	}
+	dirTableStart := w.pos
+	if err := w.writeMetadata(dirs); err != nil {
+		return err
+	}
+	// there are no fragments, so the fragment table is empty
+	fragmentTableStart := w.pos
+
+	// the ID table contains the single ID 0, which is used as the owner and group of all entries
+	ids := &squashfsMetadataWriter{}
+	if err := ids.write(make([]byte, 4)); err != nil {
+		return err
+	}
+	idBlockStart := w.pos
+	if err := w.writeMetadata(ids); err != nil {
+		return err
+	}
+	idTableStart := w.pos
+	if err := w.writeLE(idBlockStart); err != nil {
+		return err
+	}
+	bytesUsed := w.pos
+	if padding := bytesUsed % squashfsPadding; padding != 0 {
+		if err := w.write(make([]byte, squashfsPadding-padding)); err != nil {
+			return err
+		}
+	}
+
+	superblock := &bytes.Buffer{}
+	for _, v := range []interface{}{
+		uint32(squashfsMagic),
+		inodeCount,
+		w.modTime,
+		uint32(squashfsBlockSize),
+		uint32(0), // fragment count
+		uint16(squashfsCompressionGzip),
+		uint16(squashfsBlockLog),
+		uint16(squashfsFlagNoFragments | squashfsFlagNoXattrs),
+		uint16(1), // ID count
+		uint16(4), // major version
+		uint16(0), // minor version
+		root.inodeRef,
+		bytesUsed,
+		idTableStart,
+		uint64(squashfsInvalidTable), // xattr ID table
+		inodeTableStart,
+		dirTableStart,
+		fragmentTableStart,
+		uint64(squashfsInvalidTable), // export table
+	} {
+		_ = binary.Write(superblock, binary.LittleEndian, v)
+	}
+	if _, err := f.WriteAt(superblock.Bytes(), base); err != nil {
+		return errors.Wrapf(err, "failed to write squashfs superblock")
+	}
+	return nil
+}
+
+type squashfsWriter struct {
+	w       io.Writer
+	pos     uint64
+	modTime uint32
+}
+
+func (w *squashfsWriter) write(b []byte) error {
+	if _, err := w.w.Write(b); err != nil {
+		return errors.Wrapf(err, "failed to write squashfs image")
+	}
+	w.pos += uint64(len(b))
+	return nil
+}
+
+func (w *squashfsWriter) writeLE(v interface{}) error {
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, binary.LittleEndian, v)
+	return w.write(buf.Bytes())
+}
+
+func (w *squashfsWriter) writeMetadata(m *squashfsMetadataWriter) error {
+	b, err := m.bytes()
+	if err != nil {
+		return err
+	}
+	return w.write(b)
+}
+
+// writeData writes the data blocks of all of the regular files in the provided directory.
+func (w *squashfsWriter) writeData(dir *squashfsNode) error {
+	for _, name := range dir.sortedNames() {
+		child := dir.children[name]
+		switch child.inodeType() {
+		case squashfsDirType:
+			if err := w.writeData(child); err != nil {
+				return err
+			}
+		case squashfsFileType:
+			if err := w.writeFileData(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *squashfsWriter) writeFileData(file *squashfsNode) error {
+	f, err := os.Open(file.srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", file.srcPath)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	file.blocksStart = w.pos
+	buf := make([]byte, squashfsBlockSize)
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			block, compressed, cErr := compressSquashfsBlock(buf[:n])
+			if cErr != nil {
+				return cErr
+			}
+			size := uint32(len(block))
+			if !compressed {
+				size |= squashfsUncompressedData
+			}
+			if wErr := w.write(block); wErr != nil {
+				return wErr
+			}
+			file.blockSizes = append(file.blockSizes, size)
+			file.fileSize += uint64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", file.srcPath)
+		}
+	}
+	if file.blocksStart > 0xFFFFFFFF || file.fileSize > 0xFFFFFFFF {
+		return errors.Errorf("%s cannot be added to the squashfs image because the image would exceed 4 GiB", file.srcPath)
+	}
+	return nil
+}
+
+// numberInodes assigns inode numbers to the entries of the provided directory and to the directory itself in the order
+// in which their inodes are written: the entries of a directory are numbered before the directory.
+func numberInodes(dir *squashfsNode, count *uint32) {
+	for _, name := range dir.sortedNames() {
+		child := dir.children[name]
+		if child.isDir() {
+			numberInodes(child, count)
+			continue
+		}
+		*count++
+		child.inodeNumber = *count
+	}
+	*count++
+	dir.inodeNumber = *count
+}
+
+// writeDirInodes writes the inodes of the entries of the provided directory (recursively), the directory listing of
+// the directory and the inode of the directory itself.
+func (w *squashfsWriter) writeDirInodes(dir *squashfsNode, parentInodeNumber uint32, inodes, dirs *squashfsMetadataWriter) error {
+	subdirs := uint32(0)
+	for _, name := range dir.sortedNames() {
+		child := dir.children[name]
+		if child.isDir() {
+			subdirs++
+			if err := w.writeDirInodes(child, dir.inodeNumber, inodes, dirs); err != nil {
+				return err
+			}
+			continue
+		}
+		child.inodeRef = inodes.ref()
+		inode := w.inodeHeader(child)
+		if child.inodeType() == squashfsFileType {
+			writeLE(inode, uint32(child.blocksStart), uint32(squashfsInvalidFragment), uint32(0), uint32(child.fileSize), child.blockSizes)
+		} else {
+			writeLE(inode, uint32(1), uint32(len(child.target)))
+			inode.WriteString(child.target)
+		}
+		if err := inodes.write(inode.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	listingBlock, listingOffset := dirs.position()
+	listing := squashfsDirListing(dir)
+	if len(listing)+3 > 0xFFFF {
+		return errors.Errorf("directory with %d entries is too large for the squashfs image", len(dir.children))
+	}
+	if err := dirs.write(listing); err != nil {
+		return err
+	}
+	dir.inodeRef = inodes.ref()
+	inode := w.inodeHeader(dir)
+	writeLE(inode, listingBlock, 2+subdirs, uint16(len(listing)+3), listingOffset, parentInodeNumber)
+	return inodes.write(inode.Bytes())
+}
+
+func (w *squashfsWriter) inodeHeader(node *squashfsNode) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	// the uid and gid indexes refer to the single entry in the ID table
+	writeLE(buf, node.inodeType(), uint16(node.perm.Perm()), uint16(0), uint16(0), w.modTime, node.inodeNumber)
+	return buf
+}
+
+// squashfsDirListing returns the directory listing for the provided directory. The entries are sorted by name and are
+// grouped under headers that share the metadata block of the inodes of the entries.
+func squashfsDirListing(dir *squashfsNode) []byte {
+	names := dir.sortedNames()
+	buf := &bytes.Buffer{}
+	for i := 0; i < len(names); {
+		first := dir.children[names[i]]
+		start := uint32(first.inodeRef >> 16)
+		baseInodeNumber := first.inodeNumber
+		j := i
+		for ; j < len(names) && j-i < squashfsMaxDirHeaderEntries; j++ {
+			child := dir.children[names[j]]
+			diff := int64(child.inodeNumber) - int64(baseInodeNumber)
+			if uint32(child.inodeRef>>16) != start || diff < -32768 || diff > 32767 {
+				break
+			}
+		}
+		writeLE(buf, uint32(j-i-1), start, baseInodeNumber)
+		for _, name := range names[i:j] {
+			child := dir.children[name]
+			writeLE(buf, uint16(child.inodeRef&0xFFFF), int16(int64(child.inodeNumber)-int64(baseInodeNumber)), child.inodeType(), uint16(len(name)-1))
+			buf.WriteString(name)
+		}
+		i = j
+	}
+	return buf.Bytes()
+}
+
+func writeLE(buf *bytes.Buffer, vals ...interface{}) {
+	for _, v := range vals {
+		_ = binary.Write(buf, binary.LittleEndian, v)
+	}
+}
+
+// squashfsMetadataWriter writes a metadata table, which consists of blocks that each contain up to 8 KiB of data and
+// are preceded by a 2-byte header that specifies the size of the block and whether it is compressed.
+type squashfsMetadataWriter struct {
+	out bytes.Buffer
+	buf []byte
+}
+
+// position returns the offset of the current block relative to the start of the table and the offset within the
+// uncompressed current block.
+func (m *squashfsMetadataWriter) position() (uint32, uint16) {
+	return uint32(m.out.Len()), uint16(len(m.buf))
+}
+
+// ref returns the reference to the current position, which is used to locate inodes.
+func (m *squashfsMetadataWriter) ref() uint64 {
+	block, offset := m.position()
+	return uint64(block)<<16 | uint64(offset)
+}
+
+func (m *squashfsMetadataWriter) write(b []byte) error {
+	m.buf = append(m.buf, b...)
+	for len(m.buf) >= squashfsMetadataSize {
+		if err := m.flush(m.buf[:squashfsMetadataSize]); err != nil {
+			return err
+		}
+		m.buf = m.buf[squashfsMetadataSize:]
+	}
+	return nil
+}
+
+func (m *squashfsMetadataWriter) flush(block []byte) error {
+	data, compressed, err := compressSquashfsBlock(block)
+	if err != nil {
+		return err
+	}
+	header := uint16(len(data))
+	if !compressed {
+		header |= squashfsUncompressedMetadata
+	}
+	_ = binary.Write(&m.out, binary.LittleEndian, header)
+	m.out.Write(data)
+	return nil
+}
+
+func (m *squashfsMetadataWriter) bytes() ([]byte, error) {
+	if len(m.buf) > 0 {
+		if err := m.flush(m.buf); err != nil {
+			return nil, err
+		}
+		m.buf = nil
+	}
+	return m.out.Bytes(), nil
+}
+
+// compressSquashfsBlock returns the zlib-compressed content of the provided block and true if compression makes the
+// block smaller. Otherwise, returns the block itself and false.
+func compressSquashfsBlock(block []byte) ([]byte, bool, error) {
+	buf := &bytes.Buffer{}
+	zw := zlib.NewWriter(buf)
+	if _, err := zw.Write(block); err != nil {
+		return nil, false, errors.Wrapf(err, "failed to compress squashfs block")
+	}
+	if err := zw.Close(); err != nil {
+		return nil, false, errors.Wrapf(err, "failed to compress squashfs block")
+	}
+	if buf.Len() >= len(block) {
+		return block, false, nil
+	}
+	return buf.Bytes(), true, nil
+}
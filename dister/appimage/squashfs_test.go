@@ -0,0 +1,292 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appimage
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSquashfs(t *testing.T) {
+	tmpDir, cleanup, err := dirs.TempDir("", "")
+	require.NoError(t, err)
+	defer cleanup()
+
+	largeContent := make([]byte, squashfsBlockSize*2+1234)
+	for i := range largeContent {
+		largeContent[i] = byte(i * 7 % 251)
+	}
+	srcDir := path.Join(tmpDir, "src")
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "share", "empty"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "share", "large.bin"), largeContent, 0644))
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "share", "empty.txt"), nil, 0600))
+	require.NoError(t, os.Symlink("large.bin", path.Join(srcDir, "share", "link")))
+	manyDir := path.Join(srcDir, "many")
+	require.NoError(t, os.MkdirAll(manyDir, 0755))
+	for i := 0; i < 600; i++ {
+		require.NoError(t, ioutil.WriteFile(path.Join(manyDir, fmt.Sprintf("file-%03d.txt", i)), []byte(fmt.Sprintf("content %d", i)), 0644))
+	}
+	exe := path.Join(tmpDir, "foo")
+	require.NoError(t, ioutil.WriteFile(exe, []byte("#!/bin/sh\necho foo\n"), 0644))
+
+	root := newSquashfsDir()
+	require.NoError(t, root.addPath(srcDir, "."))
+	require.NoError(t, root.add("usr/bin/foo", &squashfsNode{perm: 0755, srcPath: exe}))
+	require.NoError(t, root.add("AppRun", &squashfsNode{perm: 0777, target: "usr/bin/foo"}))
+
+	runtime := []byte("\x7fELF runtime")
+	imagePath := path.Join(tmpDir, "image")
+	f, err := os.Create(imagePath)
+	require.NoError(t, err)
+	_, err = f.Write(runtime)
+	require.NoError(t, err)
+	modTime := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, writeSquashfs(f, root, modTime))
+	require.NoError(t, f.Close())
+
+	content, err := ioutil.ReadFile(imagePath)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(content, runtime))
+	img := content[len(runtime):]
+	assert.Equal(t, 0, len(img)%squashfsPadding)
+
+	r := newTestSquashfsReader(t, img)
+	assert.Equal(t, uint32(modTime.Unix()), r.sb.ModTime)
+	assert.Equal(t, uint32(612), r.sb.InodeCount)
+
+	files := make(map[string]testSquashfsInode)
+	r.walk(r.inode(r.sb.RootInode), "", files)
+
+	assert.Equal(t, uint16(squashfsSymlinkType), files["AppRun"].inodeType)
+	assert.Equal(t, "usr/bin/foo", files["AppRun"].target)
+	assert.Equal(t, uint16(squashfsFileType), files["usr/bin/foo"].inodeType)
+	assert.Equal(t, uint16(0755), files["usr/bin/foo"].perm)
+	assert.Equal(t, "#!/bin/sh\necho foo\n", string(files["usr/bin/foo"].content))
+	assert.Equal(t, largeContent, files["share/large.bin"].content)
+	assert.Len(t, files["share/large.bin"].blockSizes, 3)
+	assert.Equal(t, uint16(0600), files["share/empty.txt"].perm)
+	assert.Empty(t, files["share/empty.txt"].content)
+	assert.Equal(t, "large.bin", files["share/link"].target)
+	assert.Equal(t, uint16(squashfsDirType), files["share/empty"].inodeType)
+	for i := 0; i < 600; i++ {
+		assert.Equal(t, fmt.Sprintf("content %d", i), string(files[fmt.Sprintf("many/file-%03d.txt", i)].content))
+	}
+	assert.Len(t, files, 611)
+}
+
+func TestAddPathMergesDirectories(t *testing.T) {
+	tmpDir, cleanup, err := dirs.TempDir("", "")
+	require.NoError(t, err)
+	defer cleanup()
+
+	require.NoError(t, os.MkdirAll(path.Join(tmpDir, "usr", "share"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "usr", "share", "foo.txt"), []byte("foo"), 0644))
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "AppRun"), []byte("#!/bin/sh"), 0755))
+
+	root := newSquashfsDir()
+	require.NoError(t, root.add("usr/bin/foo", &squashfsNode{perm: 0755, srcPath: "foo"}))
+	require.NoError(t, root.add("AppRun", &squashfsNode{perm: 0777, target: "usr/bin/foo"}))
+	require.NoError(t, root.addPath(path.Join(tmpDir, "usr"), "usr"))
+	require.NoError(t, root.addPath(path.Join(tmpDir, "AppRun"), "AppRun"))
+
+	assert.Equal(t, []string{"bin", "share"}, root.children["usr"].sortedNames())
+	assert.Equal(t, filepath.Join(tmpDir, "AppRun"), root.children["AppRun"].srcPath)
+
+	err = root.add("AppRun/foo", &squashfsNode{perm: 0644, srcPath: "foo"})
+	assert.EqualError(t, err, "cannot add AppRun/foo: AppRun is not a directory")
+}
+
+type testSquashfsSuperblock struct {
+	Magic              uint32
+	InodeCount         uint32
+	ModTime            uint32
+	BlockSize          uint32
+	FragmentCount      uint32
+	Compression        uint16
+	BlockLog           uint16
+	Flags              uint16
+	IDCount            uint16
+	VersionMajor       uint16
+	VersionMinor       uint16
+	RootInode          uint64
+	BytesUsed          uint64
+	IDTableStart       uint64
+	XattrTableStart    uint64
+	InodeTableStart    uint64
+	DirTableStart      uint64
+	FragmentTableStart uint64
+	ExportTableStart   uint64
+}
+
+type testSquashfsInode struct {
+	inodeType   uint16
+	perm        uint16
+	inodeNumber uint32
+	target      string
+	content     []byte
+	blockSizes  []uint32
+
+	dirBlock  uint32
+	dirOffset uint16
+	dirSize   uint16
+}
+
+// testSquashfsReader is a minimal squashfs reader that supports the subset of the format written by writeSquashfs.
+type testSquashfsReader struct {
+	t      *testing.T
+	img    []byte
+	sb     testSquashfsSuperblock
+	inodes metadataTable
+	dirs   metadataTable
+}
+
+// metadataTable is the uncompressed content of a metadata table along with the offset within the uncompressed content
+// of every block (keyed by the offset of the block relative to the start of the table).
+type metadataTable struct {
+	data    []byte
+	offsets map[uint32]int
+}
+
+func newTestSquashfsReader(t *testing.T, img []byte) *testSquashfsReader {
+	r := &testSquashfsReader{t: t, img: img}
+	require.NoError(t, binary.Read(bytes.NewReader(img), binary.LittleEndian, &r.sb))
+	require.Equal(t, uint32(squashfsMagic), r.sb.Magic)
+	require.Equal(t, uint16(4), r.sb.VersionMajor)
+	require.Equal(t, uint32(squashfsBlockSize), r.sb.BlockSize)
+	require.True(t, r.sb.InodeTableStart < r.sb.DirTableStart)
+	require.True(t, r.sb.DirTableStart <= r.sb.FragmentTableStart)
+	require.True(t, r.sb.IDTableStart+8 == r.sb.BytesUsed)
+
+	idBlockStart := binary.LittleEndian.Uint64(img[r.sb.IDTableStart:])
+	ids := r.readMetadata(idBlockStart, r.sb.IDTableStart)
+	assert.Equal(t, []byte{0, 0, 0, 0}, ids.data)
+
+	r.inodes = r.readMetadata(r.sb.InodeTableStart, r.sb.DirTableStart)
+	r.dirs = r.readMetadata(r.sb.DirTableStart, r.sb.FragmentTableStart)
+	return r
+}
+
+func (r *testSquashfsReader) readMetadata(start, end uint64) metadataTable {
+	table := metadataTable{offsets: make(map[uint32]int)}
+	for pos := start; pos < end; {
+		header := binary.LittleEndian.Uint16(r.img[pos:])
+		size := uint64(header &^ squashfsUncompressedMetadata)
+		block := r.img[pos+2 : pos+2+size]
+		if header&squashfsUncompressedMetadata == 0 {
+			block = r.decompress(block)
+		}
+		table.offsets[uint32(pos-start)] = len(table.data)
+		table.data = append(table.data, block...)
+		pos += 2 + size
+	}
+	return table
+}
+
+func (r *testSquashfsReader) decompress(b []byte) []byte {
+	zr, err := zlib.NewReader(bytes.NewReader(b))
+	require.NoError(r.t, err)
+	out, err := ioutil.ReadAll(zr)
+	require.NoError(r.t, err)
+	return out
+}
+
+func (r *testSquashfsReader) inode(ref uint64) testSquashfsInode {
+	start, ok := r.inodes.offsets[uint32(ref>>16)]
+	require.True(r.t, ok, "no inode block at %d", ref>>16)
+	data := r.inodes.data[start+int(ref&0xFFFF):]
+	var inode testSquashfsInode
+	inode.inodeType = binary.LittleEndian.Uint16(data[0:])
+	inode.perm = binary.LittleEndian.Uint16(data[2:])
+	inode.inodeNumber = binary.LittleEndian.Uint32(data[12:])
+	data = data[16:]
+	switch inode.inodeType {
+	case squashfsDirType:
+		inode.dirBlock = binary.LittleEndian.Uint32(data[0:])
+		inode.dirSize = binary.LittleEndian.Uint16(data[8:])
+		inode.dirOffset = binary.LittleEndian.Uint16(data[10:])
+	case squashfsFileType:
+		blocksStart := uint64(binary.LittleEndian.Uint32(data[0:]))
+		require.Equal(r.t, uint32(squashfsInvalidFragment), binary.LittleEndian.Uint32(data[4:]))
+		fileSize := binary.LittleEndian.Uint32(data[12:])
+		pos := blocksStart
+		for i := uint32(0); i < (fileSize+squashfsBlockSize-1)/squashfsBlockSize; i++ {
+			size := binary.LittleEndian.Uint32(data[16+4*i:])
+			inode.blockSizes = append(inode.blockSizes, size)
+			block := r.img[pos : pos+uint64(size&^squashfsUncompressedData)]
+			if size&squashfsUncompressedData == 0 {
+				block = r.decompress(block)
+			}
+			inode.content = append(inode.content, block...)
+			pos += uint64(size &^ squashfsUncompressedData)
+		}
+		require.Equal(r.t, int(fileSize), len(inode.content))
+	case squashfsSymlinkType:
+		size := binary.LittleEndian.Uint32(data[4:])
+		inode.target = string(data[8 : 8+size])
+	default:
+		r.t.Fatalf("unexpected inode type %d", inode.inodeType)
+	}
+	return inode
+}
+
+// walk adds all of the entries in the provided directory to files (recursively).
+func (r *testSquashfsReader) walk(dir testSquashfsInode, dirPath string, files map[string]testSquashfsInode) {
+	start, ok := r.dirs.offsets[dir.dirBlock]
+	require.True(r.t, ok, "no directory block at %d", dir.dirBlock)
+	listing := r.dirs.data[start+int(dir.dirOffset) : start+int(dir.dirOffset)+int(dir.dirSize)-3]
+	prevName := ""
+	for len(listing) > 0 {
+		count := binary.LittleEndian.Uint32(listing[0:]) + 1
+		block := binary.LittleEndian.Uint32(listing[4:])
+		baseInodeNumber := binary.LittleEndian.Uint32(listing[8:])
+		listing = listing[12:]
+		for i := uint32(0); i < count; i++ {
+			offset := binary.LittleEndian.Uint16(listing[0:])
+			inodeNumber := uint32(int64(baseInodeNumber) + int64(int16(binary.LittleEndian.Uint16(listing[2:]))))
+			entryType := binary.LittleEndian.Uint16(listing[4:])
+			nameSize := int(binary.LittleEndian.Uint16(listing[6:])) + 1
+			name := string(listing[8 : 8+nameSize])
+			listing = listing[8+nameSize:]
+			require.True(r.t, name > prevName, "entries must be sorted")
+			prevName = name
+
+			inode := r.inode(uint64(block)<<16 | uint64(offset))
+			require.Equal(r.t, entryType, inode.inodeType)
+			require.Equal(r.t, inodeNumber, inode.inodeNumber)
+			entryPath := path.Join(dirPath, name)
+			files[entryPath] = inode
+			if inode.inodeType == squashfsDirType {
+				r.walk(inode, entryPath, files)
+			}
+		}
+	}
+}
@@ -20,9 +20,39 @@ Sniperkit-Bot
 package v0
 
 import (
-	"github.com/palantir/godel/pkg/versionedconfig"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
 )
 
+type Config struct {
+	// Format specifies the format of the archive. Must be one of "tgz", "tar.xz" or "zip". If blank, defaults to
+	// "tgz".
+	Format string `yaml:"format,omitempty"`
+
+	// Reproducible specifies whether the archive only depends on the content of the dist work directory. If true,
+	// entries are sorted, owners and permissions are normalized and all entries use the time specified by the
+	// SOURCE_DATE_EPOCH environment variable (or the time of the HEAD commit of the project if it is not set).
+	Reproducible bool `yaml:"reproducible,omitempty"`
+
+	// FileModes specifies the permissions and owners of the entries of the archive. Each element specifies a glob
+	// "pattern" and the "mode" (an octal string such as "0755"), "owner" and "group" (names or numeric IDs) of the
+	// entries that match it. A pattern that contains a '/' is matched against the path of the entry relative to the
+	// directory that is archived and any other pattern is matched against the name of the entry. If multiple elements
+	// match an entry, the later elements take precedence. Owners and groups are ignored for ZIP archives.
+	FileModes []distarchive.FileMode `yaml:"file-modes,omitempty"`
+
+	// PreserveSymlinks specifies whether symlinks in the dist work directory (for example, symlinks copied from an
+	// input directory with "preserve-symlinks" set) are archived as symlinks with their original targets and whether
+	// empty directories are retained in the archive.
+	PreserveSymlinks bool `yaml:"preserve-symlinks,omitempty"`
+}
+
 func UpgradeConfig(cfgBytes []byte) ([]byte, error) {
-	return versionedconfig.ConfigNotSupported("bin dister", cfgBytes)
+	var cfg Config
+	if err := yaml.UnmarshalStrict(cfgBytes, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal bin dister v0 configuration")
+	}
+	return cfgBytes, nil
 }
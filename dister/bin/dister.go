@@ -23,19 +23,28 @@ import (
 	"fmt"
 	"os"
 	"path"
-	"sort"
 
-	"github.com/mholt/archiver"
-	"github.com/palantir/godel/pkg/osarch"
 	"github.com/pkg/errors"
-	"github.com/termie/go-shutil"
 
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
 )
 
 const TypeName = "bin" // distribution that consists of the binaries in a "bin" directory
 
-type Dister struct{}
+type Dister struct {
+	// Format is the format of the archive. If empty, distarchive.FormatTGZ is used. distarchive.FormatAuto is not
+	// supported because the archive contains the executables for all of the OS/Archs of the product.
+	Format distarchive.Format
+	// Reproducible specifies whether the archive is created using distarchive.Format.MakeReproducible.
+	Reproducible bool
+	// FileModes specifies the permissions and owners of the entries of the archive.
+	FileModes distarchive.FileModes
+	// PreserveSymlinks specifies that symlinks in the dist work directory are archived as symlinks and that empty
+	// directories are retained in the archive.
+	PreserveSymlinks bool
+}
 
 func New() distgo.Dister {
 	return &Dister{}
@@ -46,11 +55,30 @@ func (d *Dister) TypeName() (string, error) {
 }
 
 func (d *Dister) Artifacts(renderedName string) ([]string, error) {
-	return []string{fmt.Sprintf("%s.tgz", renderedName)}, nil
+	format, err := d.format()
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("%s.%s", renderedName, format.Extension())}, nil
 }
 
 func (d *Dister) PackagingExtension() (string, error) {
-	return "tgz", nil
+	format, err := d.format()
+	if err != nil {
+		return "", err
+	}
+	return format.Extension(), nil
+}
+
+func (d *Dister) format() (distarchive.Format, error) {
+	format, err := distarchive.ParseFormat(string(d.Format))
+	if err != nil {
+		return "", err
+	}
+	if format == distarchive.FormatAuto {
+		return "", errors.Errorf("archive format %q is not supported by the %s dister", format, TypeName)
+	}
+	return format, nil
 }
 
 func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
@@ -59,7 +87,7 @@ func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.Prod
 	}
 
 	for _, osArch := range productTaskOutputInfo.Product.BuildOutputInfo.OSArchs {
-		if err := verifyDistTargetSupported(osArch, productTaskOutputInfo); err != nil {
+		if err := disterutil.VerifyDistTargetSupported(osArch, productTaskOutputInfo); err != nil {
 			return nil, err
 		}
 	}
@@ -72,7 +100,7 @@ func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.Prod
 	for _, osArch := range productTaskOutputInfo.Product.BuildOutputInfo.OSArchs {
 		for _, currProductOutputInfo := range productTaskOutputInfo.AllProductOutputInfos() {
 			// copy executable for current product
-			if _, err := copyArtifactForOSArch(distWorkDirBinDir, productTaskOutputInfo.Project, currProductOutputInfo, osArch); err != nil {
+			if _, err := disterutil.CopyArtifactForOSArch(path.Join(distWorkDirBinDir, osArch.String()), productTaskOutputInfo.Project, currProductOutputInfo, osArch); err != nil {
 				return nil, err
 			}
 		}
@@ -82,69 +110,22 @@ func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.Prod
 
 func (d *Dister) GenerateDistArtifacts(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo, runDistResult []byte) error {
 	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
-	dstPath := productTaskOutputInfo.ProductDistArtifactPaths()[distID][0]
-	if err := archiver.TarGz.Make(dstPath, []string{distWorkDir}); err != nil {
-		return errors.Wrapf(err, "failed to create TGZ archive")
-	}
-	return nil
-}
-
-func verifyDistTargetSupported(osArch osarch.OSArch, productTaskOutputInfo distgo.ProductTaskOutputInfo) error {
-	if err := verifySingleProduct(osArch, productTaskOutputInfo.Product); err != nil {
+	dstPath := productTaskOutputInfo.ProductDisterArtifactPaths()[distID][0]
+	format, err := d.format()
+	if err != nil {
 		return err
 	}
-	var keys []distgo.ProductID
-	for k := range productTaskOutputInfo.Deps {
-		keys = append(keys, k)
+	opts := distarchive.Options{
+		FileModes:        d.FileModes,
+		PreserveSymlinks: d.PreserveSymlinks,
 	}
-	sort.Sort(distgo.ByProductID(keys))
-	for _, currKey := range keys {
-		currSpec := productTaskOutputInfo.Deps[currKey]
-		if err := verifySingleProduct(osArch, currSpec); err != nil {
+	if d.Reproducible {
+		modTime, err := distarchive.ReproducibleModTime(productTaskOutputInfo.Project.ProjectDir)
+		if err != nil {
 			return err
 		}
+		opts.Reproducible = true
+		opts.ModTime = modTime
 	}
-	return nil
-}
-
-func verifySingleProduct(osArch osarch.OSArch, productOutputInfo distgo.ProductOutputInfo) error {
-	if !osArchInBuildSpec(osArch, productOutputInfo) {
-		buildOSArchs := "[none]"
-		if productOutputInfo.BuildOutputInfo != nil {
-			buildOSArchs = fmt.Sprint(productOutputInfo.BuildOutputInfo.OSArchs)
-		}
-		return errors.Errorf("the OS/Arch specified for the distribution of a product must be specified as a build target for the product, "+
-			"but product %s does not specify %s as one of its build targets (current build targets: %s)", productOutputInfo.ID, osArch, buildOSArchs)
-	}
-	return nil
-}
-
-func osArchInBuildSpec(osArch osarch.OSArch, productOutputInfo distgo.ProductOutputInfo) bool {
-	if productOutputInfo.BuildOutputInfo == nil {
-		return false
-	}
-	found := false
-	for _, currBuildOSArch := range productOutputInfo.BuildOutputInfo.OSArchs {
-		if currBuildOSArch == osArch {
-			found = true
-			break
-		}
-	}
-	return found
-}
-
-func copyArtifactForOSArch(outputDir string, projectInfo distgo.ProjectInfo, productInfo distgo.ProductOutputInfo, osArch osarch.OSArch) (string, error) {
-	artifactPath, ok := distgo.ProductBuildArtifactPaths(projectInfo, productInfo)[osArch]
-	if !ok {
-		return "", errors.Errorf("no build artifacts exist for %s", osArch)
-	}
-
-	dst := path.Join(outputDir, osArch.String(), distgo.ExecutableName(productInfo.BuildOutputInfo.BuildNameTemplateRendered, osArch.OS))
-	if err := os.MkdirAll(path.Dir(dst), 0755); err != nil {
-		return "", errors.Wrapf(err, "failed to create output directory for artifact")
-	}
-	if _, err := shutil.Copy(artifactPath, dst, false); err != nil {
-		return "", errors.Wrapf(err, "failed to copy build artifact from %s to %s", artifactPath, dst)
-	}
-	return dst, nil
+	return format.MakeWithOptions(dstPath, []string{distWorkDir}, opts)
 }
@@ -20,7 +20,11 @@ Sniperkit-Bot
 package integration
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
 	"io/ioutil"
+	"os"
 	"path"
 	"testing"
 
@@ -330,6 +334,112 @@ Error: dist failed for foo: bin dist failed: no build outputs for product foo
 `
 				},
 			},
+			{
+				Name: "bin creates ZIP archive",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: windows
+          arch: amd64
+    dist:
+      disters:
+        type: bin
+        config:
+          format: zip
+`,
+				},
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/bin/foo-1.0.0.zip
+Finished creating bin distribution for foo
+`
+				},
+				Validate: func(projectDir string) {
+					wantLayout := specdir.NewLayoutSpec(
+						specdir.Dir(specdir.LiteralName("foo-1.0.0"), "",
+							specdir.Dir(specdir.LiteralName("bin"), "",
+								specdir.Dir(specdir.LiteralName("windows-amd64"), "",
+									specdir.File(specdir.LiteralName("foo.exe"), ""),
+								),
+							),
+						), true,
+					)
+					tmpDir, err := ioutil.TempDir(projectDir, "expanded")
+					require.NoError(t, err)
+					require.NoError(t, archiver.Zip.Open(path.Join(projectDir, "out", "dist", "foo", "1.0.0", "bin", "foo-1.0.0.zip"), tmpDir))
+					assert.NoError(t, wantLayout.Validate(path.Join(tmpDir, "foo-1.0.0"), nil))
+				},
+			},
+			{
+				Name: "bin applies file modes to archive entries",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: bin
+        config:
+          file-modes:
+            - pattern: bin/*/*
+              mode: "0750"
+              owner: root
+              group: root
+`,
+				},
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/bin/foo-1.0.0.tgz
+Finished creating bin distribution for foo
+`
+				},
+				Validate: func(projectDir string) {
+					f, err := os.Open(path.Join(projectDir, "out", "dist", "foo", "1.0.0", "bin", "foo-1.0.0.tgz"))
+					require.NoError(t, err)
+					defer func() {
+						_ = f.Close()
+					}()
+					gr, err := gzip.NewReader(f)
+					require.NoError(t, err)
+					tr := tar.NewReader(gr)
+					for {
+						hdr, err := tr.Next()
+						if err == io.EOF {
+							break
+						}
+						require.NoError(t, err)
+						if hdr.Name != "foo-1.0.0/bin/linux-amd64/foo" {
+							continue
+						}
+						assert.Equal(t, int64(0750), hdr.Mode&0777)
+						assert.Equal(t, "root", hdr.Uname)
+						assert.Equal(t, "root", hdr.Gname)
+						return
+					}
+					assert.Fail(t, "archive does not contain foo-1.0.0/bin/linux-amd64/foo")
+				},
+			},
 		},
 	)
 }
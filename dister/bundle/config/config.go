@@ -0,0 +1,43 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/bundle"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/bundle/config/internal/v0"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+type Bundle v0.Config
+
+func (cfg *Bundle) ToDister() distgo.Dister {
+	var distIDs []distgo.DistID
+	for _, distID := range cfg.DistIDs {
+		distIDs = append(distIDs, distgo.DistID(distID))
+	}
+	return &bundle.Dister{
+		Format:       distarchive.Format(cfg.Format),
+		Reproducible: cfg.Reproducible,
+		FileModes:    distarchive.FileModes(cfg.FileModes),
+		DistIDs:      distIDs,
+		Extract:      cfg.Extract,
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v0
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+)
+
+type Config struct {
+	// DistIDs specifies the IDs of the dists of the dependent products whose artifacts are included in the bundle. A
+	// dependent product does not need to declare all of the dists, but it must declare at least one of them. If blank,
+	// the artifacts of all of the dists of the dependent products are included.
+	DistIDs []string `yaml:"dist-ids,omitempty"`
+
+	// Extract specifies whether dist artifacts that are archives (TGZ, tar.xz or ZIP) are extracted into the bundle.
+	// If true, an archive is extracted into "{{Product}}/{{ArtifactNameWithoutExtension}}". Otherwise, and for all
+	// other artifacts, the artifact is copied to "{{Product}}/{{ArtifactName}}".
+	Extract bool `yaml:"extract,omitempty"`
+
+	// Format specifies the format of the bundle archive. Must be one of "tgz", "tar.xz" or "zip". If blank, defaults
+	// to "tgz".
+	Format string `yaml:"format,omitempty"`
+
+	// Reproducible specifies whether the archive only depends on the content of the dist work directory. If true,
+	// entries are sorted, owners and permissions are normalized and all entries use the time specified by the
+	// SOURCE_DATE_EPOCH environment variable (or the time of the HEAD commit of the project if it is not set).
+	Reproducible bool `yaml:"reproducible,omitempty"`
+
+	// FileModes specifies the permissions and owners of the entries of the archive. Each element specifies a glob
+	// "pattern" and the "mode" (an octal string such as "0755"), "owner" and "group" (names or numeric IDs) of the
+	// entries that match it. A pattern that contains a '/' is matched against the path of the entry relative to the
+	// directory that is archived and any other pattern is matched against the name of the entry. If multiple elements
+	// match an entry, the later elements take precedence. Owners and groups are ignored for ZIP archives.
+	FileModes []distarchive.FileMode `yaml:"file-modes,omitempty"`
+}
+
+func UpgradeConfig(cfgBytes []byte) ([]byte, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(cfgBytes, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal bundle dister v0 configuration")
+	}
+	return cfgBytes, nil
+}
@@ -0,0 +1,239 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/termie/go-shutil"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+const (
+	TypeName = "bundle" // distribution that consists of the dist artifacts of the dependent products of a product
+	// IndexFileName is the name of the file at the root of the bundle that lists the components of the bundle.
+	IndexFileName = "bundle-index.json"
+)
+
+type Dister struct {
+	// Format is the format of the archive. If empty, distarchive.FormatTGZ is used. distarchive.FormatAuto is not
+	// supported because the bundle is not specific to an OS.
+	Format distarchive.Format
+	// Reproducible specifies whether the archive is created using distarchive.Format.MakeReproducible.
+	Reproducible bool
+	// FileModes specifies the permissions and owners of the entries of the archive.
+	FileModes distarchive.FileModes
+	// DistIDs are the IDs of the dists of the dependent products whose artifacts are bundled. If empty, the artifacts
+	// of all of the dists of the dependent products are bundled.
+	DistIDs []distgo.DistID
+	// Extract specifies whether the artifacts that are archives are extracted into the bundle rather than being
+	// bundled as-is.
+	Extract bool
+}
+
+// Index lists the components of a bundle. It is written to IndexFileName at the root of the bundle.
+type Index struct {
+	Product    distgo.ProductID `json:"product"`
+	Version    string           `json:"version"`
+	Components []Component      `json:"components"`
+}
+
+// Component is a dist artifact of a dependent product that is part of a bundle.
+type Component struct {
+	Product distgo.ProductID `json:"product"`
+	Version string           `json:"version"`
+	DistID  distgo.DistID    `json:"distId"`
+	// Artifact is the name of the dist artifact.
+	Artifact string `json:"artifact"`
+	// SHA256 is the hex-encoded SHA-256 digest of the dist artifact.
+	SHA256 string `json:"sha256"`
+	// Path is the slash-separated path of the artifact (or of the directory into which it was extracted) relative to
+	// the root of the bundle.
+	Path string `json:"path"`
+	// Extracted is true if the artifact was extracted into the directory at Path.
+	Extracted bool `json:"extracted,omitempty"`
+}
+
+func New() distgo.Dister {
+	return &Dister{}
+}
+
+func (d *Dister) TypeName() (string, error) {
+	return TypeName, nil
+}
+
+func (d *Dister) Artifacts(renderedName string) ([]string, error) {
+	format, err := d.format()
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("%s.%s", renderedName, format.Extension())}, nil
+}
+
+func (d *Dister) PackagingExtension() (string, error) {
+	format, err := d.format()
+	if err != nil {
+		return "", err
+	}
+	return format.Extension(), nil
+}
+
+func (d *Dister) format() (distarchive.Format, error) {
+	format, err := distarchive.ParseFormat(string(d.Format))
+	if err != nil {
+		return "", err
+	}
+	if format == distarchive.FormatAuto {
+		return "", errors.Errorf("archive format %q is not supported by the %s dister", format, TypeName)
+	}
+	return format, nil
+}
+
+func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
+	if len(productTaskOutputInfo.Deps) == 0 {
+		return nil, errors.Errorf("bundle dist failed: product %s does not declare any dependencies", productTaskOutputInfo.Product.ID)
+	}
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	index := Index{
+		Product: productTaskOutputInfo.Product.ID,
+		Version: productTaskOutputInfo.Project.Version,
+	}
+
+	var depIDs []distgo.ProductID
+	for k := range productTaskOutputInfo.Deps {
+		depIDs = append(depIDs, k)
+	}
+	sort.Sort(distgo.ByProductID(depIDs))
+	for _, depID := range depIDs {
+		components, err := d.bundleProduct(distWorkDir, productTaskOutputInfo.Project, productTaskOutputInfo.Deps[depID])
+		if err != nil {
+			return nil, err
+		}
+		index.Components = append(index.Components, components...)
+	}
+
+	indexBytes, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal bundle index as JSON")
+	}
+	if err := ioutil.WriteFile(path.Join(distWorkDir, IndexFileName), append(indexBytes, '\n'), 0644); err != nil {
+		return nil, errors.Wrapf(err, "failed to write bundle index")
+	}
+	return nil, nil
+}
+
+// bundleProduct copies or extracts the dist artifacts of the provided dependent product into the "{{ProductID}}"
+// directory of the dist work directory and returns the components for the artifacts.
+func (d *Dister) bundleProduct(distWorkDir string, projectInfo distgo.ProjectInfo, depOutputInfo distgo.ProductOutputInfo) ([]Component, error) {
+	if depOutputInfo.DistOutputInfos == nil {
+		return nil, errors.Errorf("bundle dist failed: dependent product %s does not declare any dists", depOutputInfo.ID)
+	}
+	distIDs := depOutputInfo.DistOutputInfos.DistIDs
+	if len(d.DistIDs) > 0 {
+		distIDs = d.DistIDs
+	}
+	artifactPaths := distgo.ProductDisterArtifactPaths(projectInfo, depOutputInfo)
+
+	productDir := path.Join(distWorkDir, string(depOutputInfo.ID))
+	var components []Component
+	for _, depDistID := range distIDs {
+		for _, artifactPath := range artifactPaths[depDistID] {
+			artifactName := path.Base(artifactPath)
+			sha256Sum, err := sha256File(artifactPath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "dist %s of product %s must be run before the bundle dist", depDistID, depOutputInfo.ID)
+			}
+			component := Component{
+				Product:  depOutputInfo.ID,
+				Version:  projectInfo.Version,
+				DistID:   depDistID,
+				Artifact: artifactName,
+				SHA256:   sha256Sum,
+				Path:     path.Join(string(depOutputInfo.ID), artifactName),
+			}
+			if err := os.MkdirAll(productDir, 0755); err != nil {
+				return nil, errors.Wrapf(err, "failed to create directory for product %s", depOutputInfo.ID)
+			}
+			if format, ok := distarchive.FormatForPath(artifactName); ok && d.Extract {
+				component.Path = path.Join(string(depOutputInfo.ID), distarchive.TrimExtension(artifactName))
+				component.Extracted = true
+				if err := format.Extract(artifactPath, path.Join(distWorkDir, component.Path)); err != nil {
+					return nil, errors.Wrapf(err, "failed to extract %s", artifactName)
+				}
+			} else if _, err := shutil.Copy(artifactPath, path.Join(distWorkDir, component.Path), false); err != nil {
+				return nil, errors.Wrapf(err, "failed to copy %s", artifactName)
+			}
+			components = append(components, component)
+		}
+	}
+	if len(components) == 0 {
+		return nil, errors.Errorf("bundle dist failed: dependent product %s does not have any artifacts for the dists %v", depOutputInfo.ID, distIDs)
+	}
+	return components, nil
+}
+
+func (d *Dister) GenerateDistArtifacts(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo, runDistResult []byte) error {
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	dstPath := productTaskOutputInfo.ProductDisterArtifactPaths()[distID][0]
+	format, err := d.format()
+	if err != nil {
+		return err
+	}
+	// extracted artifacts may contain symlinks and empty directories
+	opts := distarchive.Options{
+		FileModes:        d.FileModes,
+		PreserveSymlinks: true,
+	}
+	if d.Reproducible {
+		modTime, err := distarchive.ReproducibleModTime(productTaskOutputInfo.Project.ProjectDir)
+		if err != nil {
+			return err
+		}
+		opts.Reproducible = true
+		opts.ModTime = modTime
+	}
+	return format.MakeWithOptions(dstPath, []string{distWorkDir}, opts)
+}
+
+func sha256File(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", filePath)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", filePath)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,182 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/bundle"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+func TestBundle(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		dister    bundle.Dister
+		wantFiles map[string]string
+		wantPaths []string
+	}{
+		{
+			name:   "artifacts are nested",
+			dister: bundle.Dister{},
+			wantFiles: map[string]string{
+				"bar/bar-1.0.0.txt": "bar",
+			},
+			wantPaths: []string{"bar/bar-1.0.0.txt", "foo/foo-1.0.0.txt", "foo/foo-1.0.0-linux-amd64.tgz"},
+		},
+		{
+			name: "archive artifacts are extracted",
+			dister: bundle.Dister{
+				Extract: true,
+			},
+			wantFiles: map[string]string{
+				"bar/bar-1.0.0.txt":                       "bar",
+				"foo/foo-1.0.0-linux-amd64/foo-1.0.0/foo": "foo",
+			},
+			wantPaths: []string{"bar/bar-1.0.0.txt", "foo/foo-1.0.0.txt", "foo/foo-1.0.0-linux-amd64"},
+		},
+		{
+			name: "only specified dist IDs are bundled",
+			dister: bundle.Dister{
+				DistIDs: []distgo.DistID{"os-arch-bin", "bin"},
+			},
+			wantPaths: []string{"bar/bar-1.0.0.txt", "foo/foo-1.0.0-linux-amd64.tgz"},
+		},
+	} {
+		func() {
+			projectDir, err := ioutil.TempDir("", "")
+			require.NoError(t, err)
+			defer func() {
+				_ = os.RemoveAll(projectDir)
+			}()
+			projectInfo := distgo.ProjectInfo{
+				ProjectDir: projectDir,
+				Version:    "1.0.0",
+			}
+
+			fooDir := path.Join(projectDir, "foo-1.0.0")
+			require.NoError(t, os.MkdirAll(fooDir, 0755))
+			require.NoError(t, ioutil.WriteFile(path.Join(fooDir, "foo"), []byte("foo"), 0755))
+			fooArtifactDir := path.Join(projectDir, "out", "dist", "foo", "1.0.0", "os-arch-bin")
+			require.NoError(t, os.MkdirAll(fooArtifactDir, 0755))
+			require.NoError(t, distarchive.FormatTGZ.Make(path.Join(fooArtifactDir, "foo-1.0.0-linux-amd64.tgz"), []string{fooDir}))
+
+			for _, currArtifact := range []string{"foo/1.0.0/manual/foo-1.0.0.txt", "bar/1.0.0/bin/bar-1.0.0.txt"} {
+				artifactPath := path.Join(projectDir, "out", "dist", currArtifact)
+				require.NoError(t, os.MkdirAll(path.Dir(artifactPath), 0755))
+				require.NoError(t, ioutil.WriteFile(artifactPath, []byte(strings.SplitN(currArtifact, "/", 2)[0]), 0644))
+			}
+
+			outputInfo := distgo.ProductTaskOutputInfo{
+				Project: projectInfo,
+				Product: productOutputInfo("suite", map[distgo.DistID]string{
+					"bundle": "suite-1.0.0.tgz",
+				}),
+				Deps: map[distgo.ProductID]distgo.ProductOutputInfo{
+					"foo": productOutputInfo("foo", map[distgo.DistID]string{
+						"manual":      "foo-1.0.0.txt",
+						"os-arch-bin": "foo-1.0.0-linux-amd64.tgz",
+					}),
+					"bar": productOutputInfo("bar", map[distgo.DistID]string{
+						"bin": "bar-1.0.0.txt",
+					}),
+				},
+			}
+			distWorkDir := outputInfo.ProductDistWorkDirs()["bundle"]
+			require.NoError(t, os.MkdirAll(distWorkDir, 0755))
+
+			runDistResult, err := tc.dister.RunDist("bundle", outputInfo)
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+			err = tc.dister.GenerateDistArtifacts("bundle", outputInfo, runDistResult)
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+
+			extractDir := path.Join(projectDir, "extracted")
+			err = distarchive.FormatTGZ.Extract(outputInfo.ProductDisterArtifactPaths()["bundle"][0], extractDir)
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+			bundleDir := path.Join(extractDir, "suite-1.0.0")
+
+			for relPath, wantContent := range tc.wantFiles {
+				bytes, err := ioutil.ReadFile(path.Join(bundleDir, relPath))
+				require.NoError(t, err, "Case %d: %s", i, tc.name)
+				assert.Equal(t, wantContent, string(bytes), "Case %d: %s: %s", i, tc.name, relPath)
+			}
+
+			indexBytes, err := ioutil.ReadFile(path.Join(bundleDir, bundle.IndexFileName))
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+			var index bundle.Index
+			require.NoError(t, json.Unmarshal(indexBytes, &index), "Case %d: %s", i, tc.name)
+			assert.Equal(t, distgo.ProductID("suite"), index.Product, "Case %d: %s", i, tc.name)
+			assert.Equal(t, "1.0.0", index.Version, "Case %d: %s", i, tc.name)
+			var gotPaths []string
+			for _, component := range index.Components {
+				assert.Equal(t, "1.0.0", component.Version, "Case %d: %s", i, tc.name)
+				assert.Len(t, component.SHA256, 64, "Case %d: %s", i, tc.name)
+				gotPaths = append(gotPaths, component.Path)
+			}
+			assert.Equal(t, tc.wantPaths, gotPaths, "Case %d: %s", i, tc.name)
+		}()
+	}
+}
+
+func TestBundleRequiresDependencies(t *testing.T) {
+	d := bundle.New()
+	_, err := d.RunDist("bundle", distgo.ProductTaskOutputInfo{
+		Project: distgo.ProjectInfo{
+			Version: "1.0.0",
+		},
+		Product: productOutputInfo("suite", map[distgo.DistID]string{
+			"bundle": "suite-1.0.0.tgz",
+		}),
+	})
+	assert.EqualError(t, err, "bundle dist failed: product suite does not declare any dependencies")
+}
+
+// productOutputInfo returns the output information for a product with the provided dists, where each dist has a single
+// artifact.
+func productOutputInfo(productID distgo.ProductID, distArtifacts map[distgo.DistID]string) distgo.ProductOutputInfo {
+	distOutputInfos := &distgo.DistOutputInfos{
+		DistOutputDir: "out/dist",
+		DistInfos:     make(map[distgo.DistID]distgo.DistOutputInfo),
+	}
+	for distID, artifactName := range distArtifacts {
+		distOutputInfos.DistIDs = append(distOutputInfos.DistIDs, distID)
+		distOutputInfos.DistInfos[distID] = distgo.DistOutputInfo{
+			DistNameTemplateRendered: string(productID) + "-1.0.0",
+			DistArtifactNames:        []string{artifactName},
+		}
+	}
+	sort.Slice(distOutputInfos.DistIDs, func(i, j int) bool {
+		return distOutputInfos.DistIDs[i] < distOutputInfos.DistIDs[j]
+	})
+	return distgo.ProductOutputInfo{
+		ID:              productID,
+		DistOutputInfos: distOutputInfos,
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/gofiles"
+	"github.com/palantir/godel/framework/pluginapitester"
+	"github.com/palantir/godel/pkg/products/v2/products"
+	"github.com/palantir/pkg/specdir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/bundle"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distertester"
+)
+
+func TestBundleDist(t *testing.T) {
+	const godelYML = `exclude:
+  names:
+    - "\\..+"
+    - "vendor"
+  paths:
+    - "godel"
+`
+
+	pluginPath, err := products.Bin("dist-plugin")
+	require.NoError(t, err)
+
+	distertester.RunAssetDistTest(t,
+		pluginapitester.NewPluginProvider(pluginPath),
+		nil,
+		[]distertester.TestCase{
+			{
+				Name: "bundle extracts dist artifacts of dependent products",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+					{
+						RelPath: "bar/bar.go",
+						Src:     `package main; func main() {}`,
+					},
+					{
+						RelPath: "suite/suite.go",
+						Src:     `package main; func main() {}`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: os-arch-bin
+        config:
+          os-archs:
+            - os: linux
+              arch: amd64
+  bar:
+    build:
+      main-pkg: ./bar
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: os-arch-bin
+        config:
+          os-archs:
+            - os: linux
+              arch: amd64
+  suite:
+    build:
+      main-pkg: ./suite
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: bundle
+        config:
+          extract: true
+    dependencies:
+      - foo
+      - bar
+`,
+				},
+				Validate: func(projectDir string) {
+					wantLayout := specdir.NewLayoutSpec(
+						specdir.Dir(specdir.LiteralName("suite-1.0.0"), "",
+							specdir.File(specdir.LiteralName(bundle.IndexFileName), ""),
+							specdir.Dir(specdir.LiteralName("bar"), "",
+								specdir.Dir(specdir.LiteralName("bar-1.0.0-linux-amd64"), "",
+									specdir.File(specdir.LiteralName("bar"), ""),
+								),
+							),
+							specdir.Dir(specdir.LiteralName("foo"), "",
+								specdir.Dir(specdir.LiteralName("foo-1.0.0-linux-amd64"), "",
+									specdir.File(specdir.LiteralName("foo"), ""),
+								),
+							),
+						), true,
+					)
+					bundleDir := path.Join(projectDir, "out", "dist", "suite", "1.0.0", "bundle", "suite-1.0.0")
+					assert.NoError(t, wantLayout.Validate(bundleDir, nil))
+
+					indexBytes, err := ioutil.ReadFile(path.Join(bundleDir, bundle.IndexFileName))
+					require.NoError(t, err)
+					var index bundle.Index
+					require.NoError(t, json.Unmarshal(indexBytes, &index))
+					var gotComponents []string
+					for _, component := range index.Components {
+						gotComponents = append(gotComponents, string(component.Product)+"@"+component.Version)
+					}
+					assert.Equal(t, []string{"bar@1.0.0", "foo@1.0.0"}, gotComponents)
+				},
+			},
+		},
+	)
+}
+
+func TestBundleUpgradeConfig(t *testing.T) {
+	pluginPath, err := products.Bin("dist-plugin")
+	require.NoError(t, err)
+
+	pluginapitester.RunUpgradeConfigTest(t,
+		pluginapitester.NewPluginProvider(pluginPath),
+		nil,
+		[]pluginapitester.UpgradeConfigTestCase{
+			{
+				Name: `valid v0 config works`,
+				ConfigFiles: map[string]string{
+					"godel/config/dist-plugin.yml": `
+products:
+  suite:
+    dist:
+      disters:
+        type: bundle
+        config:
+          # comment
+          dist-ids:
+            - os-arch-bin
+          extract: true
+    dependencies:
+      - foo
+`,
+				},
+				WantOutput: ``,
+				WantFiles: map[string]string{
+					"godel/config/dist-plugin.yml": `
+products:
+  suite:
+    dist:
+      disters:
+        type: bundle
+        config:
+          # comment
+          dist-ids:
+            - os-arch-bin
+          extract: true
+    dependencies:
+      - foo
+`,
+				},
+			},
+		},
+	)
+}
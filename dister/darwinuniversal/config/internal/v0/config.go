@@ -0,0 +1,54 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v0
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+)
+
+type Config struct {
+	// Format specifies the format of the archive. Must be one of "tgz", "tar.xz", "zip" or "auto". If "auto", a TGZ
+	// archive is created. If blank, defaults to "tgz".
+	Format string `yaml:"format,omitempty"`
+
+	// Reproducible specifies whether the archive is created in a reproducible manner. If true, the modification time of
+	// every entry is set to the value of the SOURCE_DATE_EPOCH environment variable (or to the time of the HEAD commit of
+	// the project if it is not set), owners and permissions are normalized, entries are sorted and the gzip header does
+	// not include a timestamp.
+	Reproducible bool `yaml:"reproducible,omitempty"`
+
+	// FileModes specifies the permissions and owners of the entries of the archive. Each element specifies a glob
+	// "pattern" and the "mode" (an octal string such as "0755"), "owner" and "group" (names or numeric IDs) of the
+	// entries that match it. A pattern that contains a '/' is matched against the path of the entry relative to the
+	// directory that is archived and any other pattern is matched against the name of the entry. If multiple elements
+	// match an entry, the later elements take precedence. Owners and groups are ignored for ZIP archives.
+	FileModes []distarchive.FileMode `yaml:"file-modes,omitempty"`
+}
+
+func UpgradeConfig(cfgBytes []byte) ([]byte, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(cfgBytes, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal darwin-universal dister v0 configuration")
+	}
+	return cfgBytes, nil
+}
@@ -0,0 +1,169 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package darwinuniversal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+const (
+	TypeName = "darwin-universal" // distribution that consists of universal macOS binaries for amd64 and arm64
+	// Label is the label used in place of the OS/Arch for the artifacts and the work directory of the dister.
+	Label = "darwin-universal"
+)
+
+// OSArchs returns the OS/Archs whose binaries are merged into the universal binaries.
+func OSArchs() []osarch.OSArch {
+	return []osarch.OSArch{
+		{OS: "darwin", Arch: "amd64"},
+		{OS: "darwin", Arch: "arm64"},
+	}
+}
+
+type Dister struct {
+	// Format is the format of the archive. If empty, distarchive.FormatTGZ is used.
+	Format distarchive.Format
+	// Reproducible specifies whether the archive is created using distarchive.Format.MakeReproducible.
+	Reproducible bool
+	// FileModes specifies the permissions and owners of the entries of the archive.
+	FileModes distarchive.FileModes
+}
+
+func New() distgo.Dister {
+	return &Dister{}
+}
+
+func (d *Dister) TypeName() (string, error) {
+	return TypeName, nil
+}
+
+func (d *Dister) Artifacts(renderedName string) ([]string, error) {
+	format, err := d.format()
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("%s-%s.%s", renderedName, Label, format.Extension())}, nil
+}
+
+func (d *Dister) PackagingExtension() (string, error) {
+	format, err := d.format()
+	if err != nil {
+		return "", err
+	}
+	return format.Extension(), nil
+}
+
+// format returns the concrete archive format used for the archive.
+func (d *Dister) format() (distarchive.Format, error) {
+	format, err := distarchive.ParseFormat(string(d.Format))
+	if err != nil {
+		return "", err
+	}
+	return format.ForOS("darwin"), nil
+}
+
+func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
+	requirement := fmt.Sprintf("the %s dister requires %v to be specified as build targets for the product", TypeName, OSArchs())
+	for _, osArch := range OSArchs() {
+		if err := disterutil.VerifyBuildTargets(osArch, productTaskOutputInfo, requirement); err != nil {
+			return nil, err
+		}
+	}
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	var outputPaths []string
+	for _, currProductOutputInfo := range productTaskOutputInfo.AllProductOutputInfos() {
+		// merge executables for current product
+		dst, err := mergeArtifacts(distWorkDir, productTaskOutputInfo.Project, currProductOutputInfo)
+		if err != nil {
+			return nil, err
+		}
+		outputPaths = append(outputPaths, dst)
+	}
+	jsonBytes, err := json.Marshal(outputPaths)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal outputPaths as JSON")
+	}
+	return jsonBytes, nil
+}
+
+func (d *Dister) GenerateDistArtifacts(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo, runDistResult []byte) error {
+	var outputPaths []string
+	if err := json.Unmarshal(runDistResult, &outputPaths); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal runDistResult JSON %s", string(runDistResult))
+	}
+	format, err := d.format()
+	if err != nil {
+		return err
+	}
+	for _, artifactPath := range productTaskOutputInfo.ProductDisterArtifactPaths()[distID] {
+		if err := d.makeArchive(format, artifactPath, outputPaths, productTaskOutputInfo.Project); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dister) makeArchive(format distarchive.Format, dstPath string, srcPaths []string, projectInfo distgo.ProjectInfo) error {
+	opts := distarchive.Options{
+		FileModes: d.FileModes,
+	}
+	if d.Reproducible {
+		modTime, err := distarchive.ReproducibleModTime(projectInfo.ProjectDir)
+		if err != nil {
+			return err
+		}
+		opts.Reproducible = true
+		opts.ModTime = modTime
+	}
+	return format.MakeWithOptions(dstPath, srcPaths, opts)
+}
+
+// mergeArtifacts writes the universal binary that contains the build artifacts of the provided product for all of the
+// OS/Archs returned by OSArchs to "{{outputDir}}/darwin-universal/{{executable}}" and returns its path.
+func mergeArtifacts(outputDir string, projectInfo distgo.ProjectInfo, productInfo distgo.ProductOutputInfo) (string, error) {
+	buildArtifactPaths := distgo.ProductBuildArtifactPaths(projectInfo, productInfo)
+	var srcPaths []string
+	for _, osArch := range OSArchs() {
+		artifactPath, ok := buildArtifactPaths[osArch]
+		if !ok {
+			return "", errors.Errorf("no build artifacts exist for %s", osArch)
+		}
+		srcPaths = append(srcPaths, artifactPath)
+	}
+
+	dst := path.Join(outputDir, Label, distgo.ExecutableName(productInfo.BuildOutputInfo.BuildNameTemplateRendered, "darwin"))
+	if err := os.MkdirAll(path.Dir(dst), 0755); err != nil {
+		return "", errors.Wrapf(err, "failed to create output directory for artifact")
+	}
+	if err := WriteUniversal(dst, srcPaths); err != nil {
+		return "", errors.Wrapf(err, "failed to create universal binary for %s", productInfo.ID)
+	}
+	return dst, nil
+}
@@ -0,0 +1,154 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package darwinuniversal
+
+import (
+	"bufio"
+	"debug/macho"
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	fatMagic = 0xcafebabe
+	// fatAlign is the alignment (as a power of 2) of the slices in a universal binary. 2^14 is the page size of
+	// arm64, which is also a valid alignment for amd64.
+	fatAlign = 14
+)
+
+type fatSlice struct {
+	path   string
+	cpu    macho.Cpu
+	subCpu uint32
+	size   int64
+}
+
+// WriteUniversal writes a universal (fat) Mach-O binary to dstPath that contains the thin Mach-O binaries at the
+// provided paths. Returns an error if any of the inputs is not a thin Mach-O binary or if multiple inputs have the same
+// CPU type. The slices are ordered by CPU type.
+func WriteUniversal(dstPath string, srcPaths []string) error {
+	var slices []fatSlice
+	seenCPUs := make(map[macho.Cpu]string)
+	for _, srcPath := range srcPaths {
+		slice, err := readSlice(srcPath)
+		if err != nil {
+			return err
+		}
+		if prev, ok := seenCPUs[slice.cpu]; ok {
+			return errors.Errorf("%s and %s are both Mach-O binaries for CPU %v", prev, srcPath, slice.cpu)
+		}
+		seenCPUs[slice.cpu] = srcPath
+		slices = append(slices, slice)
+	}
+	if len(slices) == 0 {
+		return errors.Errorf("at least one Mach-O binary must be provided")
+	}
+	sort.Slice(slices, func(i, j int) bool {
+		return slices[i].cpu < slices[j].cpu
+	})
+
+	out, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", dstPath)
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+	w := bufio.NewWriter(out)
+
+	// fat header and fat_arch entries are big-endian
+	header := []uint32{fatMagic, uint32(len(slices))}
+	offset := alignUp(int64(8+20*len(slices)), 1<<fatAlign)
+	offsets := make([]int64, len(slices))
+	for i, slice := range slices {
+		if offset+slice.size > 1<<32-1 {
+			return errors.Errorf("universal binary is too large")
+		}
+		offsets[i] = offset
+		header = append(header, uint32(slice.cpu), slice.subCpu, uint32(offset), uint32(slice.size), fatAlign)
+		offset = alignUp(offset+slice.size, 1<<fatAlign)
+	}
+	if err := binary.Write(w, binary.BigEndian, header); err != nil {
+		return errors.Wrapf(err, "failed to write fat header")
+	}
+	written := int64(4 * len(header))
+	for i, slice := range slices {
+		if err := writePadding(w, offsets[i]-written); err != nil {
+			return err
+		}
+		if err := copySlice(w, slice); err != nil {
+			return err
+		}
+		written = offsets[i] + slice.size
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Wrapf(err, "failed to write %s", dstPath)
+	}
+	return out.Close()
+}
+
+func readSlice(srcPath string) (fatSlice, error) {
+	f, err := macho.Open(srcPath)
+	if err != nil {
+		return fatSlice{}, errors.Wrapf(err, "failed to read %s as a thin Mach-O binary", srcPath)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	fi, err := os.Stat(srcPath)
+	if err != nil {
+		return fatSlice{}, errors.Wrapf(err, "failed to stat %s", srcPath)
+	}
+	return fatSlice{
+		path:   srcPath,
+		cpu:    f.Cpu,
+		subCpu: f.SubCpu,
+		size:   fi.Size(),
+	}, nil
+}
+
+func copySlice(w io.Writer, slice fatSlice) error {
+	f, err := os.Open(slice.path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", slice.path)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := io.Copy(w, f); err != nil {
+		return errors.Wrapf(err, "failed to copy %s", slice.path)
+	}
+	return nil
+}
+
+func writePadding(w io.Writer, n int64) error {
+	if _, err := w.Write(make([]byte, n)); err != nil {
+		return errors.Wrapf(err, "failed to write padding")
+	}
+	return nil
+}
+
+func alignUp(n, align int64) int64 {
+	return (n + align - 1) / align * align
+}
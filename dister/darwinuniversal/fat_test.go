@@ -0,0 +1,113 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package darwinuniversal_test
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/darwinuniversal"
+)
+
+func TestWriteUniversal(t *testing.T) {
+	tmpDir, cleanup, err := dirs.TempDir("", "")
+	require.NoError(t, err)
+	defer cleanup()
+
+	amd64Path := path.Join(tmpDir, "foo-amd64")
+	err = ioutil.WriteFile(amd64Path, thinMachO(t, macho.CpuAmd64, 3, "amd64 content"), 0755)
+	require.NoError(t, err)
+	arm64Path := path.Join(tmpDir, "foo-arm64")
+	err = ioutil.WriteFile(arm64Path, thinMachO(t, macho.CpuArm64, 0, "arm64 content"), 0755)
+	require.NoError(t, err)
+
+	dstPath := path.Join(tmpDir, "foo")
+	err = darwinuniversal.WriteUniversal(dstPath, []string{arm64Path, amd64Path})
+	require.NoError(t, err)
+
+	fat, err := macho.OpenFat(dstPath)
+	require.NoError(t, err)
+	defer func() {
+		_ = fat.Close()
+	}()
+	require.Equal(t, 2, len(fat.Arches))
+	for i, want := range []struct {
+		cpu    macho.Cpu
+		subCpu uint32
+		src    string
+	}{
+		{macho.CpuAmd64, 3, amd64Path},
+		{macho.CpuArm64, 0, arm64Path},
+	} {
+		arch := fat.Arches[i]
+		assert.Equal(t, want.cpu, arch.Cpu)
+		assert.Equal(t, want.subCpu, arch.SubCpu)
+		assert.Equal(t, uint32(0), arch.Offset%(1<<14))
+
+		wantBytes, err := ioutil.ReadFile(want.src)
+		require.NoError(t, err)
+		dstBytes, err := ioutil.ReadFile(dstPath)
+		require.NoError(t, err)
+		assert.Equal(t, wantBytes, dstBytes[arch.Offset:arch.Offset+arch.Size])
+	}
+}
+
+func TestWriteUniversalErrors(t *testing.T) {
+	tmpDir, cleanup, err := dirs.TempDir("", "")
+	require.NoError(t, err)
+	defer cleanup()
+
+	amd64Path := path.Join(tmpDir, "foo-amd64")
+	err = ioutil.WriteFile(amd64Path, thinMachO(t, macho.CpuAmd64, 3, ""), 0755)
+	require.NoError(t, err)
+	otherAmd64Path := path.Join(tmpDir, "bar-amd64")
+	err = ioutil.WriteFile(otherAmd64Path, thinMachO(t, macho.CpuAmd64, 3, ""), 0755)
+	require.NoError(t, err)
+	notMachOPath := path.Join(tmpDir, "foo.sh")
+	err = ioutil.WriteFile(notMachOPath, []byte("#!/bin/sh\n"), 0755)
+	require.NoError(t, err)
+
+	err = darwinuniversal.WriteUniversal(path.Join(tmpDir, "out"), []string{amd64Path, otherAmd64Path})
+	assert.EqualError(t, err, amd64Path+" and "+otherAmd64Path+" are both Mach-O binaries for CPU CpuAmd64")
+
+	err = darwinuniversal.WriteUniversal(path.Join(tmpDir, "out"), []string{notMachOPath})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read "+notMachOPath+" as a thin Mach-O binary")
+}
+
+// thinMachO returns a minimal 64-bit Mach-O file with no load commands for the provided CPU followed by the provided
+// content.
+func thinMachO(t *testing.T, cpu macho.Cpu, subCpu uint32, content string) []byte {
+	buf := &bytes.Buffer{}
+	err := binary.Write(buf, binary.LittleEndian, []uint32{
+		macho.Magic64, uint32(cpu), subCpu, uint32(macho.TypeExec), 0, 0, 0, 0,
+	})
+	require.NoError(t, err)
+	buf.WriteString(content)
+	return buf.Bytes()
+}
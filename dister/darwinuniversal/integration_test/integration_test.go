@@ -0,0 +1,140 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration_test
+
+import (
+	"debug/macho"
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/gofiles"
+	"github.com/palantir/godel/framework/pluginapitester"
+	"github.com/palantir/godel/pkg/products/v2/products"
+	"github.com/palantir/pkg/specdir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distertester"
+)
+
+func TestDarwinUniversalDist(t *testing.T) {
+	const godelYML = `exclude:
+  names:
+    - "\\..+"
+    - "vendor"
+  paths:
+    - "godel"
+`
+
+	pluginPath, err := products.Bin("dist-plugin")
+	require.NoError(t, err)
+
+	distertester.RunAssetDistTest(t,
+		pluginapitester.NewPluginProvider(pluginPath),
+		nil,
+		[]distertester.TestCase{
+			{
+				Name: "darwin-universal creates expected output",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: darwin
+          arch: amd64
+        - os: darwin
+          arch: arm64
+    dist:
+      disters:
+        type: darwin-universal
+`,
+				},
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/darwin-universal/foo-1.0.0-darwin-universal.tgz
+Finished creating darwin-universal distribution for foo
+`
+				},
+				Validate: func(projectDir string) {
+					wantLayout := specdir.NewLayoutSpec(
+						specdir.Dir(specdir.LiteralName("1.0.0"), "",
+							specdir.Dir(specdir.LiteralName("darwin-universal"), "",
+								specdir.Dir(specdir.LiteralName("foo-1.0.0"), "",
+									specdir.Dir(specdir.LiteralName("darwin-universal"), "",
+										specdir.File(specdir.LiteralName("foo"), ""),
+									),
+								),
+								specdir.File(specdir.LiteralName("foo-1.0.0-darwin-universal.tgz"), ""),
+							),
+						), true,
+					)
+					assert.NoError(t, wantLayout.Validate(path.Join(projectDir, "out", "dist", "foo", "1.0.0"), nil))
+
+					fat, err := macho.OpenFat(path.Join(projectDir, "out", "dist", "foo", "1.0.0", "darwin-universal", "foo-1.0.0", "darwin-universal", "foo"))
+					require.NoError(t, err)
+					defer func() {
+						_ = fat.Close()
+					}()
+					require.Equal(t, 2, len(fat.Arches))
+					assert.Equal(t, macho.CpuAmd64, fat.Arches[0].Cpu)
+					assert.Equal(t, macho.CpuArm64, fat.Arches[1].Cpu)
+				},
+			},
+			{
+				Name: "darwin-universal fails if darwin-arm64 is not a build target",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: darwin
+          arch: amd64
+    dist:
+      disters:
+        type: darwin-universal
+`,
+				},
+				WantError: true,
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/darwin-universal/foo-1.0.0-darwin-universal.tgz
+Error: dist failed for foo: the darwin-universal dister requires [darwin-amd64 darwin-arm64] to be specified as build targets for the product, but product foo does not specify darwin-arm64 as one of its build targets (current build targets: [darwin-amd64])
+`
+				},
+			},
+		},
+	)
+}
@@ -0,0 +1,98 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v0
+
+import (
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+type Config struct {
+	// OSArchs specifies the GOOS and GOARCH pairs for which Debian packages are created. The OS must be "linux". If
+	// blank, defaults to the GOOS and GOARCH of the host system at runtime.
+	OSArchs []osarch.OSArch `yaml:"os-archs,omitempty"`
+
+	// PackageName is the name of the Debian package. If blank, the ID of the product is used.
+	PackageName string `yaml:"package-name,omitempty"`
+
+	// Prefix is the installation prefix for the package. The executables for the product and its dependencies are
+	// installed in "{{Prefix}}/bin". If blank, defaults to "/usr". All other content of the dist work directory (for
+	// example, the content of the input directory) is installed relative to the root of the file system. License notices
+	// of dependencies are installed in "{{Prefix}}/share/doc/{{PackageName}}" unless another directory is configured.
+	Prefix string `yaml:"prefix,omitempty"`
+
+	// Maintainer is the value of the "Maintainer" field of the package.
+	Maintainer string `yaml:"maintainer,omitempty"`
+
+	// Description is the value of the "Description" field of the package. The first line is used as the synopsis. If
+	// blank, the ID of the product is used.
+	Description string `yaml:"description,omitempty"`
+
+	// Section is the value of the "Section" field of the package. If blank, defaults to "misc".
+	Section string `yaml:"section,omitempty"`
+
+	// Priority is the value of the "Priority" field of the package. If blank, defaults to "optional".
+	Priority string `yaml:"priority,omitempty"`
+
+	// Homepage is the value of the "Homepage" field of the package.
+	Homepage string `yaml:"homepage,omitempty"`
+
+	// Depends specifies the packages that this package depends on. Each entry may include a version constraint: for
+	// example, "libc6 (>= 2.17)".
+	Depends []string `yaml:"depends,omitempty"`
+
+	// Recommends specifies the packages that are recommended by this package.
+	Recommends []string `yaml:"recommends,omitempty"`
+
+	// Conflicts specifies the packages that conflict with this package.
+	Conflicts []string `yaml:"conflicts,omitempty"`
+
+	// Provides specifies the virtual packages provided by this package.
+	Provides []string `yaml:"provides,omitempty"`
+
+	// Replaces specifies the packages that are replaced by this package.
+	Replaces []string `yaml:"replaces,omitempty"`
+
+	// Scripts specifies the content of the maintainer scripts for the package. Scripts that do not start with an
+	// interpreter line are run using "/bin/sh".
+	Scripts Scripts `yaml:"scripts,omitempty"`
+
+	// Reproducible specifies whether the packages are created in a reproducible manner. If true, the modification time
+	// of every member and entry of the packages is set to the value of the SOURCE_DATE_EPOCH environment variable (or
+	// to the time of the HEAD commit of the project if it is not set). Packages are always created in this manner if
+	// the SOURCE_DATE_EPOCH environment variable is set.
+	Reproducible bool `yaml:"reproducible,omitempty"`
+}
+
+type Scripts struct {
+	PreInst  string `yaml:"preinst,omitempty"`
+	PostInst string `yaml:"postinst,omitempty"`
+	PreRm    string `yaml:"prerm,omitempty"`
+	PostRm   string `yaml:"postrm,omitempty"`
+}
+
+func UpgradeConfig(cfgBytes []byte) ([]byte, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(cfgBytes, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal deb dister v0 configuration")
+	}
+	return cfgBytes, nil
+}
@@ -0,0 +1,109 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+// controlFileContent returns the content of the "control" file for a binary package with the provided properties.
+func controlFileContent(packageName, version, arch string, installedSizeKB int64, control Control, productID distgo.ProductID) string {
+	maintainer := control.Maintainer
+	if maintainer == "" {
+		maintainer = "unknown <unknown@unknown>"
+	}
+	description := control.Description
+	if description == "" {
+		description = string(productID)
+	}
+	section := control.Section
+	if section == "" {
+		section = "misc"
+	}
+	priority := control.Priority
+	if priority == "" {
+		priority = "optional"
+	}
+
+	var lines []string
+	addField := func(name, val string) {
+		if val == "" {
+			return
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", name, val))
+	}
+	addField("Package", packageName)
+	addField("Version", version)
+	addField("Architecture", arch)
+	addField("Maintainer", maintainer)
+	addField("Installed-Size", fmt.Sprint(installedSizeKB))
+	addField("Depends", strings.Join(control.Depends, ", "))
+	addField("Recommends", strings.Join(control.Recommends, ", "))
+	addField("Conflicts", strings.Join(control.Conflicts, ", "))
+	addField("Provides", strings.Join(control.Provides, ", "))
+	addField("Replaces", strings.Join(control.Replaces, ", "))
+	addField("Section", section)
+	addField("Priority", priority)
+	addField("Homepage", control.Homepage)
+	addField("Description", formatDescription(description))
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// formatDescription formats the provided description as the value of a Debian "Description" field. The first line is
+// used as the synopsis and all subsequent lines are indented by a single space, with blank lines represented by " .".
+func formatDescription(description string) string {
+	descLines := strings.Split(strings.TrimSpace(description), "\n")
+	for i := 1; i < len(descLines); i++ {
+		if strings.TrimSpace(descLines[i]) == "" {
+			descLines[i] = " ."
+			continue
+		}
+		descLines[i] = " " + descLines[i]
+	}
+	return strings.Join(descLines, "\n")
+}
+
+var (
+	debianVersionInvalidChars = regexp.MustCompile(`[^A-Za-z0-9.+~]`)
+	debianVersionPreRelease   = regexp.MustCompile(`^([0-9][0-9A-Za-z.]*)-([A-Za-z].*)$`)
+)
+
+// debianVersion converts the provided project version into a valid Debian package version. A leading "v" is removed, a
+// pre-release suffix such as "-rc1" is converted to "~rc1" so that it sorts before the release, and all other hyphens
+// and invalid characters are replaced so that the version is not interpreted as having a Debian revision. Versions that
+// do not start with a digit are prefixed with "0~".
+func debianVersion(version string) string {
+	if len(version) > 1 && version[0] == 'v' && version[1] >= '0' && version[1] <= '9' {
+		version = version[1:]
+	}
+	if matches := debianVersionPreRelease.FindStringSubmatch(version); matches != nil {
+		version = matches[1] + "~" + matches[2]
+	}
+	version = strings.Replace(version, "-", "+", -1)
+	version = debianVersionInvalidChars.ReplaceAllString(version, ".")
+	if version == "" || version[0] < '0' || version[0] > '9' {
+		version = "0~" + version
+	}
+	return version
+}
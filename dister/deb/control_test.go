@@ -0,0 +1,68 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebianVersion(t *testing.T) {
+	for i, tc := range []struct {
+		version string
+		want    string
+	}{
+		{"1.0.0", "1.0.0"},
+		{"v1.0.0", "1.0.0"},
+		{"1.0.0-rc1", "1.0.0~rc1"},
+		{"1.0.0-rc1-5-gabcdef1", "1.0.0~rc1+5+gabcdef1"},
+		{"1.0.0-5-gabcdef1", "1.0.0+5+gabcdef1"},
+		{"1.0.0-5-gabcdef1.dirty", "1.0.0+5+gabcdef1.dirty"},
+		{"1.0_beta", "1.0.beta"},
+		{"v", "0~v"},
+		{"unspecified", "0~unspecified"},
+		{"", "0~"},
+	} {
+		assert.Equal(t, tc.want, debianVersion(tc.version), "Case %d: %s", i, tc.version)
+	}
+}
+
+func TestControlFileContent(t *testing.T) {
+	got := controlFileContent("foo", "1.0.0", "arm64", 42, Control{
+		Description: "foo\nThe foo product.\n\nMore about foo.",
+		Recommends:  []string{"bar", "baz"},
+		Homepage:    "https://example.com",
+	}, "foo")
+	assert.Equal(t, `Package: foo
+Version: 1.0.0
+Architecture: arm64
+Maintainer: unknown <unknown@unknown>
+Installed-Size: 42
+Recommends: bar, baz
+Section: misc
+Priority: optional
+Homepage: https://example.com
+Description: foo
+ The foo product.
+ .
+ More about foo.
+`, got)
+}
@@ -0,0 +1,234 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+const TypeName = "deb" // distribution that consists of a Debian package for a specific OS/Architecture
+
+type Dister struct {
+	// OSArchs are the OS/Architectures for which packages are created. The OS of every entry must be "linux".
+	OSArchs []osarch.OSArch
+	// PackageName is the name of the package. If empty, the ID of the product is used.
+	PackageName string
+	// Prefix is the installation prefix for the package. Executables are installed in "{{Prefix}}/bin".
+	Prefix string
+	// Control contains the fields written to the control file of the package.
+	Control Control
+	// Scripts contains the maintainer scripts for the package.
+	Scripts Scripts
+	// Reproducible specifies whether the modification time of the members and entries of the packages is determined
+	// using distarchive.ReproducibleModTime so that the packages only depend on their content. Packages are also
+	// created in this manner if the SOURCE_DATE_EPOCH environment variable is set.
+	Reproducible bool
+}
+
+type Control struct {
+	Maintainer  string
+	Description string
+	Section     string
+	Priority    string
+	Homepage    string
+	Depends     []string
+	Recommends  []string
+	Conflicts   []string
+	Provides    []string
+	Replaces    []string
+}
+
+type Scripts struct {
+	PreInst  string
+	PostInst string
+	PreRm    string
+	PostRm   string
+}
+
+func (d *Dister) TypeName() (string, error) {
+	return TypeName, nil
+}
+
+func (d *Dister) Artifacts(renderedName string) ([]string, error) {
+	var outPaths []string
+	for _, osArch := range d.OSArchs {
+		outPaths = append(outPaths, fmt.Sprintf("%s-%s.deb", renderedName, osArch.String()))
+	}
+	return outPaths, nil
+}
+
+func (d *Dister) PackagingExtension() (string, error) {
+	return "deb", nil
+}
+
+func (d *Dister) osArchFromArtifactPath(distID distgo.DistID, artifactPath string, productTaskOutputInfo distgo.ProductTaskOutputInfo) (osarch.OSArch, error) {
+	for _, osArch := range d.OSArchs {
+		if strings.HasSuffix(artifactPath, fmt.Sprintf("%s-%s.deb", productTaskOutputInfo.Product.DistOutputInfos.DistInfos[distID].DistNameTemplateRendered, osArch.String())) {
+			return osArch, nil
+		}
+	}
+	return osarch.OSArch{}, errors.Errorf("failed to determine OS/Arch for artifact with Path %s", artifactPath)
+}
+
+func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
+	for _, osArch := range d.OSArchs {
+		if _, err := debianArch(osArch); err != nil {
+			return nil, err
+		}
+		if err := disterutil.VerifyDistTargetSupported(osArch, productTaskOutputInfo); err != nil {
+			return nil, err
+		}
+	}
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	outputPathsForOSArchs := make(map[string][]string)
+	for _, osArch := range d.OSArchs {
+		for _, currProductOutputInfo := range productTaskOutputInfo.AllProductOutputInfos() {
+			// copy executable for current product
+			dst, err := disterutil.CopyArtifactForOSArch(path.Join(distWorkDir, osArch.String()), productTaskOutputInfo.Project, currProductOutputInfo, osArch)
+			if err != nil {
+				return nil, err
+			}
+			outputPathsForOSArchs[osArch.String()] = append(outputPathsForOSArchs[osArch.String()], dst)
+		}
+	}
+	jsonBytes, err := json.Marshal(outputPathsForOSArchs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal outputPathsForOSArchs as JSON")
+	}
+	return jsonBytes, nil
+}
+
+func (d *Dister) GenerateDistArtifacts(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo, runDistResult []byte) error {
+	var outputPathsForOSArchs map[string][]string
+	if err := json.Unmarshal(runDistResult, &outputPathsForOSArchs); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal runDistResult JSON %s", string(runDistResult))
+	}
+
+	// all of the content of the dist work directory other than the per-OS/Arch executable directories is included in
+	// the package relative to the root of the file system
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	rootFiles, err := disterutil.RootFiles(distWorkDir, d.OSArchs)
+	if err != nil {
+		return err
+	}
+
+	packageName := d.packageName(productTaskOutputInfo.Product.ID)
+	modTime, err := d.modTime(productTaskOutputInfo.Project.ProjectDir)
+	if err != nil {
+		return err
+	}
+	for _, artifactPath := range productTaskOutputInfo.ProductDisterArtifactPaths()[distID] {
+		currOSArch, err := d.osArchFromArtifactPath(distID, artifactPath, productTaskOutputInfo)
+		if err != nil {
+			return err
+		}
+		arch, err := debianArch(currOSArch)
+		if err != nil {
+			return err
+		}
+
+		contents := newPackageContents()
+		for _, rootFile := range rootFiles {
+			if err := contents.addPath(path.Join(distWorkDir, rootFile), rootFile); err != nil {
+				return err
+			}
+		}
+		for _, executablePath := range outputPathsForOSArchs[currOSArch.String()] {
+			if err := contents.addExecutable(executablePath, path.Join(d.prefix(), "bin", path.Base(executablePath))); err != nil {
+				return err
+			}
+		}
+
+		controlFile := controlFileContent(packageName, debianVersion(productTaskOutputInfo.Project.Version), arch, contents.installedSizeKB(), d.Control, productTaskOutputInfo.Product.ID)
+		if err := writePackage(artifactPath, controlFile, d.Scripts, contents, modTime); err != nil {
+			return errors.Wrapf(err, "failed to create Debian package %s", artifactPath)
+		}
+	}
+	return nil
+}
+
+// modTime returns the modification time that is used for the members and entries of the packages. If the packages are
+// reproducible or the SOURCE_DATE_EPOCH environment variable is set, the time is determined by
+// distarchive.ReproducibleModTime. Otherwise, the current time is used.
+func (d *Dister) modTime(projectDir string) (time.Time, error) {
+	_, epochSet, err := distgo.SourceDateEpoch()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if d.Reproducible || epochSet {
+		return distarchive.ReproducibleModTime(projectDir)
+	}
+	return time.Now(), nil
+}
+
+// DocDir returns the directory in the dist work directory that is installed as the documentation directory of the
+// package, "{{Prefix}}/share/doc/{{PackageName}}".
+func (d *Dister) DocDir(productID distgo.ProductID) string {
+	return disterutil.CleanEntryName(path.Join(d.prefix(), "share", "doc", d.packageName(productID)))
+}
+
+func (d *Dister) packageName(productID distgo.ProductID) string {
+	if d.PackageName == "" {
+		return string(productID)
+	}
+	return d.PackageName
+}
+
+func (d *Dister) prefix() string {
+	if d.Prefix == "" {
+		return "/usr"
+	}
+	return d.Prefix
+}
+
+// debianArch returns the Debian architecture name for the provided OS/Arch. Returns an error if the OS/Arch is not one
+// for which Debian packages can be created.
+func debianArch(osArch osarch.OSArch) (string, error) {
+	if osArch.OS != "linux" {
+		return "", errors.Errorf("Debian packages can only be created for linux, but OS/Arch %s was specified", osArch)
+	}
+	switch osArch.Arch {
+	case "386":
+		return "i386", nil
+	case "arm":
+		return "armhf", nil
+	case "ppc64le":
+		return "ppc64el", nil
+	case "mipsle":
+		return "mipsel", nil
+	case "mips64le":
+		return "mips64el", nil
+	case "amd64", "arm64", "mips", "mips64", "ppc64", "riscv64", "s390x":
+		return osArch.Arch, nil
+	default:
+		return "", errors.Errorf("no Debian architecture is known for OS/Arch %s", osArch)
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration_test
+
+import (
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/gofiles"
+	"github.com/palantir/godel/framework/pluginapitester"
+	"github.com/palantir/godel/pkg/products/v2/products"
+	"github.com/palantir/pkg/specdir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distertester"
+)
+
+func TestDebDist(t *testing.T) {
+	const godelYML = `exclude:
+  names:
+    - "\\..+"
+    - "vendor"
+  paths:
+    - "godel"
+`
+
+	pluginPath, err := products.Bin("dist-plugin")
+	require.NoError(t, err)
+
+	distertester.RunAssetDistTest(t,
+		pluginapitester.NewPluginProvider(pluginPath),
+		nil,
+		[]distertester.TestCase{
+			{
+				Name: "deb creates expected output",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+					{
+						RelPath: "deb/etc/foo/foo.yml",
+						Src:     `key: value`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+        - os: linux
+          arch: 386
+    dist:
+      disters:
+        type: deb
+        input-dir: deb
+        config:
+          os-archs:
+            - os: linux
+              arch: amd64
+            - os: linux
+              arch: 386
+          prefix: /opt/foo
+          maintainer: Foo Maintainers <foo@example.com>
+          depends:
+            - libc6
+          scripts:
+            postinst: |
+              echo "installed foo"
+`,
+				},
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/deb/foo-1.0.0-linux-amd64.deb, out/dist/foo/1.0.0/deb/foo-1.0.0-linux-386.deb
+Finished creating deb distribution for foo
+`
+				},
+				Validate: func(projectDir string) {
+					wantLayout := specdir.NewLayoutSpec(
+						specdir.Dir(specdir.LiteralName("1.0.0"), "",
+							specdir.Dir(specdir.LiteralName("deb"), "",
+								specdir.Dir(specdir.LiteralName("foo-1.0.0"), "",
+									specdir.Dir(specdir.LiteralName("etc"), "",
+										specdir.Dir(specdir.LiteralName("foo"), "",
+											specdir.File(specdir.LiteralName("foo.yml"), ""),
+										),
+									),
+									specdir.Dir(specdir.LiteralName("linux-386"), "",
+										specdir.File(specdir.LiteralName("foo"), ""),
+									),
+									specdir.Dir(specdir.LiteralName("linux-amd64"), "",
+										specdir.File(specdir.LiteralName("foo"), ""),
+									),
+								),
+								specdir.File(specdir.LiteralName("foo-1.0.0-linux-386.deb"), ""),
+								specdir.File(specdir.LiteralName("foo-1.0.0-linux-amd64.deb"), ""),
+							),
+						), true,
+					)
+					assert.NoError(t, wantLayout.Validate(path.Join(projectDir, "out", "dist", "foo", "1.0.0"), nil))
+				},
+			},
+			{
+				Name: "deb fails for non-linux OS",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: darwin
+          arch: amd64
+    dist:
+      disters:
+        type: deb
+        config:
+          os-archs:
+            - os: darwin
+              arch: amd64
+`,
+				},
+				WantError: true,
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/deb/foo-1.0.0-darwin-amd64.deb
+Error: dist failed for foo: Debian packages can only be created for linux, but OS/Arch darwin-amd64 was specified
+`
+				},
+			},
+		},
+	)
+}
+
+func TestDebUpgradeConfig(t *testing.T) {
+	pluginPath, err := products.Bin("dist-plugin")
+	require.NoError(t, err)
+
+	pluginapitester.RunUpgradeConfigTest(t,
+		pluginapitester.NewPluginProvider(pluginPath),
+		nil,
+		[]pluginapitester.UpgradeConfigTestCase{
+			{
+				Name: `valid v0 config works`,
+				ConfigFiles: map[string]string{
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: deb
+        config:
+          # comment
+          prefix: /opt/foo
+          depends:
+            - libc6
+`,
+				},
+				WantOutput: ``,
+				WantFiles: map[string]string{
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: deb
+        config:
+          # comment
+          prefix: /opt/foo
+          depends:
+            - libc6
+`,
+				},
+			},
+		},
+	)
+}
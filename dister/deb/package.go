@@ -0,0 +1,340 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
+)
+
+// packageEntry is a single file, directory or symlink in the data archive of a package.
+type packageEntry struct {
+	// name is the path of the entry relative to the root of the file system (with no leading slash).
+	name     string
+	mode     os.FileMode
+	srcPath  string
+	linkname string
+	size     int64
+}
+
+func (e packageEntry) isDir() bool {
+	return e.mode.IsDir()
+}
+
+type packageContents struct {
+	entries map[string]packageEntry
+}
+
+func newPackageContents() *packageContents {
+	return &packageContents{
+		entries: make(map[string]packageEntry),
+	}
+}
+
+// addPath adds the file or directory at srcPath to the contents at dstPath. If srcPath is a directory, all of its
+// contents are added recursively. Symlinks are added as symlinks.
+func (c *packageContents) addPath(srcPath, dstPath string) error {
+	return filepath.Walk(srcPath, func(currPath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(srcPath, currPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine relative path")
+		}
+		entry := packageEntry{
+			name:    disterutil.CleanEntryName(path.Join(dstPath, filepath.ToSlash(relPath))),
+			mode:    fi.Mode(),
+			srcPath: currPath,
+		}
+		switch {
+		case fi.Mode()&os.ModeSymlink != 0:
+			linkname, err := os.Readlink(currPath)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read symlink %s", currPath)
+			}
+			entry.linkname = linkname
+		case fi.Mode().IsRegular():
+			entry.size = fi.Size()
+		case !fi.IsDir():
+			return errors.Errorf("%s is not a regular file, directory or symlink", currPath)
+		}
+		c.add(entry)
+		return nil
+	})
+}
+
+// addExecutable adds the regular file at srcPath to the contents at dstPath with mode 0755.
+func (c *packageContents) addExecutable(srcPath, dstPath string) error {
+	fi, err := os.Stat(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", srcPath)
+	}
+	c.add(packageEntry{
+		name:    disterutil.CleanEntryName(dstPath),
+		mode:    0755,
+		srcPath: srcPath,
+		size:    fi.Size(),
+	})
+	return nil
+}
+
+// add adds the provided entry and creates entries for any of its parent directories that do not already exist.
+func (c *packageContents) add(entry packageEntry) {
+	if entry.name == "" {
+		return
+	}
+	c.entries[entry.name] = entry
+	for dir := path.Dir(entry.name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if _, ok := c.entries[dir]; ok {
+			break
+		}
+		c.entries[dir] = packageEntry{
+			name: dir,
+			mode: os.ModeDir | 0755,
+		}
+	}
+}
+
+func (c *packageContents) sortedEntries() []packageEntry {
+	var names []string
+	for k := range c.entries {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var entries []packageEntry
+	for _, name := range names {
+		entries = append(entries, c.entries[name])
+	}
+	return entries
+}
+
+// installedSizeKB returns the total size of the regular files in the contents in kibibytes, rounded up.
+func (c *packageContents) installedSizeKB() int64 {
+	var total int64
+	for _, entry := range c.entries {
+		total += entry.size
+	}
+	return (total + 1023) / 1024
+}
+
+// writePackage writes a Debian binary package to dstPath. The package is an "ar" archive that contains the
+// "debian-binary", "control.tar.gz" and "data.tar.gz" members. The provided time is used as the modification time of all
+// of the members and entries.
+func writePackage(dstPath, controlFile string, scripts Scripts, contents *packageContents, modTime time.Time) error {
+	dataTarGz, md5sums, conffiles, err := dataArchive(contents, modTime)
+	if err != nil {
+		return err
+	}
+
+	controlFiles := []controlArchiveFile{
+		{name: "control", content: controlFile, mode: 0644},
+		{name: "md5sums", content: md5sums, mode: 0644},
+	}
+	if conffiles != "" {
+		controlFiles = append(controlFiles, controlArchiveFile{name: "conffiles", content: conffiles, mode: 0644})
+	}
+	for _, script := range []struct {
+		name    string
+		content string
+	}{
+		{"preinst", scripts.PreInst},
+		{"postinst", scripts.PostInst},
+		{"prerm", scripts.PreRm},
+		{"postrm", scripts.PostRm},
+	} {
+		if script.content == "" {
+			continue
+		}
+		controlFiles = append(controlFiles, controlArchiveFile{name: script.name, content: maintainerScriptContent(script.content), mode: 0755})
+	}
+	controlTarGz, err := controlArchive(controlFiles, modTime)
+	if err != nil {
+		return err
+	}
+
+	arBuf := &bytes.Buffer{}
+	arBuf.WriteString("!<arch>\n")
+	for _, member := range []struct {
+		name    string
+		content []byte
+	}{
+		{"debian-binary", []byte("2.0\n")},
+		{"control.tar.gz", controlTarGz},
+		{"data.tar.gz", dataTarGz},
+	} {
+		writeArMember(arBuf, member.name, member.content, modTime)
+	}
+	if err := ioutil.WriteFile(dstPath, arBuf.Bytes(), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", dstPath)
+	}
+	return nil
+}
+
+// writeArMember writes the provided member in the common "ar" format used by Debian packages.
+func writeArMember(w *bytes.Buffer, name string, content []byte, modTime time.Time) {
+	fmt.Fprintf(w, "%-16s%-12d%-6d%-6d%-8s%-10d`\n", name, modTime.Unix(), 0, 0, "100644", len(content))
+	w.Write(content)
+	if len(content)%2 != 0 {
+		w.WriteString("\n")
+	}
+}
+
+type controlArchiveFile struct {
+	name    string
+	content string
+	mode    int64
+}
+
+func controlArchive(files []controlArchiveFile, modTime time.Time) ([]byte, error) {
+	return tarGz(func(tw *tar.Writer) error {
+		if err := tw.WriteHeader(dirHeader("./", modTime)); err != nil {
+			return errors.Wrapf(err, "failed to write tar header")
+		}
+		for _, f := range files {
+			if err := tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     "./" + f.name,
+				Mode:     f.mode,
+				Size:     int64(len(f.content)),
+				ModTime:  modTime,
+				Uname:    "root",
+				Gname:    "root",
+			}); err != nil {
+				return errors.Wrapf(err, "failed to write tar header for %s", f.name)
+			}
+			if _, err := io.WriteString(tw, f.content); err != nil {
+				return errors.Wrapf(err, "failed to write %s", f.name)
+			}
+		}
+		return nil
+	})
+}
+
+// dataArchive returns the bytes of the "data.tar.gz" archive for the provided contents along with the content of the
+// "md5sums" and "conffiles" control files. All regular files in "/etc" are considered configuration files.
+func dataArchive(contents *packageContents, modTime time.Time) ([]byte, string, string, error) {
+	md5sums := &bytes.Buffer{}
+	conffiles := &bytes.Buffer{}
+	dataTarGz, err := tarGz(func(tw *tar.Writer) error {
+		if err := tw.WriteHeader(dirHeader("./", modTime)); err != nil {
+			return errors.Wrapf(err, "failed to write tar header")
+		}
+		for _, entry := range contents.sortedEntries() {
+			switch {
+			case entry.isDir():
+				if err := tw.WriteHeader(dirHeader("./"+entry.name+"/", modTime)); err != nil {
+					return errors.Wrapf(err, "failed to write tar header for %s", entry.name)
+				}
+			case entry.linkname != "":
+				if err := tw.WriteHeader(&tar.Header{
+					Typeflag: tar.TypeSymlink,
+					Name:     "./" + entry.name,
+					Linkname: entry.linkname,
+					Mode:     0777,
+					ModTime:  modTime,
+					Uname:    "root",
+					Gname:    "root",
+				}); err != nil {
+					return errors.Wrapf(err, "failed to write tar header for %s", entry.name)
+				}
+			default:
+				if err := tw.WriteHeader(&tar.Header{
+					Typeflag: tar.TypeReg,
+					Name:     "./" + entry.name,
+					Mode:     int64(entry.mode.Perm()),
+					Size:     entry.size,
+					ModTime:  modTime,
+					Uname:    "root",
+					Gname:    "root",
+				}); err != nil {
+					return errors.Wrapf(err, "failed to write tar header for %s", entry.name)
+				}
+				hash := md5.New()
+				if err := disterutil.CopyFile(io.MultiWriter(tw, hash), entry.srcPath); err != nil {
+					return err
+				}
+				fmt.Fprintf(md5sums, "%x  %s\n", hash.Sum(nil), entry.name)
+				if strings.HasPrefix(entry.name, "etc/") {
+					fmt.Fprintf(conffiles, "/%s\n", entry.name)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", "", err
+	}
+	return dataTarGz, md5sums.String(), conffiles.String(), nil
+}
+
+func tarGz(writeFn func(tw *tar.Writer) error) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	if err := writeFn(tw); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, errors.Wrapf(err, "failed to close tar writer")
+	}
+	if err := gw.Close(); err != nil {
+		return nil, errors.Wrapf(err, "failed to close gzip writer")
+	}
+	return buf.Bytes(), nil
+}
+
+func dirHeader(name string, modTime time.Time) *tar.Header {
+	return &tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name,
+		Mode:     0755,
+		ModTime:  modTime,
+		Uname:    "root",
+		Gname:    "root",
+	}
+}
+
+// maintainerScriptContent returns the content for a maintainer script. If the provided script does not start with an
+// interpreter line, "#!/bin/sh" is used.
+func maintainerScriptContent(script string) string {
+	if !strings.HasPrefix(script, "#!") {
+		script = "#!/bin/sh\n" + script
+	}
+	if !strings.HasSuffix(script, "\n") {
+		script += "\n"
+	}
+	return script
+}
@@ -0,0 +1,188 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePackage(t *testing.T) {
+	tmpDir, cleanup, err := dirs.TempDir("", "")
+	require.NoError(t, err)
+	defer cleanup()
+
+	srcDir := path.Join(tmpDir, "src")
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "etc", "foo"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "etc", "foo", "foo.yml"), []byte("key: value\n"), 0644))
+	require.NoError(t, os.Symlink("foo.yml", path.Join(srcDir, "etc", "foo", "link.yml")))
+	exe := path.Join(tmpDir, "foo")
+	require.NoError(t, ioutil.WriteFile(exe, []byte("#!/bin/sh\necho foo\n"), 0644))
+
+	contents := newPackageContents()
+	require.NoError(t, contents.addPath(path.Join(srcDir, "etc"), "etc"))
+	require.NoError(t, contents.addExecutable(exe, "/usr/bin/foo"))
+
+	controlFile := controlFileContent("foo", debianVersion("v1.0.0-rc1"), "amd64", contents.installedSizeKB(), Control{
+		Maintainer:  "Foo <foo@example.com>",
+		Description: "foo\nThe foo product.",
+		Depends:     []string{"libc6", "bash (>= 4.0)"},
+	}, "foo")
+	modTime := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	pkgPath := path.Join(tmpDir, "foo.deb")
+	require.NoError(t, writePackage(pkgPath, controlFile, Scripts{PostInst: "echo postinst"}, contents, modTime))
+
+	content, err := ioutil.ReadFile(pkgPath)
+	require.NoError(t, err)
+
+	// packages with the same content and modification time are identical
+	require.NoError(t, writePackage(pkgPath, controlFile, Scripts{PostInst: "echo postinst"}, contents, modTime))
+	rewrittenContent, err := ioutil.ReadFile(pkgPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, rewrittenContent)
+
+	members := parseAr(t, content)
+	var memberNames []string
+	for _, member := range members {
+		memberNames = append(memberNames, member.name)
+		assert.Equal(t, modTime.Unix(), member.modTime, "unexpected modification time for %s", member.name)
+	}
+	require.Equal(t, []string{"debian-binary", "control.tar.gz", "data.tar.gz"}, memberNames)
+	assert.Equal(t, "2.0\n", string(members[0].content))
+
+	controlEntries := parseTarGz(t, members[1].content, modTime)
+	assert.Equal(t, []string{"./", "./control", "./md5sums", "./conffiles", "./postinst"}, tarEntryNames(controlEntries))
+	assert.Equal(t, `Package: foo
+Version: 1.0.0~rc1
+Architecture: amd64
+Maintainer: Foo <foo@example.com>
+Installed-Size: 1
+Depends: libc6, bash (>= 4.0)
+Section: misc
+Priority: optional
+Description: foo
+ The foo product.
+`, controlEntries[1].content)
+	assert.Equal(t, "04db1e80f9dcbe296ea2b96b62e56517  etc/foo/foo.yml\n8e74b6cfdf9ef1dd17f6bdedd95016a5  usr/bin/foo\n", controlEntries[2].content)
+	assert.Equal(t, "/etc/foo/foo.yml\n", controlEntries[3].content)
+	assert.True(t, strings.HasSuffix(controlEntries[4].content, "echo postinst\n"), controlEntries[4].content)
+	assert.Equal(t, int64(0755), controlEntries[4].mode)
+
+	dataEntries := parseTarGz(t, members[2].content, modTime)
+	assert.Equal(t, []string{
+		"./",
+		"./etc/",
+		"./etc/foo/",
+		"./etc/foo/foo.yml",
+		"./etc/foo/link.yml",
+		"./usr/",
+		"./usr/bin/",
+		"./usr/bin/foo",
+	}, tarEntryNames(dataEntries))
+	assert.Equal(t, "key: value\n", dataEntries[3].content)
+	assert.Equal(t, "foo.yml", dataEntries[4].linkname)
+	assert.Equal(t, "#!/bin/sh\necho foo\n", dataEntries[7].content)
+	assert.Equal(t, int64(0755), dataEntries[7].mode)
+}
+
+type arMember struct {
+	name    string
+	modTime int64
+	content []byte
+}
+
+// parseAr parses the members of a common "ar" archive.
+func parseAr(t *testing.T, b []byte) []arMember {
+	require.True(t, bytes.HasPrefix(b, []byte("!<arch>\n")))
+	b = b[len("!<arch>\n"):]
+	var members []arMember
+	for len(b) > 0 {
+		require.True(t, len(b) >= 60)
+		header := string(b[:60])
+		require.Equal(t, "`\n", header[58:60])
+		modTime, err := strconv.ParseInt(strings.TrimSpace(header[16:28]), 10, 64)
+		require.NoError(t, err)
+		size, err := strconv.Atoi(strings.TrimSpace(header[48:58]))
+		require.NoError(t, err)
+		members = append(members, arMember{
+			name:    strings.TrimSpace(header[:16]),
+			modTime: modTime,
+			content: b[60 : 60+size],
+		})
+		b = b[60+size+size%2:]
+	}
+	return members
+}
+
+type tarEntry struct {
+	name     string
+	mode     int64
+	linkname string
+	content  string
+}
+
+// parseTarGz parses the entries of a gzip-compressed tar archive and verifies that all of them have the provided
+// modification time and are owned by root.
+func parseTarGz(t *testing.T, b []byte, modTime time.Time) []tarEntry {
+	gr, err := gzip.NewReader(bytes.NewReader(b))
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+	var entries []tarEntry
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.True(t, modTime.Equal(hdr.ModTime), "unexpected modification time for %s: %v", hdr.Name, hdr.ModTime)
+		assert.Equal(t, "root", hdr.Uname)
+		assert.Equal(t, "root", hdr.Gname)
+		content, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		entries = append(entries, tarEntry{
+			name:     hdr.Name,
+			mode:     hdr.Mode,
+			linkname: hdr.Linkname,
+			content:  string(content),
+		})
+	}
+	return entries
+}
+
+func tarEntryNames(entries []tarEntry) []string {
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.name)
+	}
+	return names
+}
@@ -0,0 +1,103 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distarchive
+
+import (
+	"strings"
+
+	"github.com/mholt/archiver"
+	"github.com/pkg/errors"
+)
+
+// Format is the format of an archive created by a dister.
+type Format string
+
+const (
+	FormatTGZ   Format = "tgz"
+	FormatTarXZ Format = "tar.xz"
+	FormatZip   Format = "zip"
+	// FormatAuto resolves to FormatZip for Windows targets and FormatTGZ for all other targets.
+	FormatAuto Format = "auto"
+)
+
+// Formats returns all of the valid formats.
+func Formats() []Format {
+	return []Format{FormatTGZ, FormatTarXZ, FormatZip, FormatAuto}
+}
+
+// ParseFormat returns the Format for the provided string. An empty string is parsed as FormatTGZ. Returns an error if
+// the provided string is not a valid format.
+func ParseFormat(format string) (Format, error) {
+	if format == "" {
+		return FormatTGZ, nil
+	}
+	for _, f := range Formats() {
+		if Format(format) == f {
+			return f, nil
+		}
+	}
+	var valid []string
+	for _, f := range Formats() {
+		valid = append(valid, string(f))
+	}
+	return "", errors.Errorf("invalid archive format %q: must be one of %v", format, valid)
+}
+
+// ForOS returns the concrete format that should be used for an archive that targets the provided OS. If the format is
+// FormatAuto, returns FormatZip if the OS is "windows" and FormatTGZ otherwise. For all other formats, returns the
+// format itself.
+func (f Format) ForOS(os string) Format {
+	if f != FormatAuto {
+		return f
+	}
+	if os == "windows" {
+		return FormatZip
+	}
+	return FormatTGZ
+}
+
+// Extension returns the file extension (without a leading period) for archives of this format. Returns an empty
+// string for FormatAuto.
+func (f Format) Extension() string {
+	if f == FormatAuto {
+		return ""
+	}
+	return string(f)
+}
+
+// Make creates an archive of this format at dstPath that contains the provided source paths. Directories are added
+// recursively. Returns an error if the format is not a concrete format.
+func (f Format) Make(dstPath string, srcPaths []string) error {
+	var a archiver.Archiver
+	switch f {
+	case FormatTGZ:
+		a = archiver.TarGz
+	case FormatTarXZ:
+		a = archiver.TarXZ
+	case FormatZip:
+		a = archiver.Zip
+	default:
+		return errors.Errorf("cannot create archive with format %q", f)
+	}
+	if err := a.Make(dstPath, srcPaths); err != nil {
+		return errors.Wrapf(err, "failed to create %s archive", strings.ToUpper(string(f)))
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distarchive_test
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+)
+
+func TestParseFormat(t *testing.T) {
+	for i, tc := range []struct {
+		in        string
+		want      distarchive.Format
+		wantError string
+	}{
+		{"", distarchive.FormatTGZ, ""},
+		{"tgz", distarchive.FormatTGZ, ""},
+		{"tar.xz", distarchive.FormatTarXZ, ""},
+		{"zip", distarchive.FormatZip, ""},
+		{"auto", distarchive.FormatAuto, ""},
+		{"rar", "", `invalid archive format "rar": must be one of [tgz tar.xz zip auto]`},
+	} {
+		got, err := distarchive.ParseFormat(tc.in)
+		if tc.wantError != "" {
+			assert.EqualError(t, err, tc.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got, "Case %d", i)
+	}
+}
+
+func TestFormatForOS(t *testing.T) {
+	for i, tc := range []struct {
+		format        distarchive.Format
+		os            string
+		want          distarchive.Format
+		wantExtension string
+	}{
+		{distarchive.FormatAuto, "windows", distarchive.FormatZip, "zip"},
+		{distarchive.FormatAuto, "linux", distarchive.FormatTGZ, "tgz"},
+		{distarchive.FormatTarXZ, "windows", distarchive.FormatTarXZ, "tar.xz"},
+		{distarchive.FormatTGZ, "darwin", distarchive.FormatTGZ, "tgz"},
+	} {
+		got := tc.format.ForOS(tc.os)
+		assert.Equal(t, tc.want, got, "Case %d", i)
+		assert.Equal(t, tc.wantExtension, got.Extension(), "Case %d", i)
+	}
+}
+
+func TestMakeZip(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	srcPath := path.Join(tmpDir, "foo.exe")
+	require.NoError(t, ioutil.WriteFile(srcPath, []byte("foo"), 0755))
+	dstPath := path.Join(tmpDir, "foo.zip")
+	require.NoError(t, distarchive.FormatZip.Make(dstPath, []string{srcPath}))
+
+	r, err := zip.OpenReader(dstPath)
+	require.NoError(t, err)
+	defer func() {
+		_ = r.Close()
+	}()
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"foo.exe"}, names)
+}
+
+func TestMakeAutoFails(t *testing.T) {
+	err := distarchive.FormatAuto.Make("foo", nil)
+	assert.EqualError(t, err, `cannot create archive with format "auto"`)
+}
@@ -0,0 +1,235 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distarchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ulikunitz/xz"
+)
+
+// FormatForPath returns the concrete Format of the archive at the provided path based on its extension. Returns false
+// if the extension of the path does not match a concrete format. The extension ".tar.gz" is recognized as FormatTGZ.
+func FormatForPath(archivePath string) (Format, bool) {
+	switch {
+	case strings.HasSuffix(archivePath, ".tgz"), strings.HasSuffix(archivePath, ".tar.gz"):
+		return FormatTGZ, true
+	case strings.HasSuffix(archivePath, ".tar.xz"):
+		return FormatTarXZ, true
+	case strings.HasSuffix(archivePath, ".zip"):
+		return FormatZip, true
+	default:
+		return "", false
+	}
+}
+
+// TrimExtension returns the provided archive name without the extension of the format returned by FormatForPath. If
+// the name does not have the extension of a concrete format, it is returned unmodified.
+func TrimExtension(archiveName string) string {
+	for _, ext := range []string{".tgz", ".tar.gz", ".tar.xz", ".zip"} {
+		if strings.HasSuffix(archiveName, ext) {
+			return strings.TrimSuffix(archiveName, ext)
+		}
+	}
+	return archiveName
+}
+
+// Extract extracts the archive of this format at srcPath into dstDir, which is created if it does not exist. Regular
+// files, directories and symlinks are extracted and the permission bits of the entries are preserved. Returns an error
+// if the format is not a concrete format or if any entry would be written outside of dstDir (including through a
+// symlink extracted from the archive).
+func (f Format) Extract(srcPath, dstDir string) error {
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", dstDir)
+	}
+	in, err := os.Open(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", srcPath)
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	switch f {
+	case FormatTGZ:
+		gr, err := gzip.NewReader(in)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create gzip reader for %s", srcPath)
+		}
+		return extractTar(gr, dstDir)
+	case FormatTarXZ:
+		xr, err := xz.NewReader(in)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create xz reader for %s", srcPath)
+		}
+		return extractTar(xr, dstDir)
+	case FormatZip:
+		return extractZip(srcPath, dstDir)
+	default:
+		return errors.Errorf("cannot extract archive with format %q", f)
+	}
+}
+
+func extractTar(r io.Reader, dstDir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read tar entry")
+		}
+		dstPath, err := extractPath(dstDir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		mode := os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = extractDir(dstPath, mode)
+		case tar.TypeSymlink:
+			err = extractSymlink(dstPath, hdr.Linkname)
+		case tar.TypeReg, tar.TypeRegA:
+			err = extractFile(dstPath, mode, tr)
+		default:
+			err = errors.Errorf("unsupported type for tar entry %s", hdr.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(srcPath, dstDir string) error {
+	zr, err := zip.OpenReader(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", srcPath)
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+	for _, zf := range zr.File {
+		dstPath, err := extractPath(dstDir, zf.Name)
+		if err != nil {
+			return err
+		}
+		mode := zf.Mode()
+		if mode.IsDir() {
+			if err := extractDir(dstPath, mode.Perm()); err != nil {
+				return err
+			}
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return errors.Wrapf(err, "failed to open zip entry %s", zf.Name)
+		}
+		if mode&os.ModeSymlink != 0 {
+			var target bytes.Buffer
+			if _, err = io.Copy(&target, rc); err == nil {
+				err = extractSymlink(dstPath, target.String())
+			}
+		} else {
+			err = extractFile(dstPath, mode.Perm(), rc)
+		}
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractPath returns the path in dstDir for the archive entry with the provided name. Returns an error if the name is
+// absolute or refers to a location outside of dstDir. Also returns an error if the path traverses or replaces a symlink
+// that exists in dstDir (typically one extracted from an earlier entry), since the symlink may point outside of dstDir.
+func extractPath(dstDir, name string) (string, error) {
+	slashName := filepath.ToSlash(name)
+	cleaned := path.Clean(slashName)
+	if path.IsAbs(slashName) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", errors.Errorf("invalid archive entry %q: path must be relative and within the archive", name)
+	}
+	dstPath := filepath.Join(dstDir, filepath.FromSlash(cleaned))
+	if cleaned == "." {
+		return dstPath, nil
+	}
+	currPath := dstDir
+	for _, component := range strings.Split(cleaned, "/") {
+		currPath = filepath.Join(currPath, component)
+		fi, err := os.Lstat(currPath)
+		if os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return "", errors.Wrapf(err, "failed to stat %s", currPath)
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return "", errors.Errorf("invalid archive entry %q: path must not traverse or replace a symlink", name)
+		}
+	}
+	return dstPath, nil
+}
+
+func extractDir(dstPath string, mode os.FileMode) error {
+	if err := os.MkdirAll(dstPath, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", dstPath)
+	}
+	if err := os.Chmod(dstPath, mode|0700); err != nil {
+		return errors.Wrapf(err, "failed to set mode of directory %s", dstPath)
+	}
+	return nil
+}
+
+func extractSymlink(dstPath, target string) error {
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", dstPath)
+	}
+	if err := os.Symlink(target, dstPath); err != nil {
+		return errors.Wrapf(err, "failed to create symlink %s", dstPath)
+	}
+	return nil
+}
+
+func extractFile(dstPath string, mode os.FileMode, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", dstPath)
+	}
+	out, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", dstPath)
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		_ = out.Close()
+		return errors.Wrapf(err, "failed to write %s", dstPath)
+	}
+	if err := out.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close %s", dstPath)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distarchive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+)
+
+func TestFormatForPath(t *testing.T) {
+	for i, tc := range []struct {
+		in         string
+		wantFormat distarchive.Format
+		wantOK     bool
+		wantTrim   string
+	}{
+		{"foo-1.0.0-linux-amd64.tgz", distarchive.FormatTGZ, true, "foo-1.0.0-linux-amd64"},
+		{"foo-1.0.0.tar.gz", distarchive.FormatTGZ, true, "foo-1.0.0"},
+		{"foo-1.0.0.tar.xz", distarchive.FormatTarXZ, true, "foo-1.0.0"},
+		{"foo-1.0.0.zip", distarchive.FormatZip, true, "foo-1.0.0"},
+		{"foo-1.0.0.deb", "", false, "foo-1.0.0.deb"},
+	} {
+		gotFormat, gotOK := distarchive.FormatForPath(tc.in)
+		assert.Equal(t, tc.wantFormat, gotFormat, "Case %d", i)
+		assert.Equal(t, tc.wantOK, gotOK, "Case %d", i)
+		assert.Equal(t, tc.wantTrim, distarchive.TrimExtension(tc.in), "Case %d", i)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	srcDir := path.Join(tmpDir, "foo")
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "bin"), 0755))
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "var", "log"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "bin", "foo"), []byte("foo"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "README.md"), []byte("readme"), 0644))
+	require.NoError(t, os.Symlink("README.md", path.Join(srcDir, "README")))
+
+	for _, format := range []distarchive.Format{distarchive.FormatTGZ, distarchive.FormatTarXZ, distarchive.FormatZip} {
+		archivePath := path.Join(tmpDir, "foo."+format.Extension())
+		require.NoError(t, format.MakeWithOptions(archivePath, []string{srcDir}, distarchive.Options{
+			PreserveSymlinks: true,
+		}), "format %s", format)
+		dstDir := path.Join(tmpDir, "extracted-"+string(format))
+		require.NoError(t, format.Extract(archivePath, dstDir), "format %s", format)
+
+		bytes, err := ioutil.ReadFile(path.Join(dstDir, "foo", "bin", "foo"))
+		require.NoError(t, err, "format %s", format)
+		assert.Equal(t, "foo", string(bytes), "format %s", format)
+		fi, err := os.Stat(path.Join(dstDir, "foo", "bin", "foo"))
+		require.NoError(t, err, "format %s", format)
+		assert.Equal(t, os.FileMode(0755), fi.Mode().Perm(), "format %s", format)
+
+		target, err := os.Readlink(path.Join(dstDir, "foo", "README"))
+		require.NoError(t, err, "format %s", format)
+		assert.Equal(t, "README.md", target, "format %s", format)
+
+		fi, err = os.Stat(path.Join(dstDir, "foo", "var", "log"))
+		require.NoError(t, err, "format %s", format)
+		assert.True(t, fi.IsDir(), "format %s", format)
+	}
+}
+
+func TestExtractRejectsEntriesOutsideDestination(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	archivePath := path.Join(tmpDir, "evil.tgz")
+	f, err := os.Create(archivePath)
+	require.NoError(t, err)
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "../evil",
+		Mode:     0644,
+		Size:     4,
+		Typeflag: tar.TypeReg,
+	}))
+	_, err = tw.Write([]byte("evil"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	require.NoError(t, f.Close())
+
+	err = distarchive.FormatTGZ.Extract(archivePath, path.Join(tmpDir, "out"))
+	assert.EqualError(t, err, `invalid archive entry "../evil": path must be relative and within the archive`)
+	_, err = os.Stat(path.Join(tmpDir, "evil"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExtractRejectsEntriesThroughSymlinks(t *testing.T) {
+	type entry struct {
+		name     string
+		linkname string
+		content  string
+	}
+	for i, tc := range []struct {
+		name      string
+		entries   []entry
+		wantError string
+	}{
+		{
+			name: "file in directory that is a symlink to an absolute path",
+			entries: []entry{
+				{name: "a", linkname: "{{outside}}"},
+				{name: "a/evil", content: "evil"},
+			},
+			wantError: `invalid archive entry "a/evil": path must not traverse or replace a symlink`,
+		},
+		{
+			name: "file in directory that is a symlink to a relative path outside of the destination",
+			entries: []entry{
+				{name: "a", linkname: "../outside"},
+				{name: "a/evil", content: "evil"},
+			},
+			wantError: `invalid archive entry "a/evil": path must not traverse or replace a symlink`,
+		},
+		{
+			name: "file that replaces a symlink",
+			entries: []entry{
+				{name: "evil", linkname: "{{outside}}/evil"},
+				{name: "evil", content: "evil"},
+			},
+			wantError: `invalid archive entry "evil": path must not traverse or replace a symlink`,
+		},
+	} {
+		func() {
+			tmpDir, err := ioutil.TempDir("", "")
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+			defer func() {
+				_ = os.RemoveAll(tmpDir)
+			}()
+			outsideDir := path.Join(tmpDir, "outside")
+			require.NoError(t, os.Mkdir(outsideDir, 0755), "Case %d: %s", i, tc.name)
+
+			for _, format := range []distarchive.Format{distarchive.FormatTGZ, distarchive.FormatZip} {
+				archivePath := path.Join(tmpDir, "evil"+format.Extension())
+				f, err := os.Create(archivePath)
+				require.NoError(t, err, "Case %d: %s", i, tc.name)
+				if format == distarchive.FormatZip {
+					zw := zip.NewWriter(f)
+					for _, currEntry := range tc.entries {
+						hdr := &zip.FileHeader{Name: currEntry.name}
+						content := currEntry.content
+						if currEntry.linkname != "" {
+							hdr.SetMode(os.ModeSymlink | 0777)
+							content = strings.Replace(currEntry.linkname, "{{outside}}", outsideDir, -1)
+						} else {
+							hdr.SetMode(0644)
+						}
+						w, err := zw.CreateHeader(hdr)
+						require.NoError(t, err, "Case %d: %s", i, tc.name)
+						_, err = w.Write([]byte(content))
+						require.NoError(t, err, "Case %d: %s", i, tc.name)
+					}
+					require.NoError(t, zw.Close(), "Case %d: %s", i, tc.name)
+				} else {
+					gw := gzip.NewWriter(f)
+					tw := tar.NewWriter(gw)
+					for _, currEntry := range tc.entries {
+						hdr := &tar.Header{
+							Name:     currEntry.name,
+							Mode:     0644,
+							Size:     int64(len(currEntry.content)),
+							Typeflag: tar.TypeReg,
+						}
+						if currEntry.linkname != "" {
+							hdr.Typeflag = tar.TypeSymlink
+							hdr.Linkname = strings.Replace(currEntry.linkname, "{{outside}}", outsideDir, -1)
+							hdr.Size = 0
+						}
+						require.NoError(t, tw.WriteHeader(hdr), "Case %d: %s", i, tc.name)
+						_, err = tw.Write([]byte(currEntry.content))
+						require.NoError(t, err, "Case %d: %s", i, tc.name)
+					}
+					require.NoError(t, tw.Close(), "Case %d: %s", i, tc.name)
+					require.NoError(t, gw.Close(), "Case %d: %s", i, tc.name)
+				}
+				require.NoError(t, f.Close(), "Case %d: %s", i, tc.name)
+
+				err = format.Extract(archivePath, path.Join(tmpDir, "out-"+string(format)))
+				assert.EqualError(t, err, tc.wantError, "Case %d: %s (%s)", i, tc.name, format)
+				_, err = os.Stat(path.Join(outsideDir, "evil"))
+				assert.True(t, os.IsNotExist(err), "Case %d: %s (%s)", i, tc.name, format)
+			}
+		}()
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distarchive
+
+import (
+	"archive/tar"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FileMode specifies the permissions and owner of the archive entries whose paths match a glob pattern.
+type FileMode struct {
+	// Pattern is the glob pattern for the entries. A pattern that contains a '/' is matched against the path of the
+	// entry relative to the directory that is archived (for example, "bin/*/*") and any other pattern is matched against
+	// the name of the entry (for example, "*.sh").
+	Pattern string `yaml:"pattern"`
+
+	// Mode is the permissions of the entries as an octal string (for example, "0755"). If blank, the permissions are
+	// not changed.
+	Mode string `yaml:"mode,omitempty"`
+
+	// Owner is the user name or numeric user ID of the owner of the entries. If a name is specified, the numeric user
+	// ID is 0. If blank, the owner is not changed. Ignored for ZIP archives.
+	Owner string `yaml:"owner,omitempty"`
+
+	// Group is the group name or numeric group ID of the group of the entries. If a name is specified, the numeric
+	// group ID is 0. If blank, the group is not changed. Ignored for ZIP archives.
+	Group string `yaml:"group,omitempty"`
+}
+
+// FileModes are applied in order, so if the patterns of multiple FileModes match an entry, the values of the later
+// FileModes take precedence.
+type FileModes []FileMode
+
+// Validate returns an error if any of the patterns or modes of the receiver are invalid.
+func (m FileModes) Validate() error {
+	for _, fileMode := range m {
+		if fileMode.Pattern == "" {
+			return errors.Errorf("file mode pattern must be non-empty")
+		}
+		if _, err := path.Match(fileMode.Pattern, ""); err != nil {
+			return errors.Wrapf(err, "invalid file mode pattern %q", fileMode.Pattern)
+		}
+		if fileMode.Mode != "" {
+			if _, err := parseMode(fileMode.Mode); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func parseMode(mode string) (os.FileMode, error) {
+	val, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || val > 0777 {
+		return 0, errors.Errorf("invalid file mode %q: must be an octal value between 0000 and 0777", mode)
+	}
+	return os.FileMode(val), nil
+}
+
+// matches returns true if the pattern of the receiver matches the provided slash-separated path.
+func (m FileMode) matches(matchPath string) bool {
+	name := matchPath
+	if !strings.Contains(m.Pattern, "/") {
+		name = path.Base(matchPath)
+	}
+	match, err := path.Match(m.Pattern, name)
+	return err == nil && match
+}
+
+// resolve returns the permissions, owner and group for the entry with the provided path. The returned permissions are
+// nil and the returned owner and group are empty if they are not specified by any of the matching FileModes.
+func (m FileModes) resolve(matchPath string) (*os.FileMode, string, string, error) {
+	var mode *os.FileMode
+	var owner, group string
+	for _, fileMode := range m {
+		if !fileMode.matches(matchPath) {
+			continue
+		}
+		if fileMode.Mode != "" {
+			perm, err := parseMode(fileMode.Mode)
+			if err != nil {
+				return nil, "", "", err
+			}
+			mode = &perm
+		}
+		if fileMode.Owner != "" {
+			owner = fileMode.Owner
+		}
+		if fileMode.Group != "" {
+			group = fileMode.Group
+		}
+	}
+	return mode, owner, group, nil
+}
+
+// ApplyToTarHeader updates the permissions and owner of the provided tar header based on the FileModes that match the
+// provided slash-separated path.
+func (m FileModes) ApplyToTarHeader(matchPath string, hdr *tar.Header) error {
+	mode, owner, group, err := m.resolve(matchPath)
+	if err != nil {
+		return err
+	}
+	if mode != nil {
+		hdr.Mode = hdr.Mode&^int64(os.ModePerm) | int64(*mode)
+	}
+	if owner != "" {
+		hdr.Uid, hdr.Uname = idAndName(owner)
+	}
+	if group != "" {
+		hdr.Gid, hdr.Gname = idAndName(group)
+	}
+	return nil
+}
+
+// permFor returns the permissions for the entry with the provided path based on the FileModes that match it, or the
+// provided default permissions if none of them specify permissions.
+func (m FileModes) permFor(matchPath string, defaultPerm os.FileMode) (os.FileMode, error) {
+	mode, _, _, err := m.resolve(matchPath)
+	if err != nil {
+		return 0, err
+	}
+	if mode == nil {
+		return defaultPerm, nil
+	}
+	return *mode, nil
+}
+
+func idAndName(value string) (int, string) {
+	if id, err := strconv.Atoi(value); err == nil {
+		return id, ""
+	}
+	return 0, value
+}
@@ -0,0 +1,166 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distarchive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+)
+
+var testFileModes = distarchive.FileModes{
+	{Pattern: "bin/*", Mode: "0755", Owner: "root", Group: "0"},
+	{Pattern: "*.sh", Mode: "0750", Owner: "1000", Group: "app"},
+	{Pattern: "conf/*", Mode: "0640"},
+	{Pattern: "conf/secret.yml", Mode: "0600"},
+}
+
+func writeFileModesSrcDir(t *testing.T, tmpDir string) string {
+	srcDir := path.Join(tmpDir, "foo")
+	for _, file := range []string{"bin/foo", "conf/app.yml", "conf/secret.yml", "scripts/run.sh", "README.md"} {
+		filePath := path.Join(srcDir, file)
+		require.NoError(t, os.MkdirAll(path.Dir(filePath), 0755))
+		require.NoError(t, ioutil.WriteFile(filePath, []byte(file), 0666))
+		require.NoError(t, os.Chmod(filePath, 0666))
+	}
+	return srcDir
+}
+
+func TestMakeWithFileModesTar(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	srcDir := writeFileModesSrcDir(t, tmpDir)
+
+	type entry struct {
+		mode  int64
+		uid   int
+		uname string
+		gid   int
+		gname string
+	}
+	for _, reproducible := range []bool{false, true} {
+		dstPath := path.Join(tmpDir, "out.tgz")
+		require.NoError(t, distarchive.FormatTGZ.MakeWithOptions(dstPath, []string{srcDir}, distarchive.Options{
+			Reproducible: reproducible,
+			ModTime:      time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC),
+			FileModes:    testFileModes,
+		}))
+
+		f, err := os.Open(dstPath)
+		require.NoError(t, err)
+		gr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		got := make(map[string]entry)
+		tr := tar.NewReader(gr)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			got[hdr.Name] = entry{
+				mode:  hdr.Mode & 0777,
+				uid:   hdr.Uid,
+				uname: hdr.Uname,
+				gid:   hdr.Gid,
+				gname: hdr.Gname,
+			}
+		}
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, entry{mode: 0755, uname: "root"}, got["foo/bin/foo"], "reproducible: %v", reproducible)
+		assert.Equal(t, entry{mode: 0750, uid: 1000, gname: "app"}, got["foo/scripts/run.sh"], "reproducible: %v", reproducible)
+		assert.Equal(t, int64(0640), got["foo/conf/app.yml"].mode, "reproducible: %v", reproducible)
+		assert.Equal(t, int64(0600), got["foo/conf/secret.yml"].mode, "reproducible: %v", reproducible)
+		wantREADMEMode := int64(0666)
+		if reproducible {
+			wantREADMEMode = 0644
+		}
+		assert.Equal(t, wantREADMEMode, got["foo/README.md"].mode, "reproducible: %v", reproducible)
+	}
+}
+
+func TestMakeWithFileModesZip(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	srcDir := writeFileModesSrcDir(t, tmpDir)
+
+	dstPath := path.Join(tmpDir, "out.zip")
+	require.NoError(t, distarchive.FormatZip.MakeWithOptions(dstPath, []string{srcDir}, distarchive.Options{
+		FileModes: testFileModes,
+	}))
+	zr, err := zip.OpenReader(dstPath)
+	require.NoError(t, err)
+	defer func() {
+		_ = zr.Close()
+	}()
+	got := make(map[string]os.FileMode)
+	for _, f := range zr.File {
+		got[f.Name] = f.Mode()
+	}
+	assert.Equal(t, map[string]os.FileMode{
+		"foo/":                os.ModeDir | 0755,
+		"foo/README.md":       0666,
+		"foo/bin/":            os.ModeDir | 0755,
+		"foo/bin/foo":         0755,
+		"foo/conf/":           os.ModeDir | 0755,
+		"foo/conf/app.yml":    0640,
+		"foo/conf/secret.yml": 0600,
+		"foo/scripts/":        os.ModeDir | 0755,
+		"foo/scripts/run.sh":  0750,
+	}, got)
+}
+
+func TestFileModesValidate(t *testing.T) {
+	for i, tc := range []struct {
+		fileModes distarchive.FileModes
+		wantError string
+	}{
+		{distarchive.FileModes{{Pattern: "bin/*", Mode: "0755"}}, ""},
+		{distarchive.FileModes{{Mode: "0755"}}, "file mode pattern must be non-empty"},
+		{distarchive.FileModes{{Pattern: "[a-"}}, `invalid file mode pattern "[a-": syntax error in pattern`},
+		{distarchive.FileModes{{Pattern: "*", Mode: "0999"}}, `invalid file mode "0999": must be an octal value between 0000 and 0777`},
+		{distarchive.FileModes{{Pattern: "*", Mode: "01755"}}, `invalid file mode "01755": must be an octal value between 0000 and 0777`},
+	} {
+		err := tc.fileModes.Validate()
+		if tc.wantError == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, tc.wantError, "Case %d", i)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distarchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ulikunitz/xz"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/git"
+)
+
+// SourceDateEpochEnvVar is the environment variable that specifies the timestamp (in seconds since the Unix epoch)
+// that is used as the modification time of all of the entries in reproducible archives.
+const SourceDateEpochEnvVar = distgo.SourceDateEpochEnvVar
+
+// ReproducibleModTime returns the modification time that should be used for the entries of reproducible archives for
+// the project in the provided directory. If the SOURCE_DATE_EPOCH environment variable is set, its value is used.
+// Otherwise, the time of the HEAD commit of the git repository that contains the project directory is used.
+func ReproducibleModTime(projectDir string) (time.Time, error) {
+	if epoch, ok, err := distgo.SourceDateEpoch(); err != nil || ok {
+		return epoch, err
+	}
+	commitTime, err := git.CommitTime(projectDir)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to determine commit time for reproducible archive: %s must be set if the project is not in a git repository", SourceDateEpochEnvVar)
+	}
+	return commitTime, nil
+}
+
+// MakeReproducible creates an archive of this format at dstPath that contains the provided source paths. The content
+// of the archive is the same as the content of an archive created using Make, but the archive only depends on the
+// names, types, content and executable bits of its entries: entries are written in sorted order, all entries use the
+// provided modification time, owners are normalized to uid/gid 0 with no user or group names, directories and
+// executable files use mode 0755, all other files use mode 0644 and the gzip header does not include a timestamp.
+func (f Format) MakeReproducible(dstPath string, srcPaths []string, modTime time.Time) error {
+	return f.MakeWithOptions(dstPath, srcPaths, Options{
+		Reproducible: true,
+		ModTime:      modTime,
+	})
+}
+
+// Options specifies how an archive is created by MakeWithOptions.
+type Options struct {
+	// Reproducible specifies whether the archive is created in the manner described by MakeReproducible.
+	Reproducible bool
+	// ModTime is the modification time of all of the entries of the archive. Only used if Reproducible is true.
+	ModTime time.Time
+	// FileModes are applied to the entries of the archive after their permissions and owners are determined.
+	FileModes FileModes
+	// PreserveSymlinks specifies that symlinks are archived as symlinks with their original targets and that empty
+	// directories are archived. Archives created using any of the other options always do so as well.
+	PreserveSymlinks bool
+}
+
+// MakeWithOptions creates an archive of this format at dstPath that contains the provided source paths using the
+// provided options. If the options are empty, this is equivalent to Make.
+func (f Format) MakeWithOptions(dstPath string, srcPaths []string, opts Options) error {
+	if !opts.Reproducible && len(opts.FileModes) == 0 && !opts.PreserveSymlinks {
+		return f.Make(dstPath, srcPaths)
+	}
+	if err := opts.FileModes.Validate(); err != nil {
+		return err
+	}
+	entries, err := archiveEntries(srcPaths)
+	if err != nil {
+		return err
+	}
+	opts.ModTime = opts.ModTime.UTC().Truncate(time.Second)
+
+	out, err := os.Create(dstPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", dstPath)
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+
+	switch f {
+	case FormatTGZ:
+		gw := gzip.NewWriter(out)
+		gw.Header.ModTime = time.Time{}
+		gw.Header.Name = ""
+		if err := writeTar(gw, entries, opts); err != nil {
+			return err
+		}
+		if err := gw.Close(); err != nil {
+			return errors.Wrapf(err, "failed to close gzip writer")
+		}
+	case FormatTarXZ:
+		xw, err := xz.NewWriter(out)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create xz writer")
+		}
+		if err := writeTar(xw, entries, opts); err != nil {
+			return err
+		}
+		if err := xw.Close(); err != nil {
+			return errors.Wrapf(err, "failed to close xz writer")
+		}
+	case FormatZip:
+		if err := writeZip(out, entries, opts); err != nil {
+			return err
+		}
+	default:
+		return errors.Errorf("cannot create archive with format %q", f)
+	}
+	if err := out.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close %s", dstPath)
+	}
+	return nil
+}
+
+type archiveEntry struct {
+	// name is the slash-separated path of the entry in the archive.
+	name string
+	// matchPath is the slash-separated path that is matched against the patterns of FileModes: the path of the entry
+	// relative to the source path if the source path is a directory and the name of the source path otherwise.
+	matchPath string
+	srcPath   string
+	fi        os.FileInfo
+	linkname  string
+}
+
+func (e archiveEntry) mode() int64 {
+	switch {
+	case e.fi.IsDir():
+		return 0755
+	case e.linkname != "":
+		return 0777
+	case e.fi.Mode()&0111 != 0:
+		return 0755
+	default:
+		return 0644
+	}
+}
+
+// archiveEntries returns the entries for the provided source paths sorted by name. Consistent with Make, a source path
+// that is a file is added using its base name and a source path that is a directory is added recursively under its
+// base name. Symlinks are not followed.
+func archiveEntries(srcPaths []string) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	for _, srcPath := range srcPaths {
+		baseName := filepath.Base(srcPath)
+		srcFi, err := os.Lstat(srcPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to stat %s", srcPath)
+		}
+		if err := filepath.Walk(srcPath, func(currPath string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			relPath, err := filepath.Rel(srcPath, currPath)
+			if err != nil {
+				return errors.Wrapf(err, "failed to determine relative path")
+			}
+			entry := archiveEntry{
+				name:      path.Join(baseName, filepath.ToSlash(relPath)),
+				matchPath: filepath.ToSlash(relPath),
+				srcPath:   currPath,
+				fi:        fi,
+			}
+			if !srcFi.IsDir() {
+				entry.matchPath = baseName
+			}
+			switch {
+			case fi.Mode()&os.ModeSymlink != 0:
+				linkname, err := os.Readlink(currPath)
+				if err != nil {
+					return errors.Wrapf(err, "failed to read symlink %s", currPath)
+				}
+				entry.linkname = linkname
+			case !fi.Mode().IsRegular() && !fi.IsDir():
+				return errors.Errorf("%s is not a regular file, directory or symlink", currPath)
+			}
+			entries = append(entries, entry)
+			return nil
+		}); err != nil {
+			return nil, errors.Wrapf(err, "failed to walk %s", srcPath)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+	return entries, nil
+}
+
+func writeTar(w io.Writer, entries []archiveEntry, opts Options) error {
+	tw := tar.NewWriter(w)
+	for _, entry := range entries {
+		hdr, err := tarHeader(entry, opts)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return errors.Wrapf(err, "failed to write tar header for %s", entry.name)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if err := disterutil.CopyFile(tw, entry.srcPath); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close tar writer")
+	}
+	return nil
+}
+
+func tarHeader(entry archiveEntry, opts Options) (*tar.Header, error) {
+	var hdr *tar.Header
+	if opts.Reproducible {
+		hdr = &tar.Header{
+			Name:    entry.name,
+			Mode:    entry.mode(),
+			ModTime: opts.ModTime,
+		}
+		switch {
+		case entry.fi.IsDir():
+			hdr.Typeflag = tar.TypeDir
+		case entry.linkname != "":
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = entry.linkname
+		default:
+			hdr.Typeflag = tar.TypeReg
+			hdr.Size = entry.fi.Size()
+		}
+	} else {
+		var err error
+		if hdr, err = tar.FileInfoHeader(entry.fi, entry.linkname); err != nil {
+			return nil, errors.Wrapf(err, "failed to create tar header for %s", entry.name)
+		}
+		hdr.Name = entry.name
+	}
+	if entry.fi.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := opts.FileModes.ApplyToTarHeader(entry.matchPath, hdr); err != nil {
+		return nil, err
+	}
+	return hdr, nil
+}
+
+func writeZip(w io.Writer, entries []archiveEntry, opts Options) error {
+	zw := zip.NewWriter(w)
+	for _, entry := range entries {
+		hdr, err := zipHeader(entry, opts)
+		if err != nil {
+			return err
+		}
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return errors.Wrapf(err, "failed to write zip header for %s", entry.name)
+		}
+		switch {
+		case entry.fi.IsDir():
+		case entry.linkname != "":
+			if _, err := io.WriteString(fw, entry.linkname); err != nil {
+				return errors.Wrapf(err, "failed to write symlink %s", entry.name)
+			}
+		default:
+			if err := disterutil.CopyFile(fw, entry.srcPath); err != nil {
+				return err
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close zip writer")
+	}
+	return nil
+}
+
+func zipHeader(entry archiveEntry, opts Options) (*zip.FileHeader, error) {
+	var hdr *zip.FileHeader
+	if opts.Reproducible {
+		hdr = &zip.FileHeader{
+			Modified: opts.ModTime,
+		}
+		hdr.SetMode(os.FileMode(entry.mode()))
+	} else {
+		var err error
+		if hdr, err = zip.FileInfoHeader(entry.fi); err != nil {
+			return nil, errors.Wrapf(err, "failed to create zip header for %s", entry.name)
+		}
+	}
+	hdr.Name = entry.name
+	hdr.Method = zip.Deflate
+	typeBits := os.FileMode(0)
+	switch {
+	case entry.fi.IsDir():
+		hdr.Name += "/"
+		hdr.Method = zip.Store
+		typeBits = os.ModeDir
+	case entry.linkname != "":
+		typeBits = os.ModeSymlink
+	}
+	perm, err := opts.FileModes.permFor(entry.matchPath, hdr.Mode().Perm())
+	if err != nil {
+		return nil, err
+	}
+	hdr.SetMode(typeBits | perm)
+	return hdr, nil
+}
@@ -0,0 +1,142 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distarchive_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+)
+
+func TestMakeReproducible(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	modTime := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, format := range []distarchive.Format{distarchive.FormatTGZ, distarchive.FormatTarXZ, distarchive.FormatZip} {
+		var archives [][]byte
+		for i, files := range [][]string{
+			{"foo/bin/foo", "foo/README.md", "foo/config/foo.yml"},
+			{"foo/config/foo.yml", "foo/README.md", "foo/bin/foo"},
+		} {
+			srcDir := path.Join(tmpDir, string(format), fmt.Sprint(i))
+			for j, file := range files {
+				filePath := path.Join(srcDir, file)
+				require.NoError(t, os.MkdirAll(path.Dir(filePath), 0700))
+				perm := os.FileMode(0600)
+				if path.Base(file) == "foo" {
+					perm = 0700
+				}
+				require.NoError(t, ioutil.WriteFile(filePath, []byte(file), perm))
+				fileTime := time.Now().Add(time.Duration(i*10+j) * time.Hour)
+				require.NoError(t, os.Chtimes(filePath, fileTime, fileTime))
+			}
+
+			dstPath := path.Join(srcDir, "out."+format.Extension())
+			require.NoError(t, format.MakeReproducible(dstPath, []string{path.Join(srcDir, "foo")}, modTime))
+			archiveBytes, err := ioutil.ReadFile(dstPath)
+			require.NoError(t, err)
+			archives = append(archives, archiveBytes)
+		}
+		assert.Equal(t, archives[0], archives[1], "archives for format %s differ", format)
+	}
+}
+
+func TestMakeReproducibleNormalizesHeaders(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	srcDir := path.Join(tmpDir, "foo")
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "bin"), 0700))
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "bin", "foo"), []byte("foo"), 0700))
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "README.md"), []byte("readme"), 0600))
+
+	modTime := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	dstPath := path.Join(tmpDir, "out.tgz")
+	require.NoError(t, distarchive.FormatTGZ.MakeReproducible(dstPath, []string{srcDir}, modTime))
+
+	archiveBytes, err := ioutil.ReadFile(dstPath)
+	require.NoError(t, err)
+	gr, err := gzip.NewReader(bytes.NewReader(archiveBytes))
+	require.NoError(t, err)
+	assert.True(t, gr.Header.ModTime.IsZero())
+
+	type entry struct {
+		name  string
+		mode  int64
+		owner string
+	}
+	var got []entry
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, modTime, hdr.ModTime.UTC(), hdr.Name)
+		got = append(got, entry{
+			name:  hdr.Name,
+			mode:  hdr.Mode,
+			owner: hdr.Uname + ":" + hdr.Gname,
+		})
+		assert.Equal(t, 0, hdr.Uid, hdr.Name)
+		assert.Equal(t, 0, hdr.Gid, hdr.Name)
+	}
+	assert.Equal(t, []entry{
+		{"foo/", 0755, ":"},
+		{"foo/README.md", 0644, ":"},
+		{"foo/bin/", 0755, ":"},
+		{"foo/bin/foo", 0755, ":"},
+	}, got)
+}
+
+func TestReproducibleModTime(t *testing.T) {
+	require.NoError(t, os.Setenv(distarchive.SourceDateEpochEnvVar, "1525176000"))
+	defer func() {
+		require.NoError(t, os.Unsetenv(distarchive.SourceDateEpochEnvVar))
+	}()
+
+	got, err := distarchive.ReproducibleModTime("")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC), got)
+
+	require.NoError(t, os.Setenv(distarchive.SourceDateEpochEnvVar, "yesterday"))
+	_, err = distarchive.ReproducibleModTime("")
+	assert.EqualError(t, err, `failed to parse value of SOURCE_DATE_EPOCH "yesterday" as an integer: strconv.ParseInt: parsing "yesterday": invalid syntax`)
+}
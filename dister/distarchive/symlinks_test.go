@@ -0,0 +1,109 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distarchive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+)
+
+func TestMakePreserveSymlinks(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	srcDir := path.Join(tmpDir, "foo")
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "service", "1.0.0"), 0755))
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "var", "log"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "service", "1.0.0", "run.sh"), []byte("run"), 0755))
+	require.NoError(t, os.Symlink("1.0.0", path.Join(srcDir, "service", "current")))
+
+	wantDirs := []string{"foo/", "foo/service/", "foo/service/1.0.0/", "foo/var/", "foo/var/log/"}
+
+	tgzPath := path.Join(tmpDir, "foo.tgz")
+	require.NoError(t, distarchive.FormatTGZ.MakeWithOptions(tgzPath, []string{srcDir}, distarchive.Options{
+		PreserveSymlinks: true,
+	}))
+	f, err := os.Open(tgzPath)
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+	gr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	var gotDirs []string
+	gotLinks := make(map[string]string)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			gotDirs = append(gotDirs, hdr.Name)
+		case tar.TypeSymlink:
+			gotLinks[hdr.Name] = hdr.Linkname
+		}
+	}
+	assert.Equal(t, wantDirs, gotDirs)
+	assert.Equal(t, map[string]string{"foo/service/current": "1.0.0"}, gotLinks)
+
+	zipPath := path.Join(tmpDir, "foo.zip")
+	require.NoError(t, distarchive.FormatZip.MakeWithOptions(zipPath, []string{srcDir}, distarchive.Options{
+		PreserveSymlinks: true,
+	}))
+	zr, err := zip.OpenReader(zipPath)
+	require.NoError(t, err)
+	defer func() {
+		_ = zr.Close()
+	}()
+	gotDirs = nil
+	gotLinks = make(map[string]string)
+	for _, zf := range zr.File {
+		switch {
+		case zf.Mode().IsDir():
+			gotDirs = append(gotDirs, zf.Name)
+		case zf.Mode()&os.ModeSymlink != 0:
+			rc, err := zf.Open()
+			require.NoError(t, err)
+			target, err := ioutil.ReadAll(rc)
+			require.NoError(t, err)
+			require.NoError(t, rc.Close())
+			gotLinks[zf.Name] = string(target)
+		}
+	}
+	assert.Equal(t, wantDirs, gotDirs)
+	assert.Equal(t, map[string]string{"foo/service/current": "1.0.0"}, gotLinks)
+}
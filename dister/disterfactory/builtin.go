@@ -24,12 +24,28 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/sniperkit/snk.fork.palantir-distgo/dister"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/appimage"
+	appimageconfig "github.com/sniperkit/snk.fork.palantir-distgo/dister/appimage/config"
 	"github.com/sniperkit/snk.fork.palantir-distgo/dister/bin"
 	binconfig "github.com/sniperkit/snk.fork.palantir-distgo/dister/bin/config"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/bundle"
+	bundleconfig "github.com/sniperkit/snk.fork.palantir-distgo/dister/bundle/config"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/darwinuniversal"
+	darwinuniversalconfig "github.com/sniperkit/snk.fork.palantir-distgo/dister/darwinuniversal/config"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/deb"
+	debconfig "github.com/sniperkit/snk.fork.palantir-distgo/dister/deb/config"
 	"github.com/sniperkit/snk.fork.palantir-distgo/dister/manual"
 	manualconfig "github.com/sniperkit/snk.fork.palantir-distgo/dister/manual/config"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/oci"
+	ociconfig "github.com/sniperkit/snk.fork.palantir-distgo/dister/oci/config"
 	"github.com/sniperkit/snk.fork.palantir-distgo/dister/osarchbin"
 	osarchbinconfig "github.com/sniperkit/snk.fork.palantir-distgo/dister/osarchbin/config"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/pkgmanager"
+	pkgmanagerconfig "github.com/sniperkit/snk.fork.palantir-distgo/dister/pkgmanager/config"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/rpm"
+	rpmconfig "github.com/sniperkit/snk.fork.palantir-distgo/dister/rpm/config"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/shinstaller"
+	shinstallerconfig "github.com/sniperkit/snk.fork.palantir-distgo/dister/shinstaller/config"
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
 )
 
@@ -40,9 +56,23 @@ type creatorWithUpgrader struct {
 
 func builtinDisters() map[string]creatorWithUpgrader {
 	return map[string]creatorWithUpgrader{
+		appimage.TypeName: {
+			creator: func(cfgYML []byte) (distgo.Dister, error) {
+				var cfg appimageconfig.AppImage
+				if err := yaml.UnmarshalStrict(cfgYML, &cfg); err != nil {
+					return nil, errors.Wrapf(err, "failed to unmarshal YAML")
+				}
+				return cfg.ToDister(), nil
+			},
+			upgrader: distgo.NewConfigUpgrader(appimage.TypeName, appimageconfig.UpgradeConfig),
+		},
 		bin.TypeName: {
 			creator: func(cfgYML []byte) (distgo.Dister, error) {
-				return bin.New(), nil
+				var cfg binconfig.Bin
+				if err := yaml.UnmarshalStrict(cfgYML, &cfg); err != nil {
+					return nil, errors.Wrapf(err, "failed to unmarshal YAML")
+				}
+				return cfg.ToDister(), nil
 			},
 			upgrader: distgo.NewConfigUpgrader(bin.TypeName, binconfig.UpgradeConfig),
 		},
@@ -56,6 +86,76 @@ func builtinDisters() map[string]creatorWithUpgrader {
 			},
 			upgrader: distgo.NewConfigUpgrader(osarchbin.TypeName, osarchbinconfig.UpgradeConfig),
 		},
+		darwinuniversal.TypeName: {
+			creator: func(cfgYML []byte) (distgo.Dister, error) {
+				var cfg darwinuniversalconfig.DarwinUniversal
+				if err := yaml.UnmarshalStrict(cfgYML, &cfg); err != nil {
+					return nil, errors.Wrapf(err, "failed to unmarshal YAML")
+				}
+				return cfg.ToDister(), nil
+			},
+			upgrader: distgo.NewConfigUpgrader(darwinuniversal.TypeName, darwinuniversalconfig.UpgradeConfig),
+		},
+		deb.TypeName: {
+			creator: func(cfgYML []byte) (distgo.Dister, error) {
+				var cfg debconfig.Deb
+				if err := yaml.UnmarshalStrict(cfgYML, &cfg); err != nil {
+					return nil, errors.Wrapf(err, "failed to unmarshal YAML")
+				}
+				return cfg.ToDister(), nil
+			},
+			upgrader: distgo.NewConfigUpgrader(deb.TypeName, debconfig.UpgradeConfig),
+		},
+		rpm.TypeName: {
+			creator: func(cfgYML []byte) (distgo.Dister, error) {
+				var cfg rpmconfig.RPM
+				if err := yaml.UnmarshalStrict(cfgYML, &cfg); err != nil {
+					return nil, errors.Wrapf(err, "failed to unmarshal YAML")
+				}
+				return cfg.ToDister(), nil
+			},
+			upgrader: distgo.NewConfigUpgrader(rpm.TypeName, rpmconfig.UpgradeConfig),
+		},
+		shinstaller.TypeName: {
+			creator: func(cfgYML []byte) (distgo.Dister, error) {
+				var cfg shinstallerconfig.SHInstaller
+				if err := yaml.UnmarshalStrict(cfgYML, &cfg); err != nil {
+					return nil, errors.Wrapf(err, "failed to unmarshal YAML")
+				}
+				return cfg.ToDister(), nil
+			},
+			upgrader: distgo.NewConfigUpgrader(shinstaller.TypeName, shinstallerconfig.UpgradeConfig),
+		},
+		oci.TypeName: {
+			creator: func(cfgYML []byte) (distgo.Dister, error) {
+				var cfg ociconfig.OCI
+				if err := yaml.UnmarshalStrict(cfgYML, &cfg); err != nil {
+					return nil, errors.Wrapf(err, "failed to unmarshal YAML")
+				}
+				return cfg.ToDister(), nil
+			},
+			upgrader: distgo.NewConfigUpgrader(oci.TypeName, ociconfig.UpgradeConfig),
+		},
+		pkgmanager.TypeName: {
+			creator: func(cfgYML []byte) (distgo.Dister, error) {
+				var cfg pkgmanagerconfig.PackageManager
+				if err := yaml.UnmarshalStrict(cfgYML, &cfg); err != nil {
+					return nil, errors.Wrapf(err, "failed to unmarshal YAML")
+				}
+				return cfg.ToDister(), nil
+			},
+			upgrader: distgo.NewConfigUpgrader(pkgmanager.TypeName, pkgmanagerconfig.UpgradeConfig),
+		},
+		bundle.TypeName: {
+			creator: func(cfgYML []byte) (distgo.Dister, error) {
+				var cfg bundleconfig.Bundle
+				if err := yaml.UnmarshalStrict(cfgYML, &cfg); err != nil {
+					return nil, errors.Wrapf(err, "failed to unmarshal YAML")
+				}
+				return cfg.ToDister(), nil
+			},
+			upgrader: distgo.NewConfigUpgrader(bundle.TypeName, bundleconfig.UpgradeConfig),
+		},
 		manual.TypeName: {
 			creator: func(cfgYML []byte) (distgo.Dister, error) {
 				var cfg manualconfig.Manual
@@ -0,0 +1,153 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package disterutil provides the functionality that is shared by the disters that package the build artifacts of a
+// product for specific OS/Archs.
+package disterutil
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+	"github.com/termie/go-shutil"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+// VerifyDistTargetSupported returns an error if the product or any of its dependencies does not specify the provided
+// OS/Arch as one of its build targets.
+func VerifyDistTargetSupported(osArch osarch.OSArch, productTaskOutputInfo distgo.ProductTaskOutputInfo) error {
+	return VerifyBuildTargets(osArch, productTaskOutputInfo, "the OS/Arch specified for the distribution of a product must be specified as a build target for the product")
+}
+
+// VerifyBuildTargets returns an error if the product or any of its dependencies does not specify the provided OS/Arch
+// as one of its build targets. The error message starts with the provided requirement.
+func VerifyBuildTargets(osArch osarch.OSArch, productTaskOutputInfo distgo.ProductTaskOutputInfo, requirement string) error {
+	if err := verifySingleProduct(osArch, productTaskOutputInfo.Product, requirement); err != nil {
+		return err
+	}
+	var keys []distgo.ProductID
+	for k := range productTaskOutputInfo.Deps {
+		keys = append(keys, k)
+	}
+	sort.Sort(distgo.ByProductID(keys))
+	for _, currKey := range keys {
+		currSpec := productTaskOutputInfo.Deps[currKey]
+		if err := verifySingleProduct(osArch, currSpec, requirement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func verifySingleProduct(osArch osarch.OSArch, productOutputInfo distgo.ProductOutputInfo, requirement string) error {
+	if !OSArchInBuildSpec(osArch, productOutputInfo) {
+		buildOSArchs := "[none]"
+		if productOutputInfo.BuildOutputInfo != nil {
+			buildOSArchs = fmt.Sprint(productOutputInfo.BuildOutputInfo.OSArchs)
+		}
+		return errors.Errorf("%s, but product %s does not specify %s as one of its build targets (current build targets: %s)", requirement, productOutputInfo.ID, osArch, buildOSArchs)
+	}
+	return nil
+}
+
+// OSArchInBuildSpec returns true if the provided OS/Arch is one of the build targets of the product.
+func OSArchInBuildSpec(osArch osarch.OSArch, productOutputInfo distgo.ProductOutputInfo) bool {
+	if productOutputInfo.BuildOutputInfo == nil {
+		return false
+	}
+	for _, currBuildOSArch := range productOutputInfo.BuildOutputInfo.OSArchs {
+		if currBuildOSArch == osArch {
+			return true
+		}
+	}
+	return false
+}
+
+// CopyArtifactForOSArch copies the build artifact of the product for the provided OS/Arch to "{{dstDir}}/{{executable}}"
+// and returns the path of the copy.
+func CopyArtifactForOSArch(dstDir string, projectInfo distgo.ProjectInfo, productInfo distgo.ProductOutputInfo, osArch osarch.OSArch) (string, error) {
+	artifactPath, ok := distgo.ProductBuildArtifactPaths(projectInfo, productInfo)[osArch]
+	if !ok {
+		return "", errors.Errorf("no build artifacts exist for %s", osArch)
+	}
+
+	dst := path.Join(dstDir, distgo.ExecutableName(productInfo.BuildOutputInfo.BuildNameTemplateRendered, osArch.OS))
+	if err := os.MkdirAll(path.Dir(dst), 0755); err != nil {
+		return "", errors.Wrapf(err, "failed to create output directory for artifact")
+	}
+	if _, err := shutil.Copy(artifactPath, dst, false); err != nil {
+		return "", errors.Wrapf(err, "failed to copy build artifact from %s to %s", artifactPath, dst)
+	}
+	return dst, nil
+}
+
+// RootFiles returns the names of the files in the dist work directory that are not the OS/Arch-specific outputs of the
+// dister: the outputs are the directories named after the provided OS/Archs and the files named after the OS/Archs
+// followed by any of the provided suffixes. The remaining files were written by the dist script and are included in
+// the output for every OS/Arch.
+func RootFiles(distWorkDir string, osArchs []osarch.OSArch, suffixes ...string) ([]string, error) {
+	osArchOutputs := make(map[string]struct{})
+	for _, osArch := range osArchs {
+		osArchOutputs[osArch.String()] = struct{}{}
+		for _, suffix := range suffixes {
+			osArchOutputs[osArch.String()+suffix] = struct{}{}
+		}
+	}
+	fis, err := ioutil.ReadDir(distWorkDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list files in %s", distWorkDir)
+	}
+	var rootFiles []string
+	for _, fi := range fis {
+		if _, ok := osArchOutputs[fi.Name()]; ok {
+			continue
+		}
+		rootFiles = append(rootFiles, fi.Name())
+	}
+	return rootFiles, nil
+}
+
+// CleanEntryName returns the provided path as the name of an archive entry: a clean path relative to the root of the
+// archive (for example, "/usr/bin/../bin/foo" becomes "usr/bin/foo"). The root itself is the empty string.
+func CleanEntryName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// CopyFile writes the content of the file at srcPath to the provided writer.
+func CopyFile(w io.Writer, srcPath string) error {
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", srcPath)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := io.Copy(w, f); err != nil {
+		return errors.Wrapf(err, "failed to copy %s", srcPath)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disterutil_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+func TestVerifyDistTargetSupported(t *testing.T) {
+	linuxAMD64 := osarch.OSArch{OS: "linux", Arch: "amd64"}
+	darwinAMD64 := osarch.OSArch{OS: "darwin", Arch: "amd64"}
+	productOutputInfo := func(id distgo.ProductID, osArchs ...osarch.OSArch) distgo.ProductOutputInfo {
+		return distgo.ProductOutputInfo{
+			ID: id,
+			BuildOutputInfo: &distgo.BuildOutputInfo{
+				OSArchs: osArchs,
+			},
+		}
+	}
+
+	for i, tc := range []struct {
+		name      string
+		info      distgo.ProductTaskOutputInfo
+		wantError string
+	}{
+		{
+			name: "product and dependencies build OS/Arch",
+			info: distgo.ProductTaskOutputInfo{
+				Product: productOutputInfo("foo", linuxAMD64, darwinAMD64),
+				Deps: map[distgo.ProductID]distgo.ProductOutputInfo{
+					"bar": productOutputInfo("bar", linuxAMD64),
+				},
+			},
+		},
+		{
+			name: "product does not build OS/Arch",
+			info: distgo.ProductTaskOutputInfo{
+				Product: productOutputInfo("foo", darwinAMD64),
+			},
+			wantError: "the OS/Arch specified for the distribution of a product must be specified as a build target for the product, but product foo does not specify linux-amd64 as one of its build targets (current build targets: [darwin-amd64])",
+		},
+		{
+			name: "dependency does not build",
+			info: distgo.ProductTaskOutputInfo{
+				Product: productOutputInfo("foo", linuxAMD64),
+				Deps: map[distgo.ProductID]distgo.ProductOutputInfo{
+					"bar": {ID: "bar"},
+				},
+			},
+			wantError: "the OS/Arch specified for the distribution of a product must be specified as a build target for the product, but product bar does not specify linux-amd64 as one of its build targets (current build targets: [none])",
+		},
+	} {
+		err := disterutil.VerifyDistTargetSupported(linuxAMD64, tc.info)
+		if tc.wantError == "" {
+			assert.NoError(t, err, "Case %d: %s", i, tc.name)
+		} else {
+			assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+		}
+	}
+}
+
+func TestRootFiles(t *testing.T) {
+	tmpDir, cleanup, err := dirs.TempDir("", "")
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, dir := range []string{"linux-amd64", "darwin-amd64", "etc"} {
+		err := os.MkdirAll(path.Join(tmpDir, dir), 0755)
+		require.NoError(t, err)
+	}
+	for _, file := range []string{"linux-amd64.tgz", "README.md"} {
+		err := ioutil.WriteFile(path.Join(tmpDir, file), []byte("content"), 0644)
+		require.NoError(t, err)
+	}
+	osArchs := []osarch.OSArch{{OS: "linux", Arch: "amd64"}, {OS: "darwin", Arch: "amd64"}}
+
+	rootFiles, err := disterutil.RootFiles(tmpDir, osArchs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "etc", "linux-amd64.tgz"}, rootFiles)
+
+	rootFiles, err = disterutil.RootFiles(tmpDir, osArchs, ".tgz")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "etc"}, rootFiles)
+}
+
+func TestCleanEntryName(t *testing.T) {
+	for i, tc := range []struct {
+		in   string
+		want string
+	}{
+		{"usr/bin/foo", "usr/bin/foo"},
+		{"/usr/bin/../bin/foo", "usr/bin/foo"},
+		{"../../etc/foo", "etc/foo"},
+		{"/", ""},
+	} {
+		assert.Equal(t, tc.want, disterutil.CleanEntryName(tc.in), "Case %d", i)
+	}
+}
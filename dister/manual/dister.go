@@ -55,7 +55,7 @@ func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.Prod
 }
 
 func (d *Dister) GenerateDistArtifacts(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo, runDistResult []byte) error {
-	outputArtifactPaths := productTaskOutputInfo.ProductDistArtifactPaths()[distID]
+	outputArtifactPaths := productTaskOutputInfo.ProductDisterArtifactPaths()[distID]
 	if len(outputArtifactPaths) != 1 {
 		return errors.Errorf("manual distribution must produce a single artifact")
 	}
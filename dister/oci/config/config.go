@@ -0,0 +1,52 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"github.com/palantir/godel/pkg/osarch"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/oci"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/oci/config/internal/v0"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+type OCI v0.Config
+
+func (cfg *OCI) ToDister() distgo.Dister {
+	osArchs := cfg.OSArchs
+	if len(osArchs) == 0 {
+		osArchs = []osarch.OSArch{osarch.Current()}
+	}
+	return &oci.Dister{
+		OSArchs:    osArchs,
+		BaseLayer:  cfg.BaseLayer,
+		Prefix:     cfg.Prefix,
+		Repository: cfg.Repository,
+		Tag:        cfg.Tag,
+		Config: oci.ImageConfig{
+			Entrypoint: cfg.Entrypoint,
+			Cmd:        cfg.Cmd,
+			Env:        cfg.Env,
+			Labels:     cfg.Labels,
+			User:       cfg.User,
+			WorkingDir: cfg.WorkingDir,
+		},
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v0
+
+import (
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+type Config struct {
+	// OSArchs specifies the GOOS and GOARCH pairs for which images are created. The OS must be "linux". If blank,
+	// defaults to the GOOS and GOARCH of the host system at runtime.
+	OSArchs []osarch.OSArch `yaml:"os-archs,omitempty"`
+
+	// BaseLayer is the path to a tar archive that is used as the base layer of the image: for example, an exported
+	// root file system of a distribution. The archive may be gzip-compressed. If the path is relative, it is resolved
+	// relative to the project directory. If blank, the image only contains the layer generated by the dister.
+	BaseLayer string `yaml:"base-layer,omitempty"`
+
+	// Prefix is the path in the image in which the executables for the product and its dependencies are stored in
+	// "{{Prefix}}/bin". If blank, defaults to "/usr/local". All other content of the dist work directory (for example,
+	// the content of the input directory) is stored relative to the root of the file system.
+	Prefix string `yaml:"prefix,omitempty"`
+
+	// Repository is the repository of the image (for example, "registry.example.com/foo") that is recorded in the image
+	// layout. If specified, loading the image using "docker load" tags the image as "{{Repository}}:{{Tag}}".
+	Repository string `yaml:"repository,omitempty"`
+
+	// Tag is the tag of the image. If blank, the version of the project is used.
+	Tag string `yaml:"tag,omitempty"`
+
+	// Entrypoint is the entrypoint of the image. If neither the entrypoint nor the command are specified, the
+	// entrypoint is the executable of the product.
+	Entrypoint []string `yaml:"entrypoint,omitempty"`
+
+	// Cmd is the default command (or the default arguments to the entrypoint) of the image.
+	Cmd []string `yaml:"cmd,omitempty"`
+
+	// Env specifies the environment variables of the image in the form "KEY=VALUE". If "PATH" is not specified, a
+	// default value for "PATH" is added.
+	Env []string `yaml:"env,omitempty"`
+
+	// Labels specifies the labels of the image.
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// User is the user (and, optionally, group) that the processes of the image are run as: for example, "nobody" or
+	// "65534:65534".
+	User string `yaml:"user,omitempty"`
+
+	// WorkingDir is the working directory of the processes of the image.
+	WorkingDir string `yaml:"working-dir,omitempty"`
+}
+
+func UpgradeConfig(cfgBytes []byte) ([]byte, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(cfgBytes, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal oci dister v0 configuration")
+	}
+	return cfgBytes, nil
+}
@@ -0,0 +1,209 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+const TypeName = "oci" // distribution that consists of an OCI image layout tarball for a specific OS/Architecture
+
+type Dister struct {
+	// OSArchs are the OS/Architectures for which images are created. The OS of every entry must be "linux".
+	OSArchs []osarch.OSArch
+	// BaseLayer is the path to a tar archive (optionally gzip-compressed) that is used as the first layer of the image.
+	// If the path is relative, it is resolved relative to the project directory. If empty, the image only contains the
+	// layer generated from the dist work directory.
+	BaseLayer string
+	// Prefix is the path in the image in which the executables are stored in "{{Prefix}}/bin".
+	Prefix string
+	// Repository is the repository of the image that is recorded in the image layout. Optional.
+	Repository string
+	// Tag is the tag of the image that is recorded in the image layout. If empty, the version of the project is used.
+	Tag string
+	// Config contains the execution parameters of the image.
+	Config ImageConfig
+}
+
+type ImageConfig struct {
+	Entrypoint []string
+	Cmd        []string
+	Env        []string
+	Labels     map[string]string
+	User       string
+	WorkingDir string
+}
+
+func (d *Dister) TypeName() (string, error) {
+	return TypeName, nil
+}
+
+func (d *Dister) Artifacts(renderedName string) ([]string, error) {
+	var outPaths []string
+	for _, osArch := range d.OSArchs {
+		outPaths = append(outPaths, fmt.Sprintf("%s-%s.tar", renderedName, osArch.String()))
+	}
+	return outPaths, nil
+}
+
+func (d *Dister) PackagingExtension() (string, error) {
+	return "tar", nil
+}
+
+func (d *Dister) osArchFromArtifactPath(distID distgo.DistID, artifactPath string, productTaskOutputInfo distgo.ProductTaskOutputInfo) (osarch.OSArch, error) {
+	for _, osArch := range d.OSArchs {
+		if strings.HasSuffix(artifactPath, fmt.Sprintf("%s-%s.tar", productTaskOutputInfo.Product.DistOutputInfos.DistInfos[distID].DistNameTemplateRendered, osArch.String())) {
+			return osArch, nil
+		}
+	}
+	return osarch.OSArch{}, errors.Errorf("failed to determine OS/Arch for artifact with Path %s", artifactPath)
+}
+
+func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
+	for _, osArch := range d.OSArchs {
+		if osArch.OS != "linux" {
+			return nil, errors.Errorf("OCI images can only be created for linux, but OS/Arch %s was specified", osArch)
+		}
+		if err := disterutil.VerifyDistTargetSupported(osArch, productTaskOutputInfo); err != nil {
+			return nil, err
+		}
+	}
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	outputPathsForOSArchs := make(map[string][]string)
+	for _, osArch := range d.OSArchs {
+		for _, currProductOutputInfo := range productTaskOutputInfo.AllProductOutputInfos() {
+			// copy executable for current product
+			dst, err := disterutil.CopyArtifactForOSArch(path.Join(distWorkDir, osArch.String()), productTaskOutputInfo.Project, currProductOutputInfo, osArch)
+			if err != nil {
+				return nil, err
+			}
+			outputPathsForOSArchs[osArch.String()] = append(outputPathsForOSArchs[osArch.String()], dst)
+		}
+	}
+	jsonBytes, err := json.Marshal(outputPathsForOSArchs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal outputPathsForOSArchs as JSON")
+	}
+	return jsonBytes, nil
+}
+
+func (d *Dister) GenerateDistArtifacts(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo, runDistResult []byte) error {
+	var outputPathsForOSArchs map[string][]string
+	if err := json.Unmarshal(runDistResult, &outputPathsForOSArchs); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal runDistResult JSON %s", string(runDistResult))
+	}
+
+	// the image is reproducible: all timestamps are based on the commit time of the project (or SOURCE_DATE_EPOCH)
+	created, err := distarchive.ReproducibleModTime(productTaskOutputInfo.Project.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	var baseLayer *blob
+	if d.BaseLayer != "" {
+		baseLayerPath := d.BaseLayer
+		if !path.IsAbs(baseLayerPath) {
+			baseLayerPath = path.Join(productTaskOutputInfo.Project.ProjectDir, baseLayerPath)
+		}
+		baseLayerBlob, err := fileLayerBlob(baseLayerPath)
+		if err != nil {
+			return err
+		}
+		baseLayer = &baseLayerBlob
+	}
+
+	// all of the content of the dist work directory other than the per-OS/Arch executable directories is included in
+	// the image relative to the root of the file system
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	rootFiles, err := disterutil.RootFiles(distWorkDir, d.OSArchs)
+	if err != nil {
+		return err
+	}
+
+	tag := d.Tag
+	if tag == "" {
+		tag = productTaskOutputInfo.Project.Version
+	}
+	for _, artifactPath := range productTaskOutputInfo.ProductDisterArtifactPaths()[distID] {
+		currOSArch, err := d.osArchFromArtifactPath(distID, artifactPath, productTaskOutputInfo)
+		if err != nil {
+			return err
+		}
+
+		contents := newLayerContents()
+		for _, rootFile := range rootFiles {
+			if err := contents.addPath(path.Join(distWorkDir, rootFile), rootFile); err != nil {
+				return err
+			}
+		}
+		var executables []string
+		for _, executablePath := range outputPathsForOSArchs[currOSArch.String()] {
+			dst := path.Join(d.prefix(), "bin", path.Base(executablePath))
+			if err := contents.addExecutable(executablePath, dst); err != nil {
+				return err
+			}
+			executables = append(executables, dst)
+		}
+		appLayer, err := contents.layerBlob(created)
+		if err != nil {
+			return err
+		}
+
+		var layers []blob
+		if baseLayer != nil {
+			layers = append(layers, *baseLayer)
+		}
+		layers = append(layers, appLayer)
+
+		imgConfig := d.Config
+		if len(imgConfig.Entrypoint) == 0 && len(imgConfig.Cmd) == 0 && len(executables) > 0 {
+			// by default, the entrypoint is the executable of the product (which is the first executable)
+			imgConfig.Entrypoint = []string{executables[0]}
+		}
+		if err := writeImageLayout(artifactPath, imageLayoutParams{
+			osArch:     currOSArch,
+			created:    created,
+			config:     imgConfig,
+			layers:     layers,
+			repository: d.Repository,
+			tag:        tag,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to create OCI image %s", artifactPath)
+		}
+	}
+	return nil
+}
+
+func (d *Dister) prefix() string {
+	if d.Prefix == "" {
+		return "/usr/local"
+	}
+	return d.Prefix
+}
@@ -0,0 +1,292 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
+)
+
+const (
+	mediaTypeLayer     = "application/vnd.oci.image.layer.v1.tar"
+	mediaTypeLayerGzip = "application/vnd.oci.image.layer.v1.tar+gzip"
+)
+
+// blob is content that is stored in the "blobs" directory of an image layout. The content is either stored in memory
+// or read from a file.
+type blob struct {
+	mediaType string
+	// digest is the digest of the content in the form "sha256:{{hex}}".
+	digest string
+	size   int64
+	// diffID is the digest of the uncompressed content of a layer. Only set for layers.
+	diffID  string
+	content []byte
+	srcPath string
+}
+
+func newBlob(mediaType string, content []byte) blob {
+	return blob{
+		mediaType: mediaType,
+		digest:    sha256Digest(content),
+		size:      int64(len(content)),
+		content:   content,
+	}
+}
+
+func (b blob) hex() string {
+	return strings.TrimPrefix(b.digest, "sha256:")
+}
+
+func (b blob) writeTo(w io.Writer) error {
+	if b.srcPath == "" {
+		_, err := w.Write(b.content)
+		return err
+	}
+	return disterutil.CopyFile(w, b.srcPath)
+}
+
+func sha256Digest(content []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+}
+
+// fileLayerBlob returns a layer blob for the tar archive at the provided path. If the archive is gzip-compressed, it is
+// used as a compressed layer.
+func fileLayerBlob(layerPath string) (blob, error) {
+	f, err := os.Open(layerPath)
+	if err != nil {
+		return blob{}, errors.Wrapf(err, "failed to open layer %s", layerPath)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(2)
+	compressed := len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b
+
+	digestHash := sha256.New()
+	counter := &countingWriter{}
+	if !compressed {
+		if _, err := io.Copy(io.MultiWriter(digestHash, counter), br); err != nil {
+			return blob{}, errors.Wrapf(err, "failed to read layer %s", layerPath)
+		}
+		digest := fmt.Sprintf("sha256:%x", digestHash.Sum(nil))
+		return blob{
+			mediaType: mediaTypeLayer,
+			digest:    digest,
+			size:      counter.n,
+			diffID:    digest,
+			srcPath:   layerPath,
+		}, nil
+	}
+
+	gr, err := gzip.NewReader(io.TeeReader(br, io.MultiWriter(digestHash, counter)))
+	if err != nil {
+		return blob{}, errors.Wrapf(err, "failed to read layer %s as gzip", layerPath)
+	}
+	diffIDHash := sha256.New()
+	if _, err := io.Copy(diffIDHash, gr); err != nil {
+		return blob{}, errors.Wrapf(err, "failed to decompress layer %s", layerPath)
+	}
+	// consume any trailing bytes so that the digest covers the entire file
+	if _, err := io.Copy(io.MultiWriter(digestHash, counter), br); err != nil {
+		return blob{}, errors.Wrapf(err, "failed to read layer %s", layerPath)
+	}
+	return blob{
+		mediaType: mediaTypeLayerGzip,
+		digest:    fmt.Sprintf("sha256:%x", digestHash.Sum(nil)),
+		size:      counter.n,
+		diffID:    fmt.Sprintf("sha256:%x", diffIDHash.Sum(nil)),
+		srcPath:   layerPath,
+	}, nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// layerEntry is a single file, directory or symlink in a layer.
+type layerEntry struct {
+	// name is the path of the entry relative to the root of the file system (with no leading slash).
+	name     string
+	mode     os.FileMode
+	srcPath  string
+	linkname string
+	size     int64
+}
+
+type layerContents struct {
+	entries map[string]layerEntry
+}
+
+func newLayerContents() *layerContents {
+	return &layerContents{
+		entries: make(map[string]layerEntry),
+	}
+}
+
+// addPath adds the file or directory at srcPath to the contents at dstPath. If srcPath is a directory, all of its
+// contents are added recursively. Symlinks are added as symlinks.
+func (c *layerContents) addPath(srcPath, dstPath string) error {
+	return filepath.Walk(srcPath, func(currPath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(srcPath, currPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine relative path")
+		}
+		entry := layerEntry{
+			name:    disterutil.CleanEntryName(path.Join(dstPath, filepath.ToSlash(relPath))),
+			mode:    fi.Mode(),
+			srcPath: currPath,
+		}
+		switch {
+		case fi.Mode()&os.ModeSymlink != 0:
+			linkname, err := os.Readlink(currPath)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read symlink %s", currPath)
+			}
+			entry.linkname = linkname
+		case fi.Mode().IsRegular():
+			entry.size = fi.Size()
+		case !fi.IsDir():
+			return errors.Errorf("%s is not a regular file, directory or symlink", currPath)
+		}
+		c.add(entry)
+		return nil
+	})
+}
+
+// addExecutable adds the regular file at srcPath to the contents at dstPath with mode 0755.
+func (c *layerContents) addExecutable(srcPath, dstPath string) error {
+	fi, err := os.Stat(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", srcPath)
+	}
+	c.add(layerEntry{
+		name:    disterutil.CleanEntryName(dstPath),
+		mode:    0755,
+		srcPath: srcPath,
+		size:    fi.Size(),
+	})
+	return nil
+}
+
+// add adds the provided entry and creates entries for any of its parent directories that do not already exist.
+func (c *layerContents) add(entry layerEntry) {
+	if entry.name == "" {
+		return
+	}
+	c.entries[entry.name] = entry
+	for dir := path.Dir(entry.name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if _, ok := c.entries[dir]; ok {
+			break
+		}
+		c.entries[dir] = layerEntry{
+			name: dir,
+			mode: os.ModeDir | 0755,
+		}
+	}
+}
+
+func (c *layerContents) sortedEntries() []layerEntry {
+	var names []string
+	for k := range c.entries {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var entries []layerEntry
+	for _, name := range names {
+		entries = append(entries, c.entries[name])
+	}
+	return entries
+}
+
+// layerBlob returns the contents as a gzip-compressed layer. All entries are owned by uid/gid 0 and use the provided
+// modification time so that the layer only depends on the names, types, modes and content of the entries.
+func (c *layerContents) layerBlob(modTime time.Time) (blob, error) {
+	tarBuf := &bytes.Buffer{}
+	tw := tar.NewWriter(tarBuf)
+	for _, entry := range c.sortedEntries() {
+		hdr := &tar.Header{
+			Name:    entry.name,
+			ModTime: modTime,
+		}
+		switch {
+		case entry.mode.IsDir():
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+			hdr.Mode = int64(entry.mode.Perm())
+		case entry.linkname != "":
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = entry.linkname
+			hdr.Mode = 0777
+		default:
+			hdr.Typeflag = tar.TypeReg
+			hdr.Mode = int64(entry.mode.Perm())
+			hdr.Size = entry.size
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return blob{}, errors.Wrapf(err, "failed to write tar header for %s", entry.name)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if err := disterutil.CopyFile(tw, entry.srcPath); err != nil {
+				return blob{}, err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return blob{}, errors.Wrapf(err, "failed to close tar writer")
+	}
+
+	gzBuf := &bytes.Buffer{}
+	gw := gzip.NewWriter(gzBuf)
+	if _, err := gw.Write(tarBuf.Bytes()); err != nil {
+		return blob{}, errors.Wrapf(err, "failed to compress layer")
+	}
+	if err := gw.Close(); err != nil {
+		return blob{}, errors.Wrapf(err, "failed to close gzip writer")
+	}
+	layer := newBlob(mediaTypeLayerGzip, gzBuf.Bytes())
+	layer.diffID = sha256Digest(tarBuf.Bytes())
+	return layer, nil
+}
@@ -0,0 +1,288 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+)
+
+const (
+	mediaTypeImageIndex    = "application/vnd.oci.image.index.v1+json"
+	mediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeImageConfig   = "application/vnd.oci.image.config.v1+json"
+
+	annotationRefName       = "org.opencontainers.image.ref.name"
+	annotationContainerdRef = "io.containerd.image.name"
+
+	defaultPathEnv = "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+type imageIndex struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Manifests     []descriptor `json:"manifests"`
+}
+
+type imageManifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        descriptor   `json:"config"`
+	Layers        []descriptor `json:"layers"`
+}
+
+type imageConfigFile struct {
+	Created      string          `json:"created"`
+	Architecture string          `json:"architecture"`
+	OS           string          `json:"os"`
+	Config       containerConfig `json:"config"`
+	RootFS       rootFS          `json:"rootfs"`
+}
+
+type containerConfig struct {
+	User       string            `json:"User,omitempty"`
+	Env        []string          `json:"Env,omitempty"`
+	Entrypoint []string          `json:"Entrypoint,omitempty"`
+	Cmd        []string          `json:"Cmd,omitempty"`
+	WorkingDir string            `json:"WorkingDir,omitempty"`
+	Labels     map[string]string `json:"Labels,omitempty"`
+}
+
+type rootFS struct {
+	Type    string   `json:"type"`
+	DiffIDs []string `json:"diff_ids"`
+}
+
+// dockerManifestEntry is an entry in the "manifest.json" file that is read by "docker load".
+type dockerManifestEntry struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+type imageLayoutParams struct {
+	osArch     osarch.OSArch
+	created    time.Time
+	config     ImageConfig
+	layers     []blob
+	repository string
+	tag        string
+}
+
+// writeImageLayout writes a tar archive that contains an OCI image layout for a single image to dstPath. The archive
+// also contains a "manifest.json" file so that it can be loaded using "docker load".
+func writeImageLayout(dstPath string, params imageLayoutParams) error {
+	env := params.config.Env
+	if !hasPathEnv(env) {
+		env = append([]string{defaultPathEnv}, env...)
+	}
+	var diffIDs []string
+	var layerDescriptors []descriptor
+	var layerPaths []string
+	for _, layer := range params.layers {
+		diffIDs = append(diffIDs, layer.diffID)
+		layerDescriptors = append(layerDescriptors, descriptor{
+			MediaType: layer.mediaType,
+			Digest:    layer.digest,
+			Size:      layer.size,
+		})
+		layerPaths = append(layerPaths, blobPath(layer))
+	}
+
+	configBytes, err := json.Marshal(imageConfigFile{
+		Created:      params.created.UTC().Format(time.RFC3339),
+		Architecture: params.osArch.Arch,
+		OS:           params.osArch.OS,
+		Config: containerConfig{
+			User:       params.config.User,
+			Env:        env,
+			Entrypoint: params.config.Entrypoint,
+			Cmd:        params.config.Cmd,
+			WorkingDir: params.config.WorkingDir,
+			Labels:     params.config.Labels,
+		},
+		RootFS: rootFS{
+			Type:    "layers",
+			DiffIDs: diffIDs,
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal image configuration as JSON")
+	}
+	configBlob := newBlob(mediaTypeImageConfig, configBytes)
+
+	manifestBytes, err := json.Marshal(imageManifest{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeImageManifest,
+		Config: descriptor{
+			MediaType: configBlob.mediaType,
+			Digest:    configBlob.digest,
+			Size:      configBlob.size,
+		},
+		Layers: layerDescriptors,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal image manifest as JSON")
+	}
+	manifestBlob := newBlob(mediaTypeImageManifest, manifestBytes)
+
+	annotations := map[string]string{
+		annotationRefName: params.tag,
+	}
+	var repoTags []string
+	if params.repository != "" {
+		annotations[annotationContainerdRef] = params.repository + ":" + params.tag
+		repoTags = append(repoTags, params.repository+":"+params.tag)
+	}
+	indexBytes, err := json.Marshal(imageIndex{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeImageIndex,
+		Manifests: []descriptor{
+			{
+				MediaType: manifestBlob.mediaType,
+				Digest:    manifestBlob.digest,
+				Size:      manifestBlob.size,
+				Platform: &platform{
+					Architecture: params.osArch.Arch,
+					OS:           params.osArch.OS,
+				},
+				Annotations: annotations,
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal image index as JSON")
+	}
+
+	dockerManifestBytes, err := json.Marshal([]dockerManifestEntry{
+		{
+			Config:   blobPath(configBlob),
+			RepoTags: repoTags,
+			Layers:   layerPaths,
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal Docker manifest as JSON")
+	}
+
+	// blobs are written in sorted order and each blob is written only once
+	blobs := map[string]blob{
+		configBlob.digest:   configBlob,
+		manifestBlob.digest: manifestBlob,
+	}
+	for _, layer := range params.layers {
+		blobs[layer.digest] = layer
+	}
+	var digests []string
+	for digest := range blobs {
+		digests = append(digests, digest)
+	}
+	sort.Strings(digests)
+
+	out, err := os.Create(dstPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", dstPath)
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+	tw := tar.NewWriter(out)
+	for _, dir := range []string{"blobs/", "blobs/sha256/"} {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     dir,
+			Mode:     0755,
+			ModTime:  params.created,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to write tar header for %s", dir)
+		}
+	}
+	for _, digest := range digests {
+		if err := writeTarFile(tw, blobPath(blobs[digest]), blobs[digest], params.created); err != nil {
+			return err
+		}
+	}
+	for _, f := range []struct {
+		name    string
+		content []byte
+	}{
+		{"index.json", indexBytes},
+		{"manifest.json", dockerManifestBytes},
+		{"oci-layout", []byte(`{"imageLayoutVersion":"1.0.0"}`)},
+	} {
+		if err := writeTarFile(tw, f.name, newBlob("", f.content), params.created); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close tar writer")
+	}
+	return out.Close()
+}
+
+func writeTarFile(tw *tar.Writer, name string, b blob, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     b.size,
+		ModTime:  modTime,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to write tar header for %s", name)
+	}
+	if err := b.writeTo(tw); err != nil {
+		return errors.Wrapf(err, "failed to write %s", name)
+	}
+	return nil
+}
+
+func blobPath(b blob) string {
+	return path.Join("blobs", "sha256", b.hex())
+}
+
+func hasPathEnv(env []string) bool {
+	for _, currEnv := range env {
+		if strings.HasPrefix(currEnv, "PATH=") {
+			return true
+		}
+	}
+	return false
+}
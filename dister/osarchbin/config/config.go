@@ -22,6 +22,7 @@ package config
 import (
 	"github.com/palantir/godel/pkg/osarch"
 
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
 	"github.com/sniperkit/snk.fork.palantir-distgo/dister/osarchbin"
 	"github.com/sniperkit/snk.fork.palantir-distgo/dister/osarchbin/config/internal/v0"
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
@@ -35,6 +36,9 @@ func (cfg *OSArchBin) ToDister() distgo.Dister {
 		osArchs = []osarch.OSArch{osarch.Current()}
 	}
 	return &osarchbin.Dister{
-		OSArchs: osArchs,
+		OSArchs:      osArchs,
+		Format:       distarchive.Format(cfg.Format),
+		Reproducible: cfg.Reproducible,
+		FileModes:    distarchive.FileModes(cfg.FileModes),
 	}
 }
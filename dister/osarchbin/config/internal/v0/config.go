@@ -23,12 +23,32 @@ import (
 	"github.com/palantir/godel/pkg/osarch"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
 )
 
 type Config struct {
-	// OSArchs specifies the GOOS and GOARCH pairs for which TGZ distributions are created. If blank, defaults to
+	// OSArchs specifies the GOOS and GOARCH pairs for which archive distributions are created. If blank, defaults to
 	// the GOOS and GOARCH of the host system at runtime.
 	OSArchs []osarch.OSArch `yaml:"os-archs,omitempty"`
+
+	// Format specifies the format of the archives. Must be one of "tgz", "tar.xz", "zip" or "auto". If "auto", ZIP
+	// archives are created for Windows targets and TGZ archives are created for all other targets. If blank, defaults
+	// to "tgz".
+	Format string `yaml:"format,omitempty"`
+
+	// Reproducible specifies whether the archives are created in a reproducible manner. If true, the modification
+	// time of every entry is set to the value of the SOURCE_DATE_EPOCH environment variable (or to the time of the HEAD
+	// commit of the project if it is not set), owners and permissions are normalized, entries are sorted and the gzip
+	// header does not include a timestamp. This ensures that dists of the same commit are identical byte-for-byte.
+	Reproducible bool `yaml:"reproducible,omitempty"`
+
+	// FileModes specifies the permissions and owners of the entries of the archives. Each element specifies a glob
+	// "pattern" and the "mode" (an octal string such as "0755"), "owner" and "group" (names or numeric IDs) of the
+	// entries that match it. A pattern that contains a '/' is matched against the path of the entry relative to the
+	// directory that is archived and any other pattern is matched against the name of the entry. If multiple elements
+	// match an entry, the later elements take precedence. Owners and groups are ignored for ZIP archives.
+	FileModes []distarchive.FileMode `yaml:"file-modes,omitempty"`
 }
 
 func UpgradeConfig(cfgBytes []byte) ([]byte, error) {
@@ -22,16 +22,14 @@ package osarchbin
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"path"
-	"sort"
 	"strings"
 
-	"github.com/mholt/archiver"
 	"github.com/palantir/godel/pkg/osarch"
 	"github.com/pkg/errors"
-	"github.com/termie/go-shutil"
 
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
 )
 
@@ -39,6 +37,12 @@ const TypeName = "os-arch-bin" // distribution that consists of the binaries for
 
 type Dister struct {
 	OSArchs []osarch.OSArch
+	// Format is the format of the archives. If empty, distarchive.FormatTGZ is used.
+	Format distarchive.Format
+	// Reproducible specifies whether the archives are created using distarchive.Format.MakeReproducible.
+	Reproducible bool
+	// FileModes specifies the permissions and owners of the entries of the archives.
+	FileModes distarchive.FileModes
 }
 
 func New(osArchs ...osarch.OSArch) distgo.Dister {
@@ -54,18 +58,60 @@ func (d *Dister) TypeName() (string, error) {
 func (d *Dister) Artifacts(renderedName string) ([]string, error) {
 	var outPaths []string
 	for _, osArch := range d.OSArchs {
-		outPaths = append(outPaths, fmt.Sprintf("%s-%s.tgz", renderedName, osArch.String()))
+		format, err := d.format(osArch)
+		if err != nil {
+			return nil, err
+		}
+		outPaths = append(outPaths, artifactName(renderedName, osArch, format))
 	}
 	return outPaths, nil
 }
 
+// PackagingExtension returns the extension of the archives created by the dister. If the format is
+// distarchive.FormatAuto and the OS/Archs of the dister resolve to different formats, there is no single primary
+// artifact and an empty string is returned.
 func (d *Dister) PackagingExtension() (string, error) {
-	return "tgz", nil
+	extension := ""
+	for i, osArch := range d.OSArchs {
+		format, err := d.format(osArch)
+		if err != nil {
+			return "", err
+		}
+		if i > 0 && format.Extension() != extension {
+			return "", nil
+		}
+		extension = format.Extension()
+	}
+	if extension == "" {
+		format, err := distarchive.ParseFormat(string(d.Format))
+		if err != nil {
+			return "", err
+		}
+		extension = format.ForOS("").Extension()
+	}
+	return extension, nil
+}
+
+// format returns the concrete archive format used for the provided OS/Arch.
+func (d *Dister) format(osArch osarch.OSArch) (distarchive.Format, error) {
+	format, err := distarchive.ParseFormat(string(d.Format))
+	if err != nil {
+		return "", err
+	}
+	return format.ForOS(osArch.OS), nil
+}
+
+func artifactName(renderedName string, osArch osarch.OSArch, format distarchive.Format) string {
+	return fmt.Sprintf("%s-%s.%s", renderedName, osArch.String(), format.Extension())
 }
 
 func (d *Dister) osArchFromArtifactPath(distID distgo.DistID, artifactPath string, productTaskOutputInfo distgo.ProductTaskOutputInfo) (osarch.OSArch, error) {
 	for _, osArch := range d.OSArchs {
-		if strings.HasSuffix(artifactPath, fmt.Sprintf("%s-%s.tgz", productTaskOutputInfo.Product.DistOutputInfos.DistInfos[distID].DistNameTemplateRendered, osArch.String())) {
+		format, err := d.format(osArch)
+		if err != nil {
+			return osarch.OSArch{}, err
+		}
+		if strings.HasSuffix(artifactPath, artifactName(productTaskOutputInfo.Product.DistOutputInfos.DistInfos[distID].DistNameTemplateRendered, osArch, format)) {
 			return osArch, nil
 		}
 	}
@@ -74,7 +120,7 @@ func (d *Dister) osArchFromArtifactPath(distID distgo.DistID, artifactPath strin
 
 func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
 	for _, osArch := range d.OSArchs {
-		if err := verifyDistTargetSupported(osArch, productTaskOutputInfo); err != nil {
+		if err := disterutil.VerifyDistTargetSupported(osArch, productTaskOutputInfo); err != nil {
 			return nil, err
 		}
 	}
@@ -83,7 +129,7 @@ func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.Prod
 	for _, osArch := range d.OSArchs {
 		for _, currProductOutputInfo := range productTaskOutputInfo.AllProductOutputInfos() {
 			// copy executable for current product
-			dst, err := copyArtifactForOSArch(distWorkDir, productTaskOutputInfo.Project, currProductOutputInfo, osArch)
+			dst, err := disterutil.CopyArtifactForOSArch(path.Join(distWorkDir, osArch.String()), productTaskOutputInfo.Project, currProductOutputInfo, osArch)
 			if err != nil {
 				return nil, err
 			}
@@ -102,83 +148,33 @@ func (d *Dister) GenerateDistArtifacts(distID distgo.DistID, productTaskOutputIn
 	if err := json.Unmarshal(runDistResult, &outputPathsForOSArchs); err != nil {
 		return errors.Wrapf(err, "failed to unmarshal runDistResult JSON %s", string(runDistResult))
 	}
-	artifactToInputPaths := make(map[string][]string)
-	outputArtifactPaths := productTaskOutputInfo.ProductDistArtifactPaths()[distID]
-	for _, artifactPath := range outputArtifactPaths {
+	for _, artifactPath := range productTaskOutputInfo.ProductDisterArtifactPaths()[distID] {
 		currOSArch, err := d.osArchFromArtifactPath(distID, artifactPath, productTaskOutputInfo)
 		if err != nil {
 			return err
 		}
-		artifactToInputPaths[artifactPath] = outputPathsForOSArchs[currOSArch.String()]
-	}
-	return createTgz(outputArtifactPaths, artifactToInputPaths)
-}
-
-func createTgz(dstArtifactPaths []string, dstToContentPaths map[string][]string) error {
-	for _, currDstPath := range dstArtifactPaths {
-		if err := archiver.TarGz.Make(currDstPath, dstToContentPaths[currDstPath]); err != nil {
-			return errors.Wrapf(err, "failed to create TGZ archive")
-		}
-	}
-	return nil
-}
-
-func verifyDistTargetSupported(osArch osarch.OSArch, productTaskOutputInfo distgo.ProductTaskOutputInfo) error {
-	if err := verifySingleProduct(osArch, productTaskOutputInfo.Product); err != nil {
-		return err
-	}
-	var keys []distgo.ProductID
-	for k := range productTaskOutputInfo.Deps {
-		keys = append(keys, k)
-	}
-	sort.Sort(distgo.ByProductID(keys))
-	for _, currKey := range keys {
-		currSpec := productTaskOutputInfo.Deps[currKey]
-		if err := verifySingleProduct(osArch, currSpec); err != nil {
+		format, err := d.format(currOSArch)
+		if err != nil {
 			return err
 		}
-	}
-	return nil
-}
-
-func verifySingleProduct(osArch osarch.OSArch, productOutputInfo distgo.ProductOutputInfo) error {
-	if !osArchInBuildSpec(osArch, productOutputInfo) {
-		buildOSArchs := "[none]"
-		if productOutputInfo.BuildOutputInfo != nil {
-			buildOSArchs = fmt.Sprint(productOutputInfo.BuildOutputInfo.OSArchs)
+		if err := d.makeArchive(format, artifactPath, outputPathsForOSArchs[currOSArch.String()], productTaskOutputInfo.Project); err != nil {
+			return err
 		}
-		return errors.Errorf("the OS/Arch specified for the distribution of a product must be specified as a build target for the product, "+
-			"but product %s does not specify %s as one of its build targets (current build targets: %s)", productOutputInfo.ID, osArch, buildOSArchs)
 	}
 	return nil
 }
 
-func osArchInBuildSpec(osArch osarch.OSArch, productOutputInfo distgo.ProductOutputInfo) bool {
-	if productOutputInfo.BuildOutputInfo == nil {
-		return false
+func (d *Dister) makeArchive(format distarchive.Format, dstPath string, srcPaths []string, projectInfo distgo.ProjectInfo) error {
+	opts := distarchive.Options{
+		FileModes: d.FileModes,
 	}
-	found := false
-	for _, currBuildOSArch := range productOutputInfo.BuildOutputInfo.OSArchs {
-		if currBuildOSArch == osArch {
-			found = true
-			break
+	if d.Reproducible {
+		modTime, err := distarchive.ReproducibleModTime(projectInfo.ProjectDir)
+		if err != nil {
+			return err
 		}
+		opts.Reproducible = true
+		opts.ModTime = modTime
 	}
-	return found
-}
-
-func copyArtifactForOSArch(outputDir string, projectInfo distgo.ProjectInfo, productInfo distgo.ProductOutputInfo, osArch osarch.OSArch) (string, error) {
-	artifactPath, ok := distgo.ProductBuildArtifactPaths(projectInfo, productInfo)[osArch]
-	if !ok {
-		return "", errors.Errorf("no build artifacts exist for %s", osArch)
-	}
-
-	dst := path.Join(outputDir, osArch.String(), distgo.ExecutableName(productInfo.BuildOutputInfo.BuildNameTemplateRendered, osArch.OS))
-	if err := os.MkdirAll(path.Dir(dst), 0755); err != nil {
-		return "", errors.Wrapf(err, "failed to create output directory for artifact")
-	}
-	if _, err := shutil.Copy(artifactPath, dst, false); err != nil {
-		return "", errors.Wrapf(err, "failed to copy build artifact from %s to %s", artifactPath, dst)
-	}
-	return dst, nil
+	return format.MakeWithOptions(dstPath, srcPaths, opts)
 }
@@ -105,6 +105,97 @@ Finished creating os-arch-bin distribution for foo
 					assert.NoError(t, wantLayout.Validate(path.Join(projectDir, "out", "dist", "foo", "1.0.0"), nil))
 				},
 			},
+			{
+				Name: "os-arch-bin with auto format creates ZIP archives for Windows",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+        - os: windows
+          arch: amd64
+    dist:
+      disters:
+        type: os-arch-bin
+        config:
+          format: auto
+          os-archs:
+            - os: linux
+              arch: amd64
+            - os: windows
+              arch: amd64
+`,
+				},
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/os-arch-bin/foo-1.0.0-linux-amd64.tgz, out/dist/foo/1.0.0/os-arch-bin/foo-1.0.0-windows-amd64.zip
+Finished creating os-arch-bin distribution for foo
+`
+				},
+				Validate: func(projectDir string) {
+					wantLayout := specdir.NewLayoutSpec(
+						specdir.Dir(specdir.LiteralName("1.0.0"), "",
+							specdir.Dir(specdir.LiteralName("os-arch-bin"), "",
+								specdir.Dir(specdir.LiteralName("foo-1.0.0"), "",
+									specdir.Dir(specdir.LiteralName("linux-amd64"), "",
+										specdir.File(specdir.LiteralName("foo"), ""),
+									),
+									specdir.Dir(specdir.LiteralName("windows-amd64"), "",
+										specdir.File(specdir.LiteralName("foo.exe"), ""),
+									),
+								),
+								specdir.File(specdir.LiteralName("foo-1.0.0-linux-amd64.tgz"), ""),
+								specdir.File(specdir.LiteralName("foo-1.0.0-windows-amd64.zip"), ""),
+							),
+						), true,
+					)
+					assert.NoError(t, wantLayout.Validate(path.Join(projectDir, "out", "dist", "foo", "1.0.0"), nil))
+				},
+			},
+			{
+				Name: "os-arch-bin creates tar.xz archives",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: os-arch-bin
+        config:
+          format: tar.xz
+          os-archs:
+            - os: linux
+              arch: amd64
+`,
+				},
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/os-arch-bin/foo-1.0.0-linux-amd64.tar.xz
+Finished creating os-arch-bin distribution for foo
+`
+				},
+			},
 		},
 	)
 }
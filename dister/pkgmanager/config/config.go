@@ -0,0 +1,40 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/pkgmanager"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/pkgmanager/config/internal/v0"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+type PackageManager v0.Config
+
+func (cfg *PackageManager) ToDister() distgo.Dister {
+	return &pkgmanager.Dister{
+		SourceDist:  distgo.DistID(cfg.SourceDist),
+		Managers:    cfg.Managers,
+		Name:        cfg.Name,
+		URLTemplate: cfg.URLTemplate,
+		Description: cfg.Description,
+		Homepage:    cfg.Homepage,
+		License:     cfg.License,
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v0
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+type Config struct {
+	// SourceDist is the ID of the dist of the product whose archives are referenced by the generated files. The dist
+	// must be an "os-arch-bin" dist whose archives contain the executables at the top level (which is the default). The
+	// source dist is always run before this dist. If blank, defaults to "os-arch-bin".
+	SourceDist string `yaml:"source-dist,omitempty"`
+
+	// Managers specifies the package managers for which files are generated. Valid values are "homebrew" (which
+	// generates a Homebrew formula named "{{Name}}.rb" that references the darwin and linux archives) and "scoop"
+	// (which generates a Scoop manifest named "{{Name}}.json" that references the windows archives). If blank, files
+	// are generated for all package managers.
+	Managers []string `yaml:"managers,omitempty"`
+
+	// Name is the name of the Homebrew formula and Scoop app, which determines the names of the generated files. If
+	// blank, the rendered name template of the dist is used (specifying "{{Product}}" as the name template for this dist
+	// is typically desirable).
+	Name string `yaml:"name,omitempty"`
+
+	// URLTemplate is the template used to render the download URL of each archive. Must be specified. The following
+	// template parameters can be used in the template:
+	//   * {{Product}}: the name of the product
+	//   * {{Version}}: the version of the project
+	//   * {{Artifact}}: the file name of the archive
+	//   * {{OS}}: the GOOS of the archive
+	//   * {{Arch}}: the GOARCH of the archive
+	URLTemplate string `yaml:"url-template,omitempty"`
+
+	// Description is the description of the package. For Homebrew, defaults to the ID of the product if blank.
+	Description string `yaml:"description,omitempty"`
+
+	// Homepage is the URL of the homepage of the package.
+	Homepage string `yaml:"homepage,omitempty"`
+
+	// License is the SPDX identifier of the license of the package.
+	License string `yaml:"license,omitempty"`
+}
+
+func UpgradeConfig(cfgBytes []byte) ([]byte, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(cfgBytes, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal package-manager dister v0 configuration")
+	}
+	return cfgBytes, nil
+}
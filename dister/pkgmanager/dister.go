@@ -0,0 +1,246 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkgmanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/osarchbin"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+const TypeName = "package-manager" // distribution that consists of Homebrew formulas and Scoop manifests for os-arch-bin archives
+
+const (
+	ManagerHomebrew = "homebrew"
+	ManagerScoop    = "scoop"
+)
+
+// Managers returns the package managers for which the dister can generate files.
+func Managers() []string {
+	return []string{ManagerHomebrew, ManagerScoop}
+}
+
+type Dister struct {
+	// SourceDist is the ID of the os-arch-bin dist of the product whose archives are referenced by the generated files.
+	// If empty, osarchbin.TypeName is used.
+	SourceDist distgo.DistID
+	// Managers are the package managers for which files are generated. If empty, files are generated for all of the
+	// package managers returned by Managers.
+	Managers []string
+	// Name is the name of the Homebrew formula and Scoop app. The generated files are named "{{Name}}.rb" and
+	// "{{Name}}.json". If empty, the rendered name of the dist is used.
+	Name string
+	// URLTemplate is the template used to render the download URL of each archive.
+	URLTemplate string
+	Description string
+	Homepage    string
+	License     string
+}
+
+func (d *Dister) TypeName() (string, error) {
+	return TypeName, nil
+}
+
+func (d *Dister) Artifacts(renderedName string) ([]string, error) {
+	managers, err := d.managers()
+	if err != nil {
+		return nil, err
+	}
+	name := d.Name
+	if name == "" {
+		name = renderedName
+	}
+	var artifacts []string
+	for _, manager := range managers {
+		switch manager {
+		case ManagerHomebrew:
+			artifacts = append(artifacts, name+".rb")
+		case ManagerScoop:
+			artifacts = append(artifacts, name+".json")
+		}
+	}
+	return artifacts, nil
+}
+
+func (d *Dister) PackagingExtension() (string, error) {
+	return "", nil
+}
+
+func (d *Dister) DistDependencies() []distgo.DistID {
+	return []distgo.DistID{d.sourceDist()}
+}
+
+func (d *Dister) sourceDist() distgo.DistID {
+	if d.SourceDist == "" {
+		return osarchbin.TypeName
+	}
+	return d.SourceDist
+}
+
+func (d *Dister) managers() ([]string, error) {
+	if len(d.Managers) == 0 {
+		return Managers(), nil
+	}
+	for _, manager := range d.Managers {
+		if manager != ManagerHomebrew && manager != ManagerScoop {
+			return nil, errors.Errorf("invalid package manager %q: valid values are %v", manager, Managers())
+		}
+	}
+	return d.Managers, nil
+}
+
+// archive describes an archive generated by the source dist.
+type archive struct {
+	OS     string `json:"os"`
+	Arch   string `json:"arch"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
+	if d.URLTemplate == "" {
+		return nil, errors.Errorf("url-template must be specified")
+	}
+	sourceDist := d.sourceDist()
+	sourceDistInfo, ok := productTaskOutputInfo.Product.DistOutputInfos.DistInfos[sourceDist]
+	if !ok {
+		return nil, errors.Errorf("product %s does not have a dist with ID %s", productTaskOutputInfo.Product.ID, sourceDist)
+	}
+
+	var archives []archive
+	for _, artifactPath := range productTaskOutputInfo.ProductDisterArtifactPaths()[sourceDist] {
+		artifactName := path.Base(artifactPath)
+		osArch, ok := archiveOSArch(artifactName, sourceDistInfo.DistNameTemplateRendered, productTaskOutputInfo.Product)
+		if !ok {
+			return nil, errors.Errorf("failed to determine OS/Arch for artifact %s of dist %s", artifactName, sourceDist)
+		}
+		sha256Sum, err := sha256File(artifactPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "artifacts of dist %s must exist before dist %s is run", sourceDist, distID)
+		}
+		url, err := distgo.RenderTemplate(d.URLTemplate, nil,
+			distgo.ProductTemplateFunction(productTaskOutputInfo.Product.ID),
+			distgo.VersionTemplateFunction(productTaskOutputInfo.Project.Version),
+			distgo.TemplateValueFunction("Artifact", artifactName),
+			distgo.TemplateValueFunction("OS", osArch.OS),
+			distgo.TemplateValueFunction("Arch", osArch.Arch),
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to render url-template")
+		}
+		archives = append(archives, archive{
+			OS:     osArch.OS,
+			Arch:   osArch.Arch,
+			URL:    url,
+			SHA256: sha256Sum,
+		})
+	}
+	jsonBytes, err := json.Marshal(archives)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal archives as JSON")
+	}
+	return jsonBytes, nil
+}
+
+func (d *Dister) GenerateDistArtifacts(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo, runDistResult []byte) error {
+	var archives []archive
+	if err := json.Unmarshal(runDistResult, &archives); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal runDistResult JSON %s", string(runDistResult))
+	}
+	managers, err := d.managers()
+	if err != nil {
+		return err
+	}
+	artifactPaths := productTaskOutputInfo.ProductDisterArtifactPaths()[distID]
+	if len(artifactPaths) != len(managers) {
+		return errors.Errorf("expected %d artifacts for dist %s, but was %v", len(managers), distID, artifactPaths)
+	}
+	for i, manager := range managers {
+		name := strings.TrimSuffix(path.Base(artifactPaths[i]), path.Ext(artifactPaths[i]))
+		var content []byte
+		switch manager {
+		case ManagerHomebrew:
+			content, err = d.homebrewFormula(name, archives, productTaskOutputInfo)
+		case ManagerScoop:
+			content, err = d.scoopManifest(archives, productTaskOutputInfo)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate %s file", manager)
+		}
+		if err := ioutil.WriteFile(artifactPaths[i], content, 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", artifactPaths[i])
+		}
+	}
+	return nil
+}
+
+// executableNames returns the names of the executables in the os-arch-bin archive for the provided OS, which are the
+// executables of the product and its dependencies.
+func executableNames(goos string, productTaskOutputInfo distgo.ProductTaskOutputInfo) []string {
+	var names []string
+	for _, currProductOutputInfo := range productTaskOutputInfo.AllProductOutputInfos() {
+		if currProductOutputInfo.BuildOutputInfo == nil {
+			continue
+		}
+		names = append(names, distgo.ExecutableName(currProductOutputInfo.BuildOutputInfo.BuildNameTemplateRendered, goos))
+	}
+	return names
+}
+
+// archiveOSArch returns the OS/Arch of the os-arch-bin archive with the provided name, which is of the form
+// "{{renderedName}}-{{OS}}-{{Arch}}.{{Extension}}".
+func archiveOSArch(artifactName, renderedName string, productOutputInfo distgo.ProductOutputInfo) (osarch.OSArch, bool) {
+	if productOutputInfo.BuildOutputInfo == nil || !strings.HasPrefix(artifactName, renderedName+"-") {
+		return osarch.OSArch{}, false
+	}
+	osArchAndExt := strings.TrimPrefix(artifactName, renderedName+"-")
+	for _, osArch := range productOutputInfo.BuildOutputInfo.OSArchs {
+		if strings.HasPrefix(osArchAndExt, osArch.String()+".") {
+			return osArch, true
+		}
+	}
+	return osarch.OSArch{}, false
+}
+
+func sha256File(fPath string) (string, error) {
+	f, err := os.Open(fPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", fPath)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", fPath)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
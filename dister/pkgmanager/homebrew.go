@@ -0,0 +1,127 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkgmanager
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+// homebrewOSBlocks are the Homebrew formula blocks for supported values of GOOS, in the order in which they are
+// written.
+var homebrewOSBlocks = []struct {
+	goos  string
+	block string
+}{
+	{goos: "darwin", block: "on_macos"},
+	{goos: "linux", block: "on_linux"},
+}
+
+// homebrewCPUChecks maps supported values of GOARCH to the Homebrew expression that checks for the architecture.
+var homebrewCPUChecks = map[string]string{
+	"amd64": "Hardware::CPU.intel?",
+	"arm64": "Hardware::CPU.arm?",
+}
+
+// homebrewFormula returns the content of a Homebrew formula with the provided name for the provided archives. Archives
+// for OS/Archs that are not supported by Homebrew are ignored.
+func (d *Dister) homebrewFormula(name string, archives []archive, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
+	description := d.Description
+	if description == "" {
+		description = string(productTaskOutputInfo.Product.ID)
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "class %s < Formula\n", homebrewClassName(name))
+	fmt.Fprintf(buf, "  desc %s\n", rubyString(description))
+	if d.Homepage != "" {
+		fmt.Fprintf(buf, "  homepage %s\n", rubyString(d.Homepage))
+	}
+	fmt.Fprintf(buf, "  version %s\n", rubyString(productTaskOutputInfo.Project.Version))
+	if d.License != "" {
+		fmt.Fprintf(buf, "  license %s\n", rubyString(d.License))
+	}
+
+	numArchives := 0
+	for _, osBlock := range homebrewOSBlocks {
+		var osArchives []archive
+		for _, currArchive := range archives {
+			if _, ok := homebrewCPUChecks[currArchive.Arch]; ok && currArchive.OS == osBlock.goos {
+				osArchives = append(osArchives, currArchive)
+			}
+		}
+		if len(osArchives) == 0 {
+			continue
+		}
+		numArchives += len(osArchives)
+		fmt.Fprintf(buf, "\n  %s do\n", osBlock.block)
+		for _, currArchive := range osArchives {
+			fmt.Fprintf(buf, "    if %s\n", homebrewCPUChecks[currArchive.Arch])
+			fmt.Fprintf(buf, "      url %s\n", rubyString(currArchive.URL))
+			fmt.Fprintf(buf, "      sha256 %s\n", rubyString(currArchive.SHA256))
+			fmt.Fprintf(buf, "    end\n")
+		}
+		fmt.Fprintf(buf, "  end\n")
+	}
+	if numArchives == 0 {
+		return nil, errors.Errorf("source dist does not contain any archives for an OS/Arch supported by Homebrew")
+	}
+
+	fmt.Fprintf(buf, "\n  def install\n")
+	for _, executable := range executableNames("darwin", productTaskOutputInfo) {
+		fmt.Fprintf(buf, "    bin.install %s\n", rubyString(executable))
+	}
+	fmt.Fprintf(buf, "  end\n")
+	fmt.Fprintf(buf, "end\n")
+	return buf.Bytes(), nil
+}
+
+// homebrewClassName returns the name of the Ruby class for the formula with the provided name using the same rules as
+// Homebrew: for example, "foo-bar" becomes "FooBar" and "foo@1.2" becomes "FooAT12".
+func homebrewClassName(name string) string {
+	buf := &bytes.Buffer{}
+	upperNext := true
+	for _, r := range name {
+		switch {
+		case r == '@':
+			buf.WriteString("AT")
+			upperNext = true
+		case r == '-' || r == '_' || r == '.':
+			upperNext = true
+		case upperNext:
+			buf.WriteRune(unicode.ToUpper(r))
+			upperNext = false
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+// rubyString returns the provided string as a double-quoted Ruby string literal.
+func rubyString(in string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `#`, `\#`).Replace(in) + `"`
+}
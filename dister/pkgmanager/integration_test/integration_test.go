@@ -0,0 +1,158 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration_test
+
+import (
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/gofiles"
+	"github.com/palantir/godel/framework/pluginapitester"
+	"github.com/palantir/godel/pkg/products/v2/products"
+	"github.com/palantir/pkg/specdir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distertester"
+)
+
+func TestPackageManagerDist(t *testing.T) {
+	const godelYML = `exclude:
+  names:
+    - "\\..+"
+    - "vendor"
+  paths:
+    - "godel"
+`
+
+	pluginPath, err := products.Bin("dist-plugin")
+	require.NoError(t, err)
+
+	distertester.RunAssetDistTest(t,
+		pluginapitester.NewPluginProvider(pluginPath),
+		nil,
+		[]distertester.TestCase{
+			{
+				Name: "package-manager creates Homebrew formula and Scoop manifest",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: darwin
+          arch: amd64
+        - os: windows
+          arch: amd64
+    dist:
+      disters:
+        os-arch-bin:
+          type: os-arch-bin
+          config:
+            format: auto
+            os-archs:
+              - os: darwin
+                arch: amd64
+              - os: windows
+                arch: amd64
+        package-manager:
+          type: package-manager
+          name-template: "{{Product}}"
+          config:
+            url-template: "https://example.com/{{Version}}/{{Artifact}}"
+`,
+				},
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/os-arch-bin/foo-1.0.0-darwin-amd64.tgz, out/dist/foo/1.0.0/os-arch-bin/foo-1.0.0-windows-amd64.zip
+Finished creating os-arch-bin distribution for foo
+Creating distribution for foo at out/dist/foo/1.0.0/package-manager/foo.rb, out/dist/foo/1.0.0/package-manager/foo.json
+Finished creating package-manager distribution for foo
+`
+				},
+				Validate: func(projectDir string) {
+					wantLayout := specdir.NewLayoutSpec(
+						specdir.Dir(specdir.LiteralName("package-manager"), "",
+							specdir.Dir(specdir.LiteralName("foo"), ""),
+							specdir.File(specdir.LiteralName("foo.rb"), ""),
+							specdir.File(specdir.LiteralName("foo.json"), ""),
+						), true,
+					)
+					assert.NoError(t, wantLayout.Validate(path.Join(projectDir, "out", "dist", "foo", "1.0.0", "package-manager"), nil))
+				},
+			},
+		},
+	)
+}
+
+func TestPackageManagerUpgradeConfig(t *testing.T) {
+	pluginPath, err := products.Bin("dist-plugin")
+	require.NoError(t, err)
+
+	pluginapitester.RunUpgradeConfigTest(t,
+		pluginapitester.NewPluginProvider(pluginPath),
+		nil,
+		[]pluginapitester.UpgradeConfigTestCase{
+			{
+				Name: `valid v0 config works`,
+				ConfigFiles: map[string]string{
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+    dist:
+      disters:
+        type: package-manager
+        config:
+          # comment
+          url-template: "https://example.com/{{Version}}/{{Artifact}}"
+          managers:
+            - homebrew
+`,
+				},
+				WantOutput: ``,
+				WantFiles: map[string]string{
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+    dist:
+      disters:
+        type: package-manager
+        config:
+          # comment
+          url-template: "https://example.com/{{Version}}/{{Artifact}}"
+          managers:
+            - homebrew
+`,
+				},
+			},
+		},
+	)
+}
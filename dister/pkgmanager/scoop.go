@@ -0,0 +1,81 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkgmanager
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+// scoopArchitectures maps supported values of GOARCH to the corresponding architecture key of a Scoop manifest.
+var scoopArchitectures = map[string]string{
+	"386":   "32bit",
+	"amd64": "64bit",
+	"arm64": "arm64",
+}
+
+type scoopManifest struct {
+	Version      string                       `json:"version"`
+	Description  string                       `json:"description,omitempty"`
+	Homepage     string                       `json:"homepage,omitempty"`
+	License      string                       `json:"license,omitempty"`
+	Architecture map[string]scoopArchitecture `json:"architecture"`
+	Bin          []string                     `json:"bin"`
+}
+
+type scoopArchitecture struct {
+	URL  string `json:"url"`
+	Hash string `json:"hash"`
+}
+
+// scoopManifest returns the content of a Scoop manifest for the provided archives. Archives for OS/Archs that are not
+// supported by Scoop are ignored.
+func (d *Dister) scoopManifest(archives []archive, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
+	architectures := make(map[string]scoopArchitecture)
+	for _, currArchive := range archives {
+		architecture, ok := scoopArchitectures[currArchive.Arch]
+		if !ok || currArchive.OS != "windows" {
+			continue
+		}
+		architectures[architecture] = scoopArchitecture{
+			URL:  currArchive.URL,
+			Hash: currArchive.SHA256,
+		}
+	}
+	if len(architectures) == 0 {
+		return nil, errors.Errorf("source dist does not contain any archives for an OS/Arch supported by Scoop")
+	}
+
+	manifestBytes, err := json.MarshalIndent(scoopManifest{
+		Version:      productTaskOutputInfo.Project.Version,
+		Description:  d.Description,
+		Homepage:     d.Homepage,
+		License:      d.License,
+		Architecture: architectures,
+		Bin:          executableNames("windows", productTaskOutputInfo),
+	}, "", "    ")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal Scoop manifest as JSON")
+	}
+	return append(manifestBytes, '\n'), nil
+}
@@ -0,0 +1,65 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"github.com/palantir/godel/pkg/osarch"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/rpm"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/rpm/config/internal/v0"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+type RPM v0.Config
+
+func (cfg *RPM) ToDister() distgo.Dister {
+	osArchs := cfg.OSArchs
+	if len(osArchs) == 0 {
+		osArchs = []osarch.OSArch{osarch.Current()}
+	}
+	return &rpm.Dister{
+		OSArchs:     osArchs,
+		PackageName: cfg.PackageName,
+		Release:     cfg.Release,
+		Prefix:      cfg.Prefix,
+		FileOwner:   cfg.Owner,
+		FileGroup:   cfg.Group,
+		Metadata: rpm.Metadata{
+			Summary:     cfg.Summary,
+			Description: cfg.Description,
+			License:     cfg.License,
+			Vendor:      cfg.Vendor,
+			URL:         cfg.URL,
+			Group:       cfg.PackageGroup,
+			Packager:    cfg.Packager,
+		},
+		Requires:  cfg.Requires,
+		Provides:  cfg.Provides,
+		Conflicts: cfg.Conflicts,
+		Obsoletes: cfg.Obsoletes,
+		Scripts: rpm.Scripts{
+			Pre:    cfg.Scripts.Pre,
+			Post:   cfg.Scripts.Post,
+			PreUn:  cfg.Scripts.PreUn,
+			PostUn: cfg.Scripts.PostUn,
+		},
+		Reproducible: cfg.Reproducible,
+	}
+}
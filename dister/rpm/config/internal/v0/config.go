@@ -0,0 +1,109 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v0
+
+import (
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+type Config struct {
+	// OSArchs specifies the GOOS and GOARCH pairs for which RPM packages are created. The OS must be "linux". If blank,
+	// defaults to the GOOS and GOARCH of the host system at runtime.
+	OSArchs []osarch.OSArch `yaml:"os-archs,omitempty"`
+
+	// PackageName is the name of the RPM package. If blank, the ID of the product is used.
+	PackageName string `yaml:"package-name,omitempty"`
+
+	// Release is the release of the RPM package. If blank, defaults to "1". The version of the package is derived from
+	// the version of the project.
+	Release string `yaml:"release,omitempty"`
+
+	// Prefix is the installation prefix for the package. The executables for the product and its dependencies are
+	// installed in "{{Prefix}}/bin". If blank, defaults to "/usr". All other content of the dist work directory (for
+	// example, the content of the input directory) is installed relative to the root of the file system. License notices
+	// of dependencies are installed in "{{Prefix}}/share/doc/{{PackageName}}" unless another directory is configured.
+	Prefix string `yaml:"prefix,omitempty"`
+
+	// Owner is the user that owns the files installed by the package. If blank, defaults to "root".
+	Owner string `yaml:"owner,omitempty"`
+
+	// Group is the group that owns the files installed by the package. If blank, defaults to "root".
+	Group string `yaml:"group,omitempty"`
+
+	// Summary is the one-line summary of the package. If blank, the ID of the product is used.
+	Summary string `yaml:"summary,omitempty"`
+
+	// Description is the description of the package. If blank, the summary is used.
+	Description string `yaml:"description,omitempty"`
+
+	// License is the license of the package. If blank, defaults to "Unspecified".
+	License string `yaml:"license,omitempty"`
+
+	// Vendor is the vendor of the package.
+	Vendor string `yaml:"vendor,omitempty"`
+
+	// URL is the URL of the home page of the package.
+	URL string `yaml:"url,omitempty"`
+
+	// PackageGroup is the RPM group of the package. If blank, defaults to "Unspecified".
+	PackageGroup string `yaml:"package-group,omitempty"`
+
+	// Packager is the packager of the package.
+	Packager string `yaml:"packager,omitempty"`
+
+	// Requires specifies the capabilities that this package requires. Each entry may include a version constraint of
+	// the form "name <op> version" where <op> is one of "<", "<=", "=", ">=" or ">": for example, "glibc >= 2.17".
+	Requires []string `yaml:"requires,omitempty"`
+
+	// Provides specifies the capabilities provided by this package in addition to the package itself.
+	Provides []string `yaml:"provides,omitempty"`
+
+	// Conflicts specifies the capabilities that conflict with this package.
+	Conflicts []string `yaml:"conflicts,omitempty"`
+
+	// Obsoletes specifies the capabilities that are obsoleted by this package.
+	Obsoletes []string `yaml:"obsoletes,omitempty"`
+
+	// Scripts specifies the content of the scriptlets for the package. Scriptlets are run using "/bin/sh".
+	Scripts Scripts `yaml:"scripts,omitempty"`
+
+	// Reproducible specifies whether the packages are created in a reproducible manner. If true, the build time of
+	// the packages and the modification time of their files are set to the value of the SOURCE_DATE_EPOCH environment
+	// variable (or to the time of the HEAD commit of the project if it is not set) and the build host is set to
+	// "localhost". Packages are always created in this manner if the SOURCE_DATE_EPOCH environment variable is set.
+	Reproducible bool `yaml:"reproducible,omitempty"`
+}
+
+type Scripts struct {
+	Pre    string `yaml:"pre,omitempty"`
+	Post   string `yaml:"post,omitempty"`
+	PreUn  string `yaml:"preun,omitempty"`
+	PostUn string `yaml:"postun,omitempty"`
+}
+
+func UpgradeConfig(cfgBytes []byte) ([]byte, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(cfgBytes, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal rpm dister v0 configuration")
+	}
+	return cfgBytes, nil
+}
@@ -0,0 +1,305 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+const TypeName = "rpm" // distribution that consists of an RPM package for a specific OS/Architecture
+
+type Dister struct {
+	// OSArchs are the OS/Architectures for which packages are created. The OS of every entry must be "linux".
+	OSArchs []osarch.OSArch
+	// PackageName is the name of the package. If empty, the ID of the product is used.
+	PackageName string
+	// Release is the release of the package. If empty, "1" is used.
+	Release string
+	// Prefix is the installation prefix for the package. Executables are installed in "{{Prefix}}/bin".
+	Prefix string
+	// FileOwner is the user that owns the files in the package. If empty, "root" is used.
+	FileOwner string
+	// FileGroup is the group that owns the files in the package. If empty, "root" is used.
+	FileGroup string
+	// Metadata contains the descriptive tags written to the header of the package.
+	Metadata Metadata
+	// Requires are the capabilities required by the package. Each entry may include a version constraint: for
+	// example, "glibc >= 2.17".
+	Requires []string
+	// Provides are the capabilities provided by the package in addition to the package itself.
+	Provides []string
+	// Conflicts are the capabilities that conflict with the package.
+	Conflicts []string
+	// Obsoletes are the capabilities that are obsoleted by the package.
+	Obsoletes []string
+	// Scripts contains the scriptlets for the package.
+	Scripts Scripts
+	// Reproducible specifies whether the build time of the packages is determined using
+	// distarchive.ReproducibleModTime and the build host is "localhost" so that the packages only depend on their
+	// content. Packages are also created in this manner if the SOURCE_DATE_EPOCH environment variable is set.
+	Reproducible bool
+}
+
+type Metadata struct {
+	Summary     string
+	Description string
+	License     string
+	Vendor      string
+	URL         string
+	Group       string
+	Packager    string
+}
+
+type Scripts struct {
+	Pre    string
+	Post   string
+	PreUn  string
+	PostUn string
+}
+
+func (d *Dister) TypeName() (string, error) {
+	return TypeName, nil
+}
+
+func (d *Dister) Artifacts(renderedName string) ([]string, error) {
+	var outPaths []string
+	for _, osArch := range d.OSArchs {
+		outPaths = append(outPaths, fmt.Sprintf("%s-%s.rpm", renderedName, osArch.String()))
+	}
+	return outPaths, nil
+}
+
+func (d *Dister) PackagingExtension() (string, error) {
+	return "rpm", nil
+}
+
+func (d *Dister) osArchFromArtifactPath(distID distgo.DistID, artifactPath string, productTaskOutputInfo distgo.ProductTaskOutputInfo) (osarch.OSArch, error) {
+	for _, osArch := range d.OSArchs {
+		if strings.HasSuffix(artifactPath, fmt.Sprintf("%s-%s.rpm", productTaskOutputInfo.Product.DistOutputInfos.DistInfos[distID].DistNameTemplateRendered, osArch.String())) {
+			return osArch, nil
+		}
+	}
+	return osarch.OSArch{}, errors.Errorf("failed to determine OS/Arch for artifact with Path %s", artifactPath)
+}
+
+func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
+	for _, osArch := range d.OSArchs {
+		if _, err := rpmArch(osArch); err != nil {
+			return nil, err
+		}
+		if err := disterutil.VerifyDistTargetSupported(osArch, productTaskOutputInfo); err != nil {
+			return nil, err
+		}
+	}
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	outputPathsForOSArchs := make(map[string][]string)
+	for _, osArch := range d.OSArchs {
+		for _, currProductOutputInfo := range productTaskOutputInfo.AllProductOutputInfos() {
+			// copy executable for current product
+			dst, err := disterutil.CopyArtifactForOSArch(path.Join(distWorkDir, osArch.String()), productTaskOutputInfo.Project, currProductOutputInfo, osArch)
+			if err != nil {
+				return nil, err
+			}
+			outputPathsForOSArchs[osArch.String()] = append(outputPathsForOSArchs[osArch.String()], dst)
+		}
+	}
+	jsonBytes, err := json.Marshal(outputPathsForOSArchs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal outputPathsForOSArchs as JSON")
+	}
+	return jsonBytes, nil
+}
+
+func (d *Dister) GenerateDistArtifacts(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo, runDistResult []byte) error {
+	var outputPathsForOSArchs map[string][]string
+	if err := json.Unmarshal(runDistResult, &outputPathsForOSArchs); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal runDistResult JSON %s", string(runDistResult))
+	}
+
+	// all of the content of the dist work directory other than the per-OS/Arch executable directories is included in
+	// the package relative to the root of the file system
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	rootFiles, err := disterutil.RootFiles(distWorkDir, d.OSArchs)
+	if err != nil {
+		return err
+	}
+
+	pkgInfo := packageInfo{
+		name:      d.packageName(productTaskOutputInfo.Product.ID),
+		release:   d.Release,
+		fileOwner: d.FileOwner,
+		fileGroup: d.FileGroup,
+		metadata:  d.Metadata,
+		scripts:   d.Scripts,
+	}
+	if pkgInfo.release == "" {
+		pkgInfo.release = "1"
+	}
+	if pkgInfo.fileOwner == "" {
+		pkgInfo.fileOwner = "root"
+	}
+	if pkgInfo.fileGroup == "" {
+		pkgInfo.fileGroup = "root"
+	}
+	if pkgInfo.metadata.Summary == "" {
+		pkgInfo.metadata.Summary = string(productTaskOutputInfo.Product.ID)
+	}
+	if pkgInfo.metadata.Description == "" {
+		pkgInfo.metadata.Description = pkgInfo.metadata.Summary
+	}
+	if pkgInfo.metadata.License == "" {
+		pkgInfo.metadata.License = "Unspecified"
+	}
+	if pkgInfo.metadata.Group == "" {
+		pkgInfo.metadata.Group = "Unspecified"
+	}
+	pkgInfo.version = rpmVersion(productTaskOutputInfo.Project.Version)
+	pkgInfo.buildTime, pkgInfo.buildHost, err = d.buildTimeAndHost(productTaskOutputInfo.Project.ProjectDir)
+	if err != nil {
+		return err
+	}
+	for _, dep := range []struct {
+		specs []string
+		dst   *[]dependency
+	}{
+		{d.Requires, &pkgInfo.requires},
+		{d.Provides, &pkgInfo.provides},
+		{d.Conflicts, &pkgInfo.conflicts},
+		{d.Obsoletes, &pkgInfo.obsoletes},
+	} {
+		for _, spec := range dep.specs {
+			parsed, err := parseDependency(spec)
+			if err != nil {
+				return err
+			}
+			*dep.dst = append(*dep.dst, parsed)
+		}
+	}
+
+	for _, artifactPath := range productTaskOutputInfo.ProductDisterArtifactPaths()[distID] {
+		currOSArch, err := d.osArchFromArtifactPath(distID, artifactPath, productTaskOutputInfo)
+		if err != nil {
+			return err
+		}
+		arch, err := rpmArch(currOSArch)
+		if err != nil {
+			return err
+		}
+
+		contents := newPackageContents()
+		for _, rootFile := range rootFiles {
+			if err := contents.addPath(path.Join(distWorkDir, rootFile), rootFile); err != nil {
+				return err
+			}
+		}
+		for _, executablePath := range outputPathsForOSArchs[currOSArch.String()] {
+			if err := contents.addExecutable(executablePath, path.Join(d.prefix(), "bin", path.Base(executablePath))); err != nil {
+				return err
+			}
+		}
+
+		currPkgInfo := pkgInfo
+		currPkgInfo.arch = arch
+		if err := writePackage(artifactPath, currPkgInfo, contents); err != nil {
+			return errors.Wrapf(err, "failed to create RPM package %s", artifactPath)
+		}
+	}
+	return nil
+}
+
+// buildTimeAndHost returns the build time and build host that are recorded in the packages. If the packages are
+// reproducible or the SOURCE_DATE_EPOCH environment variable is set, the time is determined by
+// distarchive.ReproducibleModTime and the host is "localhost". Otherwise, the current time and the host name are used.
+func (d *Dister) buildTimeAndHost(projectDir string) (time.Time, string, error) {
+	_, epochSet, err := distgo.SourceDateEpoch()
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	if d.Reproducible || epochSet {
+		buildTime, err := distarchive.ReproducibleModTime(projectDir)
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		return buildTime, "localhost", nil
+	}
+	buildHost, err := os.Hostname()
+	if err != nil || buildHost == "" {
+		buildHost = "localhost"
+	}
+	return time.Now(), buildHost, nil
+}
+
+func (d *Dister) prefix() string {
+	if d.Prefix == "" {
+		return "/usr"
+	}
+	return d.Prefix
+}
+
+// DocDir returns the directory in the dist work directory that is installed as the documentation directory of the
+// package, "{{Prefix}}/share/doc/{{PackageName}}".
+func (d *Dister) DocDir(productID distgo.ProductID) string {
+	return disterutil.CleanEntryName(path.Join(d.prefix(), "share", "doc", d.packageName(productID)))
+}
+
+func (d *Dister) packageName(productID distgo.ProductID) string {
+	if d.PackageName == "" {
+		return string(productID)
+	}
+	return d.PackageName
+}
+
+// rpmArch returns the RPM architecture name for the provided OS/Arch. Returns an error if the OS/Arch is not one for
+// which RPM packages can be created.
+func rpmArch(osArch osarch.OSArch) (string, error) {
+	if osArch.OS != "linux" {
+		return "", errors.Errorf("RPM packages can only be created for linux, but OS/Arch %s was specified", osArch)
+	}
+	switch osArch.Arch {
+	case "amd64":
+		return "x86_64", nil
+	case "386":
+		return "i386", nil
+	case "arm64":
+		return "aarch64", nil
+	case "arm":
+		return "armv7hl", nil
+	case "mipsle":
+		return "mipsel", nil
+	case "mips64le":
+		return "mips64el", nil
+	case "mips", "mips64", "ppc64", "ppc64le", "riscv64", "s390x":
+		return osArch.Arch, nil
+	default:
+		return "", errors.Errorf("no RPM architecture is known for OS/Arch %s", osArch)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+)
+
+// Header data types as defined by the RPM file format.
+const (
+	typeInt16       int32 = 3
+	typeInt32       int32 = 4
+	typeString      int32 = 6
+	typeBin         int32 = 7
+	typeStringArray int32 = 8
+	typeI18NString  int32 = 9
+)
+
+// Tags of the signature header.
+const (
+	sigTagHeaderSignatures int32 = 62
+	sigTagSHA1             int32 = 269
+	sigTagSHA256           int32 = 273
+	sigTagSize             int32 = 1000
+	sigTagMD5              int32 = 1004
+	sigTagPayloadSize      int32 = 1007
+)
+
+// Tags of the main header.
+const (
+	tagHeaderImmutable   int32 = 63
+	tagHeaderI18NTable   int32 = 100
+	tagName              int32 = 1000
+	tagVersion           int32 = 1001
+	tagRelease           int32 = 1002
+	tagSummary           int32 = 1004
+	tagDescription       int32 = 1005
+	tagBuildTime         int32 = 1006
+	tagBuildHost         int32 = 1007
+	tagSize              int32 = 1009
+	tagVendor            int32 = 1011
+	tagLicense           int32 = 1014
+	tagPackager          int32 = 1015
+	tagGroup             int32 = 1016
+	tagURL               int32 = 1020
+	tagOS                int32 = 1021
+	tagArch              int32 = 1022
+	tagPreIn             int32 = 1023
+	tagPostIn            int32 = 1024
+	tagPreUn             int32 = 1025
+	tagPostUn            int32 = 1026
+	tagFileSizes         int32 = 1028
+	tagFileModes         int32 = 1030
+	tagFileRDevs         int32 = 1033
+	tagFileMTimes        int32 = 1034
+	tagFileDigests       int32 = 1035
+	tagFileLinkTos       int32 = 1036
+	tagFileFlags         int32 = 1037
+	tagFileUserName      int32 = 1039
+	tagFileGroupName     int32 = 1040
+	tagSourceRPM         int32 = 1044
+	tagProvideName       int32 = 1047
+	tagRequireFlags      int32 = 1048
+	tagRequireName       int32 = 1049
+	tagRequireVersion    int32 = 1050
+	tagConflictFlags     int32 = 1053
+	tagConflictName      int32 = 1054
+	tagConflictVersion   int32 = 1055
+	tagPreInProg         int32 = 1085
+	tagPostInProg        int32 = 1086
+	tagPreUnProg         int32 = 1087
+	tagPostUnProg        int32 = 1088
+	tagObsoleteName      int32 = 1090
+	tagFileDevices       int32 = 1095
+	tagFileINodes        int32 = 1096
+	tagFileLangs         int32 = 1097
+	tagProvideFlags      int32 = 1112
+	tagProvideVersion    int32 = 1113
+	tagObsoleteFlags     int32 = 1114
+	tagObsoleteVersion   int32 = 1115
+	tagDirIndexes        int32 = 1116
+	tagBaseNames         int32 = 1117
+	tagDirNames          int32 = 1118
+	tagPayloadFormat     int32 = 1124
+	tagPayloadCompressor int32 = 1125
+	tagPayloadFlags      int32 = 1126
+	tagFileDigestAlgo    int32 = 5011
+)
+
+type headerEntry struct {
+	dataType int32
+	count    int32
+	data     []byte
+}
+
+// alignment returns the alignment required for the data of the entry in the data store of a header.
+func (e headerEntry) alignment() int {
+	switch e.dataType {
+	case typeInt16:
+		return 2
+	case typeInt32:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// rpmHeader is a header structure as used for both the signature and the main header of an RPM.
+type rpmHeader struct {
+	entries map[int32]headerEntry
+}
+
+func newRPMHeader() *rpmHeader {
+	return &rpmHeader{
+		entries: make(map[int32]headerEntry),
+	}
+}
+
+func (h *rpmHeader) addString(tag int32, val string) {
+	h.entries[tag] = headerEntry{dataType: typeString, count: 1, data: append([]byte(val), 0)}
+}
+
+func (h *rpmHeader) addI18NString(tag int32, val string) {
+	h.entries[tag] = headerEntry{dataType: typeI18NString, count: 1, data: append([]byte(val), 0)}
+}
+
+func (h *rpmHeader) addStringArray(tag int32, vals ...string) {
+	if len(vals) == 0 {
+		return
+	}
+	buf := &bytes.Buffer{}
+	for _, val := range vals {
+		buf.WriteString(val)
+		buf.WriteByte(0)
+	}
+	h.entries[tag] = headerEntry{dataType: typeStringArray, count: int32(len(vals)), data: buf.Bytes()}
+}
+
+func (h *rpmHeader) addInt32(tag int32, vals ...int32) {
+	if len(vals) == 0 {
+		return
+	}
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, binary.BigEndian, vals)
+	h.entries[tag] = headerEntry{dataType: typeInt32, count: int32(len(vals)), data: buf.Bytes()}
+}
+
+func (h *rpmHeader) addInt16(tag int32, vals ...int16) {
+	if len(vals) == 0 {
+		return
+	}
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, binary.BigEndian, vals)
+	h.entries[tag] = headerEntry{dataType: typeInt16, count: int32(len(vals)), data: buf.Bytes()}
+}
+
+func (h *rpmHeader) addBin(tag int32, val []byte) {
+	h.entries[tag] = headerEntry{dataType: typeBin, count: int32(len(val)), data: val}
+}
+
+// bytes returns the serialized form of the header. The header includes a region entry with the provided tag, which is
+// written as the first index entry and whose data (a trailing index entry that references the start of the index) is
+// written at the end of the data store.
+func (h *rpmHeader) bytes(regionTag int32) []byte {
+	var tags []int32
+	for tag := range h.entries {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i] < tags[j]
+	})
+
+	numIndexEntries := int32(len(tags) + 1)
+	dataStore := &bytes.Buffer{}
+	offsets := make([]int32, len(tags))
+	for i, tag := range tags {
+		entry := h.entries[tag]
+		if padding := dataStore.Len() % entry.alignment(); padding != 0 {
+			dataStore.Write(make([]byte, entry.alignment()-padding))
+		}
+		offsets[i] = int32(dataStore.Len())
+		dataStore.Write(entry.data)
+	}
+	regionOffset := int32(dataStore.Len())
+	_ = binary.Write(dataStore, binary.BigEndian, []int32{regionTag, typeBin, -16 * numIndexEntries, 16})
+
+	out := &bytes.Buffer{}
+	out.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0})
+	_ = binary.Write(out, binary.BigEndian, []int32{numIndexEntries, int32(dataStore.Len())})
+	_ = binary.Write(out, binary.BigEndian, []int32{regionTag, typeBin, regionOffset, 16})
+	for i, tag := range tags {
+		entry := h.entries[tag]
+		_ = binary.Write(out, binary.BigEndian, []int32{tag, entry.dataType, offsets[i], entry.count})
+	}
+	out.Write(dataStore.Bytes())
+	return out.Bytes()
+}
@@ -0,0 +1,466 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
+)
+
+// File type bits of the modes stored in the header and payload.
+const (
+	modeDir     = 0040000
+	modeRegular = 0100000
+	modeSymlink = 0120000
+)
+
+// Flags used to mark files in the header.
+const (
+	fileFlagConfig    int32 = 1 << 0
+	fileFlagNoReplace int32 = 1 << 4
+)
+
+// packageEntry is a single file, directory or symlink in the payload of a package.
+type packageEntry struct {
+	// name is the path of the entry relative to the root of the file system (with no leading slash).
+	name     string
+	mode     os.FileMode
+	srcPath  string
+	linkname string
+	size     int64
+}
+
+func (e packageEntry) rpmMode() int64 {
+	switch {
+	case e.mode.IsDir():
+		return modeDir | int64(e.mode.Perm())
+	case e.linkname != "":
+		return modeSymlink | 0777
+	default:
+		return modeRegular | int64(e.mode.Perm())
+	}
+}
+
+type packageContents struct {
+	entries map[string]packageEntry
+}
+
+func newPackageContents() *packageContents {
+	return &packageContents{
+		entries: make(map[string]packageEntry),
+	}
+}
+
+// addPath adds the file or directory at srcPath to the contents at dstPath. If srcPath is a directory, all of its
+// contents are added recursively. Symlinks are added as symlinks.
+func (c *packageContents) addPath(srcPath, dstPath string) error {
+	return filepath.Walk(srcPath, func(currPath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(srcPath, currPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine relative path")
+		}
+		entry := packageEntry{
+			name:    disterutil.CleanEntryName(path.Join(dstPath, filepath.ToSlash(relPath))),
+			mode:    fi.Mode(),
+			srcPath: currPath,
+		}
+		switch {
+		case fi.Mode()&os.ModeSymlink != 0:
+			linkname, err := os.Readlink(currPath)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read symlink %s", currPath)
+			}
+			entry.linkname = linkname
+			entry.size = int64(len(linkname))
+		case fi.Mode().IsRegular():
+			entry.size = fi.Size()
+		case !fi.IsDir():
+			return errors.Errorf("%s is not a regular file, directory or symlink", currPath)
+		}
+		c.add(entry)
+		return nil
+	})
+}
+
+// addExecutable adds the regular file at srcPath to the contents at dstPath with mode 0755.
+func (c *packageContents) addExecutable(srcPath, dstPath string) error {
+	fi, err := os.Stat(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", srcPath)
+	}
+	c.add(packageEntry{
+		name:    disterutil.CleanEntryName(dstPath),
+		mode:    0755,
+		srcPath: srcPath,
+		size:    fi.Size(),
+	})
+	return nil
+}
+
+func (c *packageContents) add(entry packageEntry) {
+	if entry.name == "" {
+		return
+	}
+	c.entries[entry.name] = entry
+}
+
+// payloadEntries returns the entries that are included in the payload of the package in sorted order. Directories are
+// only included if they are empty: directories that contain other entries are not owned by the package so that they do
+// not conflict with the directories owned by other packages (for example, "/usr/bin").
+func (c *packageContents) payloadEntries() []packageEntry {
+	nonEmptyDirs := make(map[string]struct{})
+	for name := range c.entries {
+		nonEmptyDirs[path.Dir(name)] = struct{}{}
+	}
+	var names []string
+	for name, entry := range c.entries {
+		if _, ok := nonEmptyDirs[name]; ok && entry.mode.IsDir() {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var entries []packageEntry
+	for _, name := range names {
+		entries = append(entries, c.entries[name])
+	}
+	return entries
+}
+
+// writePackage writes a binary RPM package to dstPath. The package consists of the lead, the signature header, the main
+// header and a gzip-compressed "cpio" payload.
+func writePackage(dstPath string, info packageInfo, contents *packageContents) error {
+	entries := contents.payloadEntries()
+	payload, payloadSize, digests, err := payloadArchive(entries, info.buildTime)
+	if err != nil {
+		return err
+	}
+
+	header := mainHeader(info, entries, digests).bytes(tagHeaderImmutable)
+
+	headerSHA1 := sha1.Sum(header)
+	headerSHA256 := sha256.Sum256(header)
+	headerAndPayloadMD5 := md5.New()
+	headerAndPayloadMD5.Write(header)
+	headerAndPayloadMD5.Write(payload)
+
+	sigHeader := newRPMHeader()
+	sigHeader.addString(sigTagSHA1, hex.EncodeToString(headerSHA1[:]))
+	sigHeader.addString(sigTagSHA256, hex.EncodeToString(headerSHA256[:]))
+	sigHeader.addInt32(sigTagSize, int32(len(header)+len(payload)))
+	sigHeader.addBin(sigTagMD5, headerAndPayloadMD5.Sum(nil))
+	sigHeader.addInt32(sigTagPayloadSize, int32(payloadSize))
+	sigHeaderBytes := sigHeader.bytes(sigTagHeaderSignatures)
+
+	buf := &bytes.Buffer{}
+	buf.Write(lead(fmt.Sprintf("%s-%s-%s", info.name, info.version, info.release)))
+	buf.Write(sigHeaderBytes)
+	// the signature header is padded to a multiple of 8 bytes
+	if padding := len(sigHeaderBytes) % 8; padding != 0 {
+		buf.Write(make([]byte, 8-padding))
+	}
+	buf.Write(header)
+	buf.Write(payload)
+	if err := ioutil.WriteFile(dstPath, buf.Bytes(), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", dstPath)
+	}
+	return nil
+}
+
+// lead returns the legacy 96-byte lead that starts every RPM file.
+func lead(name string) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write([]byte{0xed, 0xab, 0xee, 0xdb, 3, 0})
+	// type (binary) and architecture number
+	_ = binary.Write(buf, binary.BigEndian, []int16{0, 1})
+	nameBytes := make([]byte, 66)
+	copy(nameBytes[:65], name)
+	buf.Write(nameBytes)
+	// OS number (linux) and signature type (header-style signature)
+	_ = binary.Write(buf, binary.BigEndian, []int16{1, 5})
+	buf.Write(make([]byte, 16))
+	return buf.Bytes()
+}
+
+func mainHeader(info packageInfo, entries []packageEntry, digests []string) *rpmHeader {
+	h := newRPMHeader()
+	h.addStringArray(tagHeaderI18NTable, "C")
+	h.addString(tagName, info.name)
+	h.addString(tagVersion, info.version)
+	h.addString(tagRelease, info.release)
+	h.addI18NString(tagSummary, info.metadata.Summary)
+	h.addI18NString(tagDescription, info.metadata.Description)
+	h.addInt32(tagBuildTime, int32(info.buildTime.Unix()))
+	h.addString(tagBuildHost, info.buildHost)
+	h.addString(tagLicense, info.metadata.License)
+	h.addI18NString(tagGroup, info.metadata.Group)
+	for _, tag := range []struct {
+		tag int32
+		val string
+	}{
+		{tagVendor, info.metadata.Vendor},
+		{tagPackager, info.metadata.Packager},
+		{tagURL, info.metadata.URL},
+	} {
+		if tag.val != "" {
+			h.addString(tag.tag, tag.val)
+		}
+	}
+	h.addString(tagOS, "linux")
+	h.addString(tagArch, info.arch)
+	// binary packages are identified by the presence of the name of the source package from which they were built
+	h.addString(tagSourceRPM, fmt.Sprintf("%s-%s-%s.src.rpm", info.name, info.version, info.release))
+
+	requires := []dependency{
+		{name: "rpmlib(CompressedFileNames)", flags: senseRPMLib | senseLess | senseEqual, version: "3.0.4-1"},
+		{name: "rpmlib(FileDigests)", flags: senseRPMLib | senseLess | senseEqual, version: "4.6.0-1"},
+		{name: "rpmlib(PayloadFilesHavePrefix)", flags: senseRPMLib | senseLess | senseEqual, version: "4.0-1"},
+	}
+	if strings.Contains(info.version, "~") {
+		requires = append(requires, dependency{name: "rpmlib(TildeInVersions)", flags: senseRPMLib | senseLess | senseEqual, version: "4.10.0-1"})
+	}
+	for _, script := range []struct {
+		tag     int32
+		progTag int32
+		content string
+	}{
+		{tagPreIn, tagPreInProg, info.scripts.Pre},
+		{tagPostIn, tagPostInProg, info.scripts.Post},
+		{tagPreUn, tagPreUnProg, info.scripts.PreUn},
+		{tagPostUn, tagPostUnProg, info.scripts.PostUn},
+	} {
+		if script.content == "" {
+			continue
+		}
+		h.addString(script.tag, script.content)
+		h.addString(script.progTag, "/bin/sh")
+	}
+	if info.scripts != (Scripts{}) {
+		requires = append(requires, dependency{name: "/bin/sh"})
+	}
+	requires = append(requires, info.requires...)
+	addDependencies(h, tagRequireName, tagRequireFlags, tagRequireVersion, requires)
+
+	provides := append([]dependency{
+		{name: info.name, flags: senseEqual, version: info.version + "-" + info.release},
+	}, info.provides...)
+	addDependencies(h, tagProvideName, tagProvideFlags, tagProvideVersion, provides)
+	addDependencies(h, tagConflictName, tagConflictFlags, tagConflictVersion, info.conflicts)
+	addDependencies(h, tagObsoleteName, tagObsoleteFlags, tagObsoleteVersion, info.obsoletes)
+
+	var (
+		totalSize  int64
+		sizes      []int32
+		modes      []int16
+		rdevs      []int16
+		mtimes     []int32
+		linkTos    []string
+		flags      []int32
+		userNames  []string
+		groupNames []string
+		devices    []int32
+		inodes     []int32
+		langs      []string
+		dirIndexes []int32
+		baseNames  []string
+		dirNames   []string
+	)
+	dirIndexMap := make(map[string]int32)
+	for i, entry := range entries {
+		dir := "/" + path.Dir(entry.name) + "/"
+		if dir == "/./" {
+			dir = "/"
+		}
+		dirIndex, ok := dirIndexMap[dir]
+		if !ok {
+			dirIndex = int32(len(dirNames))
+			dirIndexMap[dir] = dirIndex
+			dirNames = append(dirNames, dir)
+		}
+		var fileFlags int32
+		if entry.mode.IsRegular() && entry.linkname == "" && strings.HasPrefix(entry.name, "etc/") {
+			fileFlags = fileFlagConfig | fileFlagNoReplace
+		}
+		size := entry.size
+		if entry.mode.IsDir() {
+			size = 4096
+		}
+		totalSize += size
+
+		sizes = append(sizes, int32(size))
+		modes = append(modes, int16(uint16(entry.rpmMode())))
+		rdevs = append(rdevs, 0)
+		mtimes = append(mtimes, int32(info.buildTime.Unix()))
+		linkTos = append(linkTos, entry.linkname)
+		flags = append(flags, fileFlags)
+		userNames = append(userNames, info.fileOwner)
+		groupNames = append(groupNames, info.fileGroup)
+		devices = append(devices, 1)
+		inodes = append(inodes, int32(i+1))
+		langs = append(langs, "")
+		dirIndexes = append(dirIndexes, dirIndex)
+		baseNames = append(baseNames, path.Base(entry.name))
+	}
+	h.addInt32(tagSize, int32(totalSize))
+	h.addInt32(tagFileSizes, sizes...)
+	h.addInt16(tagFileModes, modes...)
+	h.addInt16(tagFileRDevs, rdevs...)
+	h.addInt32(tagFileMTimes, mtimes...)
+	h.addStringArray(tagFileDigests, digests...)
+	h.addStringArray(tagFileLinkTos, linkTos...)
+	h.addInt32(tagFileFlags, flags...)
+	h.addStringArray(tagFileUserName, userNames...)
+	h.addStringArray(tagFileGroupName, groupNames...)
+	h.addInt32(tagFileDevices, devices...)
+	h.addInt32(tagFileINodes, inodes...)
+	h.addStringArray(tagFileLangs, langs...)
+	h.addInt32(tagDirIndexes, dirIndexes...)
+	h.addStringArray(tagBaseNames, baseNames...)
+	h.addStringArray(tagDirNames, dirNames...)
+	// SHA-256 (as defined by PGPHASHALGO_SHA256)
+	h.addInt32(tagFileDigestAlgo, 8)
+
+	h.addString(tagPayloadFormat, "cpio")
+	h.addString(tagPayloadCompressor, "gzip")
+	h.addString(tagPayloadFlags, "9")
+	return h
+}
+
+func addDependencies(h *rpmHeader, nameTag, flagsTag, versionTag int32, deps []dependency) {
+	if len(deps) == 0 {
+		return
+	}
+	var (
+		names    []string
+		flags    []int32
+		versions []string
+	)
+	for _, dep := range deps {
+		names = append(names, dep.name)
+		flags = append(flags, dep.flags)
+		versions = append(versions, dep.version)
+	}
+	h.addStringArray(nameTag, names...)
+	h.addInt32(flagsTag, flags...)
+	h.addStringArray(versionTag, versions...)
+}
+
+// payloadArchive returns the gzip-compressed "newc" cpio archive for the provided entries along with the size of the
+// uncompressed archive and the hex-encoded SHA-256 digests of the entries (empty for entries that are not regular
+// files).
+func payloadArchive(entries []packageEntry, modTime time.Time) ([]byte, int64, []string, error) {
+	buf := &bytes.Buffer{}
+	gw, err := gzip.NewWriterLevel(buf, gzip.BestCompression)
+	if err != nil {
+		return nil, 0, nil, errors.Wrapf(err, "failed to create gzip writer")
+	}
+	cw := &countingWriter{w: gw}
+
+	var digests []string
+	for i, entry := range entries {
+		nlink := int64(1)
+		if entry.mode.IsDir() {
+			nlink = 2
+		}
+		size := entry.size
+		if entry.mode.IsDir() {
+			size = 0
+		}
+		if err := writeCPIOHeader(cw, "./"+entry.name, int64(i+1), entry.rpmMode(), nlink, modTime.Unix(), size); err != nil {
+			return nil, 0, nil, err
+		}
+		digest := ""
+		switch {
+		case entry.mode.IsDir():
+		case entry.linkname != "":
+			if _, err := io.WriteString(cw, entry.linkname); err != nil {
+				return nil, 0, nil, errors.Wrapf(err, "failed to write symlink %s", entry.name)
+			}
+		default:
+			hash := sha256.New()
+			if err := disterutil.CopyFile(io.MultiWriter(cw, hash), entry.srcPath); err != nil {
+				return nil, 0, nil, err
+			}
+			digest = hex.EncodeToString(hash.Sum(nil))
+		}
+		if err := writeCPIOPadding(cw); err != nil {
+			return nil, 0, nil, err
+		}
+		digests = append(digests, digest)
+	}
+	if err := writeCPIOHeader(cw, "TRAILER!!!", 0, 0, 1, 0, 0); err != nil {
+		return nil, 0, nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, 0, nil, errors.Wrapf(err, "failed to close gzip writer")
+	}
+	return buf.Bytes(), cw.n, digests, nil
+}
+
+// writeCPIOHeader writes the "newc" cpio header and name for an entry.
+func writeCPIOHeader(w *countingWriter, name string, inode, mode, nlink, mtime, size int64) error {
+	if _, err := fmt.Fprintf(w, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%s\x00",
+		inode, mode, 0, 0, nlink, mtime, size, 0, 0, 0, 0, len(name)+1, 0, name); err != nil {
+		return errors.Wrapf(err, "failed to write cpio header for %s", name)
+	}
+	return writeCPIOPadding(w)
+}
+
+// writeCPIOPadding pads the archive to a multiple of 4 bytes.
+func writeCPIOPadding(w *countingWriter) error {
+	if padding := w.n % 4; padding != 0 {
+		if _, err := w.Write(make([]byte, 4-padding)); err != nil {
+			return errors.Wrapf(err, "failed to write cpio padding")
+		}
+	}
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,270 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePackage(t *testing.T) {
+	tmpDir, cleanup, err := dirs.TempDir("", "")
+	require.NoError(t, err)
+	defer cleanup()
+
+	srcDir := path.Join(tmpDir, "src")
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "etc", "foo"), 0755))
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "var", "lib", "foo"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "etc", "foo", "foo.yml"), []byte("key: value\n"), 0644))
+	require.NoError(t, os.Symlink("foo.yml", path.Join(srcDir, "etc", "foo", "link.yml")))
+	exe := path.Join(tmpDir, "foo")
+	require.NoError(t, ioutil.WriteFile(exe, []byte("#!/bin/sh\necho foo\n"), 0644))
+
+	contents := newPackageContents()
+	require.NoError(t, contents.addPath(path.Join(srcDir, "etc"), "etc"))
+	require.NoError(t, contents.addPath(path.Join(srcDir, "var"), "var"))
+	require.NoError(t, contents.addExecutable(exe, "/usr/bin/foo"))
+
+	requires, err := parseDependency("glibc >= 2.17")
+	require.NoError(t, err)
+	info := packageInfo{
+		name:      "foo",
+		version:   rpmVersion("v1.0.0-rc1"),
+		release:   "1",
+		arch:      "x86_64",
+		fileOwner: "root",
+		fileGroup: "root",
+		metadata: Metadata{
+			Summary:     "foo",
+			Description: "foo description",
+			License:     "Apache-2.0",
+			Group:       "Unspecified",
+		},
+		requires: []dependency{requires},
+		scripts: Scripts{
+			Post: "echo post",
+		},
+		buildTime: time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+		buildHost: "localhost",
+	}
+	pkgPath := path.Join(tmpDir, "foo.rpm")
+	require.NoError(t, writePackage(pkgPath, info, contents))
+
+	content, err := ioutil.ReadFile(pkgPath)
+	require.NoError(t, err)
+
+	// packages with the same content and build time are identical
+	require.NoError(t, writePackage(pkgPath, info, contents))
+	rewrittenContent, err := ioutil.ReadFile(pkgPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, rewrittenContent)
+
+	// lead
+	require.True(t, len(content) > 96)
+	assert.Equal(t, []byte{0xed, 0xab, 0xee, 0xdb, 3, 0}, content[:6])
+	assert.Equal(t, int16(0), int16(binary.BigEndian.Uint16(content[6:8])), "lead type must be binary")
+	assert.Equal(t, "foo-1.0.0~rc1-1", string(bytes.TrimRight(content[10:76], "\x00")))
+	assert.Equal(t, int16(5), int16(binary.BigEndian.Uint16(content[78:80])), "signature type must be header-style")
+
+	// signature header
+	sigHeader, rest := parseHeader(t, content[96:])
+	padding := (8 - (len(content)-96-len(rest))%8) % 8
+	rest = rest[padding:]
+	assert.Equal(t, []int32{int32(len(rest))}, sigHeader.int32s(sigTagSize))
+
+	// main header
+	header, payload := parseHeader(t, rest)
+	headerBytes := rest[:len(rest)-len(payload)]
+	headerSHA256 := sha256.Sum256(headerBytes)
+	assert.Equal(t, []string{hex.EncodeToString(headerSHA256[:])}, sigHeader.strings(sigTagSHA256))
+
+	assert.Equal(t, []string{"foo"}, header.strings(tagName))
+	assert.Equal(t, []string{"1.0.0~rc1"}, header.strings(tagVersion))
+	assert.Equal(t, []string{"1"}, header.strings(tagRelease))
+	assert.Equal(t, []string{"x86_64"}, header.strings(tagArch))
+	assert.Equal(t, []string{"linux"}, header.strings(tagOS))
+	assert.Equal(t, []string{"foo-1.0.0~rc1-1.src.rpm"}, header.strings(tagSourceRPM))
+	assert.Equal(t, []int32{int32(info.buildTime.Unix())}, header.int32s(tagBuildTime))
+	assert.Equal(t, []string{"localhost"}, header.strings(tagBuildHost))
+	assert.Equal(t, []int32{int32(info.buildTime.Unix()), int32(info.buildTime.Unix()), int32(info.buildTime.Unix()), int32(info.buildTime.Unix())}, header.int32s(tagFileMTimes))
+	assert.Equal(t, []string{
+		"rpmlib(CompressedFileNames)",
+		"rpmlib(FileDigests)",
+		"rpmlib(PayloadFilesHavePrefix)",
+		"rpmlib(TildeInVersions)",
+		"/bin/sh",
+		"glibc",
+	}, header.strings(tagRequireName))
+	assert.Equal(t, []int32{
+		senseRPMLib | senseLess | senseEqual,
+		senseRPMLib | senseLess | senseEqual,
+		senseRPMLib | senseLess | senseEqual,
+		senseRPMLib | senseLess | senseEqual,
+		0,
+		senseGreater | senseEqual,
+	}, header.int32s(tagRequireFlags))
+	assert.Equal(t, []string{"3.0.4-1", "4.6.0-1", "4.0-1", "4.10.0-1", "", "2.17"}, header.strings(tagRequireVersion))
+	assert.Equal(t, []string{"echo post"}, header.strings(tagPostIn))
+	assert.Equal(t, []string{"/bin/sh"}, header.strings(tagPostInProg))
+	assert.Nil(t, header.strings(tagPreIn))
+	assert.Equal(t, []string{"/etc/foo/", "/usr/bin/", "/var/lib/"}, header.strings(tagDirNames))
+	assert.Equal(t, []string{"foo.yml", "link.yml", "foo", "foo"}, header.strings(tagBaseNames))
+	assert.Equal(t, []int32{0, 0, 1, 2}, header.int32s(tagDirIndexes))
+	assert.Equal(t, []string{"cpio"}, header.strings(tagPayloadFormat))
+
+	// payload
+	gr, err := gzip.NewReader(bytes.NewReader(payload))
+	require.NoError(t, err)
+	cpioBytes, err := ioutil.ReadAll(gr)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{int32(len(cpioBytes))}, sigHeader.int32s(sigTagPayloadSize))
+	entries := parseCPIO(t, cpioBytes)
+	var names []string
+	for _, entry := range entries[:len(entries)-1] {
+		names = append(names, entry.name)
+		assert.Equal(t, info.buildTime.Unix(), entry.mtime, "unexpected modification time for %s", entry.name)
+	}
+	assert.Equal(t, []string{
+		"./etc/foo/foo.yml",
+		"./etc/foo/link.yml",
+		"./usr/bin/foo",
+		"./var/lib/foo",
+	}, names)
+	assert.Equal(t, "TRAILER!!!", entries[len(entries)-1].name)
+	assert.Equal(t, "key: value\n", string(entries[0].content))
+	assert.Equal(t, int64(0100644), entries[0].mode)
+	assert.Equal(t, "foo.yml", string(entries[1].content))
+	assert.Equal(t, int64(0120777), entries[1].mode)
+	assert.Equal(t, "#!/bin/sh\necho foo\n", string(entries[2].content))
+	assert.Equal(t, int64(0100755), entries[2].mode)
+	assert.Equal(t, int64(040755), entries[3].mode)
+
+	fileDigest := sha256.Sum256([]byte("key: value\n"))
+	assert.Equal(t, hex.EncodeToString(fileDigest[:]), header.strings(tagFileDigests)[0])
+}
+
+// parsedHeader is a header structure that was read from an RPM.
+type parsedHeader struct {
+	index map[int32][3]int32
+	store []byte
+}
+
+// parseHeader parses the header structure at the start of the provided bytes and returns it along with the bytes that
+// follow it.
+func parseHeader(t *testing.T, b []byte) (parsedHeader, []byte) {
+	require.True(t, len(b) >= 16)
+	require.Equal(t, []byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0}, b[:8])
+	numIndexEntries := int(binary.BigEndian.Uint32(b[8:12]))
+	storeSize := int(binary.BigEndian.Uint32(b[12:16]))
+	storeStart := 16 + 16*numIndexEntries
+	require.True(t, len(b) >= storeStart+storeSize)
+
+	h := parsedHeader{
+		index: make(map[int32][3]int32),
+		store: b[storeStart : storeStart+storeSize],
+	}
+	for i := 0; i < numIndexEntries; i++ {
+		var entry [4]int32
+		require.NoError(t, binary.Read(bytes.NewReader(b[16+16*i:32+16*i]), binary.BigEndian, &entry))
+		h.index[entry[0]] = [3]int32{entry[1], entry[2], entry[3]}
+	}
+	return h, b[storeStart+storeSize:]
+}
+
+// strings returns the values of a string, string array or I18N string entry. Returns nil if the entry does not exist.
+func (h parsedHeader) strings(tag int32) []string {
+	entry, ok := h.index[tag]
+	if !ok {
+		return nil
+	}
+	var vals []string
+	data := h.store[entry[1]:]
+	for i := int32(0); i < entry[2]; i++ {
+		end := bytes.IndexByte(data, 0)
+		vals = append(vals, string(data[:end]))
+		data = data[end+1:]
+	}
+	return vals
+}
+
+// int32s returns the values of an int32 entry. Returns nil if the entry does not exist.
+func (h parsedHeader) int32s(tag int32) []int32 {
+	entry, ok := h.index[tag]
+	if !ok {
+		return nil
+	}
+	vals := make([]int32, entry[2])
+	_ = binary.Read(bytes.NewReader(h.store[entry[1]:]), binary.BigEndian, vals)
+	return vals
+}
+
+type cpioEntry struct {
+	name    string
+	mode    int64
+	mtime   int64
+	content []byte
+}
+
+// parseCPIO parses the entries of a "newc" cpio archive.
+func parseCPIO(t *testing.T, b []byte) []cpioEntry {
+	var entries []cpioEntry
+	offset := 0
+	align := func() {
+		offset += (4 - offset%4) % 4
+	}
+	for offset < len(b) {
+		require.Equal(t, "070701", string(b[offset:offset+6]))
+		field := func(i int) int64 {
+			val, err := strconv.ParseInt(string(b[offset+6+8*i:offset+14+8*i]), 16, 64)
+			require.NoError(t, err)
+			return val
+		}
+		mode, mtime, size, nameSize := field(1), field(5), field(6), field(11)
+		offset += 110
+		name := string(b[offset : offset+int(nameSize)-1])
+		offset += int(nameSize)
+		align()
+		entries = append(entries, cpioEntry{
+			name:    name,
+			mode:    mode,
+			mtime:   mtime,
+			content: b[offset : offset+int(size)],
+		})
+		offset += int(size)
+		align()
+		if name == "TRAILER!!!" {
+			break
+		}
+	}
+	return entries
+}
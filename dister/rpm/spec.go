@@ -0,0 +1,113 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpm
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Flags used to specify the comparison of a dependency.
+const (
+	senseLess    int32 = 1 << 1
+	senseGreater int32 = 1 << 2
+	senseEqual   int32 = 1 << 3
+	senseRPMLib  int32 = 1 << 24
+)
+
+// packageInfo contains the properties of a single RPM package.
+type packageInfo struct {
+	name      string
+	version   string
+	release   string
+	arch      string
+	fileOwner string
+	fileGroup string
+	metadata  Metadata
+	requires  []dependency
+	provides  []dependency
+	conflicts []dependency
+	obsoletes []dependency
+	scripts   Scripts
+	// buildTime is the time recorded as the build time of the package and as the modification time of its files.
+	buildTime time.Time
+	// buildHost is the host recorded as the build host of the package.
+	buildHost string
+}
+
+// dependency is a capability with an optional version constraint.
+type dependency struct {
+	name    string
+	flags   int32
+	version string
+}
+
+var dependencyOperators = map[string]int32{
+	"<":  senseLess,
+	"<=": senseLess | senseEqual,
+	"=":  senseEqual,
+	"==": senseEqual,
+	">=": senseGreater | senseEqual,
+	">":  senseGreater,
+}
+
+// parseDependency parses a dependency of the form "name" or "name <op> version", where <op> is one of "<", "<=", "=",
+// ">=" or ">".
+func parseDependency(spec string) (dependency, error) {
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 1:
+		return dependency{name: fields[0]}, nil
+	case 3:
+		flags, ok := dependencyOperators[fields[1]]
+		if !ok {
+			return dependency{}, errors.Errorf("invalid operator %q in dependency %q", fields[1], spec)
+		}
+		return dependency{name: fields[0], flags: flags, version: fields[2]}, nil
+	default:
+		return dependency{}, errors.Errorf("invalid dependency %q: must be of the form \"name\" or \"name <op> version\"", spec)
+	}
+}
+
+var (
+	rpmVersionInvalidChars = regexp.MustCompile(`[^A-Za-z0-9._+~]`)
+	rpmVersionPreRelease   = regexp.MustCompile(`^([0-9][0-9A-Za-z.]*)-([A-Za-z].*)$`)
+)
+
+// rpmVersion converts the provided project version into a valid RPM version. A leading "v" is removed, a pre-release
+// suffix such as "-rc1" is converted to "~rc1" so that it sorts before the release, and all other hyphens and invalid
+// characters are replaced because RPM versions cannot contain hyphens.
+func rpmVersion(version string) string {
+	if len(version) > 1 && version[0] == 'v' && version[1] >= '0' && version[1] <= '9' {
+		version = version[1:]
+	}
+	if matches := rpmVersionPreRelease.FindStringSubmatch(version); matches != nil {
+		version = matches[1] + "~" + matches[2]
+	}
+	version = strings.Replace(version, "-", "+", -1)
+	version = rpmVersionInvalidChars.ReplaceAllString(version, ".")
+	if version == "" {
+		version = "0"
+	}
+	return version
+}
@@ -0,0 +1,233 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shinstaller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+	"github.com/termie/go-shutil"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distarchive"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterutil"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+const TypeName = "sh-installer" // distribution that consists of a self-extracting installer shell script for a specific OS/Architecture
+
+type Dister struct {
+	// OSArchs are the OS/Architectures for which installers are created. The OS of every entry must be "linux" or
+	// "darwin".
+	OSArchs []osarch.OSArch
+	// Prefix is the default installation prefix of the installers. Executables are installed in "{{Prefix}}/bin".
+	Prefix string
+}
+
+func (d *Dister) TypeName() (string, error) {
+	return TypeName, nil
+}
+
+func (d *Dister) Artifacts(renderedName string) ([]string, error) {
+	var outPaths []string
+	for _, osArch := range d.OSArchs {
+		outPaths = append(outPaths, fmt.Sprintf("%s-%s.sh", renderedName, osArch.String()))
+	}
+	return outPaths, nil
+}
+
+func (d *Dister) PackagingExtension() (string, error) {
+	return "sh", nil
+}
+
+func (d *Dister) osArchFromArtifactPath(distID distgo.DistID, artifactPath string, productTaskOutputInfo distgo.ProductTaskOutputInfo) (osarch.OSArch, error) {
+	for _, osArch := range d.OSArchs {
+		if strings.HasSuffix(artifactPath, fmt.Sprintf("%s-%s.sh", productTaskOutputInfo.Product.DistOutputInfos.DistInfos[distID].DistNameTemplateRendered, osArch.String())) {
+			return osArch, nil
+		}
+	}
+	return osarch.OSArch{}, errors.Errorf("failed to determine OS/Arch for artifact with Path %s", artifactPath)
+}
+
+func (d *Dister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
+	for _, osArch := range d.OSArchs {
+		if osArch.OS != "linux" && osArch.OS != "darwin" {
+			return nil, errors.Errorf("shell installers can only be created for linux and darwin, but OS/Arch %s was specified", osArch)
+		}
+		if err := disterutil.VerifyDistTargetSupported(osArch, productTaskOutputInfo); err != nil {
+			return nil, err
+		}
+	}
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	outputPathsForOSArchs := make(map[string][]string)
+	for _, osArch := range d.OSArchs {
+		for _, currProductOutputInfo := range productTaskOutputInfo.AllProductOutputInfos() {
+			// copy executable for current product
+			dst, err := disterutil.CopyArtifactForOSArch(path.Join(distWorkDir, osArch.String(), "bin"), productTaskOutputInfo.Project, currProductOutputInfo, osArch)
+			if err != nil {
+				return nil, err
+			}
+			outputPathsForOSArchs[osArch.String()] = append(outputPathsForOSArchs[osArch.String()], dst)
+		}
+	}
+	jsonBytes, err := json.Marshal(outputPathsForOSArchs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal outputPathsForOSArchs as JSON")
+	}
+	return jsonBytes, nil
+}
+
+func (d *Dister) GenerateDistArtifacts(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo, runDistResult []byte) error {
+	// all of the content of the dist work directory other than the per-OS/Arch directories is installed relative to the
+	// installation prefix
+	distWorkDir := productTaskOutputInfo.ProductDistWorkDirs()[distID]
+	rootFiles, err := disterutil.RootFiles(distWorkDir, d.OSArchs, ".tgz")
+	if err != nil {
+		return err
+	}
+	modTime, err := distarchive.ReproducibleModTime(productTaskOutputInfo.Project.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	for _, artifactPath := range productTaskOutputInfo.ProductDisterArtifactPaths()[distID] {
+		currOSArch, err := d.osArchFromArtifactPath(distID, artifactPath, productTaskOutputInfo)
+		if err != nil {
+			return err
+		}
+
+		// the per-OS/Arch directory contains the executables in "bin": copy the root files into it so that it contains
+		// the full content that is installed
+		osArchDir := path.Join(distWorkDir, currOSArch.String())
+		for _, rootFile := range rootFiles {
+			if err := copyPath(path.Join(distWorkDir, rootFile), path.Join(osArchDir, rootFile)); err != nil {
+				return err
+			}
+		}
+		installedFiles, installedDirs, err := installedPaths(osArchDir)
+		if err != nil {
+			return err
+		}
+
+		fis, err := ioutil.ReadDir(osArchDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list files in %s", osArchDir)
+		}
+		var payloadSrcPaths []string
+		for _, fi := range fis {
+			payloadSrcPaths = append(payloadSrcPaths, path.Join(osArchDir, fi.Name()))
+		}
+		payloadPath := osArchDir + ".tgz"
+		if err := distarchive.FormatTGZ.MakeReproducible(payloadPath, payloadSrcPaths, modTime); err != nil {
+			return errors.Wrapf(err, "failed to create installer payload")
+		}
+		payload, err := ioutil.ReadFile(payloadPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read installer payload")
+		}
+
+		header, err := installerHeader(installerParams{
+			product:        productTaskOutputInfo.Product.ID,
+			version:        productTaskOutputInfo.Project.Version,
+			osArch:         currOSArch,
+			prefix:         d.prefix(),
+			installedFiles: installedFiles,
+			installedDirs:  installedDirs,
+		}, payload)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(artifactPath, append([]byte(header), payload...), 0755); err != nil {
+			return errors.Wrapf(err, "failed to write %s", artifactPath)
+		}
+	}
+	return nil
+}
+
+func (d *Dister) prefix() string {
+	if d.Prefix == "" {
+		return "/usr/local"
+	}
+	return d.Prefix
+}
+
+// installedPaths returns the paths of the files (including symlinks) and directories in the provided directory relative
+// to the directory. The returned directories are the ones that are removed on uninstall if they are empty: they do
+// not include top-level directories (for example, "bin") because those are typically shared with other software.
+// Directories are returned in reverse order so that child directories appear before their parents.
+func installedPaths(dir string) ([]string, []string, error) {
+	var files, dirs []string
+	if err := filepath.Walk(dir, func(currPath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, currPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine relative path")
+		}
+		relPath = filepath.ToSlash(relPath)
+		if strings.ContainsAny(relPath, "\n\r") {
+			return errors.Errorf("the name of %s contains a newline", currPath)
+		}
+		switch {
+		case relPath == ".":
+		case fi.IsDir():
+			if strings.Contains(relPath, "/") {
+				dirs = append(dirs, relPath)
+			}
+		default:
+			files = append(files, relPath)
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to walk %s", dir)
+	}
+	sort.Strings(files)
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	return files, dirs, nil
+}
+
+// copyPath copies the file or directory at src to dst. Symlinks are copied as symlinks.
+func copyPath(src, dst string) error {
+	fi, err := os.Lstat(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", src)
+	}
+	if fi.IsDir() {
+		if err := shutil.CopyTree(src, dst, &shutil.CopyTreeOptions{
+			Symlinks:     true,
+			CopyFunction: shutil.Copy,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to copy directory %s to %s", src, dst)
+		}
+		return nil
+	}
+	if _, err := shutil.Copy(src, dst, false); err != nil {
+		return errors.Wrapf(err, "failed to copy %s to %s", src, dst)
+	}
+	return nil
+}
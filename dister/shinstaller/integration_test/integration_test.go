@@ -0,0 +1,221 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration_test
+
+import (
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/gofiles"
+	"github.com/palantir/godel/framework/pluginapitester"
+	"github.com/palantir/godel/pkg/products/v2/products"
+	"github.com/palantir/pkg/specdir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/distertester"
+)
+
+func TestSHInstallerDist(t *testing.T) {
+	const godelYML = `exclude:
+  names:
+    - "\\..+"
+    - "vendor"
+  paths:
+    - "godel"
+`
+
+	pluginPath, err := products.Bin("dist-plugin")
+	require.NoError(t, err)
+
+	distertester.RunAssetDistTest(t,
+		pluginapitester.NewPluginProvider(pluginPath),
+		nil,
+		[]distertester.TestCase{
+			{
+				Name: "sh-installer creates expected output",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+					{
+						RelPath: "installer/etc/foo/foo.yml",
+						Src:     `key: value`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: darwin
+          arch: amd64
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: sh-installer
+        input-dir: installer
+        config:
+          os-archs:
+            - os: darwin
+              arch: amd64
+            - os: linux
+              arch: amd64
+          prefix: /opt/foo
+`,
+				},
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/sh-installer/foo-1.0.0-darwin-amd64.sh, out/dist/foo/1.0.0/sh-installer/foo-1.0.0-linux-amd64.sh
+Finished creating sh-installer distribution for foo
+`
+				},
+				Validate: func(projectDir string) {
+					wantLayout := specdir.NewLayoutSpec(
+						specdir.Dir(specdir.LiteralName("1.0.0"), "",
+							specdir.Dir(specdir.LiteralName("sh-installer"), "",
+								specdir.Dir(specdir.LiteralName("foo-1.0.0"), "",
+									specdir.Dir(specdir.LiteralName("etc"), "",
+										specdir.Dir(specdir.LiteralName("foo"), "",
+											specdir.File(specdir.LiteralName("foo.yml"), ""),
+										),
+									),
+									specdir.Dir(specdir.LiteralName("darwin-amd64"), "",
+										specdir.Dir(specdir.LiteralName("bin"), "",
+											specdir.File(specdir.LiteralName("foo"), ""),
+										),
+										specdir.Dir(specdir.LiteralName("etc"), "",
+											specdir.Dir(specdir.LiteralName("foo"), "",
+												specdir.File(specdir.LiteralName("foo.yml"), ""),
+											),
+										),
+									),
+									specdir.File(specdir.LiteralName("darwin-amd64.tgz"), ""),
+									specdir.Dir(specdir.LiteralName("linux-amd64"), "",
+										specdir.Dir(specdir.LiteralName("bin"), "",
+											specdir.File(specdir.LiteralName("foo"), ""),
+										),
+										specdir.Dir(specdir.LiteralName("etc"), "",
+											specdir.Dir(specdir.LiteralName("foo"), "",
+												specdir.File(specdir.LiteralName("foo.yml"), ""),
+											),
+										),
+									),
+									specdir.File(specdir.LiteralName("linux-amd64.tgz"), ""),
+								),
+								specdir.File(specdir.LiteralName("foo-1.0.0-darwin-amd64.sh"), ""),
+								specdir.File(specdir.LiteralName("foo-1.0.0-linux-amd64.sh"), ""),
+							),
+						), true,
+					)
+					assert.NoError(t, wantLayout.Validate(path.Join(projectDir, "out", "dist", "foo", "1.0.0"), nil))
+				},
+			},
+			{
+				Name: "sh-installer fails for windows",
+				Specs: []gofiles.GoFileSpec{
+					{
+						RelPath: "foo/foo.go",
+						Src:     `package main; func main() {}`,
+					},
+				},
+				ConfigFiles: map[string]string{
+					"godel/config/godel.yml": godelYML,
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: windows
+          arch: amd64
+    dist:
+      disters:
+        type: sh-installer
+        config:
+          os-archs:
+            - os: windows
+              arch: amd64
+`,
+				},
+				WantError: true,
+				WantOutput: func(projectDir string) string {
+					return `Creating distribution for foo at out/dist/foo/1.0.0/sh-installer/foo-1.0.0-windows-amd64.sh
+Error: dist failed for foo: shell installers can only be created for linux and darwin, but OS/Arch windows-amd64 was specified
+`
+				},
+			},
+		},
+	)
+}
+
+func TestSHInstallerUpgradeConfig(t *testing.T) {
+	pluginPath, err := products.Bin("dist-plugin")
+	require.NoError(t, err)
+
+	pluginapitester.RunUpgradeConfigTest(t,
+		pluginapitester.NewPluginProvider(pluginPath),
+		nil,
+		[]pluginapitester.UpgradeConfigTestCase{
+			{
+				Name: `valid v0 config works`,
+				ConfigFiles: map[string]string{
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: sh-installer
+        config:
+          # comment
+          prefix: /opt/foo
+`,
+				},
+				WantOutput: ``,
+				WantFiles: map[string]string{
+					"godel/config/dist-plugin.yml": `
+products:
+  foo:
+    build:
+      main-pkg: ./foo
+      os-archs:
+        - os: linux
+          arch: amd64
+    dist:
+      disters:
+        type: sh-installer
+        config:
+          # comment
+          prefix: /opt/foo
+`,
+				},
+			},
+		},
+	)
+}
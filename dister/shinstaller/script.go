@@ -0,0 +1,168 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shinstaller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+type installerParams struct {
+	product        distgo.ProductID
+	version        string
+	osArch         osarch.OSArch
+	prefix         string
+	installedFiles []string
+	installedDirs  []string
+}
+
+// installerTemplate is the template for the POSIX shell header of the installer. The gzip-compressed tar payload is
+// appended directly after the header and starts at byte offset PayloadOffset (1-based, as used by "tail -c +N").
+var installerTemplate = template.Must(template.New("installer").Funcs(template.FuncMap{
+	"quote": shellQuote,
+}).Parse(`#!/bin/sh
+# Self-extracting installer for {{.Product}} {{.Version}} ({{.OSArch}}).
+set -e
+
+PRODUCT={{quote .Product}}
+VERSION={{quote .Version}}
+OS_ARCH={{quote .OSArch}}
+PREFIX={{quote .Prefix}}
+PAYLOAD_OFFSET={{.PayloadOffset}}
+PAYLOAD_SHA256={{quote .PayloadSHA256}}
+
+usage() {
+  cat <<EOF_USAGE
+Installs $PRODUCT $VERSION ($OS_ARCH).
+
+Usage: $0 [--prefix <dir>] [--uninstall]
+
+Options:
+  --prefix <dir>  installation prefix (default: $PREFIX); executables are installed in <dir>/bin
+  --uninstall     remove the files installed by this installer from the installation prefix
+  --help          print this help and exit
+EOF_USAGE
+}
+
+UNINSTALL=0
+while [ $# -gt 0 ]; do
+  case "$1" in
+    -h|--help)
+      usage
+      exit 0
+      ;;
+    --prefix)
+      if [ $# -lt 2 ]; then
+        echo "--prefix requires an argument" >&2
+        exit 1
+      fi
+      PREFIX="$2"
+      shift
+      ;;
+    --prefix=*)
+      PREFIX="${1#--prefix=}"
+      ;;
+    --uninstall)
+      UNINSTALL=1
+      ;;
+    *)
+      echo "unknown option: $1" >&2
+      usage >&2
+      exit 1
+      ;;
+  esac
+  shift
+done
+
+if [ "$UNINSTALL" = 1 ]; then
+  while IFS= read -r f; do
+    rm -f "$PREFIX/$f"
+  done <<'EOF_FILES'
+{{range .InstalledFiles}}{{.}}
+{{end}}EOF_FILES
+  while IFS= read -r d; do
+    if [ -n "$d" ]; then
+      rmdir "$PREFIX/$d" 2>/dev/null || true
+    fi
+  done <<'EOF_DIRS'
+{{range .InstalledDirs}}{{.}}
+{{end}}EOF_DIRS
+  echo "Uninstalled $PRODUCT $VERSION from $PREFIX"
+  exit 0
+fi
+
+if command -v sha256sum >/dev/null 2>&1; then
+  ACTUAL_SHA256=$(tail -c +$PAYLOAD_OFFSET "$0" | sha256sum | cut -d ' ' -f 1)
+elif command -v shasum >/dev/null 2>&1; then
+  ACTUAL_SHA256=$(tail -c +$PAYLOAD_OFFSET "$0" | shasum -a 256 | cut -d ' ' -f 1)
+else
+  echo "sha256sum or shasum is required to verify the installer" >&2
+  exit 1
+fi
+if [ "$ACTUAL_SHA256" != "$PAYLOAD_SHA256" ]; then
+  echo "checksum verification failed: expected $PAYLOAD_SHA256, was $ACTUAL_SHA256" >&2
+  exit 1
+fi
+
+mkdir -p "$PREFIX"
+tail -c +$PAYLOAD_OFFSET "$0" | gzip -dc | tar -xf - -C "$PREFIX"
+echo "Installed $PRODUCT $VERSION to $PREFIX"
+exit 0
+`))
+
+// installerHeader returns the shell header of the installer for the provided payload.
+func installerHeader(params installerParams, payload []byte) (string, error) {
+	// the offset of the payload depends on the length of the header, which depends on the number of digits of the
+	// offset: render until the offset is stable
+	offset := 1
+	for i := 0; i < 10; i++ {
+		buf := &bytes.Buffer{}
+		if err := installerTemplate.Execute(buf, map[string]interface{}{
+			"Product":        params.product,
+			"Version":        params.version,
+			"OSArch":         params.osArch.String(),
+			"Prefix":         params.prefix,
+			"PayloadOffset":  offset,
+			"PayloadSHA256":  fmt.Sprintf("%x", sha256.Sum256(payload)),
+			"InstalledFiles": params.installedFiles,
+			"InstalledDirs":  params.installedDirs,
+		}); err != nil {
+			return "", errors.Wrapf(err, "failed to render installer header")
+		}
+		if buf.Len()+1 == offset {
+			return buf.String(), nil
+		}
+		offset = buf.Len() + 1
+	}
+	return "", errors.Errorf("failed to determine payload offset for installer header")
+}
+
+// shellQuote returns the provided value as a single-quoted POSIX shell string.
+func shellQuote(val interface{}) string {
+	return "'" + strings.Replace(fmt.Sprint(val), "'", `'\''`, -1) + "'"
+}
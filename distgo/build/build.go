@@ -28,6 +28,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -36,12 +37,18 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/state"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/buildcache"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/imports"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/provenance"
 )
 
 type buildUnit struct {
 	buildParam            distgo.BuildParam
 	productTaskOutputInfo distgo.ProductTaskOutputInfo
 	osArch                osarch.OSArch
+	// scriptArgs is the output of the build arguments script of the product, which is shared by all of its OS/archs.
+	scriptArgs []string
 }
 
 type Options struct {
@@ -79,12 +86,18 @@ func Run(projectInfo distgo.ProjectInfo, productParams []distgo.ProductParam, bu
 		if err := distgo.WriteAndExecuteScript(projectInfo, currProductParam.Build.Script, distgo.BuildScriptEnvVariables(currProductTaskOutputInfo), stdout); err != nil {
 			return errors.Wrapf(err, "failed to execute build script")
 		}
+		// execute build arguments script once for all of the OS/archs
+		scriptArgs, err := currProductParam.Build.ScriptArgs(currProductTaskOutputInfo)
+		if err != nil {
+			return errors.Wrapf(err, "go build failed")
+		}
 
 		for _, currOSArch := range currProductParam.Build.OSArchs {
 			units = append(units, buildUnit{
 				buildParam:            *currProductParam.Build,
 				productTaskOutputInfo: currProductTaskOutputInfo,
 				osArch:                currOSArch,
+				scriptArgs:            scriptArgs,
 			})
 		}
 	}
@@ -185,36 +198,133 @@ func executeBuild(unit buildUnit, buildOpts Options, stdout io.Writer) error {
 	}
 	distgo.PrintlnOrDryRunPrintln(stdout, fmt.Sprintf("Building %s for %s at %s", name, osArch.String(), outputArtifactDisplayPath), buildOpts.DryRun)
 
+	var provenanceInfo *distgo.ProvenanceInfo
+	if unit.buildParam.Provenance != nil {
+		info, err := unit.buildParam.Provenance.Info(unit.productTaskOutputInfo.Project.ProjectDir, unit.buildParam.Cache != nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine provenance information for %s", name)
+		}
+		provenanceInfo = &info
+	}
+
+	var inputs map[string]string
+	var inputFiles imports.Files
+	var materials []provenance.Artifact
 	if !buildOpts.DryRun {
 		if err := os.MkdirAll(path.Dir(outputArtifactPath), 0755); err != nil {
 			return errors.Wrapf(err, "failed to create directories for %s", path.Dir(outputArtifactPath))
 		}
+		// compute the inputs before building so that the recorded state does not include changes made during the build.
+		// If the inputs cannot be computed, the build itself will typically fail with a more descriptive error, so the
+		// failure is reported as a warning (the build is then not recorded as up-to-date and is not cached).
+		currInputs, currInputFiles, err := buildInputs(unit.productTaskOutputInfo, unit.buildParam, osArch, unit.scriptArgs)
+		if err == nil {
+			inputs, inputFiles = currInputs, currInputFiles
+		} else if provenanceInfo == nil {
+			fmt.Fprintf(stdout, "Warning: failed to determine inputs of build of %s for %s: %v\n", name, osArch.String(), err)
+		}
+		if provenanceInfo != nil {
+			if err != nil {
+				return errors.Wrapf(err, "failed to determine inputs of build for provenance of %s", name)
+			}
+			if materials, err = provenanceMaterials(inputFilePaths(unit.productTaskOutputInfo.Project.ProjectDir, inputFiles)); err != nil {
+				return err
+			}
+		}
+		// remove any previous state and provenance so that the output is not considered up-to-date if the state cannot
+		// be recorded
+		if err := os.RemoveAll(buildStateFilePath(outputArtifactPath)); err != nil {
+			return errors.Wrapf(err, "failed to remove build state")
+		}
+		if err := os.RemoveAll(outputArtifactPath + distgo.ProvenanceFileSuffix); err != nil {
+			return errors.Wrapf(err, "failed to remove build provenance")
+		}
 	}
-	if err := doBuildAction(unit, outputArtifactPath, buildOpts.Install, buildOpts.DryRun, stdout); err != nil {
+	buildArgs, err := unit.buildParam.BuildArgsForOSArch(unit.productTaskOutputInfo, osArch, unit.scriptArgs, provenanceInfo)
+	if err != nil {
 		return errors.Wrapf(err, "go build failed")
 	}
 
+	// the executable can only be restored from the cache if the inputs of the build are known. Failures to use the cache
+	// are reported as warnings since the executable can always be built.
+	var buildCache buildcache.Cache
+	var cacheKey string
+	if unit.buildParam.Cache != nil && inputs != nil {
+		buildCache = unit.buildParam.Cache.Cache(unit.productTaskOutputInfo.Project.ProjectDir)
+		if cacheKey, err = buildCacheKey(unit, inputs, inputFiles, buildArgs); err != nil {
+			fmt.Fprintf(stdout, "Warning: failed to compute build cache key for %s for %s: %v\n", name, osArch.String(), err)
+		}
+	}
+	restored := false
+	if cacheKey != "" {
+		if restored, err = buildCache.Get(cacheKey, outputArtifactPath); err != nil {
+			fmt.Fprintf(stdout, "Warning: failed to restore %s for %s from build cache: %v\n", name, osArch.String(), err)
+		}
+	}
+	if restored {
+		fmt.Fprintf(stdout, "Restored %s for %s from build cache\n", name, osArch.String())
+	} else {
+		if err := doBuildAction(unit, outputArtifactPath, buildArgs, buildOpts.Install, buildOpts.DryRun, stdout); err != nil {
+			return errors.Wrapf(err, "go build failed")
+		}
+		if cacheKey != "" && !unit.buildParam.Cache.ReadOnly {
+			if err := buildCache.Put(cacheKey, outputArtifactPath); err != nil {
+				fmt.Fprintf(stdout, "Warning: failed to store %s for %s in build cache: %v\n", name, osArch.String(), err)
+			}
+		}
+	}
+	if unit.buildParam.SizeBudget != nil && !buildOpts.DryRun {
+		if err := checkSizeBudget(unit, outputArtifactPath, stdout); err != nil {
+			return err
+		}
+	}
+	if provenanceInfo != nil && !buildOpts.DryRun {
+		if err := writeProvenance(unit, outputArtifactPath, *provenanceInfo, materials); err != nil {
+			return err
+		}
+	}
+	if inputs != nil {
+		if err := state.Write(buildStateFilePath(outputArtifactPath), inputs, buildOutputPaths(unit.buildParam, outputArtifactPath)); err != nil {
+			return errors.Wrapf(err, "failed to record build state")
+		}
+	}
+
 	elapsed := time.Since(start)
 	distgo.PrintlnOrDryRunPrintln(stdout, fmt.Sprintf("Finished building %s for %s (%.3fs)", name, osArch.String(), elapsed.Seconds()), buildOpts.DryRun)
 	return nil
 }
 
-func doBuildAction(unit buildUnit, outputArtifactPath string, doInstall, dryRun bool, stdout io.Writer) error {
+// checkSizeBudget prints the size of the executable at outputArtifactPath and verifies that it is within the size budget
+// of the product. The size is compared to that of the executable for the same OS/Arch of the previous version of the
+// product in the build output directory.
+func checkSizeBudget(unit buildUnit, outputArtifactPath string, stdout io.Writer) error {
+	productTaskOutputInfo := unit.productTaskOutputInfo
+	name := fmt.Sprintf("%s for %s", productTaskOutputInfo.Product.ID, unit.osArch.String())
+	versionsDir := path.Dir(productTaskOutputInfo.ProductBuildOutputDir())
+	size, err := distgo.NewArtifactSize(name, outputArtifactPath, versionsDir, productTaskOutputInfo.Project.Version, func(version string) (string, error) {
+		buildOutputInfo, err := unit.buildParam.ToBuildOutputInfo(productTaskOutputInfo.Product.ID, version)
+		if err != nil {
+			return "", err
+		}
+		prevProjectInfo := productTaskOutputInfo.Project
+		prevProjectInfo.Version = version
+		prevProductOutputInfo := productTaskOutputInfo.Product
+		prevProductOutputInfo.BuildOutputInfo = &buildOutputInfo
+		return distgo.ProductBuildArtifactPaths(prevProjectInfo, prevProductOutputInfo)[unit.osArch], nil
+	})
+	if err != nil {
+		return err
+	}
+	return unit.buildParam.SizeBudget.CheckSizeBudget([]distgo.ArtifactSize{size}, stdout)
+}
+
+func doBuildAction(unit buildUnit, outputArtifactPath string, buildArgs []string, doInstall, dryRun bool, stdout io.Writer) error {
 	osArch := unit.osArch
 
 	cmd := exec.Command("go")
 	cmd.Dir = unit.productTaskOutputInfo.Project.ProjectDir
 
-	var env []string
-	if osArch.OS != "" {
-		env = append(env, "GOOS="+osArch.OS)
-	}
-	if osArch.Arch != "" {
-		env = append(env, "GOARCH="+osArch.Arch)
-	}
-	for k, v := range unit.buildParam.Environment {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
-	}
+	env := buildEnv(unit)
 	cmd.Env = append(os.Environ(), env...)
 
 	args := []string{cmd.Path}
@@ -230,10 +340,6 @@ func doBuildAction(unit buildUnit, outputArtifactPath string, doInstall, dryRun
 	}
 	args = append(args, "-o", outputArtifactPath)
 
-	buildArgs, err := unit.buildParam.BuildArgs(unit.productTaskOutputInfo)
-	if err != nil {
-		return err
-	}
 	args = append(args, buildArgs...)
 
 	mainPkg := unit.buildParam.MainPkg
@@ -260,6 +366,28 @@ func doBuildAction(unit buildUnit, outputArtifactPath string, doInstall, dryRun
 	return nil
 }
 
+// buildEnv returns the environment variables that are set for the build of the provided unit in addition to the
+// environment of the current process.
+func buildEnv(unit buildUnit) []string {
+	var env []string
+	if unit.osArch.OS != "" {
+		env = append(env, "GOOS="+unit.osArch.OS)
+	}
+	if unit.osArch.Arch != "" {
+		env = append(env, "GOARCH="+unit.osArch.Arch)
+	}
+	environment := unit.buildParam.EnvironmentFor(unit.osArch)
+	var keys []string
+	for k := range environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, fmt.Sprintf("%s=%s", k, environment[k]))
+	}
+	return env
+}
+
 const installPermissionDenied = `(?s)^go build [a-zA-Z0-9_/]+: mkdir [^:]+: permission denied.+`
 
 func goInstallErrorMsg(osArch osarch.OSArch, err error) string {
@@ -21,6 +21,7 @@ package build_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -30,6 +31,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nmiyake/pkg/dirs"
 	"github.com/palantir/godel/pkg/osarch"
@@ -39,6 +41,7 @@ import (
 
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/build"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/state"
 	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/git"
 )
 
@@ -52,6 +55,17 @@ var testVersionVar = "defaultVersion"
 func main() {
 	fmt.Println(testVersionVar)
 }
+`
+	testMainTwoVars = `package main
+
+import "fmt"
+
+var testVersionVar = "defaultVersion"
+var testOtherVar = "defaultOther"
+
+func main() {
+	fmt.Println(testVersionVar, testOtherVar)
+}
 `
 	testCMain = `package main
 
@@ -152,6 +166,34 @@ echo "-X \"main.testVersionVar=$VALUE\""`
 			runExecutable: true,
 			wantOutput:    "foo bar",
 		},
+		// linker flags generated by the version variable and provided by the build args script are both applied
+		{
+			productName:     "versionVarAndBuildArgsScriptProduct",
+			mainFileContent: testMainTwoVars,
+			mainFilePath:    "main.go",
+			productParam: createBuildProductParam(func(param *distgo.ProductParam) {
+				param.Build.VersionVar = "main.testVersionVar"
+				param.Build.BuildArgsScript = `#!/usr/bin/env bash
+echo "-ldflags"
+echo "-X 'main.testOtherVar=script value'"`
+			}),
+			runExecutable: true,
+			wantOutput:    testVersionValue + ".dirty script value",
+		},
+		{
+			productName:     "xVarsProduct",
+			mainFileContent: testMainTwoVars,
+			mainFilePath:    "main.go",
+			productParam: createBuildProductParam(func(param *distgo.ProductParam) {
+				param.Build.XVars = map[string]string{
+					"main.testOtherVar": "{{Product}} {{Version}}",
+				}
+				param.Build.LDFlags = "-s -w"
+				param.Build.Trimpath = true
+			}),
+			runExecutable: true,
+			wantOutput:    "defaultVersion testProduct " + testVersionValue + ".dirty",
+		},
 		{
 			productName:     "foo",
 			mainFileContent: testMain,
@@ -305,6 +347,530 @@ func TestBuildOnlySpecifiedOSArchs(t *testing.T) {
 	}
 }
 
+func TestBuildSizeBudget(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	mainFilePath := path.Join(tmp, "foo/main.go")
+	err = os.MkdirAll(path.Dir(mainFilePath), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(mainFilePath, []byte(testMain), 0644)
+	require.NoError(t, err)
+
+	projectInfo := distgo.ProjectInfo{
+		ProjectDir: tmp,
+		Version:    "1.0.0",
+	}
+	osArch := osarch.Current()
+
+	// executable of previous version
+	prevExecutable := path.Join(tmp, "out", "build", "testProduct", "0.9.0", osArch.String(), "testProduct")
+	err = os.MkdirAll(path.Dir(prevExecutable), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(prevExecutable, []byte("previous"), 0755)
+	require.NoError(t, err)
+
+	for i, tc := range []struct {
+		sizeBudget      distgo.SizeBudgetParam
+		wantErrorRegexp string
+		want            string
+	}{
+		{
+			sizeBudget: distgo.SizeBudgetParam{MaxSize: 1 << 30},
+			want:       fmt.Sprintf(`(?m)^Size of testProduct for %s: .+ compared to 0\.9\.0\)$`, osArch),
+		},
+		{
+			sizeBudget:      distgo.SizeBudgetParam{MaxSize: 1024},
+			wantErrorRegexp: fmt.Sprintf(`^size budget exceeded: testProduct for %s is .+, which exceeds the maximum size of 1\.0 KiB$`, osArch),
+		},
+		{
+			sizeBudget: distgo.SizeBudgetParam{MaxSize: 1024, Warn: true},
+			want:       fmt.Sprintf(`(?m)^Warning: testProduct for %s is .+, which exceeds the maximum size of 1\.0 KiB$`, osArch),
+		},
+	} {
+		productParam := createBuildProductParam(func(param *distgo.ProductParam) {
+			param.Build.MainPkg = "./foo"
+			param.Build.OSArchs = []osarch.OSArch{osArch}
+			param.Build.SizeBudget = &tc.sizeBudget
+		})
+
+		buf := &bytes.Buffer{}
+		err = build.Run(projectInfo, []distgo.ProductParam{productParam}, build.Options{}, buf)
+		if tc.wantErrorRegexp == "" {
+			require.NoError(t, err, "Case %d", i)
+			assert.Regexp(t, regexp.MustCompile(tc.want), buf.String(), "Case %d", i)
+		} else {
+			require.Error(t, err, "Case %d", i)
+			assert.Regexp(t, regexp.MustCompile(tc.wantErrorRegexp), err.Error(), "Case %d", i)
+		}
+	}
+}
+
+func TestBuildOSArchOverrides(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for relPath, content := range map[string]string{
+		"foo/main.go": `package main
+
+import "fmt"
+
+var msg = "default"
+
+func main() {
+	fmt.Println(msg)
+}
+`,
+		"foo/override.go": `// +build override
+
+package main
+
+func init() {
+	msg = "override"
+}
+`,
+	} {
+		err = os.MkdirAll(path.Join(tmp, path.Dir(relPath)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(tmp, relPath), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+
+	projectInfo := distgo.ProjectInfo{
+		ProjectDir: tmp,
+	}
+	osArch := osarch.Current()
+	otherOS := "linux"
+	if osArch.OS == otherOS {
+		otherOS = "darwin"
+	}
+
+	for i, tc := range []struct {
+		overrides map[string]distgo.OSArchBuildOverride
+		want      string
+	}{
+		{
+			overrides: map[string]distgo.OSArchBuildOverride{
+				osArch.OS: {
+					BuildArgs: []string{"-tags", "override"},
+				},
+			},
+			want: "override\n",
+		},
+		{
+			overrides: map[string]distgo.OSArchBuildOverride{
+				osArch.String(): {
+					Environment: map[string]string{
+						"GOFLAGS": "-tags=override",
+					},
+				},
+			},
+			want: "override\n",
+		},
+		{
+			overrides: map[string]distgo.OSArchBuildOverride{
+				otherOS: {
+					BuildArgs: []string{"-tags", "override"},
+				},
+			},
+			want: "default\n",
+		},
+	} {
+		productParam := createBuildProductParam(func(param *distgo.ProductParam) {
+			param.Build.MainPkg = "./foo"
+			param.Build.OSArchs = []osarch.OSArch{osArch}
+			param.Build.OSArchOverrides = tc.overrides
+		})
+
+		err = build.Run(projectInfo, []distgo.ProductParam{productParam}, build.Options{}, ioutil.Discard)
+		require.NoError(t, err, "Case %d", i)
+
+		output, err := exec.Command(path.Join(tmp, "out", "build", "testProduct", osArch.String(), "testProduct")).Output()
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, string(output), "Case %d", i)
+	}
+}
+
+func TestBuildProvenance(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	gittest.InitGitDir(t, tmp)
+	mainFilePath := path.Join(tmp, "foo/main.go")
+	err = os.MkdirAll(path.Dir(mainFilePath), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(mainFilePath, []byte(`package main
+
+import "fmt"
+
+var commit, commitDate, buildDate, dirty, builder string
+
+func main() {
+	fmt.Println(commit, commitDate, buildDate, dirty, builder)
+}
+`), 0644)
+	require.NoError(t, err)
+	gittest.CommitAllFiles(t, tmp, "Add foo")
+
+	commit, err := git.CmdOutput(tmp, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	commitTime, err := git.CommitTime(tmp)
+	require.NoError(t, err)
+
+	origEpoch, hadEpoch := os.LookupEnv("SOURCE_DATE_EPOCH")
+	defer func() {
+		if hadEpoch {
+			_ = os.Setenv("SOURCE_DATE_EPOCH", origEpoch)
+		} else {
+			_ = os.Unsetenv("SOURCE_DATE_EPOCH")
+		}
+	}()
+	err = os.Setenv("SOURCE_DATE_EPOCH", "1514764800")
+	require.NoError(t, err)
+
+	projectInfo := distgo.ProjectInfo{
+		ProjectDir: tmp,
+		Version:    "1.0.0",
+	}
+	productParam := createBuildProductParam(func(param *distgo.ProductParam) {
+		param.Build.MainPkg = "./foo"
+		param.Build.Provenance = &distgo.ProvenanceParam{
+			CommitVar:     "main.commit",
+			CommitDateVar: "main.commitDate",
+			BuildDateVar:  "main.buildDate",
+			DirtyVar:      "main.dirty",
+			BuilderVar:    "main.builder",
+			Builder:       "test-builder",
+		}
+		param.Build.Environment = map[string]string{"TEST_SECRET": "secret-value"}
+	})
+	err = build.Run(projectInfo, []distgo.ProductParam{productParam}, build.Options{}, ioutil.Discard)
+	require.NoError(t, err)
+
+	executablePath := path.Join(tmp, "out", "build", "testProduct", "1.0.0", osarch.Current().String(), "testProduct")
+	output, err := exec.Command(executablePath).Output()
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%s %s 2018-01-01T00:00:00Z false test-builder\n", commit, commitTime.Format(time.RFC3339)), string(output))
+
+	executableDigest, err := state.FileDigest(executablePath)
+	require.NoError(t, err)
+	mainFileDigest, err := state.FileDigest(mainFilePath)
+	require.NoError(t, err)
+
+	provenanceBytes, err := ioutil.ReadFile(executablePath + distgo.ProvenanceFileSuffix)
+	require.NoError(t, err)
+	var provenance struct {
+		Subject []struct {
+			Name   string            `json:"name"`
+			Digest map[string]string `json:"digest"`
+		} `json:"subject"`
+		Predicate struct {
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+			Invocation struct {
+				ConfigSource struct {
+					Digest     map[string]string `json:"digest"`
+					EntryPoint string            `json:"entryPoint"`
+				} `json:"configSource"`
+				Environment map[string]interface{} `json:"environment"`
+			} `json:"invocation"`
+			Metadata struct {
+				BuildStartedOn string `json:"buildStartedOn"`
+			} `json:"metadata"`
+			Materials []struct {
+				URI    string            `json:"uri"`
+				Digest map[string]string `json:"digest"`
+			} `json:"materials"`
+		} `json:"predicate"`
+	}
+	err = json.Unmarshal(provenanceBytes, &provenance)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, len(provenance.Subject))
+	assert.Equal(t, "testProduct", provenance.Subject[0].Name)
+	assert.Equal(t, map[string]string{"sha256": executableDigest}, provenance.Subject[0].Digest)
+	assert.Equal(t, "test-builder", provenance.Predicate.Builder.ID)
+	assert.Equal(t, map[string]string{"sha1": commit}, provenance.Predicate.Invocation.ConfigSource.Digest)
+	assert.Equal(t, "./foo", provenance.Predicate.Invocation.ConfigSource.EntryPoint)
+	assert.Equal(t, false, provenance.Predicate.Invocation.Environment["dirty"])
+	// only the names of the environment variables are recorded
+	assert.Equal(t, []interface{}{"TEST_SECRET"}, provenance.Predicate.Invocation.Environment["variables"])
+	assert.NotContains(t, string(provenanceBytes), "secret-value")
+	assert.Equal(t, "2018-01-01T00:00:00Z", provenance.Predicate.Metadata.BuildStartedOn)
+	foundMainFile := false
+	for _, material := range provenance.Predicate.Materials {
+		if material.URI == "foo/main.go" {
+			foundMainFile = true
+			assert.Equal(t, map[string]string{"sha256": mainFileDigest}, material.Digest)
+		}
+	}
+	assert.True(t, foundMainFile, "materials do not include foo/main.go: %v", provenance.Predicate.Materials)
+
+	// build is required if the provenance file is removed
+	requiresBuild, err := build.RequiresBuild(projectInfo, productParam)
+	require.NoError(t, err)
+	assert.Nil(t, requiresBuild)
+	err = os.Remove(executablePath + distgo.ProvenanceFileSuffix)
+	require.NoError(t, err)
+	requiresBuild, err = build.RequiresBuild(projectInfo, productParam)
+	require.NoError(t, err)
+	assert.NotNil(t, requiresBuild)
+}
+
+func TestBuildCache(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	mainFilePath := path.Join(tmp, "foo/main.go")
+	err = os.MkdirAll(path.Dir(mainFilePath), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(mainFilePath, []byte(testMain), 0644)
+	require.NoError(t, err)
+
+	projectInfo := distgo.ProjectInfo{
+		ProjectDir: tmp,
+		Version:    "1.0.0",
+	}
+	osArch := osarch.Current()
+	executablePath := path.Join(tmp, "out", "build", "testProduct", "1.0.0", osArch.String(), "testProduct")
+	restoredMsg := fmt.Sprintf("Restored testProduct for %s from build cache", osArch)
+
+	for i, tc := range []struct {
+		cache        distgo.BuildCacheParam
+		versionVar   string
+		wantRestored bool
+	}{
+		// read-only cache does not store executable
+		{
+			cache: distgo.BuildCacheParam{Dir: "cache", ReadOnly: true},
+		},
+		// executable is not in cache, so it is built and stored
+		{
+			cache: distgo.BuildCacheParam{Dir: "cache"},
+		},
+		// executable built from the same inputs is restored from the cache
+		{
+			cache:        distgo.BuildCacheParam{Dir: "cache"},
+			wantRestored: true,
+		},
+		// executable built with different build arguments is not restored from the cache
+		{
+			cache:      distgo.BuildCacheParam{Dir: "cache"},
+			versionVar: "main.testVersionVar",
+		},
+	} {
+		err = os.RemoveAll(path.Join(tmp, "out"))
+		require.NoError(t, err, "Case %d", i)
+
+		productParam := createBuildProductParam(func(param *distgo.ProductParam) {
+			param.Build.MainPkg = "./foo"
+			param.Build.VersionVar = tc.versionVar
+			param.Build.Cache = &tc.cache
+		})
+		buf := &bytes.Buffer{}
+		err = build.Run(projectInfo, []distgo.ProductParam{productParam}, build.Options{}, buf)
+		require.NoError(t, err, "Case %d", i)
+		if tc.wantRestored {
+			assert.Contains(t, buf.String(), restoredMsg, "Case %d", i)
+		} else {
+			assert.NotContains(t, buf.String(), restoredMsg, "Case %d", i)
+		}
+
+		output, err := exec.Command(executablePath).Output()
+		require.NoError(t, err, "Case %d", i)
+		wantOutput := "defaultVersion\n"
+		if tc.versionVar != "" {
+			wantOutput = "1.0.0\n"
+		}
+		assert.Equal(t, wantOutput, string(output), "Case %d", i)
+
+		// restored executable is considered up-to-date
+		requiresBuild, err := build.RequiresBuild(projectInfo, productParam)
+		require.NoError(t, err, "Case %d", i)
+		assert.Nil(t, requiresBuild, "Case %d", i)
+	}
+
+	// executable built from the same inputs in a project in a different location is restored from the cache even if the
+	// relative path from the project to its dependencies differs
+	gopath := path.Join(tmp, "gopath")
+	for relPath, content := range map[string]string{
+		"gopath/src/example.com/dep/dep.go": `package dep; const Name = "dep"`,
+		"first/foo/main.go":                 `package main; import ("fmt"; "example.com/dep"); func main() { fmt.Println(dep.Name) }`,
+		"second/nested/foo/main.go":         `package main; import ("fmt"; "example.com/dep"); func main() { fmt.Println(dep.Name) }`,
+	} {
+		err = os.MkdirAll(path.Dir(path.Join(tmp, relPath)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(tmp, relPath), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+	productParam := createBuildProductParam(func(param *distgo.ProductParam) {
+		param.Build.MainPkg = "./foo"
+		param.Build.Environment = map[string]string{"GOPATH": gopath, "GO111MODULE": "off"}
+		param.Build.Cache = &distgo.BuildCacheParam{Dir: path.Join(tmp, "cache")}
+	})
+	for i, projectDir := range []string{path.Join(tmp, "first"), path.Join(tmp, "second", "nested")} {
+		buf := &bytes.Buffer{}
+		err = build.Run(distgo.ProjectInfo{ProjectDir: projectDir, Version: "1.0.0"}, []distgo.ProductParam{productParam}, build.Options{}, buf)
+		require.NoError(t, err, "Project %d", i)
+		if i == 0 {
+			assert.NotContains(t, buf.String(), restoredMsg, "Project %d", i)
+		} else {
+			assert.Contains(t, buf.String(), restoredMsg, "Project %d", i)
+		}
+		output, err := exec.Command(path.Join(projectDir, "out", "build", "testProduct", "1.0.0", osArch.String(), "testProduct")).Output()
+		require.NoError(t, err, "Project %d", i)
+		assert.Equal(t, "dep\n", string(output), "Project %d", i)
+	}
+
+	// executable is not restored from the cache if "go env" values inherited from the environment differ
+	origGOFLAGS, hasGOFLAGS := os.LookupEnv("GOFLAGS")
+	defer func() {
+		if hasGOFLAGS {
+			_ = os.Setenv("GOFLAGS", origGOFLAGS)
+		} else {
+			_ = os.Unsetenv("GOFLAGS")
+		}
+	}()
+	err = os.Setenv("GOFLAGS", strings.TrimSpace(origGOFLAGS+" -ldflags=-s"))
+	require.NoError(t, err)
+	buf := &bytes.Buffer{}
+	err = build.Run(distgo.ProjectInfo{ProjectDir: path.Join(tmp, "first"), Version: "1.0.0"}, []distgo.ProductParam{productParam}, build.Options{}, buf)
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), restoredMsg)
+}
+
+func TestBuildCacheProvenance(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	gittest.InitGitDir(t, tmp)
+	err = os.MkdirAll(path.Join(tmp, "foo"), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path.Join(tmp, "foo", "main.go"), []byte(`package main
+
+import "fmt"
+
+var buildDate string
+
+func main() {
+	fmt.Println(buildDate)
+}
+`), 0644)
+	require.NoError(t, err)
+	gittest.CommitAllFiles(t, tmp, "Add foo")
+	commitTime, err := git.CommitTime(tmp)
+	require.NoError(t, err)
+
+	origEpoch, hadEpoch := os.LookupEnv("SOURCE_DATE_EPOCH")
+	defer func() {
+		if hadEpoch {
+			_ = os.Setenv("SOURCE_DATE_EPOCH", origEpoch)
+		}
+	}()
+	err = os.Unsetenv("SOURCE_DATE_EPOCH")
+	require.NoError(t, err)
+
+	projectInfo := distgo.ProjectInfo{
+		ProjectDir: tmp,
+		Version:    "1.0.0",
+	}
+	productParam := createBuildProductParam(func(param *distgo.ProductParam) {
+		param.Build.MainPkg = "./foo"
+		param.Build.Provenance = &distgo.ProvenanceParam{
+			BuildDateVar: "main.buildDate",
+		}
+		param.Build.Cache = &distgo.BuildCacheParam{Dir: path.Join(tmp, "cache")}
+	})
+	osArch := osarch.Current()
+	executablePath := path.Join(tmp, "out", "build", "testProduct", "1.0.0", osArch.String(), "testProduct")
+	restoredMsg := fmt.Sprintf("Restored testProduct for %s from build cache", osArch)
+
+	// the build date is the time of the HEAD commit, so the second build is restored from the cache
+	for i, wantRestored := range []bool{false, true} {
+		err = os.RemoveAll(path.Join(tmp, "out"))
+		require.NoError(t, err, "Build %d", i)
+
+		buf := &bytes.Buffer{}
+		err = build.Run(projectInfo, []distgo.ProductParam{productParam}, build.Options{}, buf)
+		require.NoError(t, err, "Build %d", i)
+		if wantRestored {
+			assert.Contains(t, buf.String(), restoredMsg, "Build %d", i)
+		} else {
+			assert.NotContains(t, buf.String(), restoredMsg, "Build %d", i)
+		}
+
+		output, err := exec.Command(executablePath).Output()
+		require.NoError(t, err, "Build %d", i)
+		assert.Equal(t, commitTime.Format(time.RFC3339)+"\n", string(output), "Build %d", i)
+	}
+}
+
+func TestBuildArgsScriptRunsOncePerProduct(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	err = os.MkdirAll(path.Join(tmp, "foo"), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path.Join(tmp, "foo", "main.go"), []byte(testMain), 0644)
+	require.NoError(t, err)
+
+	projectInfo := distgo.ProjectInfo{
+		ProjectDir: tmp,
+		Version:    "1.0.0",
+	}
+	productParam := createBuildProductParam(func(param *distgo.ProductParam) {
+		param.Build.MainPkg = "./foo"
+		param.Build.OSArchs = []osarch.OSArch{
+			{OS: "linux", Arch: "amd64"},
+			{OS: "darwin", Arch: "amd64"},
+		}
+		param.Build.BuildArgsScript = `#!/usr/bin/env bash
+echo run >> script-runs.txt
+echo "-tags"
+echo "script"`
+	})
+	scriptRuns := func() int {
+		bytes, err := ioutil.ReadFile(path.Join(tmp, "script-runs.txt"))
+		require.NoError(t, err)
+		return strings.Count(string(bytes), "run\n")
+	}
+
+	err = build.Run(projectInfo, []distgo.ProductParam{productParam}, build.Options{}, ioutil.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, 1, scriptRuns())
+
+	requiresBuild, err := build.RequiresBuild(projectInfo, productParam)
+	require.NoError(t, err)
+	assert.Nil(t, requiresBuild)
+	assert.Equal(t, 2, scriptRuns())
+}
+
+func TestBuildInputsWarning(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	err = os.MkdirAll(path.Join(tmp, "foo"), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path.Join(tmp, "foo", "main.go"), []byte(`package main; import _ "example.com/missing"; func main() {}`), 0644)
+	require.NoError(t, err)
+
+	productParam := createBuildProductParam(func(param *distgo.ProductParam) {
+		param.Build.MainPkg = "./foo"
+	})
+	buf := &bytes.Buffer{}
+	err = build.Run(distgo.ProjectInfo{ProjectDir: tmp, Version: "1.0.0"}, []distgo.ProductParam{productParam}, build.Options{}, buf)
+	require.Error(t, err)
+	assert.Contains(t, buf.String(), fmt.Sprintf("Warning: failed to determine inputs of build of testProduct for %s: ", osarch.Current()))
+}
+
 func TestBuildErrorMessage(t *testing.T) {
 	tmpDir, cleanup, err := dirs.TempDir(".", "")
 	require.NoError(t, err)
@@ -479,6 +1045,122 @@ func TestBuildAllParallel(t *testing.T) {
 	}
 }
 
+func TestRequiresBuild(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for i, tc := range []struct {
+		name       string
+		setup      func(projectDir string, productParam *distgo.ProductParam)
+		modify     func(projectDir string, productParam *distgo.ProductParam)
+		wantReason string
+	}{
+		{
+			name: "up-to-date if nothing changed",
+		},
+		{
+			name: "up-to-date if only modification times changed",
+			modify: func(projectDir string, productParam *distgo.ProductParam) {
+				future := time.Now().Add(time.Hour)
+				err := os.Chtimes(path.Join(projectDir, "foo", "main.go"), future, future)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "requires build if source file changed",
+			modify: func(projectDir string, productParam *distgo.ProductParam) {
+				err := ioutil.WriteFile(path.Join(projectDir, "foo", "main.go"), []byte(testMain+"\nvar _ = 1\n"), 0644)
+				require.NoError(t, err)
+			},
+			wantReason: "inputs changed: go-files",
+		},
+		{
+			name: "requires build if dependency only imported using build tags of OS/Arch override changed",
+			setup: func(projectDir string, productParam *distgo.ProductParam) {
+				for name, content := range map[string]string{
+					"go.mod":       "module example.com/test\n\ngo 1.16\n",
+					"foo/extra.go": "// +build extra\n\npackage main\n\nimport _ \"example.com/test/bar\"\n",
+					"bar/bar.go":   "package bar\n",
+				} {
+					err := os.MkdirAll(path.Dir(path.Join(projectDir, name)), 0755)
+					require.NoError(t, err)
+					err = ioutil.WriteFile(path.Join(projectDir, name), []byte(content), 0644)
+					require.NoError(t, err)
+				}
+				productParam.Build.Environment = map[string]string{
+					"GO111MODULE": "on",
+				}
+				productParam.Build.OSArchOverrides = map[string]distgo.OSArchBuildOverride{
+					osarch.Current().String(): {
+						BuildArgs: []string{"-tags", "extra"},
+					},
+				}
+			},
+			modify: func(projectDir string, productParam *distgo.ProductParam) {
+				err := ioutil.WriteFile(path.Join(projectDir, "bar", "bar.go"), []byte("package bar\n\nvar _ = 1\n"), 0644)
+				require.NoError(t, err)
+			},
+			wantReason: "inputs changed: go-files",
+		},
+		{
+			name: "requires build if build configuration changed",
+			modify: func(projectDir string, productParam *distgo.ProductParam) {
+				productParam.Build.Environment = map[string]string{
+					"CGO_ENABLED": "0",
+				}
+			},
+			wantReason: "inputs changed: build-config",
+		},
+		{
+			name: "requires build if output was modified",
+			modify: func(projectDir string, productParam *distgo.ProductParam) {
+				err := ioutil.WriteFile(path.Join(projectDir, "out", "build", "testProduct", "0.1.0", osarch.Current().String(), "testProduct"), []byte("modified"), 0755)
+				require.NoError(t, err)
+			},
+			wantReason: "output testProduct was modified",
+		},
+	} {
+		projectDir, err := ioutil.TempDir(tmp, "")
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		err = os.MkdirAll(path.Join(projectDir, "foo"), 0755)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		err = ioutil.WriteFile(path.Join(projectDir, "foo", "main.go"), []byte(testMain), 0644)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+
+		productParam := createBuildProductParam(func(param *distgo.ProductParam) {
+			param.Build.MainPkg = "./foo"
+		})
+		projectInfo := distgo.ProjectInfo{
+			ProjectDir: projectDir,
+			Version:    "0.1.0",
+		}
+		if tc.setup != nil {
+			tc.setup(projectDir, &productParam)
+		}
+
+		requiresBuildParam, reasons, err := build.RequiresBuildWithReasons(projectInfo, productParam)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		require.NotNil(t, requiresBuildParam, "Case %d: %s", i, tc.name)
+		assert.Equal(t, "output testProduct does not exist", reasons[osarch.Current()], "Case %d: %s", i, tc.name)
+
+		err = build.Run(projectInfo, []distgo.ProductParam{productParam}, build.Options{}, ioutil.Discard)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+
+		if tc.modify != nil {
+			tc.modify(projectDir, &productParam)
+		}
+		requiresBuildParam, reasons, err = build.RequiresBuildWithReasons(projectInfo, productParam)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		if tc.wantReason == "" {
+			assert.Nil(t, requiresBuildParam, "Case %d: %s\nReasons: %v", i, tc.name, reasons)
+		} else {
+			require.NotNil(t, requiresBuildParam, "Case %d: %s", i, tc.name)
+			assert.Equal(t, tc.wantReason, reasons[osarch.Current()], "Case %d: %s", i, tc.name)
+		}
+	}
+}
+
 func createBuildProductParam(fn func(*distgo.ProductParam)) distgo.ProductParam {
 	param := distgo.ProductParam{
 		ID: "testProduct",
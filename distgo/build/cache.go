@@ -0,0 +1,131 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/state"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/imports"
+)
+
+// cacheKeyGoEnvVars are the "go env" variables that affect the executable produced by a build and are therefore covered
+// by build cache keys. Variables that are not known to the "go" executable in use are reported as empty.
+var cacheKeyGoEnvVars = []string{
+	"CC",
+	"CGO_CFLAGS",
+	"CGO_CPPFLAGS",
+	"CGO_CXXFLAGS",
+	"CGO_ENABLED",
+	"CGO_FFLAGS",
+	"CGO_LDFLAGS",
+	"CXX",
+	"GO111MODULE",
+	"GO386",
+	"GOAMD64",
+	"GOARCH",
+	"GOARM",
+	"GOARM64",
+	"GOEXPERIMENT",
+	"GOFLAGS",
+	"GOMIPS",
+	"GOMIPS64",
+	"GOOS",
+	"GOPPC64",
+	"GORISCV64",
+	"GOTOOLCHAIN",
+	"GOVERSION",
+	"GOWASM",
+	"GOWORK",
+}
+
+// buildCacheKey returns the key of the executable built for the provided unit using the provided inputs and input files
+// (as computed by buildInputs) and build arguments in the build cache. The key does not depend on the location of the
+// project or of the module cache: files in the project directory are identified by their path relative to the project
+// directory and other files are identified by their location-independent name (the module path and version and their
+// path within the module for files that belong to a module). In addition to the inputs and arguments, the key covers
+// the version of the Go toolchain and the "go env" variables that affect the build.
+func buildCacheKey(unit buildUnit, inputs map[string]string, inputFiles imports.Files, buildArgs []string) (string, error) {
+	files := make(map[string]string)
+	for name, filePath := range inputFilePaths(unit.productTaskOutputInfo.Project.ProjectDir, inputFiles) {
+		if filepath.IsAbs(name) || strings.HasPrefix(name, "..") {
+			independentName, ok := inputFiles.Names[filePath]
+			if !ok {
+				return "", errors.Errorf("failed to determine location-independent name of %s", filePath)
+			}
+			name = independentName
+		}
+		files[name] = filePath
+	}
+	filesDigest, err := state.FilesDigest(files)
+	if err != nil {
+		return "", err
+	}
+	keyInputs := make(map[string]string)
+	for k, v := range inputs {
+		keyInputs[k] = v
+	}
+	// the "go-files" input names files outside of the project directory by paths that depend on the location of the
+	// project
+	keyInputs["go-files"] = filesDigest
+
+	goVersion, err := exec.Command("go", "version").CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine Go version: %s", string(goVersion))
+	}
+	goEnv, err := buildGoEnv(unit)
+	if err != nil {
+		return "", err
+	}
+	return state.JSONDigest(struct {
+		Inputs    map[string]string
+		BuildArgs []string
+		GoVersion string
+		GoEnv     map[string]string
+	}{
+		Inputs:    keyInputs,
+		BuildArgs: buildArgs,
+		GoVersion: strings.TrimSpace(string(goVersion)),
+		GoEnv:     goEnv,
+	})
+}
+
+// buildGoEnv returns the values of cacheKeyGoEnvVars reported by "go env" in the environment used to build the
+// provided unit, which includes values inherited from the environment of the current process.
+func buildGoEnv(unit buildUnit) (map[string]string, error) {
+	cmd := exec.Command("go", append([]string{"env", "-json"}, cacheKeyGoEnvVars...)...)
+	cmd.Dir = unit.productTaskOutputInfo.Project.ProjectDir
+	cmd.Env = append(os.Environ(), buildEnv(unit)...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run %v", cmd.Args)
+	}
+	goEnv := make(map[string]string)
+	if err := json.Unmarshal(output, &goEnv); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse output of %v", cmd.Args)
+	}
+	return goEnv, nil
+}
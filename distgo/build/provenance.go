@@ -0,0 +1,101 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/state"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/provenance"
+)
+
+// provenanceMaterials returns the materials for the provided input files, which is a map from the name of a file to its
+// path. Files that do not exist are omitted.
+func provenanceMaterials(inputFiles map[string]string) ([]provenance.Artifact, error) {
+	var materials []provenance.Artifact
+	for name, filePath := range inputFiles {
+		digest, err := state.FileDigest(filePath)
+		if os.IsNotExist(errors.Cause(err)) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		materials = append(materials, provenance.Artifact{
+			Name:   name,
+			SHA256: digest,
+		})
+	}
+	return materials, nil
+}
+
+// writeProvenance writes the provenance file for the executable at outputArtifactPath.
+func writeProvenance(unit buildUnit, outputArtifactPath string, info distgo.ProvenanceInfo, materials []provenance.Artifact) error {
+	digest, err := state.FileDigest(outputArtifactPath)
+	if err != nil {
+		return err
+	}
+	projectInfo := unit.productTaskOutputInfo.Project
+	environment := map[string]interface{}{
+		"commitDate": info.CommitDate.Format(time.RFC3339),
+		"dirty":      info.Dirty,
+	}
+	// only the names of the environment variables are recorded since their values may contain secrets
+	if env := unit.buildParam.EnvironmentFor(unit.osArch); len(env) > 0 {
+		var names []string
+		for name := range env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		environment["variables"] = names
+	}
+	content, err := provenance.InTotoStatement(provenance.Document{
+		Subject: provenance.Artifact{
+			Name:   path.Base(outputArtifactPath),
+			SHA256: digest,
+		},
+		BuilderID:  info.Builder,
+		Repository: info.Repository,
+		Commit:     info.Commit,
+		EntryPoint: unit.buildParam.MainPkg,
+		Parameters: map[string]interface{}{
+			"product": string(unit.productTaskOutputInfo.Product.ID),
+			"version": projectInfo.Version,
+			"osArch":  unit.osArch.String(),
+		},
+		Environment:    environment,
+		BuildStartedOn: info.BuildDate,
+		Materials:      materials,
+	})
+	if err != nil {
+		return err
+	}
+	provenancePath := outputArtifactPath + distgo.ProvenanceFileSuffix
+	if err := ioutil.WriteFile(provenancePath, content, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write provenance file %s", provenancePath)
+	}
+	return nil
+}
@@ -20,23 +20,34 @@ Sniperkit-Bot
 package build
 
 import (
-	"os"
+	"fmt"
 	"path"
+	"path/filepath"
 
 	"github.com/palantir/godel/pkg/osarch"
 	"github.com/pkg/errors"
 
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/state"
 	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/imports"
 )
 
 // RequiresBuild returns a pointer to a distgo.ProductParam that contains only the OS/arch parameters for the outputs
-// that require building. A product is considered to require building if its output executable does not exist or if the
-// output executable's modification date is older than any of the Go files required to build the product. Returns nil if
-// all of the outputs exist and are up-to-date.
+// that require building. A product is considered to require building for an OS/arch if its output executable does not
+// exist, if the output executable was modified after it was built, or if the digests of the inputs of the build (the
+// files required to build the product for the OS/arch, including module files and embedded files, the build
+// configuration and the version) differ from the ones recorded in the state file written when the output executable
+// was built. Returns nil if all of the outputs exist and are up-to-date.
 func RequiresBuild(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam) (*distgo.ProductParam, error) {
+	requiresBuildParam, _, err := RequiresBuildWithReasons(projectInfo, productParam)
+	return requiresBuildParam, err
+}
+
+// RequiresBuildWithReasons returns the same distgo.ProductParam as RequiresBuild along with a map from the OS/archs that
+// require building to a description of why building is required.
+func RequiresBuildWithReasons(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam) (*distgo.ProductParam, map[osarch.OSArch]string, error) {
 	if productParam.Build == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	// create a copy of the build parameter so that it is safe for modification
@@ -45,27 +56,137 @@ func RequiresBuild(projectInfo distgo.ProjectInfo, productParam distgo.ProductPa
 
 	productTaskOutputInfo, err := distgo.ToProductTaskOutputInfo(projectInfo, productParam)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to compute output information for %s", productParam.ID)
+		return nil, nil, errors.Wrapf(err, "failed to compute output information for %s", productParam.ID)
 	}
 
+	// the build arguments script is run once for all of the OS/archs
+	scriptArgs, scriptErr := productParam.Build.ScriptArgs(productTaskOutputInfo)
+
 	pathsMap := productTaskOutputInfo.ProductBuildArtifactPaths()
 	var requiresBuildOSArchs []osarch.OSArch
+	reasons := make(map[osarch.OSArch]string)
 	for _, currOSArch := range productParam.Build.OSArchs {
-		if fi, err := os.Stat(pathsMap[currOSArch]); err == nil {
-			if goFiles, err := imports.AllFiles(path.Join(projectInfo.ProjectDir, productParam.Build.MainPkg)); err == nil {
-				if newerThan, err := goFiles.NewerThan(fi); err == nil && !newerThan {
-					// if the build artifact for the product already exists and none of the source files for the
-					// product are newer than the build artifact, consider spec up-to-date
-					continue
-				}
+		reason := fmt.Sprintf("failed to compute inputs: %v", scriptErr)
+		if scriptErr == nil {
+			reason, err = buildStaleReason(productTaskOutputInfo, *productParam.Build, currOSArch, scriptArgs, pathsMap[currOSArch])
+			if err != nil {
+				return nil, nil, err
 			}
 		}
+		if reason == "" {
+			continue
+		}
 		requiresBuildOSArchs = append(requiresBuildOSArchs, currOSArch)
+		reasons[currOSArch] = reason
 	}
 
 	if len(requiresBuildOSArchs) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 	productParam.Build.OSArchs = requiresBuildOSArchs
-	return &productParam, nil
+	return &productParam, reasons, nil
+}
+
+func buildStaleReason(productTaskOutputInfo distgo.ProductTaskOutputInfo, buildParam distgo.BuildParam, osArch osarch.OSArch, scriptArgs []string, artifactPath string) (string, error) {
+	inputs, _, err := buildInputs(productTaskOutputInfo, buildParam, osArch, scriptArgs)
+	if err != nil {
+		return fmt.Sprintf("failed to compute inputs: %v", err), nil
+	}
+	return state.StaleReason(buildStateFilePath(artifactPath), inputs, buildOutputPaths(buildParam, artifactPath))
+}
+
+// buildInputs returns the digests of the inputs used to build the provided OS/arch of a product along with the files
+// that are inputs to the build. scriptArgs is the output of the build arguments script of the product.
+func buildInputs(productTaskOutputInfo distgo.ProductTaskOutputInfo, buildParam distgo.BuildParam, osArch osarch.OSArch, scriptArgs []string) (map[string]string, imports.Files, error) {
+	projectInfo := productTaskOutputInfo.Project
+	tags, err := buildParam.TagsForOSArch(osArch, scriptArgs)
+	if err != nil {
+		return nil, imports.Files{}, err
+	}
+	// resolve the files for the target OS/arch, build environment and build tags so that files and dependencies that
+	// are only included for the target (and module files such as "go.mod" and "go.sum") are considered
+	files, err := imports.Resolve(path.Join(projectInfo.ProjectDir, buildParam.MainPkg), imports.Options{
+		GOOS:   osArch.OS,
+		GOARCH: osArch.Arch,
+		Env:    buildParam.EnvironmentFor(osArch),
+		Tags:   tags,
+	})
+	if err != nil {
+		return nil, imports.Files{}, err
+	}
+	goFilesDigest, err := state.FilesDigest(inputFilePaths(projectInfo.ProjectDir, files))
+	if err != nil {
+		return nil, imports.Files{}, err
+	}
+	buildConfigDigest, err := state.JSONDigest(struct {
+		MainPkg         string
+		BuildArgsScript string
+		VersionVar      string
+		Environment     map[string]string
+		Script          string
+		OSArch          string
+		BuildArgs       []string                `json:",omitempty"`
+		LDFlags         string                  `json:",omitempty"`
+		XVars           map[string]string       `json:",omitempty"`
+		Tags            []string                `json:",omitempty"`
+		GCFlags         string                  `json:",omitempty"`
+		ASMFlags        string                  `json:",omitempty"`
+		Trimpath        bool                    `json:",omitempty"`
+		BuildMode       string                  `json:",omitempty"`
+		Provenance      *distgo.ProvenanceParam `json:",omitempty"`
+	}{
+		MainPkg:         buildParam.MainPkg,
+		BuildArgsScript: buildParam.BuildArgsScript,
+		VersionVar:      buildParam.VersionVar,
+		Environment:     buildParam.EnvironmentFor(osArch),
+		Script:          buildParam.Script,
+		OSArch:          osArch.String(),
+		BuildArgs:       buildParam.OverrideFor(osArch).BuildArgs,
+		LDFlags:         buildParam.LDFlags,
+		XVars:           buildParam.XVars,
+		Tags:            buildParam.Tags,
+		GCFlags:         buildParam.GCFlags,
+		ASMFlags:        buildParam.ASMFlags,
+		Trimpath:        buildParam.Trimpath,
+		BuildMode:       buildParam.BuildMode,
+		Provenance:      buildParam.Provenance,
+	})
+	if err != nil {
+		return nil, imports.Files{}, err
+	}
+	return map[string]string{
+		"go-files":     goFilesDigest,
+		"build-config": buildConfigDigest,
+		"version":      state.StringDigest(projectInfo.Version),
+	}, files, nil
+}
+
+// inputFilePaths returns a map from the name of each of the provided files to its path. The name of a file in the
+// project directory is its path relative to the project directory.
+func inputFilePaths(projectDir string, files imports.Files) map[string]string {
+	filePaths := make(map[string]string)
+	for _, currPath := range files.Paths() {
+		name := currPath
+		if relPath, err := filepath.Rel(projectDir, currPath); err == nil {
+			name = relPath
+		}
+		filePaths[name] = currPath
+	}
+	return filePaths
+}
+
+// buildOutputPaths returns the paths of the outputs of building the executable at the provided path, which are the
+// executable and its provenance file (if provenance is configured).
+func buildOutputPaths(buildParam distgo.BuildParam, artifactPath string) []string {
+	outputPaths := []string{artifactPath}
+	if buildParam.Provenance != nil {
+		outputPaths = append(outputPaths, artifactPath+distgo.ProvenanceFileSuffix)
+	}
+	return outputPaths
+}
+
+// buildStateFilePath returns the path of the state file for the build artifact at the provided path. The state file is
+// written to the OS/arch-specific directory that contains the build artifact.
+func buildStateFilePath(artifactPath string) string {
+	return path.Join(path.Dir(artifactPath), state.FileName)
 }
@@ -0,0 +1,170 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distgo
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/git"
+)
+
+// BuildModes are the valid values for BuildParam.BuildMode.
+var BuildModes = []string{"archive", "c-archive", "c-shared", "default", "exe", "pie", "plugin", "shared"}
+
+// BuildFlagTemplateFunctions returns the template functions that can be used in the templates for the linker flags
+// and "-X" variable values of a product:
+//   - {{Product}}: the name of the product
+//   - {{Version}}: the version of the project
+//   - {{Commit}}: the full hash of the HEAD commit of the git repository that contains the project directory
+//   - {{Date}}: the time of the HEAD commit in RFC 3339 format in UTC. If the SOURCE_DATE_EPOCH environment variable
+//     is set, its value is used instead so that the output is reproducible.
+//
+// The git repository is only queried if the {{Commit}} or {{Date}} functions are used.
+func BuildFlagTemplateFunctions(productTaskOutputInfo ProductTaskOutputInfo) []TemplateFunction {
+	projectDir := productTaskOutputInfo.Project.ProjectDir
+	return []TemplateFunction{
+		ProductTemplateFunction(productTaskOutputInfo.Product.ID),
+		VersionTemplateFunction(productTaskOutputInfo.Project.Version),
+		func(fnMap template.FuncMap) {
+			fnMap["Commit"] = func() (string, error) {
+				commit, err := git.CmdOutput(projectDir, "rev-parse", "HEAD")
+				if err != nil {
+					return "", errors.Wrapf(err, "failed to determine commit of project")
+				}
+				return commit, nil
+			}
+		},
+		func(fnMap template.FuncMap) {
+			fnMap["Date"] = func() (string, error) {
+				date, err := buildDate(projectDir)
+				if err != nil {
+					return "", err
+				}
+				return date.Format(time.RFC3339), nil
+			}
+		},
+	}
+}
+
+func buildDate(projectDir string) (time.Time, error) {
+	if epoch, ok, err := SourceDateEpoch(); err != nil || ok {
+		return epoch, err
+	}
+	commitTime, err := git.CommitTime(projectDir)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to determine commit time of project")
+	}
+	return commitTime, nil
+}
+
+// SourceDateEpochEnvVar is the environment variable that specifies the timestamp (in seconds since the Unix epoch)
+// that is used as the time of reproducible outputs.
+const SourceDateEpochEnvVar = "SOURCE_DATE_EPOCH"
+
+// SourceDateEpoch returns the time specified by the SOURCE_DATE_EPOCH environment variable and true if it is set.
+// Returns an error if the variable is set to a value that is not an integer.
+func SourceDateEpoch() (time.Time, bool, error) {
+	epoch := os.Getenv(SourceDateEpochEnvVar)
+	if epoch == "" {
+		return time.Time{}, false, nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, false, errors.Wrapf(err, "failed to parse value of %s %q as an integer", SourceDateEpochEnvVar, epoch)
+	}
+	return time.Unix(seconds, 0).UTC(), true, nil
+}
+
+// linkerXFlag returns the "-X" linker flag that sets the provided variable to the provided value. The flag is quoted
+// if the value contains whitespace or quotes.
+func linkerXFlag(variable, value string) (string, error) {
+	flag := variable + "=" + value
+	if !strings.ContainsAny(flag, "'\"") && strings.IndexFunc(flag, unicode.IsSpace) == -1 {
+		return "-X " + flag, nil
+	}
+	switch {
+	case !strings.Contains(flag, "'"):
+		return "-X '" + flag + "'", nil
+	case !strings.Contains(flag, `"`):
+		return `-X "` + flag + `"`, nil
+	default:
+		return "", errors.Errorf("value of %s cannot be provided as a linker flag because it contains both single and double quotes: %q", variable, value)
+	}
+}
+
+// extractMergedBuildFlags returns the provided build arguments with the "-ldflags" and "-tags" flags removed along with
+// the values of the removed flags (in the order in which they appear). Both the "-flag value" and "-flag=value" forms
+// are recognized.
+func extractMergedBuildFlags(args []string) (otherArgs, ldFlags, tags []string, rErr error) {
+	for i := 0; i < len(args); i++ {
+		name, val, hasVal := args[i], "", false
+		if idx := strings.Index(name, "="); idx != -1 {
+			name, val, hasVal = name[:idx], name[idx+1:], true
+		}
+		switch name {
+		case "-ldflags", "--ldflags":
+			name = "ldflags"
+		case "-tags", "--tags":
+			name = "tags"
+		default:
+			otherArgs = append(otherArgs, args[i])
+			continue
+		}
+		if !hasVal {
+			if i+1 >= len(args) {
+				return nil, nil, nil, errors.Errorf("build argument -%s must be followed by a value", name)
+			}
+			i++
+			val = args[i]
+		}
+		if name == "ldflags" {
+			ldFlags = append(ldFlags, val)
+		} else {
+			tags = append(tags, val)
+		}
+	}
+	return otherArgs, ldFlags, tags, nil
+}
+
+// mergeBuildTags returns the comma-separated list of the unique build tags in the provided values, each of which may
+// be a comma-separated or space-separated list of tags.
+func mergeBuildTags(vals []string) string {
+	var tags []string
+	seen := make(map[string]struct{})
+	for _, val := range vals {
+		for _, tag := range strings.FieldsFunc(val, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		}) {
+			if _, ok := seen[tag]; ok {
+				continue
+			}
+			seen[tag] = struct{}{}
+			tags = append(tags, tag)
+		}
+	}
+	return strings.Join(tags, ",")
+}
@@ -0,0 +1,141 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distgo_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/palantir/pkg/gittest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/git"
+)
+
+func TestBuildArgsForOSArch(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	gittest.InitGitDir(t, tmp)
+	commit, err := git.CmdOutput(tmp, "rev-parse", "HEAD")
+	require.NoError(t, err)
+
+	origEpoch, hadEpoch := os.LookupEnv("SOURCE_DATE_EPOCH")
+	defer func() {
+		if hadEpoch {
+			_ = os.Setenv("SOURCE_DATE_EPOCH", origEpoch)
+		} else {
+			_ = os.Unsetenv("SOURCE_DATE_EPOCH")
+		}
+	}()
+	err = os.Setenv("SOURCE_DATE_EPOCH", "1514764800")
+	require.NoError(t, err)
+
+	productTaskOutputInfo := distgo.ProductTaskOutputInfo{
+		Project: distgo.ProjectInfo{
+			ProjectDir: tmp,
+			Version:    "1.0.0",
+		},
+		Product: distgo.ProductOutputInfo{
+			ID: "foo",
+		},
+	}
+	osArch := osarch.OSArch{OS: "linux", Arch: "amd64"}
+
+	for i, tc := range []struct {
+		name      string
+		param     distgo.BuildParam
+		want      []string
+		wantError string
+	}{
+		{
+			name: "version variable only",
+			param: distgo.BuildParam{
+				VersionVar: "main.version",
+			},
+			want: []string{"-ldflags", "-X main.version=1.0.0"},
+		},
+		{
+			name: "linker flags from version variable and script are merged",
+			param: distgo.BuildParam{
+				VersionVar: "main.version",
+				BuildArgsScript: `#!/usr/bin/env bash
+echo "-race"
+echo "-ldflags"
+echo "-X main.year=2018"`,
+			},
+			want: []string{"-ldflags", "-X main.version=1.0.0 -X main.year=2018", "-race"},
+		},
+		{
+			name: "all flags",
+			param: distgo.BuildParam{
+				XVars: map[string]string{
+					"main.commit": "{{Commit}}",
+					"main.date":   "{{Date}}",
+					"main.name":   "{{Product}} {{Version}}",
+				},
+				LDFlags:   "-s -w",
+				Tags:      []string{"netgo"},
+				GCFlags:   "all=-N -l",
+				ASMFlags:  "all=-trimpath",
+				Trimpath:  true,
+				BuildMode: "pie",
+				BuildArgsScript: `#!/usr/bin/env bash
+echo "-tags=osusergo netgo"`,
+				OSArchOverrides: map[string]distgo.OSArchBuildOverride{
+					"linux": {
+						BuildArgs: []string{"--ldflags", "-extldflags=-static", "-tags", "linux_only"},
+					},
+				},
+			},
+			want: []string{
+				"-trimpath",
+				"-buildmode=pie",
+				"-gcflags", "all=-N -l",
+				"-asmflags", "all=-trimpath",
+				"-tags", "netgo,osusergo,linux_only",
+				"-ldflags", "-X main.commit=" + commit + " -X main.date=2018-01-01T00:00:00Z -X 'main.name=foo 1.0.0' -s -w -extldflags=-static",
+			},
+		},
+		{
+			name: "flag without value",
+			param: distgo.BuildParam{
+				BuildArgsScript: `#!/usr/bin/env bash
+echo "-ldflags"`,
+			},
+			wantError: "build argument -ldflags must be followed by a value",
+		},
+	} {
+		scriptArgs, err := tc.param.ScriptArgs(productTaskOutputInfo)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		got, err := tc.param.BuildArgsForOSArch(productTaskOutputInfo, osArch, scriptArgs, nil)
+		if tc.wantError != "" {
+			assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, got, "Case %d: %s", i, tc.name)
+	}
+}
@@ -205,7 +205,7 @@ func TestClean(t *testing.T) {
 				gittest.CreateGitTag(t, projectDir, "0.1.0")
 			},
 			func(projectInfo distgo.ProjectInfo, projectParam distgo.ProjectParam) {
-				err := dist.Products(projectInfo, projectParam, nil, nil, false, ioutil.Discard)
+				err := dist.Products(projectInfo, projectParam, nil, nil, dist.Options{}, ioutil.Discard)
 				require.NoError(t, err)
 
 				productTaskOutputInfo, err := distgo.ToProductTaskOutputInfo(projectInfo, projectParam.Products["foo"])
@@ -263,7 +263,7 @@ func TestClean(t *testing.T) {
 				gittest.CreateGitTag(t, projectDir, "0.1.0")
 			},
 			func(projectInfo distgo.ProjectInfo, projectParam distgo.ProjectParam) {
-				err := dist.Products(projectInfo, projectParam, nil, nil, false, ioutil.Discard)
+				err := dist.Products(projectInfo, projectParam, nil, nil, dist.Options{}, ioutil.Discard)
 				require.NoError(t, err)
 
 				productTaskOutputInfo, err := distgo.ToProductTaskOutputInfo(projectInfo, projectParam.Products["foo"])
@@ -277,7 +277,7 @@ func TestClean(t *testing.T) {
 				require.NoError(t, err, "expected dist output to exist at %s", distArtifactPath)
 
 				projectInfo.Version = "0.1.0-dirty"
-				err = dist.Products(projectInfo, projectParam, nil, nil, false, ioutil.Discard)
+				err = dist.Products(projectInfo, projectParam, nil, nil, dist.Options{}, ioutil.Discard)
 				require.NoError(t, err)
 
 				productTaskOutputInfo, err = distgo.ToProductTaskOutputInfo(projectInfo, projectParam.Products["foo"])
@@ -1006,6 +1006,630 @@ products:
 	}
 }
 
+func TestProjectConfig_InvalidChecksums(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		yml       string
+		wantError string
+	}{
+		{
+			"unsupported checksum algorithm",
+			`
+products:
+  test-1:
+    dist:
+      disters:
+        type: os-arch-bin
+        checksums:
+          algorithms:
+            - md5
+`,
+			`failed to generate parameter for dist configuration os-arch-bin: invalid checksum algorithm "md5": valid values are [sha256 sha512]`,
+		},
+		{
+			"duplicate checksum algorithm",
+			`
+products:
+  test-1:
+    dist:
+      disters:
+        type: os-arch-bin
+        checksums:
+          algorithms:
+            - sha256
+            - sha256
+`,
+			`failed to generate parameter for dist configuration os-arch-bin: checksum algorithm "sha256" is specified more than once`,
+		},
+	} {
+		var gotCfg distgoconfig.ProjectConfig
+		err := yaml.Unmarshal([]byte(tc.yml), &gotCfg)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		_, err = testfuncs.NewProjectParamReturnError(t, gotCfg, "", fmt.Sprintf("Case %d: %s", i, tc.name))
+		assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfig_InvalidInputDir(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		yml       string
+		wantError string
+	}{
+		{
+			"invalid template pattern",
+			`
+products:
+  test-1:
+    dist:
+      disters:
+        type: os-arch-bin
+        input-dir:
+          path: input-dir
+          templates:
+            - "[a-"
+`,
+			`failed to generate parameter for dist configuration os-arch-bin: invalid input-dir template pattern "[a-": syntax error in pattern`,
+		},
+	} {
+		var gotCfg distgoconfig.ProjectConfig
+		err := yaml.Unmarshal([]byte(tc.yml), &gotCfg)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		_, err = testfuncs.NewProjectParamReturnError(t, gotCfg, "", fmt.Sprintf("Case %d: %s", i, tc.name))
+		assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfig_InvalidSBOM(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		yml       string
+		wantError string
+	}{
+		{
+			"unsupported SBOM format",
+			`
+products:
+  test-1:
+    dist:
+      disters:
+        type: os-arch-bin
+        sbom:
+          formats:
+            - swid
+`,
+			`failed to generate parameter for dist configuration os-arch-bin: invalid SBOM format "swid": valid values are [spdx cyclonedx]`,
+		},
+		{
+			"duplicate SBOM format",
+			`
+products:
+  test-1:
+    dist:
+      disters:
+        type: os-arch-bin
+        sbom:
+          formats:
+            - spdx
+            - spdx
+`,
+			`failed to generate parameter for dist configuration os-arch-bin: SBOM format "spdx" is specified more than once`,
+		},
+	} {
+		var gotCfg distgoconfig.ProjectConfig
+		err := yaml.Unmarshal([]byte(tc.yml), &gotCfg)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		_, err = testfuncs.NewProjectParamReturnError(t, gotCfg, "", fmt.Sprintf("Case %d: %s", i, tc.name))
+		assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfig_InvalidLicenses(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		yml       string
+		wantError string
+	}{
+		{
+			"unsupported license type",
+			`
+products:
+  test-1:
+    dist:
+      disters:
+        type: os-arch-bin
+        licenses:
+          disallowed:
+            - GPL-3.0
+            - WTFPL
+`,
+			`failed to generate parameter for dist configuration os-arch-bin: invalid license type "WTFPL": valid values are [AGPL-3.0 Apache-2.0 BSD-2-Clause BSD-3-Clause GPL-2.0 GPL-3.0 ISC LGPL-2.1 LGPL-3.0 MIT MPL-2.0 Unlicense unknown]`,
+		},
+	} {
+		var gotCfg distgoconfig.ProjectConfig
+		err := yaml.Unmarshal([]byte(tc.yml), &gotCfg)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		_, err = testfuncs.NewProjectParamReturnError(t, gotCfg, "", fmt.Sprintf("Case %d: %s", i, tc.name))
+		assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfig_InvalidSigning(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		yml       string
+		wantError string
+	}{
+		{
+			"unsupported signing type",
+			`
+products:
+  test-1:
+    dist:
+      signing:
+        type: unknown
+`,
+			`invalid signing type "unknown": valid values are [gpg minisign cosign]`,
+		},
+		{
+			"minisign requires key file",
+			`
+products:
+  test-1:
+    dist:
+      signing:
+        type: minisign
+`,
+			`key-file must be specified for signing type "minisign"`,
+		},
+		{
+			"gpg-specific configuration cannot be used for cosign",
+			`
+products:
+  test-1:
+    dist:
+      signing:
+        type: cosign
+        key-file: cosign.key
+        key-id: test@example.com
+`,
+			`key-id and gpg-home can only be specified for signing type "gpg"`,
+		},
+	} {
+		var gotCfg distgoconfig.ProjectConfig
+		err := yaml.Unmarshal([]byte(tc.yml), &gotCfg)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		_, err = testfuncs.NewProjectParamReturnError(t, gotCfg, "", fmt.Sprintf("Case %d: %s", i, tc.name))
+		assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfig_OSArchOverrides(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		yml       string
+		want      map[string]distgo.OSArchBuildOverride
+		wantError string
+	}{
+		{
+			name: "OS and OS/Arch overrides",
+			yml: `
+products:
+  test-1:
+    build:
+      os-archs:
+        - os: darwin
+          arch: amd64
+        - os: linux
+          arch: amd64
+      os-arch-overrides:
+        darwin:
+          environment:
+            CGO_ENABLED: "1"
+            CC: o64-clang
+        linux-amd64:
+          build-args:
+            - "-tags"
+            - "netgo"
+`,
+			want: map[string]distgo.OSArchBuildOverride{
+				"darwin": {
+					Environment: map[string]string{
+						"CGO_ENABLED": "1",
+						"CC":          "o64-clang",
+					},
+				},
+				"linux-amd64": {
+					BuildArgs: []string{"-tags", "netgo"},
+				},
+			},
+		},
+		{
+			name: "product defaults are used",
+			yml: `
+products:
+  test-1:
+    build:
+      os-archs:
+        - os: linux
+          arch: amd64
+product-defaults:
+  build:
+    os-arch-overrides:
+      linux:
+        build-args:
+          - "-tags"
+          - "netgo"
+`,
+			want: map[string]distgo.OSArchBuildOverride{
+				"linux": {
+					BuildArgs: []string{"-tags", "netgo"},
+				},
+			},
+		},
+		{
+			name: "key that does not match os-archs",
+			yml: `
+products:
+  test-1:
+    build:
+      os-archs:
+        - os: darwin
+          arch: amd64
+      os-arch-overrides:
+        darwin-386:
+          build-args:
+            - "-tags"
+            - "netgo"
+`,
+			wantError: `os-arch-overrides key "darwin-386" does not match the OS or OS/Arch of any of the os-archs [darwin-amd64]`,
+		},
+	} {
+		var gotCfg distgoconfig.ProjectConfig
+		err := yaml.Unmarshal([]byte(tc.yml), &gotCfg)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		projectParam, err := testfuncs.NewProjectParamReturnError(t, gotCfg, "", fmt.Sprintf("Case %d: %s", i, tc.name))
+		if tc.wantError != "" {
+			assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		productParam := projectParam.Products["test-1"]
+		require.NotNil(t, productParam.Build, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, productParam.Build.OSArchOverrides, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfig_BuildFlags(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		yml       string
+		want      distgo.BuildParam
+		wantError string
+	}{
+		{
+			name: "build flags",
+			yml: `
+products:
+  test-1:
+    build:
+      ldflags: "-s -w"
+      x-vars:
+        main.commit: "{{Commit}}"
+      tags:
+        - netgo
+      gcflags: "all=-N -l"
+      asmflags: "all=-trimpath"
+      trimpath: true
+      buildmode: pie
+`,
+			want: distgo.BuildParam{
+				LDFlags: "-s -w",
+				XVars: map[string]string{
+					"main.commit": "{{Commit}}",
+				},
+				Tags:      []string{"netgo"},
+				GCFlags:   "all=-N -l",
+				ASMFlags:  "all=-trimpath",
+				Trimpath:  true,
+				BuildMode: "pie",
+			},
+		},
+		{
+			name: "product defaults are used",
+			yml: `
+products:
+  test-1:
+    build:
+      tags:
+        - osusergo
+product-defaults:
+  build:
+    tags:
+      - netgo
+    trimpath: true
+`,
+			want: distgo.BuildParam{
+				Tags:     []string{"osusergo"},
+				Trimpath: true,
+			},
+		},
+		{
+			name: "invalid buildmode",
+			yml: `
+products:
+  test-1:
+    build:
+      buildmode: wasm
+`,
+			wantError: `invalid buildmode "wasm": valid values are [archive c-archive c-shared default exe pie plugin shared]`,
+		},
+	} {
+		var gotCfg distgoconfig.ProjectConfig
+		err := yaml.Unmarshal([]byte(tc.yml), &gotCfg)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		projectParam, err := testfuncs.NewProjectParamReturnError(t, gotCfg, "", fmt.Sprintf("Case %d: %s", i, tc.name))
+		if tc.wantError != "" {
+			assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		got := projectParam.Products["test-1"].Build
+		require.NotNil(t, got, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want.LDFlags, got.LDFlags, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want.XVars, got.XVars, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want.Tags, got.Tags, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want.GCFlags, got.GCFlags, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want.ASMFlags, got.ASMFlags, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want.Trimpath, got.Trimpath, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want.BuildMode, got.BuildMode, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfig_Provenance(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		yml       string
+		want      *distgo.ProvenanceParam
+		wantError string
+	}{
+		{
+			name: "provenance variables",
+			yml: `
+products:
+  test-1:
+    build:
+      provenance:
+        vars:
+          commit: main.commit
+          commit-date: main.commitDate
+          build-date: main.buildDate
+          dirty: main.dirty
+          builder: main.builder
+        builder: https://ci.example.com
+`,
+			want: &distgo.ProvenanceParam{
+				CommitVar:     "main.commit",
+				CommitDateVar: "main.commitDate",
+				BuildDateVar:  "main.buildDate",
+				DirtyVar:      "main.dirty",
+				BuilderVar:    "main.builder",
+				Builder:       "https://ci.example.com",
+			},
+		},
+		{
+			name: "product defaults are used",
+			yml: `
+products:
+  test-1:
+    build:
+      main-pkg: ./test-1
+product-defaults:
+  build:
+    provenance:
+      vars:
+        commit: main.commit
+`,
+			want: &distgo.ProvenanceParam{
+				CommitVar: "main.commit",
+			},
+		},
+		{
+			name: "provenance is not written if not configured",
+			yml: `
+products:
+  test-1:
+    build:
+      main-pkg: ./test-1
+`,
+		},
+		{
+			name: "variable used for multiple fields",
+			yml: `
+products:
+  test-1:
+    build:
+      provenance:
+        vars:
+          commit-date: main.date
+          build-date: main.date
+`,
+			wantError: "provenance vars commit-date and build-date cannot both set the variable main.date",
+		},
+	} {
+		var gotCfg distgoconfig.ProjectConfig
+		err := yaml.Unmarshal([]byte(tc.yml), &gotCfg)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		projectParam, err := testfuncs.NewProjectParamReturnError(t, gotCfg, "", fmt.Sprintf("Case %d: %s", i, tc.name))
+		if tc.wantError != "" {
+			assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		productParam := projectParam.Products["test-1"]
+		require.NotNil(t, productParam.Build, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, productParam.Build.Provenance, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfig_BuildCache(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		yml       string
+		want      *distgo.BuildCacheParam
+		wantError string
+	}{
+		{
+			name: "directory cache",
+			yml: `
+products:
+  test-1:
+    build:
+      cache:
+        dir: out/cache
+`,
+			want: &distgo.BuildCacheParam{
+				Dir: "out/cache",
+			},
+		},
+		{
+			name: "HTTP cache from product defaults",
+			yml: `
+products:
+  test-1:
+    build:
+      main-pkg: ./test-1
+product-defaults:
+  build:
+    cache:
+      url: https://cache.example.com/distgo
+      token-env-var: CACHE_TOKEN
+      read-only: true
+`,
+			want: &distgo.BuildCacheParam{
+				URL:         "https://cache.example.com/distgo",
+				TokenEnvVar: "CACHE_TOKEN",
+				ReadOnly:    true,
+			},
+		},
+		{
+			name: "directory and URL",
+			yml: `
+products:
+  test-1:
+    build:
+      cache:
+        dir: out/cache
+        url: https://cache.example.com/distgo
+`,
+			wantError: "cache must specify exactly one of dir or url",
+		},
+		{
+			name: "invalid URL",
+			yml: `
+products:
+  test-1:
+    build:
+      cache:
+        url: cache.example.com/distgo
+`,
+			wantError: `cache url "cache.example.com/distgo" must be an http or https URL`,
+		},
+	} {
+		var gotCfg distgoconfig.ProjectConfig
+		err := yaml.Unmarshal([]byte(tc.yml), &gotCfg)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		projectParam, err := testfuncs.NewProjectParamReturnError(t, gotCfg, "", fmt.Sprintf("Case %d: %s", i, tc.name))
+		if tc.wantError != "" {
+			assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		productParam := projectParam.Products["test-1"]
+		require.NotNil(t, productParam.Build, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, productParam.Build.Cache, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfig_SizeBudget(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		yml       string
+		wantBuild *distgo.SizeBudgetParam
+		wantDist  *distgo.SizeBudgetParam
+		wantError string
+	}{
+		{
+			name: "shorthand and full form",
+			yml: `
+products:
+  test-1:
+    build:
+      max-size: 20MiB
+    dist:
+      max-size:
+        size: 50MB
+        max-growth: 10%
+        warn: true
+`,
+			wantBuild: &distgo.SizeBudgetParam{MaxSize: 20 << 20},
+			wantDist:  &distgo.SizeBudgetParam{MaxSize: 50000000, MaxGrowth: 10, Warn: true},
+		},
+		{
+			name: "product defaults are used",
+			yml: `
+products:
+  test-1:
+    build:
+      main-pkg: ./test-1
+product-defaults:
+  build:
+    max-size:
+      max-growth: "5"
+`,
+			wantBuild: &distgo.SizeBudgetParam{MaxGrowth: 5},
+		},
+		{
+			name: "invalid size",
+			yml: `
+products:
+  test-1:
+    build:
+      max-size: 20PB
+`,
+			wantError: `invalid max-size: invalid unit "PB" in size "20PB": valid units are B, KB, MB, GB, KiB, MiB and GiB`,
+		},
+		{
+			name: "invalid growth",
+			yml: `
+products:
+  test-1:
+    dist:
+      max-size:
+        max-growth: -5%
+`,
+			wantError: `invalid max-size: invalid max-growth "-5%": must be a positive percentage such as "10%"`,
+		},
+	} {
+		var gotCfg distgoconfig.ProjectConfig
+		err := yaml.Unmarshal([]byte(tc.yml), &gotCfg)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		projectParam, err := testfuncs.NewProjectParamReturnError(t, gotCfg, "", fmt.Sprintf("Case %d: %s", i, tc.name))
+		if tc.wantError != "" {
+			assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		productParam := projectParam.Products["test-1"]
+		require.NotNil(t, productParam.Build, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantBuild, productParam.Build.SizeBudget, "Case %d: %s", i, tc.name)
+		if tc.wantDist != nil {
+			require.NotNil(t, productParam.Dist, "Case %d: %s", i, tc.name)
+			assert.Equal(t, tc.wantDist, productParam.Dist.SizeBudget, "Case %d: %s", i, tc.name)
+		}
+	}
+}
+
 func TestProductTaskParam_ToProductTaskOutputInfo(t *testing.T) {
 	tmpDir, cleanup, err := dirs.TempDir("", "")
 	require.NoError(t, err)
@@ -21,6 +21,7 @@ package config
 
 import (
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/palantir/godel/pkg/osarch"
@@ -49,6 +50,27 @@ func (cfg *BuildConfig) ToParam(scriptIncludes string, defaultCfg BuildConfig) (
 	if mainPkg != "" && !strings.HasPrefix(mainPkg, "./") {
 		mainPkg = "./" + mainPkg
 	}
+	sizeBudget, err := sizeBudgetParam(cfg.MaxSize, defaultCfg.MaxSize)
+	if err != nil {
+		return distgo.BuildParam{}, err
+	}
+	buildMode := getConfigStringValue(cfg.BuildMode, defaultCfg.BuildMode, "")
+	if buildMode != "" && !validBuildMode(buildMode) {
+		return distgo.BuildParam{}, errors.Errorf("invalid buildmode %q: valid values are %v", buildMode, distgo.BuildModes)
+	}
+	provenance, err := provenanceParam(cfg.Provenance, defaultCfg.Provenance)
+	if err != nil {
+		return distgo.BuildParam{}, err
+	}
+	cache, err := buildCacheParam(cfg.Cache, defaultCfg.Cache)
+	if err != nil {
+		return distgo.BuildParam{}, err
+	}
+	osArchs := getConfigValue(cfg.OSArchs, defaultCfg.OSArchs, []osarch.OSArch{osarch.Current()}).([]osarch.OSArch)
+	osArchOverrides, err := osArchOverridesParam(getConfigValue(cfg.OSArchOverrides, defaultCfg.OSArchOverrides, nil).(map[string]v0.OSArchBuildOverrideConfig), osArchs)
+	if err != nil {
+		return distgo.BuildParam{}, err
+	}
 
 	return distgo.BuildParam{
 		NameTemplate:    getConfigStringValue(cfg.NameTemplate, defaultCfg.NameTemplate, "{{Product}}"),
@@ -56,8 +78,136 @@ func (cfg *BuildConfig) ToParam(scriptIncludes string, defaultCfg BuildConfig) (
 		MainPkg:         mainPkg,
 		BuildArgsScript: distgo.CreateScriptContent(getConfigStringValue(cfg.BuildArgsScript, defaultCfg.BuildArgsScript, ""), scriptIncludes),
 		VersionVar:      getConfigStringValue(cfg.VersionVar, defaultCfg.VersionVar, ""),
+		LDFlags:         getConfigStringValue(cfg.LDFlags, defaultCfg.LDFlags, ""),
+		XVars:           getConfigValue(cfg.XVars, defaultCfg.XVars, nil).(map[string]string),
+		Tags:            getConfigValue(cfg.Tags, defaultCfg.Tags, nil).([]string),
+		GCFlags:         getConfigStringValue(cfg.GCFlags, defaultCfg.GCFlags, ""),
+		ASMFlags:        getConfigStringValue(cfg.ASMFlags, defaultCfg.ASMFlags, ""),
+		Trimpath:        getConfigValue(cfg.Trimpath, defaultCfg.Trimpath, false).(bool),
+		BuildMode:       buildMode,
 		Script:          getConfigStringValue(cfg.Script, defaultCfg.Script, ""),
 		Environment:     getConfigValue(cfg.Environment, defaultCfg.Environment, nil).(map[string]string),
-		OSArchs:         getConfigValue(cfg.OSArchs, defaultCfg.OSArchs, []osarch.OSArch{osarch.Current()}).([]osarch.OSArch),
+		OSArchs:         osArchs,
+		SizeBudget:      sizeBudget,
+		OSArchOverrides: osArchOverrides,
+		Provenance:      provenance,
+		Cache:           cache,
+	}, nil
+}
+
+// buildCacheParam returns the build cache parameter represented by the provided configuration (or the default
+// configuration if the configuration is nil). Returns nil if both are nil and an error if the configuration does not
+// specify exactly one of a directory or URL.
+func buildCacheParam(cfg, defaultCfg *v0.BuildCacheConfig) (*distgo.BuildCacheParam, error) {
+	if cfg == nil && defaultCfg == nil {
+		return nil, nil
+	}
+	if cfg == nil {
+		cfg = defaultCfg
+	}
+	if (cfg.Dir == "") == (cfg.URL == "") {
+		return nil, errors.Errorf("cache must specify exactly one of dir or url")
+	}
+	if cfg.URL != "" && !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, errors.Errorf("cache url %q must be an http or https URL", cfg.URL)
+	}
+	return &distgo.BuildCacheParam{
+		Dir:         cfg.Dir,
+		URL:         cfg.URL,
+		TokenEnvVar: cfg.TokenEnvVar,
+		ReadOnly:    cfg.ReadOnly,
+	}, nil
+}
+
+// provenanceParam returns the provenance parameter represented by the provided configuration (or the default
+// configuration if the configuration is nil). Returns nil if both are nil and an error if multiple provenance fields
+// are set using the same variable.
+func provenanceParam(cfg, defaultCfg *v0.ProvenanceConfig) (*distgo.ProvenanceParam, error) {
+	if cfg == nil && defaultCfg == nil {
+		return nil, nil
+	}
+	if cfg == nil {
+		cfg = defaultCfg
+	}
+	seen := make(map[string]string)
+	for _, currVar := range []struct {
+		field    string
+		variable string
+	}{
+		{"commit", cfg.Vars.Commit},
+		{"commit-date", cfg.Vars.CommitDate},
+		{"build-date", cfg.Vars.BuildDate},
+		{"dirty", cfg.Vars.Dirty},
+		{"builder", cfg.Vars.Builder},
+	} {
+		if currVar.variable == "" {
+			continue
+		}
+		if prevField, ok := seen[currVar.variable]; ok {
+			return nil, errors.Errorf("provenance vars %s and %s cannot both set the variable %s", prevField, currVar.field, currVar.variable)
+		}
+		seen[currVar.variable] = currVar.field
+	}
+	return &distgo.ProvenanceParam{
+		CommitVar:     cfg.Vars.Commit,
+		CommitDateVar: cfg.Vars.CommitDate,
+		BuildDateVar:  cfg.Vars.BuildDate,
+		DirtyVar:      cfg.Vars.Dirty,
+		BuilderVar:    cfg.Vars.Builder,
+		Builder:       cfg.Builder,
 	}, nil
 }
+
+func validBuildMode(buildMode string) bool {
+	for _, currBuildMode := range distgo.BuildModes {
+		if buildMode == currBuildMode {
+			return true
+		}
+	}
+	return false
+}
+
+type OSArchBuildOverrideConfig v0.OSArchBuildOverrideConfig
+
+func ToOSArchOverrides(in *map[string]OSArchBuildOverrideConfig) *map[string]v0.OSArchBuildOverrideConfig {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]v0.OSArchBuildOverrideConfig, len(*in))
+	for k, v := range *in {
+		out[k] = v0.OSArchBuildOverrideConfig(v)
+	}
+	return &out
+}
+
+// osArchOverridesParam returns the OS/Arch overrides represented by the provided configuration. Returns an error if
+// a key is not an OS or OS/Arch that matches one of the provided OS/Archs.
+func osArchOverridesParam(cfgs map[string]v0.OSArchBuildOverrideConfig, osArchs []osarch.OSArch) (map[string]distgo.OSArchBuildOverride, error) {
+	if len(cfgs) == 0 {
+		return nil, nil
+	}
+	var keys []string
+	for k := range cfgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	overrides := make(map[string]distgo.OSArchBuildOverride)
+	for _, key := range keys {
+		matches := false
+		for _, currOSArch := range osArchs {
+			if key == currOSArch.OS || key == currOSArch.String() {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			return nil, errors.Errorf("os-arch-overrides key %q does not match the OS or OS/Arch of any of the os-archs %v", key, osArchs)
+		}
+		overrides[key] = distgo.OSArchBuildOverride{
+			Environment: cfgs[key].Environment,
+			BuildArgs:   cfgs[key].BuildArgs,
+		}
+	}
+	return overrides, nil
+}
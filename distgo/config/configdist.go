@@ -21,6 +21,7 @@ package config
 
 import (
 	"path"
+	"strings"
 
 	"github.com/palantir/pkg/matcher"
 	"github.com/pkg/errors"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/config/internal/v0"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/licenses"
 )
 
 type DistConfig v0.DistConfig
@@ -49,9 +51,58 @@ func (cfg *DistConfig) ToParam(scriptIncludes string, defaultCfg DistConfig, dis
 	if err != nil {
 		return distgo.DistParam{}, err
 	}
+	var signingParam *distgo.SigningParam
+	if cfg.Signing != nil || defaultCfg.Signing != nil {
+		signingCfg := getConfigValue((*SigningConfig)(cfg.Signing), (*SigningConfig)(defaultCfg.Signing), SigningConfig{}).(SigningConfig)
+		signingParamVal, err := signingCfg.ToParam()
+		if err != nil {
+			return distgo.DistParam{}, err
+		}
+		signingParam = &signingParamVal
+	}
+	sizeBudget, err := sizeBudgetParam(cfg.MaxSize, defaultCfg.MaxSize)
+	if err != nil {
+		return distgo.DistParam{}, err
+	}
 	return distgo.DistParam{
 		OutputDir:  outputDir,
 		DistParams: disters,
+		Signing:    signingParam,
+		SizeBudget: sizeBudget,
+	}, nil
+}
+
+type SigningConfig v0.SigningConfig
+
+func ToSigningConfig(in *SigningConfig) *v0.SigningConfig {
+	return (*v0.SigningConfig)(in)
+}
+
+func (cfg *SigningConfig) ToParam() (distgo.SigningParam, error) {
+	validType := false
+	for _, signingType := range distgo.SigningTypes() {
+		if cfg.Type == signingType {
+			validType = true
+			break
+		}
+	}
+	if !validType {
+		return distgo.SigningParam{}, errors.Errorf("invalid signing type %q: valid values are %v", cfg.Type, distgo.SigningTypes())
+	}
+	if cfg.Type != distgo.SigningTypeGPG {
+		if cfg.KeyFile == "" {
+			return distgo.SigningParam{}, errors.Errorf("key-file must be specified for signing type %q", cfg.Type)
+		}
+		if cfg.KeyID != "" || cfg.GPGHome != "" {
+			return distgo.SigningParam{}, errors.Errorf("key-id and gpg-home can only be specified for signing type %q", distgo.SigningTypeGPG)
+		}
+	}
+	return distgo.SigningParam{
+		Type:           cfg.Type,
+		KeyFile:        cfg.KeyFile,
+		KeyID:          cfg.KeyID,
+		GPGHome:        cfg.GPGHome,
+		PasswordEnvVar: cfg.PasswordEnvVar,
 	}, nil
 }
 
@@ -66,17 +117,131 @@ func (cfg *DisterConfig) ToParam(defaultCfg DisterConfig, scriptIncludes string,
 	if disterType == "" {
 		return distgo.DisterParam{}, errors.Errorf("dister type must be specified for DisterConfig")
 	}
-	dister, err := newDister(disterType, getConfigValue(cfg.Config, defaultCfg.Config, nil).(yaml.MapSlice), disterFactory)
+	disterCfg := getConfigValue(cfg.Config, defaultCfg.Config, nil).(yaml.MapSlice)
+	disterCfgYML, err := yaml.Marshal(disterCfg)
+	if err != nil {
+		return distgo.DisterParam{}, errors.Wrapf(err, "failed to marshal configuration")
+	}
+	dister, err := newDister(disterType, disterCfgYML, disterFactory)
 	if err != nil {
 		return distgo.DisterParam{}, err
 	}
+	if disterCfg == nil {
+		// only record the configuration if one was specified
+		disterCfgYML = nil
+	}
 
 	inputDirCfg := getConfigValue((*InputDirConfig)(cfg.InputDir), (*InputDirConfig)(defaultCfg.InputDir), InputDirConfig{}).(InputDirConfig)
+	inputDirParam, err := inputDirCfg.ToParam()
+	if err != nil {
+		return distgo.DisterParam{}, err
+	}
+	checksumsCfg := getConfigValue((*ChecksumsConfig)(cfg.Checksums), (*ChecksumsConfig)(defaultCfg.Checksums), ChecksumsConfig{}).(ChecksumsConfig)
+	checksumsParam, err := checksumsCfg.ToParam()
+	if err != nil {
+		return distgo.DisterParam{}, err
+	}
+	sbomCfg := getConfigValue((*SBOMConfig)(cfg.SBOM), (*SBOMConfig)(defaultCfg.SBOM), SBOMConfig{}).(SBOMConfig)
+	sbomParam, err := sbomCfg.ToParam()
+	if err != nil {
+		return distgo.DisterParam{}, err
+	}
+	var licensesParam *distgo.LicensesParam
+	if cfg.Licenses != nil || defaultCfg.Licenses != nil {
+		licensesCfg := getConfigValue((*LicensesConfig)(cfg.Licenses), (*LicensesConfig)(defaultCfg.Licenses), LicensesConfig{}).(LicensesConfig)
+		licensesParamVal, err := licensesCfg.ToParam()
+		if err != nil {
+			return distgo.DisterParam{}, err
+		}
+		licensesParam = &licensesParamVal
+	}
 	return distgo.DisterParam{
 		NameTemplate: getConfigStringValue(cfg.NameTemplate, defaultCfg.NameTemplate, "{{Product}}-{{Version}}"),
-		InputDir:     inputDirCfg.ToParam(),
+		InputDir:     inputDirParam,
 		Script:       distgo.CreateScriptContent(getConfigStringValue(cfg.Script, defaultCfg.Script, ""), scriptIncludes),
 		Dister:       dister,
+		DisterConfig: disterCfgYML,
+		Checksums:    checksumsParam,
+		SBOM:         sbomParam,
+		Licenses:     licensesParam,
+	}, nil
+}
+
+type SBOMConfig v0.SBOMConfig
+
+func ToSBOMConfig(in *SBOMConfig) *v0.SBOMConfig {
+	return (*v0.SBOMConfig)(in)
+}
+
+func (cfg *SBOMConfig) ToParam() (distgo.SBOMParam, error) {
+	validFormats := make(map[string]struct{})
+	for _, format := range distgo.SBOMFormats() {
+		validFormats[format] = struct{}{}
+	}
+	seenFormats := make(map[string]struct{})
+	for _, format := range cfg.Formats {
+		if _, ok := validFormats[format]; !ok {
+			return distgo.SBOMParam{}, errors.Errorf("invalid SBOM format %q: valid values are %v", format, distgo.SBOMFormats())
+		}
+		if _, ok := seenFormats[format]; ok {
+			return distgo.SBOMParam{}, errors.Errorf("SBOM format %q is specified more than once", format)
+		}
+		seenFormats[format] = struct{}{}
+	}
+	return distgo.SBOMParam{
+		Formats: cfg.Formats,
+	}, nil
+}
+
+type LicensesConfig v0.LicensesConfig
+
+func ToLicensesConfig(in *LicensesConfig) *v0.LicensesConfig {
+	return (*v0.LicensesConfig)(in)
+}
+
+func (cfg *LicensesConfig) ToParam() (distgo.LicensesParam, error) {
+	validTypes := make(map[string]struct{})
+	for _, licenseType := range licenses.Types() {
+		validTypes[licenseType] = struct{}{}
+	}
+	for _, licenseType := range cfg.Disallowed {
+		if _, ok := validTypes[licenseType]; !ok {
+			return distgo.LicensesParam{}, errors.Errorf("invalid license type %q: valid values are %v", licenseType, licenses.Types())
+		}
+	}
+	if cfg.Dir != "" && (path.IsAbs(cfg.Dir) || path.Clean(cfg.Dir) == ".." || strings.HasPrefix(path.Clean(cfg.Dir), "../")) {
+		return distgo.LicensesParam{}, errors.Errorf("licenses dir %q must be a relative path within the dist work directory", cfg.Dir)
+	}
+	return distgo.LicensesParam{
+		Disallowed: cfg.Disallowed,
+		Dir:        cfg.Dir,
+	}, nil
+}
+
+type ChecksumsConfig v0.ChecksumsConfig
+
+func ToChecksumsConfig(in *ChecksumsConfig) *v0.ChecksumsConfig {
+	return (*v0.ChecksumsConfig)(in)
+}
+
+func (cfg *ChecksumsConfig) ToParam() (distgo.ChecksumsParam, error) {
+	validAlgorithms := make(map[string]struct{})
+	for _, algorithm := range distgo.ChecksumAlgorithms() {
+		validAlgorithms[algorithm] = struct{}{}
+	}
+	seenAlgorithms := make(map[string]struct{})
+	for _, algorithm := range cfg.Algorithms {
+		if _, ok := validAlgorithms[algorithm]; !ok {
+			return distgo.ChecksumsParam{}, errors.Errorf("invalid checksum algorithm %q: valid values are %v", algorithm, distgo.ChecksumAlgorithms())
+		}
+		if _, ok := seenAlgorithms[algorithm]; ok {
+			return distgo.ChecksumsParam{}, errors.Errorf("checksum algorithm %q is specified more than once", algorithm)
+		}
+		seenAlgorithms[algorithm] = struct{}{}
+	}
+	return distgo.ChecksumsParam{
+		Algorithms: cfg.Algorithms,
+		Manifest:   cfg.Manifest,
 	}, nil
 }
 
@@ -86,29 +251,32 @@ func ToInputDirConfig(in *InputDirConfig) *v0.InputDirConfig {
 	return (*v0.InputDirConfig)(in)
 }
 
-func (cfg *InputDirConfig) ToParam() distgo.InputDirParam {
+func (cfg *InputDirConfig) ToParam() (distgo.InputDirParam, error) {
 	var excludeMatcher matcher.Matcher
 	if !cfg.Exclude.Empty() {
 		excludeMatcher = cfg.Exclude.Matcher()
 	}
-	return distgo.InputDirParam{
-		Path:    cfg.Path,
-		Exclude: excludeMatcher,
+	for _, pattern := range cfg.Templates {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return distgo.InputDirParam{}, errors.Wrapf(err, "invalid input-dir template pattern %q", pattern)
+		}
 	}
+	return distgo.InputDirParam{
+		Path:             cfg.Path,
+		Exclude:          excludeMatcher,
+		Templates:        cfg.Templates,
+		PreserveSymlinks: cfg.PreserveSymlinks,
+	}, nil
 }
 
-func newDister(disterType string, cfgYML yaml.MapSlice, disterFactory distgo.DisterFactory) (distgo.Dister, error) {
+func newDister(disterType string, cfgYML []byte, disterFactory distgo.DisterFactory) (distgo.Dister, error) {
 	if disterType == "" {
 		return nil, errors.Errorf("dister type must be non-empty")
 	}
 	if disterFactory == nil {
 		return nil, errors.Errorf("disterFactory must be provided")
 	}
-	cfgYMLBytes, err := yaml.Marshal(cfgYML)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to marshal configuration")
-	}
-	return disterFactory.NewDister(disterType, cfgYMLBytes)
+	return disterFactory.NewDister(disterType, cfgYML)
 }
 
 type DistersConfig v0.DistersConfig
@@ -0,0 +1,76 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/config/internal/v0"
+)
+
+type SizeBudgetConfig v0.SizeBudgetConfig
+
+func ToSizeBudgetConfig(in *SizeBudgetConfig) *v0.SizeBudgetConfig {
+	return (*v0.SizeBudgetConfig)(in)
+}
+
+func (cfg *SizeBudgetConfig) ToParam() (distgo.SizeBudgetParam, error) {
+	if cfg.Size == "" && cfg.MaxGrowth == "" {
+		return distgo.SizeBudgetParam{}, errors.Errorf("max-size must specify a size, a maximum growth or both")
+	}
+	var maxSize int64
+	if cfg.Size != "" {
+		var err error
+		if maxSize, err = distgo.ParseSize(cfg.Size); err != nil {
+			return distgo.SizeBudgetParam{}, err
+		}
+	}
+	var maxGrowth float64
+	if cfg.MaxGrowth != "" {
+		var err error
+		maxGrowth, err = strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(cfg.MaxGrowth), "%")), 64)
+		if err != nil || maxGrowth <= 0 {
+			return distgo.SizeBudgetParam{}, errors.Errorf("invalid max-growth %q: must be a positive percentage such as \"10%%\"", cfg.MaxGrowth)
+		}
+	}
+	return distgo.SizeBudgetParam{
+		MaxSize:   maxSize,
+		MaxGrowth: maxGrowth,
+		Warn:      cfg.Warn,
+	}, nil
+}
+
+// sizeBudgetParam returns the SizeBudgetParam for the provided configuration and default configuration. Returns nil
+// if neither configuration is specified.
+func sizeBudgetParam(cfg, defaultCfg *v0.SizeBudgetConfig) (*distgo.SizeBudgetParam, error) {
+	if cfg == nil && defaultCfg == nil {
+		return nil, nil
+	}
+	sizeBudgetCfg := getConfigValue((*SizeBudgetConfig)(cfg), (*SizeBudgetConfig)(defaultCfg), SizeBudgetConfig{}).(SizeBudgetConfig)
+	param, err := sizeBudgetCfg.ToParam()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid max-size")
+	}
+	return &param, nil
+}
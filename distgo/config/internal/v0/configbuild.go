@@ -61,6 +61,43 @@ type BuildConfig struct {
 	// ldflag.
 	VersionVar *string `yaml:"version-var,omitempty"`
 
+	// LDFlags is a template for the flags that are provided to the linker. For example, "-s -w". The following
+	// template functions can be used in the template:
+	//   * {{Product}}: the name of the product
+	//   * {{Version}}: the version of the project
+	//   * {{Commit}}: the full hash of the HEAD commit of the project
+	//   * {{Date}}: the time of the HEAD commit of the project (or the value of the SOURCE_DATE_EPOCH environment
+	//     variable if it is set) in RFC 3339 format
+	//
+	// The flags generated by "version-var", "x-vars" and "ldflags" and the values of any "-ldflags" arguments
+	// provided by "build-args-script" or "os-arch-overrides" are merged into a single "-ldflags" argument.
+	LDFlags *string `yaml:"ldflags,omitempty"`
+
+	// XVars is a map from the path of a string variable to a template for the value that the variable is set to using
+	// the "-X" linker flag. The same template functions as "ldflags" can be used in the templates. For example:
+	//
+	//   x-vars:
+	//     main.commit: "{{Commit}}"
+	//     main.date: "{{Date}}"
+	XVars *map[string]string `yaml:"x-vars,omitempty"`
+
+	// Tags are the build tags that are provided to the "build" command. The tags and the tags of any "-tags"
+	// arguments provided by "build-args-script" or "os-arch-overrides" are merged into a single "-tags" argument.
+	Tags *[]string `yaml:"tags,omitempty"`
+
+	// GCFlags is the value of the "-gcflags" flag that is provided to the "build" command. For example, "all=-N -l".
+	GCFlags *string `yaml:"gcflags,omitempty"`
+
+	// ASMFlags is the value of the "-asmflags" flag that is provided to the "build" command.
+	ASMFlags *string `yaml:"asmflags,omitempty"`
+
+	// Trimpath specifies whether the "-trimpath" flag is provided to the "build" command.
+	Trimpath *bool `yaml:"trimpath,omitempty"`
+
+	// BuildMode is the value of the "-buildmode" flag that is provided to the "build" command. Valid values are
+	// "archive", "c-archive", "c-shared", "default", "exe", "pie", "plugin" and "shared".
+	BuildMode *string `yaml:"buildmode,omitempty"`
+
 	// Environment specifies values for the environment variables that should be set for the build. For example,
 	// the following sets CGO to false:
 	//
@@ -76,4 +113,112 @@ type BuildConfig struct {
 	// OSArchs specifies the GOOS and GOARCH pairs for which the product is built. If blank, defaults to the GOOS
 	// and GOARCH of the host system at runtime.
 	OSArchs *[]osarch.OSArch `yaml:"os-archs,omitempty"`
+
+	// MaxSize specifies the size budget for the executables of the product. The size of every executable is printed
+	// after it is built along with how it compares to the executable of the previous version (if one exists in the
+	// output directory). The YAML representation can be a size (for example, "20MiB") or a full SizeBudgetConfig.
+	MaxSize *SizeBudgetConfig `yaml:"max-size,omitempty"`
+
+	// OSArchOverrides specifies environment variables and build arguments that only apply when building some of the
+	// OS/Archs in "os-archs". A key is either an OS (for example, "darwin"), in which case the override applies to all
+	// of the architectures for that OS, or an OS/Arch (for example, "darwin-amd64"). Every key must match at least one
+	// of the OS/Archs for which the product is built. If both an OS and an OS/Arch override apply, the OS/Arch override
+	// is applied after the OS override. For example, the following enables cgo with a cross-compiler for darwin and
+	// builds with the "netgo" tag for linux:
+	//
+	//   os-arch-overrides:
+	//     darwin:
+	//       environment:
+	//         CGO_ENABLED: "1"
+	//         CC: o64-clang
+	//     linux:
+	//       build-args:
+	//         - "-tags"
+	//         - "netgo"
+	OSArchOverrides *map[string]OSArchBuildOverrideConfig `yaml:"os-arch-overrides,omitempty"`
+
+	// Provenance specifies the string variables that are set to the provenance information of the build. If specified,
+	// a JSON provenance file (an in-toto statement with a SLSA provenance predicate that includes the digests of the
+	// input files of the build) is also written next to every executable as "{{Executable}}.provenance.json". The
+	// project must be in a git repository. For example:
+	//
+	//   provenance:
+	//     vars:
+	//       commit: main.commit
+	//       commit-date: main.commitDate
+	//       build-date: main.buildDate
+	//       dirty: main.dirty
+	//       builder: main.builder
+	Provenance *ProvenanceConfig `yaml:"provenance,omitempty"`
+
+	// Cache specifies a cache for the executables of the product. Before an executable is built, the cache is checked
+	// for an executable built from the same inputs (the input files, build configuration, environment variables, build
+	// arguments, version, Go version and OS/Arch). If one exists, it is restored instead of building the executable.
+	// Otherwise, the executable is built and stored in the cache. Failures to access the cache are reported as warnings.
+	// Note that the build arguments include the values of the provenance variables, so "build-date" and "builder"
+	// prevent executables from being restored unless SOURCE_DATE_EPOCH and "builder" are set. For example:
+	//
+	//   cache:
+	//     url: https://cache.example.com/distgo
+	//     token-env-var: DISTGO_CACHE_TOKEN
+	Cache *BuildCacheConfig `yaml:"cache,omitempty"`
+}
+
+type BuildCacheConfig struct {
+	// Dir is the directory of a local cache. If the path is relative, it is resolved relative to the project
+	// directory. Exactly one of "dir" and "url" must be specified.
+	Dir string `yaml:"dir,omitempty"`
+
+	// URL is the base URL of a remote HTTP cache. The executable for a key is retrieved using a GET request to
+	// "{{url}}/{{key}}" (the server must respond with a 404 status if it does not have the executable) and stored
+	// using a PUT request to the same URL.
+	URL string `yaml:"url,omitempty"`
+
+	// TokenEnvVar is the name of the environment variable that contains the bearer token used to authenticate with
+	// the remote cache.
+	TokenEnvVar string `yaml:"token-env-var,omitempty"`
+
+	// ReadOnly specifies that executables are only restored from the cache and that built executables are not stored
+	// in it.
+	ReadOnly bool `yaml:"read-only,omitempty"`
+}
+
+type ProvenanceConfig struct {
+	// Vars specifies the variables that are set to the provenance information of the build.
+	Vars ProvenanceVarsConfig `yaml:"vars,omitempty"`
+
+	// Builder is the identity of the builder (for example, the URL of a CI system). If not specified,
+	// "{{user}}@{{hostname}}" for the user running the build is used.
+	Builder string `yaml:"builder,omitempty"`
+}
+
+type ProvenanceVarsConfig struct {
+	// Commit is the path to a string variable that is set to the hash of the HEAD commit of the project.
+	Commit string `yaml:"commit,omitempty"`
+
+	// CommitDate is the path to a string variable that is set to the time of the HEAD commit in RFC 3339 format.
+	CommitDate string `yaml:"commit-date,omitempty"`
+
+	// BuildDate is the path to a string variable that is set to the time of the build in RFC 3339 format. If the
+	// SOURCE_DATE_EPOCH environment variable is set, its value is used as the time of the build so that the output is
+	// reproducible. Otherwise, if the build cache is enabled, the time of the HEAD commit is used as the time of the
+	// build so that executables can be restored from the cache.
+	BuildDate string `yaml:"build-date,omitempty"`
+
+	// Dirty is the path to a string variable that is set to "true" if the project has uncommitted changes (including
+	// untracked files) and to "false" otherwise.
+	Dirty string `yaml:"dirty,omitempty"`
+
+	// Builder is the path to a string variable that is set to the identity of the builder.
+	Builder string `yaml:"builder,omitempty"`
+}
+
+type OSArchBuildOverrideConfig struct {
+	// Environment specifies environment variables that are set for the build in addition to the variables in
+	// "environment". If a variable is specified in both, the value specified here is used.
+	Environment map[string]string `yaml:"environment,omitempty"`
+
+	// BuildArgs are the arguments that are provided to the "build" command after the arguments generated by
+	// "build-args-script" and "version-var".
+	BuildArgs []string `yaml:"build-args,omitempty"`
 }
@@ -39,6 +39,41 @@ type DistConfig struct {
 	// Disters is the configuration for the disters for this product. The YAML representation can be a single DisterConfig
 	// or a map[DistID]DisterConfig.
 	Disters *DistersConfig `yaml:"disters,omitempty"`
+
+	// Signing specifies the configuration for creating detached signatures for the dist artifacts. If specified, a
+	// signature file is written next to every dist artifact (including the checksum, manifest and SBOM sidecar files) and the
+	// signature files are considered dist artifacts themselves (for example, they are published along with the other
+	// dist artifacts).
+	Signing *SigningConfig `yaml:"signing,omitempty"`
+
+	// MaxSize specifies the size budget for every artifact generated by the disters of the product (sidecar files are
+	// not included). The size of every artifact is printed after it is generated along with how it compares to the
+	// artifact of the previous version (if one exists in the output directory). The YAML representation can be a size
+	// (for example, "50MiB") or a full SizeBudgetConfig.
+	MaxSize *SizeBudgetConfig `yaml:"max-size,omitempty"`
+}
+
+type SigningConfig struct {
+	// Type is the type of signing. Valid values are "gpg", "minisign" and "cosign". Signing is performed by invoking
+	// the executable of the same name, which must be on the PATH. The signature for an artifact is written to
+	// "{{Artifact}}.asc" for "gpg", "{{Artifact}}.minisig" for "minisign" and "{{Artifact}}.sig" for "cosign".
+	Type string `yaml:"type,omitempty"`
+
+	// KeyFile is the path to the private key used for signing. If the path is relative, it is resolved relative to the
+	// project directory. Must be specified for the "minisign" and "cosign" types. For the "gpg" type, the key is
+	// imported into a temporary keyring that is only used for signing.
+	KeyFile string `yaml:"key-file,omitempty"`
+
+	// KeyID is the ID, fingerprint or user ID of the key used for signing. Only valid for the "gpg" type.
+	KeyID string `yaml:"key-id,omitempty"`
+
+	// GPGHome is the GnuPG home directory whose keys (or whose gpg-agent socket) are used for signing if "key-file" is
+	// not specified. Only valid for the "gpg" type. If neither "key-file" nor "gpg-home" are specified, the default
+	// GnuPG home directory of the user is used.
+	GPGHome string `yaml:"gpg-home,omitempty"`
+
+	// PasswordEnvVar is the name of the environment variable that contains the password for the private key.
+	PasswordEnvVar string `yaml:"password-env-var,omitempty"`
 }
 
 type DisterConfig struct {
@@ -58,7 +93,7 @@ type DisterConfig struct {
 
 	// InputDir specifies an input directory whose contents will be copied to the dist work directory before the
 	// distribution operation is run. Symlinks are not followed. Also supports specifying names or paths that should be
-	// skipped.
+	// skipped and glob patterns for files that should be rendered as templates.
 	InputDir *InputDirConfig `yaml:"input-dir,omitempty"`
 
 	// Script is the content of a script that is written to a file and run after the initial distribution process but
@@ -67,15 +102,90 @@ type DisterConfig struct {
 	// process and also has dist-related environment variables. Refer to the documentation for the
 	// distgo.DistScriptEnvVariables function for the extra environment variables.
 	Script *string `yaml:"script,omitempty"`
+
+	// Checksums specifies the checksum and manifest sidecar files that are written after the dist artifacts are
+	// generated. The sidecar files are written to the same directory as the dist artifacts and are considered dist
+	// artifacts themselves (for example, they are published along with the other dist artifacts).
+	Checksums *ChecksumsConfig `yaml:"checksums,omitempty"`
+
+	// SBOM specifies the software bill of materials sidecar files that are written after the dist artifacts are
+	// generated. The sidecar files are written to the same directory as the dist artifacts and are considered dist
+	// artifacts themselves (for example, they are published along with the other dist artifacts).
+	SBOM *SBOMConfig `yaml:"sbom,omitempty"`
+
+	// Licenses specifies that the license and notice files ("LICENSE", "NOTICE", "COPYING", etc.) of the vendored and
+	// non-standard library dependencies of the product are written to the dist work directory before the Dister is
+	// run. The files are copied to "licenses/{{ModulePath}}" and their content is concatenated into a
+	// "THIRD_PARTY_NOTICES" file. Both are written to the directory specified by "dir", which defaults to the
+	// documentation directory of the package for disters that create OS packages ("{{Prefix}}/share/doc/{{PackageName}}"
+	// for "deb" and "rpm") and to the root of the dist work directory for other disters.
+	Licenses *LicensesConfig `yaml:"licenses,omitempty"`
+}
+
+type SBOMConfig struct {
+	// Formats specifies the formats for which SBOM files are written. Valid values are "spdx" (SPDX 2.3 JSON) and
+	// "cyclonedx" (CycloneDX 1.4 JSON). The file for a format is named "{{NameTemplate}}-{{DistID}}.spdx.json" or
+	// "{{NameTemplate}}-{{DistID}}.cdx.json": for example, "foo-1.0.0-os-arch-bin.spdx.json". The SBOM lists the
+	// non-project Go packages compiled into the product and its dependent products, the versions of the modules that
+	// contain them (as reported by "go list" for products built in module mode and based on "Gopkg.lock" otherwise)
+	// and the name and digests of every artifact generated by the Dister. The creation time recorded in the SBOM is the value of the SOURCE_DATE_EPOCH
+	// environment variable if it is set and the time of the HEAD commit of the project otherwise.
+	Formats []string `yaml:"formats,omitempty"`
+}
+
+type LicensesConfig struct {
+	// Disallowed specifies the license types that dependencies may not use. Valid values are "AGPL-3.0",
+	// "Apache-2.0", "BSD-2-Clause", "BSD-3-Clause", "GPL-2.0", "GPL-3.0", "ISC", "LGPL-2.1", "LGPL-3.0", "MIT",
+	// "MPL-2.0", "Unlicense" and "unknown" (for dependencies whose license type cannot be determined). The dist
+	// operation fails if any dependency uses one of these license types.
+	Disallowed []string `yaml:"disallowed,omitempty"`
+
+	// Dir is the path relative to the dist work directory of the directory to which the license and notice files are
+	// written. Must not be absolute or refer to a location outside of the dist work directory.
+	Dir string `yaml:"dir,omitempty"`
+}
+
+type ChecksumsConfig struct {
+	// Algorithms specifies the algorithms for which "SHA256SUMS"-style files are written. Valid values are "sha256" and
+	// "sha512". The file for an algorithm is named "{{NameTemplate}}-{{DistID}}-{{ALGORITHM}}SUMS": for example,
+	// "foo-1.0.0-os-arch-bin-SHA256SUMS".
+	Algorithms []string `yaml:"algorithms,omitempty"`
+
+	// Manifest specifies whether a JSON manifest named "{{NameTemplate}}-{{DistID}}-manifest.json" is written. The
+	// manifest specifies the product, version and DistID and lists the name, size, digests and OS/Arch (if it can be
+	// determined) of every dist artifact.
+	Manifest bool `yaml:"manifest,omitempty"`
 }
 
 type InputDirConfig struct {
 	Path    string                `yaml:"path,omitempty"`
 	Exclude matcher.NamesPathsCfg `yaml:"exclude,omitempty"`
+
+	// Templates specifies glob patterns for the files in the input directory that are rendered as Go templates when
+	// they are copied to the dist work directory. A pattern that contains a '/' is matched against the path of the file
+	// relative to the input directory (for example, "conf/*.yml") and any other pattern is matched against the name of
+	// the file (for example, "*.service"). Files that do not match any pattern are copied verbatim. The following
+	// template functions can be used in the files:
+	//   * {{Product}}: the name of the product
+	//   * {{Version}}: the version of the project
+	//   * {{Repository}}: the Docker repository of the product. If the repository is non-empty and does not end in a
+	//     '/', appends '/'.
+	//   * {{RepositoryLiteral}}: the Docker repository of the product
+	//   * {{BuildArtifact "product" "os-arch"}}: the file name of the build artifact of the product or one of its
+	//     dependent products for the OS/Arch
+	//   * {{DistArtifacts "product" "dist-id"}}: the file names of the dist artifacts of the product or one of its
+	//     dependent products for the DistID
+	Templates []string `yaml:"templates,omitempty"`
+
+	// PreserveSymlinks specifies whether symlinks in the input directory are copied to the dist work directory as
+	// symlinks with the same target (for example, a relative link such as "current -> 1.0.0"). Symlinks are not
+	// rendered as templates. Directories, including empty directories, are always copied. Disters that create
+	// archives of the dist work directory may need to be configured to archive symlinks as symlinks.
+	PreserveSymlinks bool `yaml:"preserve-symlinks,omitempty"`
 }
 
 func (cfg InputDirConfig) MarshalYAML() (interface{}, error) {
-	if cfg.Exclude.Empty() {
+	if cfg.Exclude.Empty() && len(cfg.Templates) == 0 && !cfg.PreserveSymlinks {
 		// if exclude configuration is empty, marshal as string (shorthand form)
 		return cfg, nil
 	}
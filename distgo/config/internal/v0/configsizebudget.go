@@ -0,0 +1,67 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v0
+
+type SizeBudgetConfig struct {
+	// Size is the maximum size of every artifact. The value is a number of bytes optionally followed by one of the
+	// units "B", "KB", "MB", "GB" (powers of 1000) or "KiB", "MiB", "GiB" (powers of 1024): for example, "20MiB".
+	Size string `yaml:"size,omitempty"`
+
+	// MaxGrowth is the maximum percentage by which the size of an artifact may grow compared to the same artifact of
+	// the previous version of the product: for example, "10%". The previous version is the greatest version in the
+	// output directory of the product that is lower than the current version and that contains the artifact (versions
+	// are ordered numerically with pre-releases before releases and untagged "git describe" versions after the tagged
+	// version that they describe). If no previous artifact exists, growth is not checked.
+	MaxGrowth string `yaml:"max-growth,omitempty"`
+
+	// Warn specifies that an artifact that exceeds the budget causes a warning to be printed rather than causing the
+	// task to fail.
+	Warn bool `yaml:"warn,omitempty"`
+}
+
+func (cfg SizeBudgetConfig) MarshalYAML() (interface{}, error) {
+	if cfg.MaxGrowth == "" && !cfg.Warn {
+		// if only the size is specified, marshal as string (shorthand form)
+		return cfg.Size, nil
+	}
+	// otherwise, marshal full form
+	type SizeBudgetConfigAlias SizeBudgetConfig
+	return SizeBudgetConfigAlias(cfg), nil
+}
+
+func (cfg *SizeBudgetConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// if configuration is specified as string only, consider as just a size
+	var sizeVal string
+	if err := unmarshal(&sizeVal); err == nil && sizeVal != "" {
+		*cfg = SizeBudgetConfig{
+			Size: sizeVal,
+		}
+		return nil
+	}
+
+	// otherwise, unmarshal as full configuration
+	type SizeBudgetConfigAlias SizeBudgetConfig
+	var cfgVal SizeBudgetConfigAlias
+	if err := unmarshal(&cfgVal); err != nil {
+		return err
+	}
+	*cfg = SizeBudgetConfig(cfgVal)
+	return nil
+}
@@ -0,0 +1,175 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+// Manifest is the content of the JSON manifest sidecar file written for a dist.
+type Manifest struct {
+	Product   distgo.ProductID   `json:"product"`
+	Version   string             `json:"version"`
+	DistID    distgo.DistID      `json:"distId"`
+	Artifacts []ManifestArtifact `json:"artifacts"`
+}
+
+type ManifestArtifact struct {
+	// Name is the file name of the artifact.
+	Name string `json:"name"`
+	// Size is the size of the artifact in bytes.
+	Size int64 `json:"size"`
+	// Digests is a map from algorithm to the hex-encoded digest of the artifact.
+	Digests map[string]string `json:"digests"`
+	// OSArch is the OS/Arch of the artifact. Only specified if the artifact is specific to a single OS/Arch, which is
+	// determined based on whether the name of the artifact contains exactly one of the build OS/Archs of the product.
+	OSArch string `json:"osArch,omitempty"`
+}
+
+var checksumHashFns = map[string]func() hash.Hash{
+	distgo.ChecksumSHA256: sha256.New,
+	distgo.ChecksumSHA512: sha512.New,
+}
+
+// writeSidecars writes the checksum and manifest sidecar files specified by the provided ChecksumsParam for the
+// artifacts generated by the Dister for the provided DistID.
+func writeSidecars(distID distgo.DistID, checksumsParam distgo.ChecksumsParam, productTaskOutputInfo distgo.ProductTaskOutputInfo) error {
+	if len(checksumsParam.Algorithms) == 0 && !checksumsParam.Manifest {
+		return nil
+	}
+
+	// if a manifest is written without any checksum files, the manifest includes SHA-256 digests
+	algorithms := checksumsParam.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []string{distgo.ChecksumSHA256}
+	}
+
+	distOutputDir := distgo.ProductDistOutputDir(productTaskOutputInfo.Project, productTaskOutputInfo.Product, distID)
+	var artifacts []ManifestArtifact
+	for _, artifactPath := range productTaskOutputInfo.ProductDisterArtifactPaths()[distID] {
+		artifact, err := manifestArtifact(artifactPath, algorithms, productTaskOutputInfo.Product)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, artifact)
+	}
+
+	renderedName := productTaskOutputInfo.Product.DistOutputInfos.DistInfos[distID].DistNameTemplateRendered
+	for _, algorithm := range checksumsParam.Algorithms {
+		buf := &bytes.Buffer{}
+		for _, artifact := range artifacts {
+			fmt.Fprintf(buf, "%s  %s\n", artifact.Digests[algorithm], artifact.Name)
+		}
+		checksumsFilePath := path.Join(distOutputDir, distgo.ChecksumsFileName(renderedName, distID, algorithm))
+		if err := ioutil.WriteFile(checksumsFilePath, buf.Bytes(), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write checksums file %s", checksumsFilePath)
+		}
+	}
+
+	if checksumsParam.Manifest {
+		manifestBytes, err := json.MarshalIndent(Manifest{
+			Product:   productTaskOutputInfo.Product.ID,
+			Version:   productTaskOutputInfo.Project.Version,
+			DistID:    distID,
+			Artifacts: artifacts,
+		}, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal manifest as JSON")
+		}
+		manifestFilePath := path.Join(distOutputDir, distgo.ManifestFileName(renderedName, distID))
+		if err := ioutil.WriteFile(manifestFilePath, append(manifestBytes, '\n'), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write manifest file %s", manifestFilePath)
+		}
+	}
+	return nil
+}
+
+func manifestArtifact(artifactPath string, algorithms []string, productOutputInfo distgo.ProductOutputInfo) (ManifestArtifact, error) {
+	f, err := os.Open(artifactPath)
+	if err != nil {
+		return ManifestArtifact{}, errors.Wrapf(err, "failed to open dist artifact %s", artifactPath)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	hashes := make(map[string]hash.Hash)
+	var writers []io.Writer
+	for _, algorithm := range algorithms {
+		hashFn, ok := checksumHashFns[algorithm]
+		if !ok {
+			return ManifestArtifact{}, errors.Errorf("unsupported checksum algorithm %q", algorithm)
+		}
+		hashes[algorithm] = hashFn()
+		writers = append(writers, hashes[algorithm])
+	}
+	size, err := io.Copy(io.MultiWriter(writers...), f)
+	if err != nil {
+		return ManifestArtifact{}, errors.Wrapf(err, "failed to read dist artifact %s", artifactPath)
+	}
+
+	digests := make(map[string]string)
+	for algorithm, h := range hashes {
+		digests[algorithm] = hex.EncodeToString(h.Sum(nil))
+	}
+	name := path.Base(artifactPath)
+	return ManifestArtifact{
+		Name:    name,
+		Size:    size,
+		Digests: digests,
+		OSArch:  artifactOSArch(name, productOutputInfo),
+	}, nil
+}
+
+// artifactOSArch returns the OS/Arch of the artifact with the provided name if the name contains exactly one of the
+// build OS/Archs of the product as a delimited segment: that is, preceded by "-" and either followed by "." or at the end
+// of the name (for example, "foo-0.1.0-linux-arm.tgz" matches "linux-arm" but not "linux-arm64"). Returns an empty
+// string otherwise.
+func artifactOSArch(name string, productOutputInfo distgo.ProductOutputInfo) string {
+	if productOutputInfo.BuildOutputInfo == nil {
+		return ""
+	}
+	var matches []string
+	for _, osArch := range productOutputInfo.BuildOutputInfo.OSArchs {
+		segment := "-" + osArch.String()
+		if strings.Contains(name, segment+".") || strings.HasSuffix(name, segment) {
+			matches = append(matches, osArch.String())
+		}
+	}
+	if len(matches) != 1 {
+		return ""
+	}
+	return matches[0]
+}
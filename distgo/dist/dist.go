@@ -30,15 +30,30 @@ import (
 	"strings"
 	"time"
 
-	"github.com/palantir/pkg/matcher"
 	"github.com/pkg/errors"
 	"github.com/termie/go-shutil"
 
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/build"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/state"
 )
 
-func Products(projectInfo distgo.ProjectInfo, projectParam distgo.ProjectParam, configModTime *time.Time, productDistIDs []distgo.ProductDistID, dryRun bool, stdout io.Writer) error {
+type Options struct {
+	Parallel bool
+	DryRun   bool
+	// Explain specifies whether the reason that a product requires building or a dist requires generation is printed.
+	Explain bool
+}
+
+// Products runs the dist action for the products specified by productDistIDs (and the products they depend on) using
+// the options specified in distOpts. The products are built first if required. If distOpts.Parallel is true, then the
+// dists are run in parallel with N workers, where N is the number of logical processors reported by Go. Each
+// (Product, DistID) pair is treated as an individual unit of work: the dists for a product are started only after all of
+// the dists for the products it depends on have completed, and the dist for a DistID is started only after the dists
+// for the DistIDs it depends on (as specified by distgo.DependentDister) have completed. The output of each unit is
+// buffered and written to stdout when the unit completes. If any dist returns an error, the first error returned is
+// propagated back (and any dists that have not started will not be started).
+func Products(projectInfo distgo.ProjectInfo, projectParam distgo.ProjectParam, configModTime *time.Time, productDistIDs []distgo.ProductDistID, distOpts Options, stdout io.Writer) error {
 	// pre-filter step: expand productDistIDs to include all dependent products
 	var allDepProductDistIDs []distgo.ProductDistID
 	for _, currDistID := range productDistIDs {
@@ -76,24 +91,32 @@ func Products(projectInfo distgo.ProjectInfo, projectParam distgo.ProjectParam,
 			// case, no need to build the build outputs because they will not be used.
 			continue
 		}
-		requiresBuildParam, err := build.RequiresBuild(projectInfo, projectParam.Products[currProductID])
+		requiresBuildParam, reasons, err := build.RequiresBuildWithReasons(projectInfo, projectParam.Products[currProductID])
 		if err != nil {
 			return err
 		}
 		if requiresBuildParam == nil {
 			continue
 		}
+		if distOpts.Explain {
+			for _, currOSArch := range requiresBuildParam.Build.OSArchs {
+				distgo.PrintlnOrDryRunPrintln(stdout, fmt.Sprintf("%s requires build for %s: %s", currProductID, currOSArch, reasons[currOSArch]), distOpts.DryRun)
+			}
+		}
 		productParamsToBuild = append(productParamsToBuild, *requiresBuildParam)
 	}
 	if len(productParamsToBuild) != 0 {
 		if err := build.Run(projectInfo, productParamsToBuild, build.Options{
 			Parallel: true,
-			DryRun:   dryRun,
+			DryRun:   distOpts.DryRun,
 		}, stdout); err != nil {
 			return err
 		}
-		// if any of the products needed to be re-built, require dist to be performed
-		configModTime = nil
+		if distOpts.DryRun {
+			// build artifacts are not updated in a dry run, so the build artifacts of the products that need to be re-built
+			// cannot be used to determine whether dist is required: require dist to be performed
+			configModTime = nil
+		}
 	}
 
 	// sort dist product tasks in topological order
@@ -101,19 +124,7 @@ func Products(projectInfo distgo.ProjectInfo, projectParam distgo.ProjectParam,
 	if err != nil {
 		return err
 	}
-	for _, currProductID := range topoOrderedIDs {
-		requiresDistParam, err := RequiresDist(projectInfo, targetProducts[currProductID], configModTime)
-		if err != nil {
-			return err
-		}
-		if requiresDistParam == nil {
-			continue
-		}
-		if err := Run(projectInfo, *requiresDistParam, dryRun, stdout); err != nil {
-			return errors.Wrapf(err, "dist failed for %s", currProductID)
-		}
-	}
-	return nil
+	return runScheduled(projectInfo, targetProducts, topoOrderedIDs, configModTime, distOpts, stdout)
 }
 
 // Run executes the Dist action for the specified product. Produces both the dist output directory and the dist
@@ -124,63 +135,222 @@ func Run(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam, dryRu
 		distgo.PrintlnOrDryRunPrintln(stdout, fmt.Sprintf("%s does not define a dist configuration; skipping dist", productParam.ID), dryRun)
 		return nil
 	}
-
-	productOutputInfo, err := productParam.ToProductOutputInfo(projectInfo.Version)
+	productDist, err := newProductDist(projectInfo, productParam)
 	if err != nil {
 		return err
 	}
+	for _, currDistID := range productDist.distIDs {
+		if err := productDist.run(currDistID, dryRun, stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// productDist stores the information required to run the dists for a single product. The dist for each DistID only
+// modifies its own dist work directory and artifacts, so the dists for different DistIDs can be run concurrently once
+// the dists for their dependencies have completed.
+type productDist struct {
+	projectInfo           distgo.ProjectInfo
+	productParam          distgo.ProductParam
+	productOutputInfo     distgo.ProductOutputInfo
+	productTaskOutputInfo distgo.ProductTaskOutputInfo
+	distWorkDirs          map[distgo.DistID]string
+	// distIDs are the DistIDs of the product in the order returned by distIDsInDependencyOrder.
+	distIDs []distgo.DistID
+}
 
+func newProductDist(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam) (*productDist, error) {
+	productOutputInfo, err := productParam.ToProductOutputInfo(projectInfo.Version)
+	if err != nil {
+		return nil, err
+	}
 	productTaskOutputInfo, err := distgo.ToProductTaskOutputInfo(projectInfo, productParam)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	distWorkDirs := distgo.ProductDistWorkDirs(projectInfo, productOutputInfo)
-
-	for _, currDistID := range productTaskOutputInfo.Product.DistOutputInfos.DistIDs {
-		// create empty output directory
-		distWorkDir := distWorkDirs[currDistID]
-		if !dryRun {
-			// remove output directory if it already exists
-			if err := os.RemoveAll(distWorkDir); err != nil {
-				return errors.Wrapf(err, "failed to remove dist output directory %s", distWorkDir)
-			}
-			// create output directory
-			if err := os.MkdirAll(distWorkDir, 0755); err != nil {
-				return errors.Wrapf(err, "failed to create dist output directory %s", distWorkDir)
-			}
+	distIDs, err := distIDsInDependencyOrder(productTaskOutputInfo.Product.DistOutputInfos.DistIDs, productParam.Dist.DistParams)
+	if err != nil {
+		return nil, err
+	}
+	return &productDist{
+		projectInfo:           projectInfo,
+		productParam:          productParam,
+		productOutputInfo:     productOutputInfo,
+		productTaskOutputInfo: productTaskOutputInfo,
+		distWorkDirs:          distgo.ProductDistWorkDirs(projectInfo, productOutputInfo),
+		distIDs:               distIDs,
+	}, nil
+}
+
+// run executes the dist for the provided DistID of the product.
+func (p *productDist) run(currDistID distgo.DistID, dryRun bool, stdout io.Writer) error {
+	projectInfo := p.projectInfo
+	productParam := p.productParam
+	productTaskOutputInfo := p.productTaskOutputInfo
+
+	// create empty output directory
+	distWorkDir := p.distWorkDirs[currDistID]
+	stateFilePath := distStateFilePath(currDistID, productTaskOutputInfo)
+	var inputs map[string]string
+	if !dryRun {
+		// compute the inputs before running the dist so that the recorded state does not include the changes made by the
+		// dist. Remove any previous state so that the dist is not considered up-to-date if the state cannot be recorded.
+		// Failure to compute the inputs is reported as a warning since the dist can still be run.
+		if currInputs, err := distInputs(currDistID, productParam, productTaskOutputInfo); err == nil {
+			inputs = currInputs
+		} else {
+			fmt.Fprintf(stdout, "Warning: failed to determine inputs of dist %s of %s: %v\n", currDistID, productParam.ID, err)
 		}
+		if err := os.RemoveAll(stateFilePath); err != nil {
+			return errors.Wrapf(err, "failed to remove dist state")
+		}
+		// remove output directory if it already exists
+		if err := os.RemoveAll(distWorkDir); err != nil {
+			return errors.Wrapf(err, "failed to remove dist output directory %s", distWorkDir)
+		}
+		// create output directory
+		if err := os.MkdirAll(distWorkDir, 0755); err != nil {
+			return errors.Wrapf(err, "failed to create dist output directory %s", distWorkDir)
+		}
+	}
 
-		distgo.PrintlnOrDryRunPrintln(stdout, fmt.Sprintf("Creating distribution for %s at %v", productParam.ID, strings.Join(outputArtifactDisplayPaths(distgo.ProductDistArtifactPaths(projectInfo, productOutputInfo)[currDistID]), ", ")), dryRun)
-		if !dryRun {
-			currDistParam := productParam.Dist.DistParams[currDistID]
+	distgo.PrintlnOrDryRunPrintln(stdout, fmt.Sprintf("Creating distribution for %s at %v", productParam.ID, strings.Join(outputArtifactDisplayPaths(distgo.ProductDistArtifactPaths(projectInfo, p.productOutputInfo)[currDistID]), ", ")), dryRun)
+	if !dryRun {
+		currDistParam := productParam.Dist.DistParams[currDistID]
 
-			// copy input dir contents
-			if currDistParam.InputDir.Path != "" {
-				if err := copyInputDir(path.Join(projectInfo.ProjectDir, currDistParam.InputDir.Path), currDistParam.InputDir.Exclude, distWorkDir); err != nil {
-					return errors.Wrapf(err, "failed to copy input directory")
-				}
+		// copy input dir contents
+		if currDistParam.InputDir.Path != "" {
+			if err := copyInputDir(path.Join(projectInfo.ProjectDir, currDistParam.InputDir.Path), currDistParam.InputDir, distWorkDir, inputDirTemplateFunctions(productTaskOutputInfo)); err != nil {
+				return errors.Wrapf(err, "failed to copy input directory")
 			}
+		}
 
-			// run dist task
-			runDistOutput, err := currDistParam.Dister.RunDist(currDistID, productTaskOutputInfo)
-			if err != nil {
+		// write license and notice files of dependencies
+		if currDistParam.Licenses != nil {
+			if err := writeLicenses(projectInfo, productParam, *currDistParam.Licenses, licensesDir(distWorkDir, productParam.ID, currDistParam)); err != nil {
+				return errors.Wrapf(err, "failed to write third-party license notices")
+			}
+		}
+
+		// run dist task
+		runDistOutput, err := currDistParam.Dister.RunDist(currDistID, productTaskOutputInfo)
+		if err != nil {
+			return err
+		}
+		// execute dist script
+		if err := distgo.WriteAndExecuteScript(projectInfo, currDistParam.Script, distgo.DistScriptEnvVariables(currDistID, productTaskOutputInfo), stdout); err != nil {
+			return errors.Wrapf(err, "failed to execute dist script")
+		}
+		// generate dist artifacts
+		if err := currDistParam.Dister.GenerateDistArtifacts(currDistID, productTaskOutputInfo, runDistOutput); err != nil {
+			return err
+		}
+		// check the size of the dist artifacts
+		if productParam.Dist.SizeBudget != nil {
+			if err := checkSizeBudget(*productParam.Dist.SizeBudget, currDistID, productParam, productTaskOutputInfo, stdout); err != nil {
 				return err
 			}
-			// execute dist script
-			if err := distgo.WriteAndExecuteScript(projectInfo, currDistParam.Script, distgo.DistScriptEnvVariables(currDistID, productTaskOutputInfo), stdout); err != nil {
-				return errors.Wrapf(err, "failed to execute dist script")
+		}
+		// write SBOM sidecar files
+		if err := writeSBOMs(currDistID, currDistParam.SBOM, productParam, productTaskOutputInfo); err != nil {
+			return errors.Wrapf(err, "failed to write SBOMs for dist artifacts")
+		}
+		// write checksum and manifest sidecar files
+		if err := writeSidecars(currDistID, currDistParam.Checksums, productTaskOutputInfo); err != nil {
+			return errors.Wrapf(err, "failed to write checksums for dist artifacts")
+		}
+		// write detached signatures
+		if productParam.Dist.Signing != nil {
+			if err := signArtifacts(*productParam.Dist.Signing, currDistID, currDistParam, productTaskOutputInfo); err != nil {
+				return errors.Wrapf(err, "failed to sign dist artifacts")
 			}
-			// generate dist artifacts
-			if err := currDistParam.Dister.GenerateDistArtifacts(currDistID, productTaskOutputInfo, runDistOutput); err != nil {
-				return err
+		}
+		// record the state used to determine whether the dist is up-to-date
+		if inputs != nil {
+			if err := state.Write(stateFilePath, inputs, productTaskOutputInfo.ProductDistArtifactPaths()[currDistID]); err != nil {
+				return errors.Wrapf(err, "failed to record dist state")
 			}
 		}
-		distgo.PrintlnOrDryRunPrintln(stdout, fmt.Sprintf("Finished creating %s distribution for %s", currDistID, productParam.ID), dryRun)
 	}
+	distgo.PrintlnOrDryRunPrintln(stdout, fmt.Sprintf("Finished creating %s distribution for %s", currDistID, productParam.ID), dryRun)
 	return nil
 }
 
-func copyInputDir(inputDir string, exclude matcher.Matcher, dstDir string) error {
+// distDependencies returns the DistIDs in distIDs that are returned by the DistDependencies function of the dister for
+// the provided DistID if the dister is a distgo.DependentDister.
+func distDependencies(distID distgo.DistID, distIDs map[distgo.DistID]struct{}, distParams map[distgo.DistID]distgo.DisterParam) []distgo.DistID {
+	dependentDister, ok := distParams[distID].Dister.(distgo.DependentDister)
+	if !ok {
+		return nil
+	}
+	var deps []distgo.DistID
+	for _, depDistID := range dependentDister.DistDependencies() {
+		if _, ok := distIDs[depDistID]; !ok {
+			continue
+		}
+		deps = append(deps, depDistID)
+	}
+	return deps
+}
+
+// distIDsInDependencyOrder returns the provided DistIDs ordered such that the dists returned by the DistDependencies
+// function of a distgo.DependentDister appear before the DistID of that dister. Dists that do not have dependencies
+// keep their relative order. Dependencies that are not in the provided DistIDs are ignored.
+func distIDsInDependencyOrder(distIDs []distgo.DistID, distParams map[distgo.DistID]distgo.DisterParam) ([]distgo.DistID, error) {
+	inDistIDs := make(map[distgo.DistID]struct{})
+	for _, distID := range distIDs {
+		inDistIDs[distID] = struct{}{}
+	}
+
+	var ordered []distgo.DistID
+	visited := make(map[distgo.DistID]bool)
+	var visit func(distID distgo.DistID, chain []distgo.DistID) error
+	visit = func(distID distgo.DistID, chain []distgo.DistID) error {
+		if done, ok := visited[distID]; ok {
+			if !done {
+				return errors.Errorf("dists have a dependency cycle: %v", append(chain, distID))
+			}
+			return nil
+		}
+		visited[distID] = false
+		for _, depDistID := range distDependencies(distID, inDistIDs, distParams) {
+			if err := visit(depDistID, append(chain, distID)); err != nil {
+				return err
+			}
+		}
+		visited[distID] = true
+		ordered = append(ordered, distID)
+		return nil
+	}
+	for _, distID := range distIDs {
+		if err := visit(distID, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// copyInputDir copies the contents of the provided input directory to dstDir. Files that match the templates of the
+// provided InputDirParam are rendered as templates using the provided template functions.
+func copyInputDir(inputDir string, inputDirParam distgo.InputDirParam, dstDir string, templateFns []distgo.TemplateFunction) error {
+	exclude := inputDirParam.Exclude
+	copyFn := func(src, dst string, followSymlinks bool) (string, error) {
+		if inputDirParam.PreserveSymlinks {
+			if fi, err := os.Lstat(src); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+				return dst, copySymlink(src, dst)
+			}
+		}
+		relPath, err := filepath.Rel(inputDir, src)
+		if err != nil || !inputDirParam.IsTemplate(relPath) {
+			return shutil.Copy(src, dst, followSymlinks)
+		}
+		if err := renderInputDirTemplate(src, dst, templateFns); err != nil {
+			return "", errors.Wrapf(err, "failed to render template %s", relPath)
+		}
+		return dst, nil
+	}
+
 	inputDirFiles, err := ioutil.ReadDir(inputDir)
 	if err != nil {
 		return errors.Wrapf(err, "failed to list files in input directory %s", inputDir)
@@ -200,7 +370,7 @@ func copyInputDir(inputDir string, exclude matcher.Matcher, dstDir string) error
 			if exclude != nil && exclude.Match(topLevelFileName) {
 				continue
 			}
-			if _, err := shutil.Copy(srcPath, dstPath, false); err != nil {
+			if _, err := copyFn(srcPath, dstPath, false); err != nil {
 				return errors.Wrapf(err, "failed to copy file %s", topLevelFileName)
 			}
 			continue
@@ -208,7 +378,7 @@ func copyInputDir(inputDir string, exclude matcher.Matcher, dstDir string) error
 
 		// copy directory recursively
 		if err := shutil.CopyTree(srcPath, dstPath, &shutil.CopyTreeOptions{
-			CopyFunction: shutil.Copy,
+			CopyFunction: copyFn,
 			Ignore: func(dir string, files []os.FileInfo) []string {
 				if exclude == nil {
 					return nil
@@ -233,6 +403,18 @@ func copyInputDir(inputDir string, exclude matcher.Matcher, dstDir string) error
 	return nil
 }
 
+// copySymlink creates a symlink at dst with the same target as the symlink at src.
+func copySymlink(src, dst string) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read symlink %s", src)
+	}
+	if err := os.Symlink(target, dst); err != nil {
+		return errors.Wrapf(err, "failed to create symlink %s", dst)
+	}
+	return nil
+}
+
 func outputArtifactDisplayPaths(in []string) []string {
 	if in == nil {
 		return nil
@@ -20,12 +20,22 @@ Sniperkit-Bot
 package dist_test
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
 	"fmt"
+	"go/build"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
 	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/nmiyake/pkg/dirs"
 	"github.com/nmiyake/pkg/gofiles"
@@ -34,13 +44,19 @@ import (
 	"github.com/palantir/pkg/matcher"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
 
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister"
 	"github.com/sniperkit/snk.fork.palantir-distgo/dister/disterfactory"
 	"github.com/sniperkit/snk.fork.palantir-distgo/dister/osarchbin"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dister/pkgmanager"
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
 	distgoconfig "github.com/sniperkit/snk.fork.palantir-distgo/distgo/config"
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/dist"
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/testfuncs"
+	"github.com/sniperkit/snk.fork.palantir-distgo/dockerbuilder/dockerbuilderfactory"
+	"github.com/sniperkit/snk.fork.palantir-distgo/projectversioner/projectversionerfactory"
+	"github.com/sniperkit/snk.fork.palantir-distgo/publisher/publisherfactory"
 )
 
 const (
@@ -437,6 +453,405 @@ func main() {}
 				assert.True(t, info.IsDir(), "Case %d: %s", caseNum, name)
 			},
 		},
+		{
+			name: "checksum and manifest sidecar files are written",
+			projectCfg: distgoconfig.ProjectConfig{
+				ProductDefaults: *distgoconfig.ToProductConfig(&distgoconfig.ProductConfig{
+					Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+						Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+							osarchbin.TypeName: {
+								Type:   defaultDisterCfg.Type,
+								Config: defaultDisterCfg.Config,
+								Checksums: distgoconfig.ToChecksumsConfig(&distgoconfig.ChecksumsConfig{
+									Algorithms: []string{"sha256", "sha512"},
+									Manifest:   true,
+								}),
+							},
+						}),
+					}),
+				}),
+			},
+			preDistAction: func(projectDir string, projectCfg distgoconfig.ProjectConfig) {
+				gittest.CreateGitTag(t, projectDir, "0.1.0")
+			},
+			validate: func(caseNum int, name, projectDir string) {
+				distOutputDir := path.Join(projectDir, "out", "dist", "foo", "0.1.0", "os-arch-bin")
+				artifactName := fmt.Sprintf("foo-0.1.0-%v.tgz", osarch.Current())
+				artifactBytes, err := ioutil.ReadFile(path.Join(distOutputDir, artifactName))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				sha256Sum := fmt.Sprintf("%x", sha256.Sum256(artifactBytes))
+				sha512Sum := fmt.Sprintf("%x", sha512.Sum512(artifactBytes))
+
+				bytes, err := ioutil.ReadFile(path.Join(distOutputDir, "foo-0.1.0-os-arch-bin-SHA256SUMS"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, fmt.Sprintf("%s  %s\n", sha256Sum, artifactName), string(bytes), "Case %d: %s", caseNum, name)
+
+				bytes, err = ioutil.ReadFile(path.Join(distOutputDir, "foo-0.1.0-os-arch-bin-SHA512SUMS"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, fmt.Sprintf("%s  %s\n", sha512Sum, artifactName), string(bytes), "Case %d: %s", caseNum, name)
+
+				bytes, err = ioutil.ReadFile(path.Join(distOutputDir, "foo-0.1.0-os-arch-bin-manifest.json"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				var manifest dist.Manifest
+				err = json.Unmarshal(bytes, &manifest)
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, dist.Manifest{
+					Product: "foo",
+					Version: "0.1.0",
+					DistID:  "os-arch-bin",
+					Artifacts: []dist.ManifestArtifact{
+						{
+							Name: artifactName,
+							Size: int64(len(artifactBytes)),
+							Digests: map[string]string{
+								"sha256": sha256Sum,
+								"sha512": sha512Sum,
+							},
+							OSArch: osarch.Current().String(),
+						},
+					},
+				}, manifest, "Case %d: %s", caseNum, name)
+			},
+		},
+		{
+			name: "manifest records OS/Arch of artifacts whose OS/Arch is a prefix of another OS/Arch",
+			projectCfg: distgoconfig.ProjectConfig{
+				Products: distgoconfig.ToProductsMap(map[distgo.ProductID]distgoconfig.ProductConfig{
+					"foo": {
+						Build: distgoconfig.ToBuildConfig(&distgoconfig.BuildConfig{
+							MainPkg: stringPtr("foo"),
+							OSArchs: &[]osarch.OSArch{
+								{OS: "linux", Arch: "arm"},
+								{OS: "linux", Arch: "arm64"},
+							},
+						}),
+						Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+							Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+								osarchbin.TypeName: {
+									Type: stringPtr(osarchbin.TypeName),
+									Config: &yaml.MapSlice{
+										{Key: "os-archs", Value: []map[string]string{
+											{"os": "linux", "arch": "arm"},
+											{"os": "linux", "arch": "arm64"},
+										}},
+									},
+									Checksums: distgoconfig.ToChecksumsConfig(&distgoconfig.ChecksumsConfig{
+										Algorithms: []string{"sha256"},
+										Manifest:   true,
+									}),
+								},
+							}),
+						}),
+					},
+				}),
+			},
+			preDistAction: func(projectDir string, projectCfg distgoconfig.ProjectConfig) {
+				gittest.CreateGitTag(t, projectDir, "0.1.0")
+			},
+			validate: func(caseNum int, name, projectDir string) {
+				bytes, err := ioutil.ReadFile(path.Join(projectDir, "out", "dist", "foo", "0.1.0", "os-arch-bin", "foo-0.1.0-os-arch-bin-manifest.json"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				var manifest dist.Manifest
+				err = json.Unmarshal(bytes, &manifest)
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				artifactOSArchs := make(map[string]string)
+				for _, artifact := range manifest.Artifacts {
+					artifactOSArchs[artifact.Name] = artifact.OSArch
+				}
+				assert.Equal(t, map[string]string{
+					"foo-0.1.0-linux-arm.tgz":   "linux-arm",
+					"foo-0.1.0-linux-arm64.tgz": "linux-arm64",
+				}, artifactOSArchs, "Case %d: %s", caseNum, name)
+			},
+		},
+		{
+			name: "package-manager dist is run after the os-arch-bin dist it depends on",
+			projectCfg: distgoconfig.ProjectConfig{
+				Products: distgoconfig.ToProductsMap(map[distgo.ProductID]distgoconfig.ProductConfig{
+					"foo": {
+						Build: distgoconfig.ToBuildConfig(&distgoconfig.BuildConfig{
+							MainPkg: stringPtr("foo"),
+							OSArchs: &[]osarch.OSArch{
+								{OS: "darwin", Arch: "amd64"},
+								{OS: "linux", Arch: "arm64"},
+								{OS: "windows", Arch: "amd64"},
+							},
+						}),
+						Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+							Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+								"brew": {
+									Type:         stringPtr(pkgmanager.TypeName),
+									NameTemplate: stringPtr("{{Product}}"),
+									Config: &yaml.MapSlice{
+										{Key: "url-template", Value: "https://example.com/{{Version}}/{{Artifact}}"},
+										{Key: "homepage", Value: "https://example.com"},
+									},
+								},
+								osarchbin.TypeName: {
+									Type: stringPtr(osarchbin.TypeName),
+									Config: &yaml.MapSlice{
+										{Key: "format", Value: "auto"},
+										{Key: "os-archs", Value: []map[string]string{
+											{"os": "darwin", "arch": "amd64"},
+											{"os": "linux", "arch": "arm64"},
+											{"os": "windows", "arch": "amd64"},
+										}},
+									},
+								},
+							}),
+						}),
+					},
+				}),
+			},
+			preDistAction: func(projectDir string, projectCfg distgoconfig.ProjectConfig) {
+				gittest.CreateGitTag(t, projectDir, "0.1.0")
+			},
+			validate: func(caseNum int, name, projectDir string) {
+				sha256Sum := func(artifactName string) string {
+					bytes, err := ioutil.ReadFile(path.Join(projectDir, "out", "dist", "foo", "0.1.0", "os-arch-bin", artifactName))
+					require.NoError(t, err, "Case %d: %s", caseNum, name)
+					return fmt.Sprintf("%x", sha256.Sum256(bytes))
+				}
+
+				bytes, err := ioutil.ReadFile(path.Join(projectDir, "out", "dist", "foo", "0.1.0", "brew", "foo.rb"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, fmt.Sprintf(`class Foo < Formula
+  desc "foo"
+  homepage "https://example.com"
+  version "0.1.0"
+
+  on_macos do
+    if Hardware::CPU.intel?
+      url "https://example.com/0.1.0/foo-0.1.0-darwin-amd64.tgz"
+      sha256 "%s"
+    end
+  end
+
+  on_linux do
+    if Hardware::CPU.arm?
+      url "https://example.com/0.1.0/foo-0.1.0-linux-arm64.tgz"
+      sha256 "%s"
+    end
+  end
+
+  def install
+    bin.install "foo"
+  end
+end
+`, sha256Sum("foo-0.1.0-darwin-amd64.tgz"), sha256Sum("foo-0.1.0-linux-arm64.tgz")), string(bytes), "Case %d: %s", caseNum, name)
+
+				bytes, err = ioutil.ReadFile(path.Join(projectDir, "out", "dist", "foo", "0.1.0", "brew", "foo.json"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, fmt.Sprintf(`{
+    "version": "0.1.0",
+    "homepage": "https://example.com",
+    "architecture": {
+        "64bit": {
+            "url": "https://example.com/0.1.0/foo-0.1.0-windows-amd64.zip",
+            "hash": "%s"
+        }
+    },
+    "bin": [
+        "foo.exe"
+    ]
+}
+`, sha256Sum("foo-0.1.0-windows-amd64.zip")), string(bytes), "Case %d: %s", caseNum, name)
+			},
+		},
+		{
+			name: "input-dir templates are rendered",
+			projectCfg: distgoconfig.ProjectConfig{
+				ProductDefaults: *distgoconfig.ToProductConfig(&distgoconfig.ProductConfig{
+					Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+						Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+							osarchbin.TypeName: {
+								Type:   defaultDisterCfg.Type,
+								Config: defaultDisterCfg.Config,
+								InputDir: distgoconfig.ToInputDirConfig(&distgoconfig.InputDirConfig{
+									Path:      "input-dir",
+									Templates: []string{"README.md", "conf/*.yml"},
+								}),
+							},
+						}),
+					}),
+				}),
+			},
+			preDistAction: func(projectDir string, projectCfg distgoconfig.ProjectConfig) {
+				for _, currFile := range []struct {
+					relPath string
+					content string
+				}{
+					{"README.md", fmt.Sprintf(`{{Product}} {{Version}} {{BuildArtifact "foo" "%v"}} {{range DistArtifacts "foo" "os-arch-bin"}}{{.}}{{end}}`, osarch.Current())},
+					{"docs/README.md", "{{Version}}"},
+					{"conf/app.yml", "version: {{Version}}"},
+					{"raw.yml", "version: {{Version}}"},
+				} {
+					inputFile := path.Join(projectDir, "input-dir", currFile.relPath)
+					err := os.MkdirAll(path.Dir(inputFile), 0755)
+					require.NoError(t, err)
+					err = ioutil.WriteFile(inputFile, []byte(currFile.content), 0644)
+					require.NoError(t, err)
+				}
+				gittest.CommitAllFiles(t, projectDir, "Commit input directory")
+				gittest.CreateGitTag(t, projectDir, "0.1.0")
+			},
+			validate: func(caseNum int, name, projectDir string) {
+				distWorkDir := path.Join(projectDir, "out", "dist", "foo", "0.1.0", "os-arch-bin", "foo-0.1.0")
+				for relPath, wantContent := range map[string]string{
+					"README.md":      fmt.Sprintf("foo 0.1.0 foo foo-0.1.0-%v.tgz", osarch.Current()),
+					"docs/README.md": "0.1.0",
+					"conf/app.yml":   "version: 0.1.0",
+					"raw.yml":        "version: {{Version}}",
+				} {
+					bytes, err := ioutil.ReadFile(path.Join(distWorkDir, relPath))
+					require.NoError(t, err, "Case %d: %s", caseNum, name)
+					assert.Equal(t, wantContent, string(bytes), "Case %d: %s: %s", caseNum, name, relPath)
+				}
+			},
+		},
+		{
+			name: "input-dir symlinks and empty directories are preserved",
+			projectCfg: distgoconfig.ProjectConfig{
+				ProductDefaults: *distgoconfig.ToProductConfig(&distgoconfig.ProductConfig{
+					Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+						Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+							osarchbin.TypeName: {
+								Type:   defaultDisterCfg.Type,
+								Config: defaultDisterCfg.Config,
+								InputDir: distgoconfig.ToInputDirConfig(&distgoconfig.InputDirConfig{
+									Path:             "input-dir",
+									PreserveSymlinks: true,
+								}),
+							},
+						}),
+					}),
+				}),
+			},
+			preDistAction: func(projectDir string, projectCfg distgoconfig.ProjectConfig) {
+				inputDir := path.Join(projectDir, "input-dir")
+				err := os.MkdirAll(path.Join(inputDir, "service", "1.0.0"), 0755)
+				require.NoError(t, err)
+				err = ioutil.WriteFile(path.Join(inputDir, "service", "1.0.0", "run.sh"), []byte("run"), 0755)
+				require.NoError(t, err)
+				err = ioutil.WriteFile(path.Join(inputDir, "README.md"), []byte("readme"), 0644)
+				require.NoError(t, err)
+				err = os.Symlink("1.0.0", path.Join(inputDir, "service", "current"))
+				require.NoError(t, err)
+				err = os.Symlink("README.md", path.Join(inputDir, "README"))
+				require.NoError(t, err)
+				gittest.CommitAllFiles(t, projectDir, "Commit input directory")
+				gittest.CreateGitTag(t, projectDir, "0.1.0")
+
+				// empty directories cannot be committed
+				err = os.MkdirAll(path.Join(inputDir, "var", "log"), 0755)
+				require.NoError(t, err)
+			},
+			validate: func(caseNum int, name, projectDir string) {
+				distWorkDir := path.Join(projectDir, "out", "dist", "foo", "0.1.0", "os-arch-bin", "foo-0.1.0")
+				for relPath, wantTarget := range map[string]string{
+					"service/current": "1.0.0",
+					"README":          "README.md",
+				} {
+					target, err := os.Readlink(path.Join(distWorkDir, relPath))
+					require.NoError(t, err, "Case %d: %s: %s", caseNum, name, relPath)
+					assert.Equal(t, wantTarget, target, "Case %d: %s: %s", caseNum, name, relPath)
+				}
+				bytes, err := ioutil.ReadFile(path.Join(distWorkDir, "service", "current", "run.sh"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, "run", string(bytes), "Case %d: %s", caseNum, name)
+
+				fi, err := os.Stat(path.Join(distWorkDir, "var", "log"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.True(t, fi.IsDir(), "Case %d: %s", caseNum, name)
+			},
+		},
+		{
+			name: "SBOM sidecar files are written for dist artifacts",
+			projectCfg: distgoconfig.ProjectConfig{
+				ProductDefaults: *distgoconfig.ToProductConfig(&distgoconfig.ProductConfig{
+					Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+						Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+							osarchbin.TypeName: {
+								Type:   defaultDisterCfg.Type,
+								Config: defaultDisterCfg.Config,
+								SBOM: distgoconfig.ToSBOMConfig(&distgoconfig.SBOMConfig{
+									Formats: []string{"spdx", "cyclonedx"},
+								}),
+							},
+						}),
+					}),
+				}),
+			},
+			preDistAction: func(projectDir string, projectCfg distgoconfig.ProjectConfig) {
+				gittest.CreateGitTag(t, projectDir, "0.1.0")
+			},
+			validate: func(caseNum int, name, projectDir string) {
+				distOutputDir := path.Join(projectDir, "out", "dist", "foo", "0.1.0", "os-arch-bin")
+				artifactName := fmt.Sprintf("foo-0.1.0-%v.tgz", osarch.Current())
+				artifactBytes, err := ioutil.ReadFile(path.Join(distOutputDir, artifactName))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				sha256Sum := fmt.Sprintf("%x", sha256.Sum256(artifactBytes))
+
+				// creation time of the SBOMs is the time of the HEAD commit so that they only depend on their content
+				commitTimeOutput, err := exec.Command("git", "-C", projectDir, "show", "-s", "--format=%ct", "HEAD").Output()
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				commitTime, err := strconv.ParseInt(strings.TrimSpace(string(commitTimeOutput)), 10, 64)
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				wantCreated := time.Unix(commitTime, 0).UTC().Format(time.RFC3339)
+
+				var spdxDoc struct {
+					Name         string `json:"name"`
+					CreationInfo struct {
+						Created string `json:"created"`
+					} `json:"creationInfo"`
+					Files []struct {
+						FileName  string `json:"fileName"`
+						Checksums []struct {
+							Algorithm     string `json:"algorithm"`
+							ChecksumValue string `json:"checksumValue"`
+						} `json:"checksums"`
+					} `json:"files"`
+				}
+				bytes, err := ioutil.ReadFile(path.Join(distOutputDir, "foo-0.1.0-os-arch-bin.spdx.json"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				err = json.Unmarshal(bytes, &spdxDoc)
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, "foo-0.1.0", spdxDoc.Name, "Case %d: %s", caseNum, name)
+				assert.Equal(t, wantCreated, spdxDoc.CreationInfo.Created, "Case %d: %s", caseNum, name)
+				require.Equal(t, 1, len(spdxDoc.Files), "Case %d: %s", caseNum, name)
+				assert.Equal(t, artifactName, spdxDoc.Files[0].FileName, "Case %d: %s", caseNum, name)
+				assert.Equal(t, "SHA256", spdxDoc.Files[0].Checksums[0].Algorithm, "Case %d: %s", caseNum, name)
+				assert.Equal(t, sha256Sum, spdxDoc.Files[0].Checksums[0].ChecksumValue, "Case %d: %s", caseNum, name)
+
+				var cycloneDXDoc struct {
+					Metadata struct {
+						Timestamp string `json:"timestamp"`
+						Component struct {
+							Name    string `json:"name"`
+							Version string `json:"version"`
+						} `json:"component"`
+					} `json:"metadata"`
+					Components []struct {
+						Name   string `json:"name"`
+						Hashes []struct {
+							Alg     string `json:"alg"`
+							Content string `json:"content"`
+						} `json:"hashes"`
+					} `json:"components"`
+				}
+				bytes, err = ioutil.ReadFile(path.Join(distOutputDir, "foo-0.1.0-os-arch-bin.cdx.json"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				err = json.Unmarshal(bytes, &cycloneDXDoc)
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, "foo", cycloneDXDoc.Metadata.Component.Name, "Case %d: %s", caseNum, name)
+				assert.Equal(t, "0.1.0", cycloneDXDoc.Metadata.Component.Version, "Case %d: %s", caseNum, name)
+				assert.Equal(t, wantCreated, cycloneDXDoc.Metadata.Timestamp, "Case %d: %s", caseNum, name)
+				require.Equal(t, 1, len(cycloneDXDoc.Components), "Case %d: %s", caseNum, name)
+				assert.Equal(t, artifactName, cycloneDXDoc.Components[0].Name, "Case %d: %s", caseNum, name)
+				assert.Equal(t, "SHA-256", cycloneDXDoc.Components[0].Hashes[0].Alg, "Case %d: %s", caseNum, name)
+				assert.Equal(t, sha256Sum, cycloneDXDoc.Components[0].Hashes[0].Content, "Case %d: %s", caseNum, name)
+			},
+		},
 	} {
 		projectDir, err := ioutil.TempDir(tmp, "")
 		require.NoError(t, err, "Case %d: %s", i, tc.name)
@@ -456,7 +871,7 @@ func main() {}
 		projectInfo, err := projectParam.ProjectInfo(projectDir)
 		require.NoError(t, err, "Case %d: %s", i, tc.name)
 
-		err = dist.Products(projectInfo, projectParam, nil, tc.productDistIDs, false, ioutil.Discard)
+		err = dist.Products(projectInfo, projectParam, nil, tc.productDistIDs, dist.Options{}, ioutil.Discard)
 		if tc.wantErrorRegexp == "" {
 			require.NoError(t, err, "Case %d: %s", i, tc.name)
 		} else {
@@ -470,6 +885,862 @@ func main() {}
 	}
 }
 
+func TestDistSBOMModules(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	projectDir, err := ioutil.TempDir(tmp, "")
+	require.NoError(t, err)
+	gittest.InitGitDir(t, projectDir)
+	_, err = gofiles.Write(projectDir, []gofiles.GoFileSpec{
+		{
+			RelPath: "go.mod",
+			Src: `module example.com/foo
+
+go 1.16
+
+require example.com/dep v1.0.0
+
+replace example.com/dep => example.com/fork v1.2.0
+`,
+		},
+		{
+			RelPath: "foo/main.go",
+			Src: `package main
+
+import "example.com/dep/sub"
+
+func main() {
+	sub.Foo()
+}
+`,
+		},
+		{
+			RelPath: "vendor/modules.txt",
+			Src: `# example.com/dep v1.0.0 => example.com/fork v1.2.0
+## explicit
+example.com/dep/sub
+# example.com/dep => example.com/fork v1.2.0
+`,
+		},
+		{
+			RelPath: "vendor/example.com/dep/sub/sub.go",
+			Src:     `package sub; func Foo() {}`,
+		},
+	})
+	require.NoError(t, err)
+	gittest.CommitAllFiles(t, projectDir, "Commit")
+	gittest.CreateGitTag(t, projectDir, "0.1.0")
+
+	defaultDisterCfg, err := disterfactory.DefaultConfig()
+	require.NoError(t, err)
+	projectCfg := distgoconfig.ProjectConfig{
+		Products: distgoconfig.ToProductsMap(map[distgo.ProductID]distgoconfig.ProductConfig{
+			"foo": {
+				Build: distgoconfig.ToBuildConfig(&distgoconfig.BuildConfig{
+					MainPkg: stringPtr("./foo"),
+					// the product is built in module mode using the vendored module
+					Environment: &map[string]string{
+						"GO111MODULE": "on",
+						"GOFLAGS":     "-mod=vendor",
+					},
+				}),
+				Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+					Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+						osarchbin.TypeName: {
+							Type:   defaultDisterCfg.Type,
+							Config: defaultDisterCfg.Config,
+							SBOM: distgoconfig.ToSBOMConfig(&distgoconfig.SBOMConfig{
+								Formats: []string{"spdx"},
+							}),
+						},
+					}),
+				}),
+			},
+		}),
+	}
+	projectParam := testfuncs.NewProjectParam(t, projectCfg, projectDir, "")
+	projectInfo, err := projectParam.ProjectInfo(projectDir)
+	require.NoError(t, err)
+
+	err = dist.Products(projectInfo, projectParam, nil, nil, dist.Options{}, ioutil.Discard)
+	require.NoError(t, err)
+
+	var spdxDoc struct {
+		Packages []struct {
+			Name         string `json:"name"`
+			VersionInfo  string `json:"versionInfo"`
+			ExternalRefs []struct {
+				ReferenceLocator string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+	}
+	bytes, err := ioutil.ReadFile(path.Join(projectDir, "out", "dist", "foo", "0.1.0", "os-arch-bin", "foo-0.1.0-os-arch-bin.spdx.json"))
+	require.NoError(t, err)
+	err = json.Unmarshal(bytes, &spdxDoc)
+	require.NoError(t, err)
+
+	// the first package is the product itself and the packages of the main module are not included
+	require.Equal(t, 2, len(spdxDoc.Packages), string(bytes))
+	assert.Equal(t, "example.com/dep/sub", spdxDoc.Packages[1].Name)
+	// the version and package URL are those of the module that replaces the required module
+	assert.Equal(t, "v1.2.0", spdxDoc.Packages[1].VersionInfo)
+	require.Equal(t, 1, len(spdxDoc.Packages[1].ExternalRefs))
+	assert.Equal(t, "pkg:golang/example.com/fork@v1.2.0#sub", spdxDoc.Packages[1].ExternalRefs[0].ReferenceLocator)
+}
+
+func TestDistSigning(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	defaultDisterCfg, err := disterfactory.DefaultConfig()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name string
+		// writeKey writes the signing key for the project and returns the signing configuration that uses it
+		writeKey func(t *testing.T, projectDir string) distgoconfig.SigningConfig
+		// verifyCmd returns the command that verifies the signature of the provided artifact
+		verifyCmd func(projectDir, artifactPath, signaturePath string) *exec.Cmd
+	}{
+		{
+			name: "gpg",
+			writeKey: func(t *testing.T, projectDir string) distgoconfig.SigningConfig {
+				writeGPGTestKey(t, projectDir, "signing-key.asc")
+				return distgoconfig.SigningConfig{
+					Type:    "gpg",
+					KeyFile: "signing-key.asc",
+				}
+			},
+			verifyCmd: func(projectDir, artifactPath, signaturePath string) *exec.Cmd {
+				return exec.Command("gpg", "--batch", "--homedir", path.Join(projectDir, "gpg-home"), "--verify", signaturePath, artifactPath)
+			},
+		},
+		{
+			name: "minisign",
+			writeKey: func(t *testing.T, projectDir string) distgoconfig.SigningConfig {
+				output, err := exec.Command("minisign", "-G", "-W", "-p", path.Join(projectDir, "minisign.pub"), "-s", path.Join(projectDir, "minisign.key")).CombinedOutput()
+				require.NoError(t, err, "Output: %s", string(output))
+				return distgoconfig.SigningConfig{
+					Type:    "minisign",
+					KeyFile: "minisign.key",
+				}
+			},
+			verifyCmd: func(projectDir, artifactPath, signaturePath string) *exec.Cmd {
+				return exec.Command("minisign", "-V", "-p", path.Join(projectDir, "minisign.pub"), "-m", artifactPath, "-x", signaturePath)
+			},
+		},
+		{
+			name: "cosign",
+			writeKey: func(t *testing.T, projectDir string) distgoconfig.SigningConfig {
+				// key pair is generated with an empty password, which is the password used for signing if no password
+				// environment variable is configured
+				cmd := exec.Command("cosign", "generate-key-pair")
+				cmd.Dir = projectDir
+				cmd.Env = append(os.Environ(), "COSIGN_PASSWORD=")
+				output, err := cmd.CombinedOutput()
+				require.NoError(t, err, "Output: %s", string(output))
+				return distgoconfig.SigningConfig{
+					Type:    "cosign",
+					KeyFile: "cosign.key",
+				}
+			},
+			verifyCmd: func(projectDir, artifactPath, signaturePath string) *exec.Cmd {
+				return exec.Command("cosign", "verify-blob", "--key", path.Join(projectDir, "cosign.pub"), "--signature", signaturePath, artifactPath)
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := exec.LookPath(tc.name); err != nil {
+				t.Skipf("%s is not available", tc.name)
+			}
+
+			projectDir, err := ioutil.TempDir(tmp, "")
+			require.NoError(t, err)
+			gittest.InitGitDir(t, projectDir)
+			err = os.MkdirAll(path.Join(projectDir, "foo"), 0755)
+			require.NoError(t, err)
+			err = ioutil.WriteFile(path.Join(projectDir, "foo", "main.go"), []byte(testMain), 0644)
+			require.NoError(t, err)
+			signingCfg := tc.writeKey(t, projectDir)
+			gittest.CommitAllFiles(t, projectDir, "Commit")
+			gittest.CreateGitTag(t, projectDir, "0.1.0")
+
+			projectCfg := distgoconfig.ProjectConfig{
+				ProductDefaults: *distgoconfig.ToProductConfig(&distgoconfig.ProductConfig{
+					Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+						Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+							osarchbin.TypeName: {
+								Type:   defaultDisterCfg.Type,
+								Config: defaultDisterCfg.Config,
+								Checksums: distgoconfig.ToChecksumsConfig(&distgoconfig.ChecksumsConfig{
+									Algorithms: []string{"sha256"},
+								}),
+							},
+						}),
+						Signing: distgoconfig.ToSigningConfig(&signingCfg),
+					}),
+				}),
+			}
+			projectParam := testfuncs.NewProjectParam(t, projectCfg, projectDir, "")
+			projectInfo, err := projectParam.ProjectInfo(projectDir)
+			require.NoError(t, err)
+
+			err = dist.Products(projectInfo, projectParam, nil, nil, dist.Options{}, ioutil.Discard)
+			require.NoError(t, err)
+
+			// the artifacts and the sidecar files are signed
+			signingParam := distgo.SigningParam{Type: signingCfg.Type}
+			distOutputDir := path.Join(projectDir, "out", "dist", "foo", "0.1.0", "os-arch-bin")
+			for _, currArtifact := range []string{
+				fmt.Sprintf("foo-0.1.0-%v.tgz", osarch.Current()),
+				"foo-0.1.0-os-arch-bin-SHA256SUMS",
+			} {
+				output, err := tc.verifyCmd(projectDir, path.Join(distOutputDir, currArtifact), path.Join(distOutputDir, signingParam.SignatureName(currArtifact))).CombinedOutput()
+				assert.NoError(t, err, "Artifact %s\nOutput: %s", currArtifact, string(output))
+			}
+		})
+	}
+}
+
+func TestDistParallel(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	defaultDisterCfg, err := disterfactory.DefaultConfig()
+	require.NoError(t, err)
+
+	const distScript = `#!/usr/bin/env bash
+echo "start $PRODUCT" >> "$PROJECT_DIR/dist.log"
+echo "output for $PRODUCT"
+sleep 0.5
+echo "end $PRODUCT" >> "$PROJECT_DIR/dist.log"
+`
+	productCfg := func(script string, deps ...distgo.ProductID) distgoconfig.ProductConfig {
+		cfg := distgoconfig.ProductConfig{
+			Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+				Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+					osarchbin.TypeName: {
+						Type:   defaultDisterCfg.Type,
+						Config: defaultDisterCfg.Config,
+						Script: stringPtr(script),
+					},
+				}),
+			}),
+		}
+		if len(deps) > 0 {
+			cfg.Dependencies = &deps
+		}
+		return cfg
+	}
+
+	for i, tc := range []struct {
+		name            string
+		products        map[distgo.ProductID]distgoconfig.ProductConfig
+		wantErrorRegexp string
+		validate        func(caseNum int, name, projectDir, output string)
+	}{
+		{
+			name: "independent products are run in parallel and dependent products are run after their dependencies",
+			products: map[distgo.ProductID]distgoconfig.ProductConfig{
+				"foo": productCfg(distScript, "bar"),
+				"bar": productCfg(distScript),
+				"baz": productCfg(distScript),
+			},
+			validate: func(caseNum int, name, projectDir, output string) {
+				// output of every unit is written contiguously
+				for _, product := range []string{"foo", "bar", "baz"} {
+					assert.Regexp(t, regexp.MustCompile(fmt.Sprintf(`(?m)^Creating distribution for %s at .+\noutput for %s\nFinished creating os-arch-bin distribution for %s$`, product, product, product)), output, "Case %d: %s", caseNum, name)
+				}
+
+				logBytes, err := ioutil.ReadFile(path.Join(projectDir, "dist.log"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				logLines := strings.Split(strings.TrimSpace(string(logBytes)), "\n")
+				lineIndex := func(line string) int {
+					for i, currLine := range logLines {
+						if currLine == line {
+							return i
+						}
+					}
+					return -1
+				}
+				for _, line := range []string{"start foo", "end foo", "start bar", "end bar", "start baz", "end baz"} {
+					assert.NotEqual(t, -1, lineIndex(line), "Case %d: %s\nLog:\n%s", caseNum, name, string(logBytes))
+				}
+				assert.True(t, lineIndex("end bar") < lineIndex("start foo"), "Case %d: %s\nLog:\n%s", caseNum, name, string(logBytes))
+				if runtime.NumCPU() > 1 {
+					assert.True(t, lineIndex("start baz") < lineIndex("end bar") && lineIndex("start bar") < lineIndex("end baz"), "Case %d: %s\nLog:\n%s", caseNum, name, string(logBytes))
+				}
+			},
+		},
+		{
+			name: "dists that depend on a failed dist are not run",
+			products: map[distgo.ProductID]distgoconfig.ProductConfig{
+				"foo": productCfg(distScript, "bar"),
+				"bar": productCfg(`#!/usr/bin/env bash
+echo "start $PRODUCT" >> "$PROJECT_DIR/dist.log"
+exit 1
+`),
+			},
+			wantErrorRegexp: `^dist failed for bar: failed to execute dist script: script execution failed: exit status 1$`,
+			validate: func(caseNum int, name, projectDir, output string) {
+				logBytes, err := ioutil.ReadFile(path.Join(projectDir, "dist.log"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, "start bar\n", string(logBytes), "Case %d: %s", caseNum, name)
+			},
+		},
+	} {
+		projectDir, err := ioutil.TempDir(tmp, "")
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+
+		gittest.InitGitDir(t, projectDir)
+		var specs []gofiles.GoFileSpec
+		for productID, productCfg := range tc.products {
+			specs = append(specs, gofiles.GoFileSpec{
+				RelPath: path.Join(string(productID), "main.go"),
+				Src:     testMain,
+			})
+			productCfg.Build = distgoconfig.ToBuildConfig(&distgoconfig.BuildConfig{
+				MainPkg: stringPtr(string(productID)),
+			})
+			tc.products[productID] = productCfg
+		}
+		_, err = gofiles.Write(projectDir, specs)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		err = ioutil.WriteFile(path.Join(projectDir, ".gitignore"), []byte("dist.log\n"), 0644)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		gittest.CommitAllFiles(t, projectDir, "Commit")
+		gittest.CreateGitTag(t, projectDir, "0.1.0")
+
+		projectCfg := distgoconfig.ProjectConfig{
+			Products: distgoconfig.ToProductsMap(tc.products),
+		}
+		projectParam := testfuncs.NewProjectParam(t, projectCfg, projectDir, fmt.Sprintf("Case %d: %s", i, tc.name))
+		projectInfo, err := projectParam.ProjectInfo(projectDir)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+
+		output := &bytes.Buffer{}
+		err = dist.Products(projectInfo, projectParam, nil, nil, dist.Options{
+			Parallel: true,
+		}, output)
+		if tc.wantErrorRegexp == "" {
+			require.NoError(t, err, "Case %d: %s\nOutput: %s", i, tc.name, output.String())
+		} else {
+			require.Error(t, err, fmt.Sprintf("Case %d: %s", i, tc.name))
+			assert.Regexp(t, regexp.MustCompile(tc.wantErrorRegexp), err.Error(), "Case %d: %s", i, tc.name)
+		}
+
+		if tc.validate != nil {
+			tc.validate(i, tc.name, projectDir, output.String())
+		}
+	}
+}
+
+func TestDistUpToDate(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	defaultDisterCfg, err := disterfactory.DefaultConfig()
+	require.NoError(t, err)
+
+	projectDir, err := ioutil.TempDir(tmp, "")
+	require.NoError(t, err)
+	gittest.InitGitDir(t, projectDir)
+	err = os.MkdirAll(path.Join(projectDir, "foo"), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path.Join(projectDir, "foo", "main.go"), []byte(testMain), 0644)
+	require.NoError(t, err)
+	// ignore the output directory and the generated source file so that the version does not change to a dirty version
+	err = ioutil.WriteFile(path.Join(projectDir, ".gitignore"), []byte("out/\nfoo/generated.go\n"), 0644)
+	require.NoError(t, err)
+	writeGenerated := func(value string) {
+		err := ioutil.WriteFile(path.Join(projectDir, "foo", "generated.go"), []byte(fmt.Sprintf("package main\n\nfunc init() {\n\ttestVersionVar = %q\n}\n", value)), 0644)
+		require.NoError(t, err)
+	}
+	writeGenerated("generated")
+	gittest.CommitAllFiles(t, projectDir, "Commit")
+	gittest.CreateGitTag(t, projectDir, "0.1.0")
+
+	runDist := func(script string) string {
+		projectCfg := distgoconfig.ProjectConfig{
+			ProductDefaults: *distgoconfig.ToProductConfig(&distgoconfig.ProductConfig{
+				Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+					Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+						osarchbin.TypeName: {
+							Type:   defaultDisterCfg.Type,
+							Config: defaultDisterCfg.Config,
+							Script: stringPtr(script),
+						},
+					}),
+				}),
+			}),
+		}
+		projectParam := testfuncs.NewProjectParam(t, projectCfg, projectDir, "")
+		projectInfo, err := projectParam.ProjectInfo(projectDir)
+		require.NoError(t, err)
+
+		configModTime := time.Now()
+		output := &bytes.Buffer{}
+		err = dist.Products(projectInfo, projectParam, &configModTime, nil, dist.Options{
+			Explain: true,
+		}, output)
+		require.NoError(t, err, "Output: %s", output.String())
+		return output.String()
+	}
+	const script = `#!/usr/bin/env bash
+echo hello`
+	osArch := osarch.Current().String()
+
+	output := runDist(script)
+	assert.Contains(t, output, fmt.Sprintf("foo requires build for %s: output foo does not exist\n", osArch))
+	assert.Contains(t, output, fmt.Sprintf("foo requires dist for os-arch-bin: output foo-0.1.0-%s.tgz does not exist\n", osArch))
+
+	// nothing is run if nothing changed
+	output = runDist(script)
+	assert.Equal(t, "", output)
+
+	// nothing is run if only modification times changed
+	future := time.Now().Add(time.Hour)
+	for _, currPath := range []string{
+		path.Join(projectDir, "foo", "main.go"),
+		path.Join(projectDir, "out", "build", "foo", "0.1.0", osArch, "foo"),
+	} {
+		err = os.Chtimes(currPath, future, future)
+		require.NoError(t, err)
+	}
+	output = runDist(script)
+	assert.Equal(t, "", output)
+
+	// dist is run if the script changed
+	output = runDist(script + " world")
+	assert.NotContains(t, output, "requires build")
+	assert.Contains(t, output, "foo requires dist for os-arch-bin: inputs changed: script\n")
+
+	// build and dist are run if the source changed
+	writeGenerated("regenerated")
+	output = runDist(script + " world")
+	assert.Contains(t, output, fmt.Sprintf("foo requires build for %s: inputs changed: go-files\n", osArch))
+	assert.Contains(t, output, "foo requires dist for os-arch-bin: inputs changed: build-artifacts\n")
+}
+
+func TestDistUpToDateDisterConfig(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	projectDir, err := ioutil.TempDir(tmp, "")
+	require.NoError(t, err)
+	gittest.InitGitDir(t, projectDir)
+	err = os.MkdirAll(path.Join(projectDir, "foo"), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path.Join(projectDir, "foo", "main.go"), []byte(testMain), 0644)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path.Join(projectDir, ".gitignore"), []byte("out/\n"), 0644)
+	require.NoError(t, err)
+	gittest.CommitAllFiles(t, projectDir, "Commit")
+	gittest.CreateGitTag(t, projectDir, "0.1.0")
+
+	// creates Disters that only store their configuration in unexported fields (like asset Disters do)
+	disterFactory, err := disterfactory.New([]dister.Creator{
+		dister.NewCreator(configDisterTypeName, func(cfgYML []byte) (distgo.Dister, error) {
+			return &configDister{
+				delegate: osarchbin.New(osarch.Current()),
+				cfgYML:   string(cfgYML),
+			}, nil
+		}),
+	}, nil)
+	require.NoError(t, err)
+
+	runDist := func(cfgValue string) string {
+		projectCfg := distgoconfig.ProjectConfig{
+			ProductDefaults: *distgoconfig.ToProductConfig(&distgoconfig.ProductConfig{
+				Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+					Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+						configDisterTypeName: {
+							Type: stringPtr(configDisterTypeName),
+							Config: &yaml.MapSlice{
+								{Key: "value", Value: cfgValue},
+							},
+						},
+					}),
+				}),
+			}),
+		}
+		projectVersionerFactory, err := projectversionerfactory.New(nil, nil)
+		require.NoError(t, err)
+		defaultDisterCfg, err := disterfactory.DefaultConfig()
+		require.NoError(t, err)
+		dockerBuilderFactory, err := dockerbuilderfactory.New(nil, nil)
+		require.NoError(t, err)
+		publisherFactory, err := publisherfactory.New(nil, nil)
+		require.NoError(t, err)
+		projectParam, err := projectCfg.ToParam(projectDir, projectVersionerFactory, disterFactory, defaultDisterCfg, dockerBuilderFactory, publisherFactory)
+		require.NoError(t, err)
+		projectInfo, err := projectParam.ProjectInfo(projectDir)
+		require.NoError(t, err)
+
+		configModTime := time.Now()
+		output := &bytes.Buffer{}
+		err = dist.Products(projectInfo, projectParam, &configModTime, nil, dist.Options{
+			Explain: true,
+		}, output)
+		require.NoError(t, err, "Output: %s", output.String())
+		return output.String()
+	}
+
+	output := runDist("foo")
+	assert.Contains(t, output, fmt.Sprintf("foo requires dist for %s: output foo-0.1.0-%s.tgz does not exist\n", configDisterTypeName, osarch.Current()))
+
+	// nothing is run if the configuration did not change
+	output = runDist("foo")
+	assert.Equal(t, "", output)
+
+	// dist is run if the configuration of the Dister changed
+	output = runDist("bar")
+	assert.Contains(t, output, fmt.Sprintf("foo requires dist for %s: inputs changed: dist-config\n", configDisterTypeName))
+}
+
+func TestDistSizeBudget(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	defaultDisterCfg, err := disterfactory.DefaultConfig()
+	require.NoError(t, err)
+
+	osArch := osarch.Current().String()
+	for i, tc := range []struct {
+		name            string
+		sizeBudget      distgoconfig.SizeBudgetConfig
+		wantErrorRegexp string
+		wantOutput      []string
+	}{
+		{
+			name:       "sizes are compared to previous version",
+			sizeBudget: distgoconfig.SizeBudgetConfig{Size: "1GiB"},
+			wantOutput: []string{
+				fmt.Sprintf("Size of foo-0.1.0-%s.tgz: ", osArch),
+				"compared to 0.0.1)\n",
+			},
+		},
+		{
+			name:            "dist fails if artifact exceeds maximum size",
+			sizeBudget:      distgoconfig.SizeBudgetConfig{Size: "1KB"},
+			wantErrorRegexp: fmt.Sprintf(`^dist failed for foo: size budget exceeded: foo-0\.1\.0-%s\.tgz is .+, which exceeds the maximum size of 1000 B$`, osArch),
+		},
+		{
+			name:       "dist prints warning if artifact exceeds maximum growth",
+			sizeBudget: distgoconfig.SizeBudgetConfig{MaxGrowth: "10%", Warn: true},
+			wantOutput: []string{
+				fmt.Sprintf("Warning: foo-0.1.0-%s.tgz grew by ", osArch),
+				"compared to 0.0.1, which exceeds the maximum growth of 10%\n",
+			},
+		},
+	} {
+		projectDir, err := ioutil.TempDir(tmp, "")
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		gittest.InitGitDir(t, projectDir)
+		err = os.MkdirAll(path.Join(projectDir, "foo"), 0755)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		err = ioutil.WriteFile(path.Join(projectDir, "foo", "main.go"), []byte(testMain), 0644)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		err = ioutil.WriteFile(path.Join(projectDir, ".gitignore"), []byte("out/\n"), 0644)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		gittest.CommitAllFiles(t, projectDir, "Commit")
+		gittest.CreateGitTag(t, projectDir, "0.1.0")
+
+		// artifact of previous version
+		prevArtifact := path.Join(projectDir, "out", "dist", "foo", "0.0.1", "os-arch-bin", fmt.Sprintf("foo-0.0.1-%s.tgz", osArch))
+		err = os.MkdirAll(path.Dir(prevArtifact), 0755)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		err = ioutil.WriteFile(prevArtifact, []byte("previous"), 0644)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+
+		sizeBudget := tc.sizeBudget
+		projectCfg := distgoconfig.ProjectConfig{
+			ProductDefaults: *distgoconfig.ToProductConfig(&distgoconfig.ProductConfig{
+				Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+					Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+						osarchbin.TypeName: {
+							Type:   defaultDisterCfg.Type,
+							Config: defaultDisterCfg.Config,
+						},
+					}),
+					MaxSize: distgoconfig.ToSizeBudgetConfig(&sizeBudget),
+				}),
+			}),
+		}
+		projectParam := testfuncs.NewProjectParam(t, projectCfg, projectDir, fmt.Sprintf("Case %d: %s", i, tc.name))
+		projectInfo, err := projectParam.ProjectInfo(projectDir)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+
+		output := &bytes.Buffer{}
+		err = dist.Products(projectInfo, projectParam, nil, nil, dist.Options{}, output)
+		if tc.wantErrorRegexp == "" {
+			require.NoError(t, err, "Case %d: %s\nOutput: %s", i, tc.name, output.String())
+		} else {
+			require.Error(t, err, fmt.Sprintf("Case %d: %s", i, tc.name))
+			assert.Regexp(t, regexp.MustCompile(tc.wantErrorRegexp), err.Error(), "Case %d: %s", i, tc.name)
+		}
+		for _, want := range tc.wantOutput {
+			assert.Contains(t, output.String(), want, "Case %d: %s", i, tc.name)
+		}
+	}
+}
+
+func TestDistLicenses(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	// vendored dependencies are only resolved for projects in a GOPATH
+	origGOPATH := build.Default.GOPATH
+	defer func() {
+		build.Default.GOPATH = origGOPATH
+		err := os.Setenv("GOPATH", origGOPATH)
+		require.NoError(t, err)
+	}()
+	build.Default.GOPATH = tmp
+	err = os.Setenv("GOPATH", tmp)
+	require.NoError(t, err)
+
+	defaultDisterCfg, err := disterfactory.DefaultConfig()
+	require.NoError(t, err)
+
+	const mitLicense = `The MIT License (MIT)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction.
+`
+	wantNotices := fmt.Sprintf(`foo uses the following third-party software.
+
+%s
+github.com/dep/mit (MIT)
+%s
+
+%s`, strings.Repeat("=", 80), strings.Repeat("=", 80), mitLicense)
+
+	for i, tc := range []struct {
+		name            string
+		disallowed      []string
+		licensesDir     string
+		disterType      string
+		disterCfg       *yaml.MapSlice
+		wantErrorRegexp string
+		validate        func(caseNum int, name, projectDir string)
+	}{
+		{
+			name: "license and notice files of dependencies are written to dist work directory",
+			validate: func(caseNum int, name, projectDir string) {
+				distWorkDir := path.Join(projectDir, "out", "dist", "foo", "0.1.0", "os-arch-bin", "foo-0.1.0")
+				bytes, err := ioutil.ReadFile(path.Join(distWorkDir, "licenses", "github.com", "dep", "mit", "LICENSE"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, mitLicense, string(bytes), "Case %d: %s", caseNum, name)
+
+				bytes, err = ioutil.ReadFile(path.Join(distWorkDir, "THIRD_PARTY_NOTICES"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, wantNotices, string(bytes), "Case %d: %s", caseNum, name)
+			},
+		},
+		{
+			name:            "dist fails if dependency uses a disallowed license",
+			disallowed:      []string{"GPL-3.0", "MIT"},
+			wantErrorRegexp: `(?s)failed to write third-party license notices: dependencies use disallowed licenses:\ngithub.com/dep/mit uses disallowed license MIT$`,
+		},
+		{
+			name:        "license and notice files are written to the configured directory",
+			licensesDir: "doc/third-party",
+			validate: func(caseNum int, name, projectDir string) {
+				distWorkDir := path.Join(projectDir, "out", "dist", "foo", "0.1.0", "os-arch-bin", "foo-0.1.0")
+				bytes, err := ioutil.ReadFile(path.Join(distWorkDir, "doc", "third-party", "THIRD_PARTY_NOTICES"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, wantNotices, string(bytes), "Case %d: %s", caseNum, name)
+				_, err = os.Stat(path.Join(distWorkDir, "THIRD_PARTY_NOTICES"))
+				assert.True(t, os.IsNotExist(err), "Case %d: %s", caseNum, name)
+			},
+		},
+		{
+			name:       "license and notice files are installed in the documentation directory of Debian packages",
+			disterType: "deb",
+			disterCfg: &yaml.MapSlice{
+				{Key: "os-archs", Value: []map[string]string{{"os": "linux", "arch": "amd64"}}},
+				{Key: "prefix", Value: "/opt/foo"},
+			},
+			validate: func(caseNum int, name, projectDir string) {
+				distWorkDir := path.Join(projectDir, "out", "dist", "foo", "0.1.0", "deb", "foo-0.1.0")
+				bytes, err := ioutil.ReadFile(path.Join(distWorkDir, "opt", "foo", "share", "doc", "foo", "THIRD_PARTY_NOTICES"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, wantNotices, string(bytes), "Case %d: %s", caseNum, name)
+				for _, rootName := range []string{"THIRD_PARTY_NOTICES", "licenses"} {
+					_, err = os.Stat(path.Join(distWorkDir, rootName))
+					assert.True(t, os.IsNotExist(err), "Case %d: %s", caseNum, name)
+				}
+
+				if _, err := exec.LookPath("dpkg-deb"); err != nil {
+					return
+				}
+				output, err := exec.Command("dpkg-deb", "--contents", path.Join(projectDir, "out", "dist", "foo", "0.1.0", "deb", "foo-0.1.0-linux-amd64.deb")).CombinedOutput()
+				require.NoError(t, err, "Case %d: %s\nOutput: %s", caseNum, name, string(output))
+				assert.Contains(t, string(output), " ./opt/foo/share/doc/foo/THIRD_PARTY_NOTICES\n", "Case %d: %s", caseNum, name)
+				assert.Contains(t, string(output), " ./opt/foo/share/doc/foo/licenses/github.com/dep/mit/LICENSE\n", "Case %d: %s", caseNum, name)
+				assert.NotContains(t, string(output), " ./THIRD_PARTY_NOTICES\n", "Case %d: %s", caseNum, name)
+				assert.NotContains(t, string(output), " ./licenses/", "Case %d: %s", caseNum, name)
+			},
+		},
+		{
+			name:       "license and notice files are installed in the documentation directory of RPM packages",
+			disterType: "rpm",
+			disterCfg: &yaml.MapSlice{
+				{Key: "os-archs", Value: []map[string]string{{"os": "linux", "arch": "amd64"}}},
+				{Key: "package-name", Value: "foo-pkg"},
+			},
+			validate: func(caseNum int, name, projectDir string) {
+				distWorkDir := path.Join(projectDir, "out", "dist", "foo", "0.1.0", "rpm", "foo-0.1.0")
+				bytes, err := ioutil.ReadFile(path.Join(distWorkDir, "usr", "share", "doc", "foo-pkg", "THIRD_PARTY_NOTICES"))
+				require.NoError(t, err, "Case %d: %s", caseNum, name)
+				assert.Equal(t, wantNotices, string(bytes), "Case %d: %s", caseNum, name)
+				_, err = os.Stat(path.Join(distWorkDir, "usr", "share", "doc", "foo-pkg", "licenses", "github.com", "dep", "mit", "LICENSE"))
+				assert.NoError(t, err, "Case %d: %s", caseNum, name)
+				for _, rootName := range []string{"THIRD_PARTY_NOTICES", "licenses"} {
+					_, err = os.Stat(path.Join(distWorkDir, rootName))
+					assert.True(t, os.IsNotExist(err), "Case %d: %s", caseNum, name)
+				}
+
+				if _, err := exec.LookPath("rpm"); err != nil {
+					return
+				}
+				output, err := exec.Command("rpm", "-qlp", path.Join(projectDir, "out", "dist", "foo", "0.1.0", "rpm", "foo-0.1.0-linux-amd64.rpm")).CombinedOutput()
+				require.NoError(t, err, "Case %d: %s\nOutput: %s", caseNum, name, string(output))
+				assert.Contains(t, string(output), "/usr/share/doc/foo-pkg/THIRD_PARTY_NOTICES\n", "Case %d: %s", caseNum, name)
+				assert.NotContains(t, string(output), "\n/THIRD_PARTY_NOTICES\n", "Case %d: %s", caseNum, name)
+			},
+		},
+	} {
+		projectDir := path.Join(tmp, "src", "github.com", "org", fmt.Sprintf("project-%d", i))
+		err := os.MkdirAll(projectDir, 0755)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		gittest.InitGitDir(t, projectDir)
+		_, err = gofiles.Write(projectDir, []gofiles.GoFileSpec{
+			{
+				RelPath: "foo/main.go",
+				Src: `package main
+
+import "github.com/dep/mit"
+
+func main() {
+	mit.Foo()
+}
+`,
+			},
+			{
+				RelPath: "vendor/github.com/dep/mit/mit.go",
+				Src:     "package mit\n\nfunc Foo() {}\n",
+			},
+		})
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		err = ioutil.WriteFile(path.Join(projectDir, "vendor", "github.com", "dep", "mit", "LICENSE"), []byte(mitLicense), 0644)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		gittest.CommitAllFiles(t, projectDir, "Commit")
+		gittest.CreateGitTag(t, projectDir, "0.1.0")
+
+		disterType, disterCfg := osarchbin.TypeName, defaultDisterCfg.Config
+		var buildCfg *distgoconfig.BuildConfig
+		if tc.disterType != "" {
+			disterType, disterCfg = tc.disterType, tc.disterCfg
+			buildCfg = &distgoconfig.BuildConfig{
+				OSArchs: &[]osarch.OSArch{{OS: "linux", Arch: "amd64"}},
+			}
+		}
+		projectCfg := distgoconfig.ProjectConfig{
+			ProductDefaults: *distgoconfig.ToProductConfig(&distgoconfig.ProductConfig{
+				Build: distgoconfig.ToBuildConfig(buildCfg),
+				Dist: distgoconfig.ToDistConfig(&distgoconfig.DistConfig{
+					Disters: distgoconfig.ToDistersConfig(&distgoconfig.DistersConfig{
+						distgo.DistID(disterType): {
+							Type:   stringPtr(disterType),
+							Config: disterCfg,
+							Licenses: distgoconfig.ToLicensesConfig(&distgoconfig.LicensesConfig{
+								Disallowed: tc.disallowed,
+								Dir:        tc.licensesDir,
+							}),
+						},
+					}),
+				}),
+			}),
+		}
+		projectParam := testfuncs.NewProjectParam(t, projectCfg, projectDir, fmt.Sprintf("Case %d: %s", i, tc.name))
+		projectInfo, err := projectParam.ProjectInfo(projectDir)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+
+		err = dist.Products(projectInfo, projectParam, nil, nil, dist.Options{}, ioutil.Discard)
+		if tc.wantErrorRegexp == "" {
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+		} else {
+			require.Error(t, err, fmt.Sprintf("Case %d: %s", i, tc.name))
+			assert.Regexp(t, regexp.MustCompile(tc.wantErrorRegexp), err.Error(), "Case %d: %s", i, tc.name)
+		}
+
+		if tc.validate != nil {
+			tc.validate(i, tc.name, projectDir)
+		}
+	}
+}
+
+// writeGPGTestKey generates a throwaway GPG key in the "gpg-home" directory of the project and exports its private key
+// to the provided path.
+func writeGPGTestKey(t *testing.T, projectDir, keyFile string) {
+	gpgHome := path.Join(projectDir, "gpg-home")
+	err := os.Mkdir(gpgHome, 0700)
+	require.NoError(t, err)
+	defer func() {
+		_ = exec.Command("gpgconf", "--homedir", gpgHome, "--kill", "gpg-agent").Run()
+	}()
+
+	output, err := exec.Command("gpg", "--batch", "--homedir", gpgHome, "--passphrase", "", "--quick-gen-key", "Test <test@example.com>", "default", "default", "never").CombinedOutput()
+	require.NoError(t, err, "Output: %s", string(output))
+	keyBytes, err := exec.Command("gpg", "--batch", "--homedir", gpgHome, "--armor", "--export-secret-keys").Output()
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path.Join(projectDir, keyFile), keyBytes, 0600)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path.Join(gpgHome, ".gitignore"), []byte("*\n"), 0644)
+	require.NoError(t, err)
+}
+
+const configDisterTypeName = "config-dister"
+
+// configDister is a distgo.Dister that delegates to another Dister and only stores its configuration in unexported
+// fields.
+type configDister struct {
+	delegate distgo.Dister
+	cfgYML   string
+}
+
+func (d *configDister) TypeName() (string, error) {
+	return configDisterTypeName, nil
+}
+
+func (d *configDister) Artifacts(renderedName string) ([]string, error) {
+	return d.delegate.Artifacts(renderedName)
+}
+
+func (d *configDister) PackagingExtension() (string, error) {
+	return d.delegate.PackagingExtension()
+}
+
+func (d *configDister) RunDist(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo) ([]byte, error) {
+	return d.delegate.RunDist(distID, productTaskOutputInfo)
+}
+
+func (d *configDister) GenerateDistArtifacts(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo, runDistResult []byte) error {
+	return d.delegate.GenerateDistArtifacts(distID, productTaskOutputInfo, runDistResult)
+}
+
 func stringPtr(in string) *string {
 	return &in
 }
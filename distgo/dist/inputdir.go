@@ -0,0 +1,126 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"text/template"
+
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+// renderInputDirTemplate renders the file at srcPath as a template using the provided functions and writes the result
+// to dstPath using the permissions of the source file.
+func renderInputDirTemplate(srcPath, dstPath string, templateFns []distgo.TemplateFunction) error {
+	fi, err := os.Stat(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", srcPath)
+	}
+	content, err := ioutil.ReadFile(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", srcPath)
+	}
+	rendered, err := distgo.RenderTemplate(string(content), nil, templateFns...)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(dstPath, []byte(rendered), fi.Mode().Perm()); err != nil {
+		return errors.Wrapf(err, "failed to write %s", dstPath)
+	}
+	return nil
+}
+
+// inputDirTemplateFunctions returns the functions that can be used in the input directory files that are rendered as
+// templates.
+func inputDirTemplateFunctions(productTaskOutputInfo distgo.ProductTaskOutputInfo) []distgo.TemplateFunction {
+	var repository string
+	if productTaskOutputInfo.Product.DockerOutputInfos != nil {
+		repository = productTaskOutputInfo.Product.DockerOutputInfos.Repository
+	}
+	return []distgo.TemplateFunction{
+		distgo.ProductTemplateFunction(productTaskOutputInfo.Product.ID),
+		distgo.VersionTemplateFunction(productTaskOutputInfo.Project.Version),
+		distgo.RepositoryTemplateFunction(repository),
+		distgo.RepositoryLiteralTemplateFunction(repository),
+		buildArtifactTemplateFunction(productTaskOutputInfo),
+		distArtifactsTemplateFunction(productTaskOutputInfo),
+	}
+}
+
+func buildArtifactTemplateFunction(productTaskOutputInfo distgo.ProductTaskOutputInfo) distgo.TemplateFunction {
+	allOutputInfos := productTaskOutputInfo.AllProductOutputInfosMap()
+	return func(fnMap template.FuncMap) {
+		fnMap["BuildArtifact"] = func(productID, osArchStr string) (string, error) {
+			productOutputInfo, err := templateProductOutputInfo(productTaskOutputInfo.Product.ID, allOutputInfos, productID)
+			if err != nil {
+				return "", err
+			}
+			if productOutputInfo.BuildOutputInfo == nil {
+				return "", errors.Errorf("product %s does not declare build outputs", productID)
+			}
+			osArch, err := osarch.New(osArchStr)
+			if err != nil {
+				return "", errors.Wrapf(err, "input %s is not a valid OS/Arch", osArchStr)
+			}
+			artifactPath, ok := distgo.ProductBuildArtifactPaths(productTaskOutputInfo.Project, productOutputInfo)[osArch]
+			if !ok {
+				return "", errors.Errorf("OS/Arch %s is not a build OS/Arch of product %s", osArchStr, productID)
+			}
+			return path.Base(artifactPath), nil
+		}
+	}
+}
+
+func distArtifactsTemplateFunction(productTaskOutputInfo distgo.ProductTaskOutputInfo) distgo.TemplateFunction {
+	allOutputInfos := productTaskOutputInfo.AllProductOutputInfosMap()
+	return func(fnMap template.FuncMap) {
+		fnMap["DistArtifacts"] = func(productID, distID string) ([]string, error) {
+			productOutputInfo, err := templateProductOutputInfo(productTaskOutputInfo.Product.ID, allOutputInfos, productID)
+			if err != nil {
+				return nil, err
+			}
+			if productOutputInfo.DistOutputInfos == nil {
+				return nil, errors.Errorf("product %s does not declare dist outputs", productID)
+			}
+			artifactPaths, ok := distgo.ProductDistArtifactPaths(productTaskOutputInfo.Project, productOutputInfo)[distgo.DistID(distID)]
+			if !ok {
+				return nil, errors.Errorf("dist %s is not defined for product %s", distID, productID)
+			}
+			var names []string
+			for _, artifactPath := range artifactPaths {
+				names = append(names, path.Base(artifactPath))
+			}
+			return names, nil
+		}
+	}
+}
+
+func templateProductOutputInfo(primaryProductID distgo.ProductID, allOutputInfos map[distgo.ProductID]distgo.ProductOutputInfo, productID string) (distgo.ProductOutputInfo, error) {
+	productOutputInfo, ok := allOutputInfos[distgo.ProductID(productID)]
+	if !ok {
+		return distgo.ProductOutputInfo{}, errors.Errorf("product %s is not the product or a dependent product of %s", productID, primaryProductID)
+	}
+	return productOutputInfo, nil
+}
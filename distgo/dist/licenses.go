@@ -0,0 +1,199 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/termie/go-shutil"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/imports"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/licenses"
+)
+
+const (
+	// ThirdPartyNoticesFileName is the name of the file written to the licenses directory of the dist work directory
+	// that contains the content of the license and notice files of all of the dependencies of the product.
+	ThirdPartyNoticesFileName = "THIRD_PARTY_NOTICES"
+	// LicensesDirName is the name of the directory in the licenses directory of the dist work directory to which the
+	// license and notice files of the dependencies of the product are copied.
+	LicensesDirName = "licenses"
+)
+
+// licensesDir returns the directory in the provided dist work directory to which the license and notice files are
+// written for the provided DisterParam.
+func licensesDir(distWorkDir string, productID distgo.ProductID, disterParam distgo.DisterParam) string {
+	if disterParam.Licenses != nil && disterParam.Licenses.Dir != "" {
+		return path.Join(distWorkDir, disterParam.Licenses.Dir)
+	}
+	if docDister, ok := disterParam.Dister.(distgo.DocDister); ok {
+		return path.Join(distWorkDir, docDister.DocDir(productID))
+	}
+	return distWorkDir
+}
+
+// writeLicenses writes the license and notice files of the dependencies of the product and its dependent products to
+// the provided directory. Returns an error if any of the dependencies uses a license type that is disallowed by the
+// provided LicensesParam.
+func writeLicenses(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam, licensesParam distgo.LicensesParam, dstDir string) error {
+	modules, err := productModules(projectInfo, productParam)
+	if err != nil {
+		return err
+	}
+
+	disallowed := make(map[string]struct{})
+	for _, licenseType := range licensesParam.Disallowed {
+		disallowed[licenseType] = struct{}{}
+	}
+	var violations []string
+	for _, module := range modules {
+		for _, licenseType := range module.Types {
+			if _, ok := disallowed[licenseType]; ok {
+				violations = append(violations, fmt.Sprintf("%s uses disallowed license %s", module.Path, licenseType))
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return errors.Errorf("dependencies use disallowed licenses:\n%s", strings.Join(violations, "\n"))
+	}
+
+	notices := &bytes.Buffer{}
+	fmt.Fprintf(notices, "%s uses the following third-party software.\n", productParam.ID)
+	for _, module := range modules {
+		fmt.Fprintf(notices, "\n%s\n%s (%s)\n%s\n", strings.Repeat("=", 80), module.Path, strings.Join(module.Types, ", "), strings.Repeat("=", 80))
+
+		moduleLicensesDir := path.Join(dstDir, LicensesDirName, module.Path)
+		if len(module.Files) > 0 {
+			if err := os.MkdirAll(moduleLicensesDir, 0755); err != nil {
+				return errors.Wrapf(err, "failed to create directory %s", moduleLicensesDir)
+			}
+		}
+		for _, currFile := range module.Files {
+			srcPath := path.Join(module.Dir, currFile)
+			if _, err := shutil.Copy(srcPath, path.Join(moduleLicensesDir, currFile), false); err != nil {
+				return errors.Wrapf(err, "failed to copy file %s", srcPath)
+			}
+			content, err := ioutil.ReadFile(srcPath)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read file %s", srcPath)
+			}
+			fmt.Fprintf(notices, "\n%s\n", strings.TrimRight(string(content), "\n"))
+		}
+	}
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", dstDir)
+	}
+	noticesPath := path.Join(dstDir, ThirdPartyNoticesFileName)
+	if err := ioutil.WriteFile(noticesPath, notices.Bytes(), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", noticesPath)
+	}
+	return nil
+}
+
+// productModules returns the modules that contain the non-standard library packages imported by the main packages of
+// the product and its dependent products.
+func productModules(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam) ([]licenses.Module, error) {
+	pkgDirs, err := productPkgDirs(projectInfo, productParam)
+	if err != nil {
+		return nil, err
+	}
+	return licenses.Find(projectInfo.ProjectDir, pkgDirs)
+}
+
+// productPkgDirs returns the sorted directories of the non-standard library packages imported by the main packages of
+// the product and its dependent products (including the main packages themselves).
+func productPkgDirs(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam) ([]string, error) {
+	files, err := productFiles(projectInfo, productParam)
+	if err != nil {
+		return nil, err
+	}
+	return sortedPkgDirs(files), nil
+}
+
+// productFiles returns the input files of the main packages of the product and its dependent products for the host
+// build context and the build environment of the products (so that the packages are resolved in module mode if the
+// products are built in module mode). The packages, import paths and modules of all of the main packages are merged.
+func productFiles(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam) (imports.Files, error) {
+	mainPkgEnvs := make(map[string]map[string]string)
+	if productParam.Build != nil {
+		mainPkgEnvs[productParam.Build.MainPkg] = productParam.Build.Environment
+	}
+	var depIDs []string
+	for depID := range productParam.AllDependencies {
+		depIDs = append(depIDs, string(depID))
+	}
+	sort.Strings(depIDs)
+	for _, depID := range depIDs {
+		depBuildParam := productParam.AllDependencies[distgo.ProductID(depID)].Build
+		if depBuildParam == nil {
+			continue
+		}
+		if _, ok := mainPkgEnvs[depBuildParam.MainPkg]; !ok {
+			mainPkgEnvs[depBuildParam.MainPkg] = depBuildParam.Environment
+		}
+	}
+	var sortedMainPkgs []string
+	for mainPkg := range mainPkgEnvs {
+		sortedMainPkgs = append(sortedMainPkgs, mainPkg)
+	}
+	sort.Strings(sortedMainPkgs)
+
+	merged := imports.Files{
+		Packages:    make(imports.GoFiles),
+		ImportPaths: make(map[string]string),
+		Modules:     make(map[string]imports.Module),
+	}
+	for _, mainPkg := range sortedMainPkgs {
+		files, err := imports.Resolve(path.Join(projectInfo.ProjectDir, mainPkg), imports.Options{
+			Env: mainPkgEnvs[mainPkg],
+		})
+		if err != nil {
+			return imports.Files{}, errors.Wrapf(err, "failed to determine imports of %s", mainPkg)
+		}
+		for pkgDir, goFiles := range files.Packages {
+			merged.Packages[pkgDir] = goFiles
+		}
+		for pkgDir, importPath := range files.ImportPaths {
+			merged.ImportPaths[pkgDir] = importPath
+		}
+		for pkgDir, module := range files.Modules {
+			merged.Modules[pkgDir] = module
+		}
+	}
+	return merged, nil
+}
+
+// sortedPkgDirs returns the sorted directories of the packages in the provided files.
+func sortedPkgDirs(files imports.Files) []string {
+	var pkgDirs []string
+	for pkgDir := range files.Packages {
+		pkgDirs = append(pkgDirs, pkgDir)
+	}
+	sort.Strings(pkgDirs)
+	return pkgDirs
+}
@@ -20,27 +20,42 @@ Sniperkit-Bot
 package dist
 
 import (
+	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/palantir/pkg/matcher"
 	"github.com/pkg/errors"
 
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/state"
 )
 
 // RequiresDist returns a pointer to a distgo.ProductParam that contains only the Dister parameters for the output dist
 // artifacts that require generation. A product is considered to require generating dist artifacts if any of the
 // following is true:
-//   * Any of the dist artifact output paths do not exist
-//   * The product's dist configuration (as specified by configModTime) is more recent than any of its dist artifacts
-//   * The product has dependencies and any of the dependent build or dist artifacts are newer (have a later
-//     modification date) than any of the dist artifacts for the provided product
-//   * The product does not define a dist configuration
+//   - configModTime is nil (which is used to force generating the dist artifacts)
+//   - Any of the dist artifacts do not exist or were modified after they were generated
+//   - The digests of the inputs of the dist differ from the ones recorded in the state file written when the dist
+//     artifacts were generated. The inputs of a dist are the version, the dist configuration, the dist script, the
+//     contents of the input directory, the build artifacts of the product and its dependencies and the dist artifacts
+//     of the dependencies.
+//   - The product does not define a dist configuration
 //
 // Returns nil if all of the outputs exist and are up-to-date.
 func RequiresDist(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam, configModTime *time.Time) (*distgo.ProductParam, error) {
+	requiresDistParam, _, err := RequiresDistWithReasons(projectInfo, productParam, configModTime)
+	return requiresDistParam, err
+}
+
+// RequiresDistWithReasons returns the same distgo.ProductParam as RequiresDist along with a map from the DistIDs that
+// require generation to a description of why generation is required.
+func RequiresDistWithReasons(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam, configModTime *time.Time) (*distgo.ProductParam, map[distgo.DistID]string, error) {
 	if productParam.Dist == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	// create a copy of the dist parameter so that it is safe for modification
@@ -49,104 +64,195 @@ func RequiresDist(projectInfo distgo.ProjectInfo, productParam distgo.ProductPar
 
 	productTaskOutputInfo, err := distgo.ToProductTaskOutputInfo(projectInfo, productParam)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to compute output information for %s", productParam.ID)
+		return nil, nil, errors.Wrapf(err, "failed to compute output information for %s", productParam.ID)
 	}
 
-	requiresDistIDs := make(map[distgo.DistID]struct{})
+	reasons := make(map[distgo.DistID]string)
 	for _, currDistID := range productTaskOutputInfo.Product.DistOutputInfos.DistIDs {
-		if !disterRequiresDist(currDistID, productTaskOutputInfo, configModTime) {
+		reason, err := disterStaleReason(currDistID, productParam, productTaskOutputInfo, configModTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		if reason == "" {
 			continue
 		}
-		requiresDistIDs[currDistID] = struct{}{}
+		reasons[currDistID] = reason
 	}
 
-	if len(requiresDistIDs) == 0 {
-		return nil, nil
+	if len(reasons) == 0 {
+		return nil, nil, nil
 	}
 	requiresDistParams := make(map[distgo.DistID]distgo.DisterParam)
 	for distID, distParam := range productParam.Dist.DistParams {
-		if _, ok := requiresDistIDs[distID]; !ok {
+		if _, ok := reasons[distID]; !ok {
 			continue
 		}
 		requiresDistParams[distID] = distParam
 	}
 	productParam.Dist.DistParams = requiresDistParams
-	return &productParam, nil
+	return &productParam, reasons, nil
 }
 
-func disterRequiresDist(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo, configModTime *time.Time) bool {
-	// determine oldest dist artifact for current Dister. If any artifact is missing, Dister needs to be run.
-	oldestDistTime := time.Now()
-	for _, currArtifactPath := range productTaskOutputInfo.ProductDistWorkDirsAndArtifactPaths()[distID] {
-		fi, err := os.Stat(currArtifactPath)
-		if err != nil {
-			return true
-		}
-		if fiModTime := fi.ModTime(); fiModTime.Before(oldestDistTime) {
-			oldestDistTime = fiModTime
-		}
+func disterStaleReason(distID distgo.DistID, productParam distgo.ProductParam, productTaskOutputInfo distgo.ProductTaskOutputInfo, configModTime *time.Time) (string, error) {
+	if configModTime == nil {
+		return "dist was forced", nil
+	}
+	inputs, err := distInputs(distID, productParam, productTaskOutputInfo)
+	if err != nil {
+		return fmt.Sprintf("failed to compute inputs: %v", err), nil
 	}
+	return state.StaleReason(distStateFilePath(distID, productTaskOutputInfo), inputs, productTaskOutputInfo.ProductDistArtifactPaths()[distID])
+}
+
+// distInputs returns the digests of the inputs of the dist for the provided DistID.
+func distInputs(distID distgo.DistID, productParam distgo.ProductParam, productTaskOutputInfo distgo.ProductTaskOutputInfo) (map[string]string, error) {
+	projectInfo := productTaskOutputInfo.Project
+	distParam := productParam.Dist
+	disterParam := distParam.DistParams[distID]
 
-	// if the newest build artifact is more recent than the oldest dist, consider dist out-of-date
-	if newestBuildArtifactForDist := newestArtifactModTime(buildArtifactPaths(productTaskOutputInfo.Project, productTaskOutputInfo.Product)); newestBuildArtifactForDist != nil && newestBuildArtifactForDist.Truncate(time.Second).After(oldestDistTime.Truncate(time.Second)) {
-		return true
+	disterType, err := disterParam.Dister.TypeName()
+	if err != nil {
+		return nil, err
+	}
+	distConfigDigest, err := state.JSONDigest(struct {
+		Type              string
+		Dister            distgo.Dister
+		DisterConfig      string
+		NameTemplate      string
+		InputDirTemplates []string
+		InputDirSymlinks  bool
+		Checksums         distgo.ChecksumsParam
+		SBOM              distgo.SBOMParam
+		Signing           *distgo.SigningParam
+		Licenses          *distgo.LicensesParam
+	}{
+		Type:              disterType,
+		Dister:            disterParam.Dister,
+		DisterConfig:      string(disterParam.DisterConfig),
+		NameTemplate:      disterParam.NameTemplate,
+		InputDirTemplates: disterParam.InputDir.Templates,
+		InputDirSymlinks:  disterParam.InputDir.PreserveSymlinks,
+		Checksums:         disterParam.Checksums,
+		SBOM:              disterParam.SBOM,
+		Signing:           distParam.Signing,
+		Licenses:          disterParam.Licenses,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// if the configuration modification time was not provided or was modified more recently than the oldest dist
-	// artifact, consider it out-of-date. Truncate times to second granularity for purposes of comparison. If mod time
-	// and artifact generation time are the same, consider out-of-date and run.
-	if configModTime == nil || !configModTime.Truncate(time.Second).Before(oldestDistTime.Truncate(time.Second)) {
-		return true
+	inputDirFiles := make(map[string]string)
+	if disterParam.InputDir.Path != "" {
+		inputDirFiles, err = inputDirFilePaths(path.Join(projectInfo.ProjectDir, disterParam.InputDir.Path), disterParam.InputDir.Exclude)
+		if err != nil {
+			return nil, err
+		}
+	}
+	inputDirDigest, err := state.FilesDigest(inputDirFiles)
+	if err != nil {
+		return nil, err
 	}
 
-	// if any dependent artifact (build or dist) is newer than the oldest dist artifact, consider dist artifact out-of-date
-	for _, depProductOutputInfo := range productTaskOutputInfo.Deps {
-		newestDependencyTime := newestArtifactModTime(append(
-			buildArtifactPaths(productTaskOutputInfo.Project, depProductOutputInfo),
-			distArtifactPaths(productTaskOutputInfo.Project, distID, depProductOutputInfo)...,
-		))
-		if newestDependencyTime != nil && newestDependencyTime.After(oldestDistTime) {
-			return true
+	licenseFiles := make(map[string]string)
+	if disterParam.Licenses != nil {
+		modules, err := productModules(projectInfo, productParam)
+		if err != nil {
+			return nil, err
+		}
+		for _, module := range modules {
+			for _, currFile := range module.Files {
+				licenseFiles[path.Join(module.Path, currFile)] = path.Join(module.Dir, currFile)
+			}
 		}
 	}
-	return false
-}
+	licensesDigest, err := state.FilesDigest(licenseFiles)
+	if err != nil {
+		return nil, err
+	}
 
-func buildArtifactPaths(projectInfo distgo.ProjectInfo, productInfo distgo.ProductOutputInfo) []string {
-	if productInfo.BuildOutputInfo == nil {
-		return nil
+	sbomFiles := make(map[string]string)
+	if len(disterParam.SBOM.Formats) > 0 {
+		for _, currFile := range sbomLockFiles(projectInfo.ProjectDir) {
+			sbomFiles[path.Base(currFile)] = currFile
+		}
 	}
-	var artifacts []string
-	for _, v := range distgo.ProductBuildArtifactPaths(projectInfo, productInfo) {
-		artifacts = append(artifacts, v)
+	sbomDigest, err := state.FilesDigest(sbomFiles)
+	if err != nil {
+		return nil, err
 	}
-	return artifacts
-}
 
-func distArtifactPaths(projectInfo distgo.ProjectInfo, distID distgo.DistID, productInfo distgo.ProductOutputInfo) []string {
-	if productInfo.DistOutputInfos == nil {
-		return nil
+	buildArtifacts := make(map[string]string)
+	depDistArtifacts := make(map[string]string)
+	for _, currProductOutputInfo := range productTaskOutputInfo.AllProductOutputInfos() {
+		for osArch, artifactPath := range distgo.ProductBuildArtifactPaths(projectInfo, currProductOutputInfo) {
+			buildArtifacts[path.Join(string(currProductOutputInfo.ID), osArch.String())] = artifactPath
+		}
+		if currProductOutputInfo.ID == productTaskOutputInfo.Product.ID || currProductOutputInfo.DistOutputInfos == nil {
+			continue
+		}
+		// include the artifacts of all of the dists of the dependencies because disters such as "bundle" use them
+		for depDistID, artifactPaths := range distgo.ProductDistArtifactPaths(projectInfo, currProductOutputInfo) {
+			for _, artifactPath := range artifactPaths {
+				depDistArtifacts[path.Join(string(currProductOutputInfo.ID), string(depDistID), path.Base(artifactPath))] = artifactPath
+			}
+		}
+	}
+	buildArtifactsDigest, err := state.FilesDigest(buildArtifacts)
+	if err != nil {
+		return nil, err
 	}
-	var artifacts []string
-	for _, v := range distgo.ProductDistWorkDirsAndArtifactPaths(projectInfo, productInfo)[distID] {
-		artifacts = append(artifacts, v)
+	depDistArtifactsDigest, err := state.FilesDigest(depDistArtifacts)
+	if err != nil {
+		return nil, err
 	}
-	return artifacts
+
+	return map[string]string{
+		"version":                   state.StringDigest(projectInfo.Version),
+		"dist-config":               distConfigDigest,
+		"script":                    state.StringDigest(disterParam.Script),
+		"input-dir":                 inputDirDigest,
+		"licenses":                  licensesDigest,
+		"sbom":                      sbomDigest,
+		"build-artifacts":           buildArtifactsDigest,
+		"dependency-dist-artifacts": depDistArtifactsDigest,
+	}, nil
 }
 
-func newestArtifactModTime(artifacts []string) *time.Time {
-	var newestModTime *time.Time
-	newestModTimeFn := func(currPath string) {
-		fi, err := os.Stat(currPath)
+// inputDirFilePaths returns a map from the path relative to the input directory to the path of all of the files that
+// are copied from the input directory by copyInputDir.
+func inputDirFilePaths(inputDir string, exclude matcher.Matcher) (map[string]string, error) {
+	files := make(map[string]string)
+	if err := filepath.Walk(inputDir, func(currPath string, info os.FileInfo, err error) error {
 		if err != nil {
-			return
+			return err
 		}
-		if fiModTime := fi.ModTime(); newestModTime == nil || fiModTime.After(*newestModTime) {
-			newestModTime = &fiModTime
+		relPath, err := filepath.Rel(inputDir, currPath)
+		if err != nil {
+			return err
 		}
+		if relPath == "." {
+			return nil
+		}
+		// copyInputDir does not apply the exclude matcher to top-level directories
+		isTopLevelDir := info.IsDir() && !strings.Contains(relPath, string(filepath.Separator))
+		if exclude != nil && !isTopLevelDir && exclude.Match(relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			files[relPath] = currPath
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list files in input directory %s", inputDir)
 	}
-	for _, v := range artifacts {
-		newestModTimeFn(v)
-	}
-	return newestModTime
+	return files, nil
+}
+
+// distStateFilePath returns the path of the state file for the dist with the provided DistID. The state file is
+// written to the dist output directory.
+func distStateFilePath(distID distgo.DistID, productTaskOutputInfo distgo.ProductTaskOutputInfo) string {
+	return path.Join(distgo.ProductDistOutputDir(productTaskOutputInfo.Project, productTaskOutputInfo.Product, distID), state.FileName)
 }
@@ -0,0 +1,208 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/git"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/imports"
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/sbom"
+)
+
+var sbomFormatFns = map[string]func(sbom.Document) ([]byte, error){
+	distgo.SBOMFormatSPDX:      sbom.SPDX,
+	distgo.SBOMFormatCycloneDX: sbom.CycloneDX,
+}
+
+// writeSBOMs writes the SBOM sidecar files specified by the provided SBOMParam for the artifacts generated by the Dister
+// for the provided DistID.
+func writeSBOMs(distID distgo.DistID, sbomParam distgo.SBOMParam, productParam distgo.ProductParam, productTaskOutputInfo distgo.ProductTaskOutputInfo) error {
+	if len(sbomParam.Formats) == 0 {
+		return nil
+	}
+
+	projectInfo := productTaskOutputInfo.Project
+	pkgs, err := sbomPackages(projectInfo, productParam)
+	if err != nil {
+		return err
+	}
+	var artifacts []sbom.Artifact
+	for _, artifactPath := range productTaskOutputInfo.ProductDisterArtifactPaths()[distID] {
+		artifact, err := manifestArtifact(artifactPath, distgo.ChecksumAlgorithms(), productTaskOutputInfo.Product)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, sbom.Artifact{
+			Name:    artifact.Name,
+			Digests: artifact.Digests,
+		})
+	}
+	created, err := sbomCreated(projectInfo.ProjectDir)
+	if err != nil {
+		return err
+	}
+	doc := sbom.Document{
+		Name:      string(productParam.ID),
+		Version:   projectInfo.Version,
+		Created:   created,
+		Packages:  pkgs,
+		Artifacts: artifacts,
+	}
+
+	distOutputDir := distgo.ProductDistOutputDir(projectInfo, productTaskOutputInfo.Product, distID)
+	renderedName := productTaskOutputInfo.Product.DistOutputInfos.DistInfos[distID].DistNameTemplateRendered
+	for _, format := range sbomParam.Formats {
+		formatFn, ok := sbomFormatFns[format]
+		if !ok {
+			return errors.Errorf("unsupported SBOM format %q", format)
+		}
+		sbomBytes, err := formatFn(doc)
+		if err != nil {
+			return err
+		}
+		sbomFilePath := path.Join(distOutputDir, distgo.SBOMFileName(renderedName, distID, format))
+		if err := ioutil.WriteFile(sbomFilePath, sbomBytes, 0644); err != nil {
+			return errors.Wrapf(err, "failed to write SBOM file %s", sbomFilePath)
+		}
+	}
+	return nil
+}
+
+// sbomCreated returns the creation time that is recorded in the SBOMs for the project in the provided directory so that
+// the SBOMs (which are checksummed and signed like the artifacts) only depend on their content. If the
+// SOURCE_DATE_EPOCH environment variable is set, its value is used. Otherwise, the time of the HEAD commit of the git
+// repository that contains the project directory is used. The current time is only used if the project is not in a git
+// repository.
+func sbomCreated(projectDir string) (time.Time, error) {
+	if epoch, ok, err := distgo.SourceDateEpoch(); err != nil || ok {
+		return epoch, err
+	}
+	if commitTime, err := git.CommitTime(projectDir); err == nil {
+		return commitTime, nil
+	}
+	return time.Now(), nil
+}
+
+// sbomPackages returns the non-project Go packages compiled into the product and its dependent products along with the
+// modules that contain them. If the product is built in module mode, the module of a package is the module reported by
+// "go list" (taking "replace" directives into account). Otherwise, it is the project in the "Gopkg.lock" file of the
+// project that contains the package. If neither contain the package, the version of its module is unknown.
+func sbomPackages(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam) ([]sbom.Package, error) {
+	files, err := productFiles(projectInfo, productParam)
+	if err != nil {
+		return nil, err
+	}
+	lockedModules, err := gopkgLockModules(projectInfo.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []sbom.Package
+	for _, pkgDir := range sortedPkgDirs(files) {
+		goModule, inModule := files.Modules[pkgDir]
+		if inModule && goModule.Main {
+			continue
+		}
+		if relPath, err := filepath.Rel(projectInfo.ProjectDir, pkgDir); err == nil && !strings.HasPrefix(relPath, "..") && !strings.Contains("/"+filepath.ToSlash(relPath)+"/", "/vendor/") {
+			// package is part of the project
+			continue
+		}
+		importPath := files.ImportPaths[pkgDir]
+		if idx := strings.LastIndex(importPath, "/vendor/"); idx != -1 {
+			// package in a GOPATH vendor directory
+			importPath = importPath[idx+len("/vendor/"):]
+		}
+		if importPath == "" || strings.HasPrefix(importPath, "_/") {
+			// package outside of GOPATH that is not in a module
+			importPath = filepath.ToSlash(pkgDir)
+		}
+
+		module := sbom.Module{
+			Path: importPath,
+		}
+		if inModule {
+			module = sbomModule(goModule)
+		} else if lockedModule, ok := sbom.ModuleForPackage(lockedModules, importPath); ok {
+			module = lockedModule
+		}
+		pkgs = append(pkgs, sbom.Package{
+			ImportPath: importPath,
+			Module:     module,
+		})
+	}
+	return pkgs, nil
+}
+
+// sbomModule returns the SBOM module for the provided Go module. A module that is replaced by another module is
+// represented by the replacement module. A module that is replaced by a local directory has no version.
+func sbomModule(goModule imports.Module) sbom.Module {
+	if goModule.Replace == nil {
+		return sbom.Module{
+			Path:    goModule.Path,
+			Version: goModule.Version,
+		}
+	}
+	if goModule.Replace.Version == "" {
+		return sbom.Module{
+			Path: goModule.Path,
+		}
+	}
+	module := sbom.Module{
+		Path:    goModule.Replace.Path,
+		Version: goModule.Replace.Version,
+	}
+	if goModule.Replace.Path != goModule.Path {
+		module.Replaces = goModule.Path
+	}
+	return module
+}
+
+// gopkgLockModules returns the projects specified in the "Gopkg.lock" file of the project (if it exists) as modules.
+func gopkgLockModules(projectDir string) ([]sbom.Module, error) {
+	lockFile := path.Join(projectDir, "Gopkg.lock")
+	f, err := os.Open(lockFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", lockFile)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return sbom.ReadGopkgLock(f)
+}
+
+// sbomLockFiles returns the paths to the files in the project that determine the versions of its dependencies.
+func sbomLockFiles(projectDir string) []string {
+	return []string{
+		path.Join(projectDir, "Gopkg.lock"),
+		path.Join(projectDir, "go.mod"),
+		path.Join(projectDir, "go.sum"),
+	}
+}
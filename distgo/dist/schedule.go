@@ -0,0 +1,255 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+// distUnit is the unit of work for the dist scheduler: the dist for a single DistID of a product.
+type distUnit struct {
+	productDist *productDist
+	distID      distgo.DistID
+	// productIndex and distIndex determine the order of the unit in a serial run: units are ordered by the topological
+	// order of their product and then by the dependency order of their DistID.
+	productIndex int
+	distIndex    int
+	// pending is the number of dists of the same product that must complete before this unit can start.
+	pending int
+	// dependents are the units of the same product that depend on this unit.
+	dependents []*distUnit
+}
+
+type distUnitResult struct {
+	unit   *distUnit
+	output *bytes.Buffer
+	err    error
+}
+
+// distScheduler runs dist units once their dependencies have completed. The dists for a product are only computed
+// (using RequiresDist) once all of the dists for the products it depends on have completed, which ensures that the
+// up-to-date checks consider the dist artifacts of the dependencies that were just generated.
+type distScheduler struct {
+	projectInfo    distgo.ProjectInfo
+	targetProducts map[distgo.ProductID]distgo.ProductParam
+	configModTime  *time.Time
+	dryRun         bool
+	explain        bool
+	// stdout is only written to by the goroutine that runs the scheduler.
+	stdout io.Writer
+
+	topoIndex map[distgo.ProductID]int
+	// pendingProducts is the number of products that must complete before the dists for a product can be computed.
+	pendingProducts map[distgo.ProductID]int
+	// productDependents are the products that depend on a product.
+	productDependents map[distgo.ProductID][]distgo.ProductID
+	// pendingUnits is the number of units of a product that have not completed.
+	pendingUnits map[distgo.ProductID]int
+	ready        []*distUnit
+}
+
+// runScheduled runs the dists for the provided products. The products must be provided in topological order. If
+// distOpts.Parallel is false, the units are run one at a time in topological order and their output is written
+// directly to stdout.
+func runScheduled(projectInfo distgo.ProjectInfo, targetProducts map[distgo.ProductID]distgo.ProductParam, topoOrderedIDs []distgo.ProductID, configModTime *time.Time, distOpts Options, stdout io.Writer) error {
+	s := &distScheduler{
+		projectInfo:       projectInfo,
+		targetProducts:    targetProducts,
+		configModTime:     configModTime,
+		dryRun:            distOpts.DryRun,
+		explain:           distOpts.Explain,
+		stdout:            stdout,
+		topoIndex:         make(map[distgo.ProductID]int),
+		pendingProducts:   make(map[distgo.ProductID]int),
+		productDependents: make(map[distgo.ProductID][]distgo.ProductID),
+		pendingUnits:      make(map[distgo.ProductID]int),
+	}
+	for i, currProductID := range topoOrderedIDs {
+		s.topoIndex[currProductID] = i
+	}
+	for _, currProductID := range topoOrderedIDs {
+		currProductParam := targetProducts[currProductID]
+		for _, depProductID := range currProductParam.AllDependenciesSortedIDs() {
+			if _, ok := s.topoIndex[depProductID]; !ok {
+				continue
+			}
+			s.pendingProducts[currProductID]++
+			s.productDependents[depProductID] = append(s.productDependents[depProductID], currProductID)
+		}
+	}
+	for _, currProductID := range topoOrderedIDs {
+		if s.pendingProducts[currProductID] != 0 {
+			continue
+		}
+		if err := s.startProduct(currProductID); err != nil {
+			return err
+		}
+	}
+
+	nWorkers := 1
+	if distOpts.Parallel {
+		nWorkers = runtime.NumCPU()
+	}
+	results := make(chan distUnitResult)
+	running := 0
+	var firstErr error
+	for {
+		for firstErr == nil && len(s.ready) > 0 && running < nWorkers {
+			unit := s.ready[0]
+			s.ready = s.ready[1:]
+			running++
+			unitStdout, output := stdout, (*bytes.Buffer)(nil)
+			if nWorkers > 1 {
+				output = &bytes.Buffer{}
+				unitStdout = output
+			}
+			go func() {
+				err := unit.productDist.run(unit.distID, s.dryRun, unitStdout)
+				results <- distUnitResult{
+					unit:   unit,
+					output: output,
+					err:    err,
+				}
+			}()
+		}
+		if running == 0 {
+			break
+		}
+
+		result := <-results
+		running--
+		if result.output != nil {
+			if _, err := result.output.WriteTo(stdout); err != nil && firstErr == nil {
+				firstErr = errors.Wrapf(err, "failed to write output")
+			}
+		}
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = errors.Wrapf(result.err, "dist failed for %s", result.unit.productDist.productParam.ID)
+			}
+			continue
+		}
+		if firstErr == nil {
+			if err := s.completeUnit(result.unit); err != nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// startProduct computes the units for the dists of the provided product that require generation and adds the units
+// that do not have any dependencies to the ready queue. Must only be called once all of the products that the provided
+// product depends on have completed.
+func (s *distScheduler) startProduct(productID distgo.ProductID) error {
+	requiresDistParam, reasons, err := RequiresDistWithReasons(s.projectInfo, s.targetProducts[productID], s.configModTime)
+	if err != nil {
+		return err
+	}
+	if requiresDistParam == nil {
+		return s.completeProduct(productID)
+	}
+	productDist, err := newProductDist(s.projectInfo, *requiresDistParam)
+	if err != nil {
+		return errors.Wrapf(err, "dist failed for %s", productID)
+	}
+	if s.explain {
+		for _, currDistID := range productDist.distIDs {
+			distgo.PrintlnOrDryRunPrintln(s.stdout, fmt.Sprintf("%s requires dist for %s: %s", productID, currDistID, reasons[currDistID]), s.dryRun)
+		}
+	}
+
+	inDistIDs := make(map[distgo.DistID]struct{})
+	for _, currDistID := range productDist.distIDs {
+		inDistIDs[currDistID] = struct{}{}
+	}
+	units := make(map[distgo.DistID]*distUnit)
+	for i, currDistID := range productDist.distIDs {
+		unit := &distUnit{
+			productDist:  productDist,
+			distID:       currDistID,
+			productIndex: s.topoIndex[productID],
+			distIndex:    i,
+		}
+		// units are created in dependency order, so the units for all dependencies already exist
+		for _, depDistID := range distDependencies(currDistID, inDistIDs, requiresDistParam.Dist.DistParams) {
+			unit.pending++
+			units[depDistID].dependents = append(units[depDistID].dependents, unit)
+		}
+		units[currDistID] = unit
+	}
+	s.pendingUnits[productID] = len(units)
+	if len(units) == 0 {
+		return s.completeProduct(productID)
+	}
+	for _, currDistID := range productDist.distIDs {
+		if units[currDistID].pending == 0 {
+			s.addReady(units[currDistID])
+		}
+	}
+	return nil
+}
+
+func (s *distScheduler) completeUnit(unit *distUnit) error {
+	for _, dependent := range unit.dependents {
+		dependent.pending--
+		if dependent.pending == 0 {
+			s.addReady(dependent)
+		}
+	}
+	productID := unit.productDist.productParam.ID
+	s.pendingUnits[productID]--
+	if s.pendingUnits[productID] == 0 {
+		return s.completeProduct(productID)
+	}
+	return nil
+}
+
+func (s *distScheduler) completeProduct(productID distgo.ProductID) error {
+	for _, dependentProductID := range s.productDependents[productID] {
+		s.pendingProducts[dependentProductID]--
+		if s.pendingProducts[dependentProductID] == 0 {
+			if err := s.startProduct(dependentProductID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addReady adds the provided unit to the ready queue, which is ordered by the serial run order of the units.
+func (s *distScheduler) addReady(unit *distUnit) {
+	s.ready = append(s.ready, unit)
+	sort.SliceStable(s.ready, func(i, j int) bool {
+		if s.ready[i].productIndex != s.ready[j].productIndex {
+			return s.ready[i].productIndex < s.ready[j].productIndex
+		}
+		return s.ready[i].distIndex < s.ready[j].distIndex
+	})
+}
@@ -0,0 +1,145 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+// signArtifacts writes a detached signature for every artifact generated by the Dister for the provided DistID and for
+// every checksum, manifest and SBOM sidecar file of the dist.
+func signArtifacts(signingParam distgo.SigningParam, distID distgo.DistID, disterParam distgo.DisterParam, productTaskOutputInfo distgo.ProductTaskOutputInfo) error {
+	pathsToSign := productTaskOutputInfo.ProductDisterArtifactPaths()[distID]
+	distOutputDir := distgo.ProductDistOutputDir(productTaskOutputInfo.Project, productTaskOutputInfo.Product, distID)
+	renderedName := productTaskOutputInfo.Product.DistOutputInfos.DistInfos[distID].DistNameTemplateRendered
+	for _, currSidecarName := range disterParam.SidecarNames(renderedName, distID) {
+		pathsToSign = append(pathsToSign, path.Join(distOutputDir, currSidecarName))
+	}
+
+	var password string
+	if signingParam.PasswordEnvVar != "" {
+		password = os.Getenv(signingParam.PasswordEnvVar)
+	}
+	keyFile := signingKeyPath(productTaskOutputInfo.Project.ProjectDir, signingParam.Type, signingParam.KeyFile)
+
+	var signCmdFn func(artifactPath, signaturePath string) *exec.Cmd
+	switch signingParam.Type {
+	case distgo.SigningTypeGPG:
+		gpgHome := signingParam.GPGHome
+		if gpgHome != "" && !path.IsAbs(gpgHome) {
+			gpgHome = path.Join(productTaskOutputInfo.Project.ProjectDir, gpgHome)
+		}
+		if keyFile != "" {
+			// import the key into a temporary keyring so that the keyring of the user is not modified
+			tmpGPGHome, cleanup, err := importGPGKey(keyFile, password)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			gpgHome = tmpGPGHome
+		}
+		signCmdFn = func(artifactPath, signaturePath string) *exec.Cmd {
+			args := []string{"--batch", "--yes"}
+			if gpgHome != "" {
+				args = append(args, "--homedir", gpgHome)
+			}
+			if signingParam.KeyID != "" {
+				args = append(args, "--local-user", signingParam.KeyID)
+			}
+			if signingParam.PasswordEnvVar != "" {
+				args = append(args, "--pinentry-mode", "loopback", "--passphrase-fd", "0")
+			}
+			args = append(args, "--armor", "--detach-sign", "--output", signaturePath, artifactPath)
+			cmd := exec.Command("gpg", args...)
+			cmd.Stdin = strings.NewReader(password)
+			return cmd
+		}
+	case distgo.SigningTypeMinisign:
+		signCmdFn = func(artifactPath, signaturePath string) *exec.Cmd {
+			cmd := exec.Command("minisign", "-S", "-s", keyFile, "-m", artifactPath, "-x", signaturePath)
+			// minisign reads the password for the key from stdin if it is not connected to a terminal
+			cmd.Stdin = strings.NewReader(password + "\n")
+			return cmd
+		}
+	case distgo.SigningTypeCosign:
+		signCmdFn = func(artifactPath, signaturePath string) *exec.Cmd {
+			cmd := exec.Command("cosign", "sign-blob", "--yes", "--key", keyFile, "--output-signature", signaturePath, artifactPath)
+			cmd.Env = append(os.Environ(), "COSIGN_PASSWORD="+password)
+			return cmd
+		}
+	default:
+		return errors.Errorf("unsupported signing type %q", signingParam.Type)
+	}
+
+	for _, currPath := range pathsToSign {
+		signaturePath := path.Join(path.Dir(currPath), signingParam.SignatureName(path.Base(currPath)))
+		cmd := signCmdFn(currPath, signaturePath)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return errors.Wrapf(err, "failed to sign %s: command %v failed with output:\n%s", currPath, cmd.Args, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// signingKeyPath returns the path to the key file for the provided signing type. Relative paths are resolved relative
+// to the project directory. Key references for cosign that are URIs (for example, KMS keys) are returned unmodified.
+func signingKeyPath(projectDir, signingType, keyFile string) string {
+	if keyFile == "" || path.IsAbs(keyFile) {
+		return keyFile
+	}
+	if signingType == distgo.SigningTypeCosign && strings.Contains(keyFile, "://") {
+		return keyFile
+	}
+	return path.Join(projectDir, keyFile)
+}
+
+// importGPGKey creates a temporary GnuPG home directory and imports the provided key file into it. Returns the path to
+// the directory and a function that removes it.
+func importGPGKey(keyFile, password string) (string, func(), error) {
+	tmpDir, err := ioutil.TempDir("", "distgo-gpg-")
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to create temporary directory")
+	}
+	cleanup := func() {
+		// stop the agent started for the temporary directory (if any)
+		_ = exec.Command("gpgconf", "--homedir", tmpDir, "--kill", "gpg-agent").Run()
+		_ = os.RemoveAll(tmpDir)
+	}
+	if err := os.Chmod(tmpDir, 0700); err != nil {
+		cleanup()
+		return "", nil, errors.Wrapf(err, "failed to set permissions of directory %s", tmpDir)
+	}
+
+	cmd := exec.Command("gpg", "--batch", "--homedir", tmpDir, "--pinentry-mode", "loopback", "--passphrase-fd", "0", "--import", keyFile)
+	cmd.Stdin = strings.NewReader(password)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		cleanup()
+		return "", nil, errors.Wrapf(err, "failed to import key %s: command %v failed with output:\n%s", keyFile, cmd.Args, strings.TrimSpace(string(output)))
+	}
+	return tmpDir, cleanup, nil
+}
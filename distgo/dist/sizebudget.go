@@ -0,0 +1,55 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"io"
+	"path"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+// checkSizeBudget prints the size of the artifacts generated by the Dister for the provided DistID and verifies that
+// they are within the provided size budget. The size of each artifact is compared to that of the corresponding artifact
+// of the previous version of the product in the dist output directory.
+func checkSizeBudget(sizeBudget distgo.SizeBudgetParam, distID distgo.DistID, productParam distgo.ProductParam, productTaskOutputInfo distgo.ProductTaskOutputInfo, stdout io.Writer) error {
+	// dist output directory is "{{OutputDir}}/{{ProductID}}/{{Version}}/{{DistID}}"
+	versionsDir := path.Dir(path.Dir(productTaskOutputInfo.ProductDistOutputDir(distID)))
+	disterParam := productParam.Dist.DistParams[distID]
+
+	var sizes []distgo.ArtifactSize
+	for i, artifactPath := range productTaskOutputInfo.ProductDisterArtifactPaths()[distID] {
+		size, err := distgo.NewArtifactSize(path.Base(artifactPath), artifactPath, versionsDir, productTaskOutputInfo.Project.Version, func(version string) (string, error) {
+			distOutputInfo, err := disterParam.ToDistOutputInfo(productParam.ID, version)
+			if err != nil {
+				return "", err
+			}
+			if i >= len(distOutputInfo.DistArtifactNames) {
+				return "", nil
+			}
+			return path.Join(versionsDir, version, string(distID), distOutputInfo.DistArtifactNames[i]), nil
+		})
+		if err != nil {
+			return err
+		}
+		sizes = append(sizes, size)
+	}
+	return sizeBudget.CheckSizeBudget(sizes, stdout)
+}
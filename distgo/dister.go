@@ -48,6 +48,27 @@ type Dister interface {
 	GenerateDistArtifacts(distID DistID, productTaskOutputInfo ProductTaskOutputInfo, runDistResult []byte) error
 }
 
+// DependentDister is a Dister whose dist artifacts are generated based on the dist artifacts of other dists of the
+// same product. When a product is dist'd, the dists returned by DistDependencies are run before the dist for the
+// DependentDister.
+type DependentDister interface {
+	Dister
+
+	// DistDependencies returns the IDs of the dists of the product whose artifacts are used by this dister.
+	DistDependencies() []DistID
+}
+
+// DocDister is a Dister that installs the content of the dist work directory relative to the root of the file system
+// (for example, an OS package). Documentation for the product, such as the license notices of its dependencies, is
+// written to the directory returned by DocDir rather than to the root of the dist work directory.
+type DocDister interface {
+	Dister
+
+	// DocDir returns the path relative to the dist work directory of the directory to which documentation for the
+	// provided product is written.
+	DocDir(productID ProductID) string
+}
+
 type DisterFactory interface {
 	Types() []string
 	NewDister(typeName string, cfgYMLBytes []byte) (Dister, error)
@@ -76,7 +76,10 @@ func BuildProducts(projectInfo distgo.ProjectInfo, projectParam distgo.ProjectPa
 		productDistIDs = append(productDistIDs, distgo.ProductDistID(currProductParam.ID))
 	}
 	// run dist for products that require dist artifact generation
-	if err := dist.Products(projectInfo, projectParam, configModTime, productDistIDs, dryRun, stdout); err != nil {
+	if err := dist.Products(projectInfo, projectParam, configModTime, productDistIDs, dist.Options{
+		Parallel: true,
+		DryRun:   dryRun,
+	}, stdout); err != nil {
 		return err
 	}
 
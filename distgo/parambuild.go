@@ -20,7 +20,8 @@ Sniperkit-Bot
 package distgo
 
 import (
-	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/palantir/godel/pkg/osarch"
 	"github.com/pkg/errors"
@@ -69,6 +70,29 @@ type BuildParam struct {
 	// ldflag.
 	VersionVar string
 
+	// LDFlags is a template for the flags that are provided to the linker. The template is rendered using the
+	// functions described by BuildFlagTemplateFunctions.
+	LDFlags string
+
+	// XVars is a map from the path of a string variable to a template for the value that the variable is set to using
+	// the "-X" linker flag. The templates are rendered using the functions described by BuildFlagTemplateFunctions.
+	XVars map[string]string
+
+	// Tags are the build tags that are provided to the "build" command.
+	Tags []string
+
+	// GCFlags is the value of the "-gcflags" flag that is provided to the "build" command.
+	GCFlags string
+
+	// ASMFlags is the value of the "-asmflags" flag that is provided to the "build" command.
+	ASMFlags string
+
+	// Trimpath specifies whether the "-trimpath" flag is provided to the "build" command.
+	Trimpath bool
+
+	// BuildMode is the value of the "-buildmode" flag that is provided to the "build" command.
+	BuildMode string
+
 	// Environment specifies values for the environment variables that should be set for the build. For example,
 	// a value of map[string]string{"CGO_ENABLED": "0"} would build with CGo disabled.
 	Environment map[string]string
@@ -80,6 +104,72 @@ type BuildParam struct {
 
 	// OSArchs specifies the GOOS and GOARCH pairs for which the product is built.
 	OSArchs []osarch.OSArch
+
+	// SizeBudget specifies the size budget for the executables of the product. If nil, the size of the executables is
+	// not checked.
+	SizeBudget *SizeBudgetParam
+
+	// OSArchOverrides specifies environment variables and build arguments that only apply when building some of the
+	// OS/Archs in OSArchs. A key is either an OS (for example, "darwin"), in which case the override applies to all of
+	// the architectures for that OS, or the string representation of an OS/Arch (for example, "darwin-amd64"). If both
+	// an OS and an OS/Arch override apply, the OS/Arch override is applied after the OS override.
+	OSArchOverrides map[string]OSArchBuildOverride
+
+	// Provenance specifies the variables that are set with the provenance information of the build. If non-nil, a
+	// provenance file is also written next to every executable. Refer to ProvenanceFileSuffix for the name of the file.
+	Provenance *ProvenanceParam
+
+	// Cache specifies the cache that executables are restored from instead of being built if the cache contains an
+	// executable built from the same inputs. If nil, executables are always built.
+	Cache *BuildCacheParam
+}
+
+type OSArchBuildOverride struct {
+	// Environment specifies environment variables that are set for the build in addition to the variables in
+	// Environment. If a variable is specified in both, the value in the override is used.
+	Environment map[string]string
+
+	// BuildArgs are the arguments that are provided to the "build" command after the arguments generated by
+	// BuildArgsScript. For example, []string{"-tags", "netgo"}. Linker flags and build tags are merged with the
+	// other linker flags and build tags in the manner described by BuildArgsForOSArch.
+	BuildArgs []string
+}
+
+// OverrideFor returns the override for the provided OS/Arch, which is the result of applying the override for the OS of
+// the OS/Arch and then the override for the OS/Arch.
+func (p *BuildParam) OverrideFor(osArch osarch.OSArch) OSArchBuildOverride {
+	var merged OSArchBuildOverride
+	for _, key := range []string{osArch.OS, osArch.String()} {
+		override, ok := p.OSArchOverrides[key]
+		if !ok {
+			continue
+		}
+		for k, v := range override.Environment {
+			if merged.Environment == nil {
+				merged.Environment = make(map[string]string)
+			}
+			merged.Environment[k] = v
+		}
+		merged.BuildArgs = append(merged.BuildArgs, override.BuildArgs...)
+	}
+	return merged
+}
+
+// EnvironmentFor returns the environment variables that are set when building the provided OS/Arch, which are the
+// variables in Environment along with the variables in the override for the OS/Arch.
+func (p *BuildParam) EnvironmentFor(osArch osarch.OSArch) map[string]string {
+	override := p.OverrideFor(osArch)
+	if len(override.Environment) == 0 {
+		return p.Environment
+	}
+	env := make(map[string]string)
+	for k, v := range p.Environment {
+		env[k] = v
+	}
+	for k, v := range override.Environment {
+		env[k] = v
+	}
+	return env
 }
 
 type BuildOutputInfo struct {
@@ -100,13 +190,149 @@ func (p *BuildParam) ToBuildOutputInfo(productID ProductID, version string) (Bui
 	}, nil
 }
 
+// BuildArgs returns the arguments that are provided to the "build" command for the product. The arguments are
+// determined in the manner described by BuildArgsForOSArch, but do not include the arguments of any OS/Arch
+// override. If Provenance is non-nil, the provenance information is determined based on the current state of the
+// project.
 func (p *BuildParam) BuildArgs(productTaskOutputInfo ProductTaskOutputInfo) ([]string, error) {
-	buildArgs, err := BuildArgsFromScript(productTaskOutputInfo, p.BuildArgsScript)
+	var provenance *ProvenanceInfo
+	if p.Provenance != nil {
+		info, err := p.Provenance.Info(productTaskOutputInfo.Project.ProjectDir, p.Cache != nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to determine provenance information")
+		}
+		provenance = &info
+	}
+	scriptArgs, err := p.ScriptArgs(productTaskOutputInfo)
+	if err != nil {
+		return nil, err
+	}
+	return p.buildArgs(productTaskOutputInfo, scriptArgs, provenance)
+}
+
+// ScriptArgs runs BuildArgsScript and returns its output as build arguments. The script is run by the caller and its
+// output is provided to BuildArgsForOSArch and TagsForOSArch so that it only runs once per build rather than once per
+// OS/Arch and per use.
+func (p *BuildParam) ScriptArgs(productTaskOutputInfo ProductTaskOutputInfo) ([]string, error) {
+	scriptArgs, err := BuildArgsFromScript(productTaskOutputInfo, p.BuildArgsScript)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to execute script to generate build arguments")
 	}
+	return scriptArgs, nil
+}
+
+// BuildArgsForOSArch returns the arguments that are provided to the "build" command when building the product for
+// the provided OS/Arch. The arguments consist of the flags specified by the build parameters, followed by the provided
+// output of BuildArgsScript (as returned by ScriptArgs) and the build arguments of the override for the OS/Arch. The linker flags generated by VersionVar,
+// XVars, the provenance variables and LDFlags and any "-ldflags" arguments provided by BuildArgsScript or the override
+// are merged into a single "-ldflags" argument (in that order, so later "-X" flags for the same variable take
+// precedence). Similarly, Tags and the tags of any "-tags" arguments are merged into a single "-tags" argument. The
+// provenance variables are only set if Provenance and the provided provenance information are both non-nil.
+func (p *BuildParam) BuildArgsForOSArch(productTaskOutputInfo ProductTaskOutputInfo, osArch osarch.OSArch, scriptArgs []string, provenance *ProvenanceInfo) ([]string, error) {
+	return p.buildArgs(productTaskOutputInfo, p.argsForOSArch(osArch, scriptArgs), provenance)
+}
+
+// TagsForOSArch returns the build tags that are used when building the product for the provided OS/Arch, which are
+// Tags merged with the tags of any "-tags" arguments in the provided output of BuildArgsScript (as returned by
+// ScriptArgs) or the override for the OS/Arch in the manner described by BuildArgsForOSArch.
+func (p *BuildParam) TagsForOSArch(osArch osarch.OSArch, scriptArgs []string) ([]string, error) {
+	_, _, argTags, err := extractMergedBuildFlags(p.argsForOSArch(osArch, scriptArgs))
+	if err != nil {
+		return nil, err
+	}
+	tags := p.mergedTags(argTags)
+	if tags == "" {
+		return nil, nil
+	}
+	return strings.Split(tags, ","), nil
+}
+
+// argsForOSArch returns the provided output of BuildArgsScript followed by the build arguments of the override for the
+// provided OS/Arch.
+func (p *BuildParam) argsForOSArch(osArch osarch.OSArch, scriptArgs []string) []string {
+	return append(append([]string(nil), scriptArgs...), p.OverrideFor(osArch).BuildArgs...)
+}
+
+// buildArgs returns the build arguments for the provided arguments from BuildArgsScript and the OS/Arch override with
+// the "-ldflags" and "-tags" flags extracted and merged in the manner described by extractMergedBuildFlags.
+func (p *BuildParam) buildArgs(productTaskOutputInfo ProductTaskOutputInfo, args []string, provenance *ProvenanceInfo) ([]string, error) {
+	otherArgs, argLDFlags, argTags, err := extractMergedBuildFlags(args)
+	if err != nil {
+		return nil, err
+	}
+
+	templateFns := BuildFlagTemplateFunctions(productTaskOutputInfo)
+	var ldFlags []string
 	if versionVar := p.VersionVar; versionVar != "" {
-		buildArgs = append(buildArgs, "-ldflags", fmt.Sprintf("-X %s=%s", versionVar, productTaskOutputInfo.Project.Version))
+		xFlag, err := linkerXFlag(versionVar, productTaskOutputInfo.Project.Version)
+		if err != nil {
+			return nil, err
+		}
+		ldFlags = append(ldFlags, xFlag)
 	}
-	return buildArgs, nil
+	var xVars []string
+	for k := range p.XVars {
+		xVars = append(xVars, k)
+	}
+	sort.Strings(xVars)
+	for _, xVar := range xVars {
+		val, err := RenderTemplate(p.XVars[xVar], nil, templateFns...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to render value of x-var %s", xVar)
+		}
+		xFlag, err := linkerXFlag(xVar, val)
+		if err != nil {
+			return nil, err
+		}
+		ldFlags = append(ldFlags, xFlag)
+	}
+	if p.Provenance != nil && provenance != nil {
+		provenanceXVars := p.Provenance.XVars(*provenance)
+		var provenanceVars []string
+		for k := range provenanceXVars {
+			provenanceVars = append(provenanceVars, k)
+		}
+		sort.Strings(provenanceVars)
+		for _, provenanceVar := range provenanceVars {
+			xFlag, err := linkerXFlag(provenanceVar, provenanceXVars[provenanceVar])
+			if err != nil {
+				return nil, err
+			}
+			ldFlags = append(ldFlags, xFlag)
+		}
+	}
+	if p.LDFlags != "" {
+		rendered, err := RenderTemplate(p.LDFlags, nil, templateFns...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to render ldflags")
+		}
+		ldFlags = append(ldFlags, rendered)
+	}
+	ldFlags = append(ldFlags, argLDFlags...)
+
+	var buildArgs []string
+	if p.Trimpath {
+		buildArgs = append(buildArgs, "-trimpath")
+	}
+	if p.BuildMode != "" {
+		buildArgs = append(buildArgs, "-buildmode="+p.BuildMode)
+	}
+	if p.GCFlags != "" {
+		buildArgs = append(buildArgs, "-gcflags", p.GCFlags)
+	}
+	if p.ASMFlags != "" {
+		buildArgs = append(buildArgs, "-asmflags", p.ASMFlags)
+	}
+	if tags := p.mergedTags(argTags); tags != "" {
+		buildArgs = append(buildArgs, "-tags", tags)
+	}
+	if ldFlagsVal := strings.TrimSpace(strings.Join(ldFlags, " ")); ldFlagsVal != "" {
+		buildArgs = append(buildArgs, "-ldflags", ldFlagsVal)
+	}
+	return append(buildArgs, otherArgs...), nil
+}
+
+// mergedTags returns the comma-separated list of the unique tags in Tags and the provided tags from build arguments.
+func (p *BuildParam) mergedTags(argTags []string) string {
+	return mergeBuildTags(append(append([]string(nil), p.Tags...), argTags...))
 }
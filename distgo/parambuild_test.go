@@ -0,0 +1,97 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distgo_test
+
+import (
+	"testing"
+
+	"github.com/palantir/godel/pkg/osarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+func TestBuildParamOverrideFor(t *testing.T) {
+	buildParam := distgo.BuildParam{
+		Tags: []string{"netgo"},
+		Environment: map[string]string{
+			"CGO_ENABLED": "0",
+			"FOO":         "foo",
+		},
+		OSArchOverrides: map[string]distgo.OSArchBuildOverride{
+			"darwin": {
+				Environment: map[string]string{
+					"CGO_ENABLED": "1",
+					"CC":          "o64-clang",
+				},
+				BuildArgs: []string{"-tags", "darwin"},
+			},
+			"darwin-arm64": {
+				Environment: map[string]string{
+					"CC": "oa64-clang",
+				},
+				BuildArgs: []string{"-tags", "darwin,arm64"},
+			},
+		},
+	}
+
+	for i, tc := range []struct {
+		osArch        osarch.OSArch
+		wantEnv       map[string]string
+		wantBuildArgs []string
+		wantTags      []string
+	}{
+		{
+			osArch: osarch.OSArch{OS: "linux", Arch: "amd64"},
+			wantEnv: map[string]string{
+				"CGO_ENABLED": "0",
+				"FOO":         "foo",
+			},
+			wantTags: []string{"netgo"},
+		},
+		{
+			osArch: osarch.OSArch{OS: "darwin", Arch: "amd64"},
+			wantEnv: map[string]string{
+				"CGO_ENABLED": "1",
+				"CC":          "o64-clang",
+				"FOO":         "foo",
+			},
+			wantBuildArgs: []string{"-tags", "darwin"},
+			wantTags:      []string{"netgo", "darwin"},
+		},
+		{
+			osArch: osarch.OSArch{OS: "darwin", Arch: "arm64"},
+			wantEnv: map[string]string{
+				"CGO_ENABLED": "1",
+				"CC":          "oa64-clang",
+				"FOO":         "foo",
+			},
+			wantBuildArgs: []string{"-tags", "darwin", "-tags", "darwin,arm64"},
+			wantTags:      []string{"netgo", "darwin", "arm64"},
+		},
+	} {
+		assert.Equal(t, tc.wantEnv, buildParam.EnvironmentFor(tc.osArch), "Case %d", i)
+		assert.Equal(t, tc.wantBuildArgs, buildParam.OverrideFor(tc.osArch).BuildArgs, "Case %d", i)
+		tags, err := buildParam.TagsForOSArch(tc.osArch, nil)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.wantTags, tags, "Case %d", i)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distgo
+
+import (
+	"os"
+	"path"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/buildcache"
+)
+
+type BuildCacheParam struct {
+	// Dir is the directory of a local cache. If the path is relative, it is resolved relative to the project
+	// directory. Exactly one of Dir and URL must be non-empty.
+	Dir string
+
+	// URL is the base URL of a remote HTTP cache. Refer to buildcache.NewHTTPCache for the protocol.
+	URL string
+
+	// TokenEnvVar is the name of the environment variable that contains the bearer token used to authenticate with
+	// the remote cache. Optional.
+	TokenEnvVar string
+
+	// ReadOnly specifies that executables are only restored from the cache and that built executables are not stored
+	// in it.
+	ReadOnly bool
+}
+
+// Cache returns the cache specified by the parameter for the project in the provided directory.
+func (p *BuildCacheParam) Cache(projectDir string) buildcache.Cache {
+	if p.URL != "" {
+		var token string
+		if p.TokenEnvVar != "" {
+			token = os.Getenv(p.TokenEnvVar)
+		}
+		return buildcache.NewHTTPCache(p.URL, token, nil)
+	}
+	dir := p.Dir
+	if !path.IsAbs(dir) {
+		dir = path.Join(projectDir, dir)
+	}
+	return buildcache.NewDirCache(dir)
+}
@@ -20,7 +20,11 @@ Sniperkit-Bot
 package distgo
 
 import (
+	"fmt"
+	"path"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/palantir/pkg/matcher"
 	"github.com/pkg/errors"
@@ -44,6 +48,14 @@ type DistParam struct {
 
 	// DistParams contains the dist params for this distribution.
 	DistParams map[DistID]DisterParam
+
+	// Signing specifies how detached signatures are created for the dist artifacts. If nil, dist artifacts are not
+	// signed.
+	Signing *SigningParam
+
+	// SizeBudget specifies the size budget for the artifacts generated by the Disters of the product. If nil, the size
+	// of the artifacts is not checked.
+	SizeBudget *SizeBudgetParam
 }
 
 type DistOutputInfos struct {
@@ -63,6 +75,18 @@ func (p *DistParam) ToDistOutputInfos(productID ProductID, version string) (Dist
 			if err != nil {
 				return DistOutputInfos{}, err
 			}
+			distOutputInfo.DistSidecarNames = distParam.SidecarNames(distOutputInfo.DistNameTemplateRendered, distID)
+			if p.Signing != nil {
+				// every artifact generated by the Dister and every sidecar file is signed
+				var signatureNames []string
+				for _, currName := range distOutputInfo.DistArtifactNames {
+					signatureNames = append(signatureNames, p.Signing.SignatureName(currName))
+				}
+				for _, currName := range distOutputInfo.DistSidecarNames {
+					signatureNames = append(signatureNames, p.Signing.SignatureName(currName))
+				}
+				distOutputInfo.DistSidecarNames = append(distOutputInfo.DistSidecarNames, signatureNames...)
+			}
 			distInfos[distID] = distOutputInfo
 		}
 		sort.Sort(ByDistID(distIDs))
@@ -93,16 +117,203 @@ type DisterParam struct {
 
 	// Dister is the Dister that performs the dist operation for this parameter.
 	Dister Dister
+
+	// DisterConfig is the YAML configuration that was used to create the Dister. It is used to determine whether the
+	// configuration of a Dister changed, since the Dister itself may not expose its configuration.
+	DisterConfig []byte
+
+	// Checksums specifies the checksum and manifest sidecar files that are written for the artifacts generated by the
+	// Dister.
+	Checksums ChecksumsParam
+
+	// SBOM specifies the software bill of materials sidecar files that are written for the artifacts generated by the
+	// Dister.
+	SBOM SBOMParam
+
+	// Licenses specifies the configuration for writing the license and notice files of the dependencies of the product
+	// to the dist work directory. If nil, license files are not written.
+	Licenses *LicensesParam
+}
+
+type LicensesParam struct {
+	// Disallowed are the license types that are not allowed for dependencies of the product. The dist operation fails
+	// if the license of any dependency is of one of these types.
+	Disallowed []string
+
+	// Dir is the path relative to the dist work directory of the directory to which the license and notice files are
+	// written. If empty, the files are written to the directory returned by DocDir for a DocDister and to the root of
+	// the dist work directory for any other Dister.
+	Dir string
+}
+
+const (
+	ChecksumSHA256 = "sha256"
+	ChecksumSHA512 = "sha512"
+)
+
+// ChecksumAlgorithms returns the algorithms that can be used for checksum sidecar files.
+func ChecksumAlgorithms() []string {
+	return []string{ChecksumSHA256, ChecksumSHA512}
+}
+
+type ChecksumsParam struct {
+	// Algorithms are the algorithms for which "SHA256SUMS"-style sidecar files are written. Each sidecar file contains
+	// a line of the form "{{digest}}  {{artifact}}" for every artifact generated by the Dister.
+	Algorithms []string
+
+	// Manifest specifies whether a JSON manifest that describes the artifacts generated by the Dister is written.
+	Manifest bool
+}
+
+// ChecksumsFileName returns the name of the checksum sidecar file for the provided algorithm, which is
+// "{{NameTemplateRendered}}-{{DistID}}-{{ALGORITHM}}SUMS".
+func ChecksumsFileName(renderedName string, distID DistID, algorithm string) string {
+	return fmt.Sprintf("%s-%s-%sSUMS", renderedName, distID, strings.ToUpper(algorithm))
+}
+
+// ManifestFileName returns the name of the manifest sidecar file, which is
+// "{{NameTemplateRendered}}-{{DistID}}-manifest.json".
+func ManifestFileName(renderedName string, distID DistID) string {
+	return fmt.Sprintf("%s-%s-manifest.json", renderedName, distID)
+}
+
+// SidecarNames returns the names of the sidecar files specified by the receiver.
+func (p ChecksumsParam) SidecarNames(renderedName string, distID DistID) []string {
+	var names []string
+	for _, algorithm := range p.Algorithms {
+		names = append(names, ChecksumsFileName(renderedName, distID, algorithm))
+	}
+	if p.Manifest {
+		names = append(names, ManifestFileName(renderedName, distID))
+	}
+	return names
+}
+
+// SidecarNames returns the names of the checksum, manifest and SBOM sidecar files specified by the receiver.
+func (p DisterParam) SidecarNames(renderedName string, distID DistID) []string {
+	return append(p.Checksums.SidecarNames(renderedName, distID), p.SBOM.SidecarNames(renderedName, distID)...)
+}
+
+const (
+	SBOMFormatSPDX      = "spdx"
+	SBOMFormatCycloneDX = "cyclonedx"
+)
+
+// SBOMFormats returns the formats that can be used for SBOM sidecar files.
+func SBOMFormats() []string {
+	return []string{SBOMFormatSPDX, SBOMFormatCycloneDX}
+}
+
+type SBOMParam struct {
+	// Formats are the formats for which software bill of materials sidecar files are written. Each sidecar file lists
+	// the Go packages compiled into the product, the versions of the modules that contain them and the artifacts
+	// generated by the Dister along with their digests.
+	Formats []string
+}
+
+// SBOMFileName returns the name of the SBOM sidecar file for the provided format, which is
+// "{{NameTemplateRendered}}-{{DistID}}.spdx.json" for SPDX and "{{NameTemplateRendered}}-{{DistID}}.cdx.json" for
+// CycloneDX.
+func SBOMFileName(renderedName string, distID DistID, format string) string {
+	extension := format
+	if format == SBOMFormatCycloneDX {
+		extension = "cdx"
+	}
+	return fmt.Sprintf("%s-%s.%s.json", renderedName, distID, extension)
+}
+
+// SidecarNames returns the names of the SBOM sidecar files specified by the receiver.
+func (p SBOMParam) SidecarNames(renderedName string, distID DistID) []string {
+	var names []string
+	for _, format := range p.Formats {
+		names = append(names, SBOMFileName(renderedName, distID, format))
+	}
+	return names
+}
+
+const (
+	SigningTypeGPG      = "gpg"
+	SigningTypeMinisign = "minisign"
+	SigningTypeCosign   = "cosign"
+)
+
+// SigningTypes returns the types of signing that are supported.
+func SigningTypes() []string {
+	return []string{SigningTypeGPG, SigningTypeMinisign, SigningTypeCosign}
+}
+
+type SigningParam struct {
+	// Type is the type of signing: one of "gpg", "minisign" or "cosign". Signing is performed by invoking the
+	// executable of the same name, which must be on the PATH.
+	Type string
+
+	// KeyFile is the path to the private key used for signing. If the path is relative, it is resolved relative to the
+	// project directory. For the "gpg" type, the key is imported into a temporary keyring that is used only for
+	// signing. For the "cosign" type, the value can also be a KMS URI supported by cosign.
+	KeyFile string
+
+	// KeyID is the identifier of the key used for signing ("gpg" type only). Optional.
+	KeyID string
+
+	// GPGHome is the GnuPG home directory used for signing ("gpg" type only). If KeyFile is not specified, the keys
+	// in this directory (or in the default GnuPG home directory if GPGHome is also not specified) are used, which
+	// allows keys held by a running gpg-agent to be used through the agent socket in that directory.
+	GPGHome string
+
+	// PasswordEnvVar is the name of the environment variable that contains the password for the private key. If
+	// empty, the key is expected to not require a password (or, for "gpg", the agent provides it).
+	PasswordEnvVar string
+}
+
+// SignatureExtension returns the extension of the signature files created by the receiver: ".asc" for "gpg",
+// ".minisig" for "minisign" and ".sig" for "cosign".
+func (p *SigningParam) SignatureExtension() string {
+	switch p.Type {
+	case SigningTypeGPG:
+		return ".asc"
+	case SigningTypeMinisign:
+		return ".minisig"
+	default:
+		return ".sig"
+	}
+}
+
+// SignatureName returns the name of the detached signature file for the artifact with the provided name.
+func (p *SigningParam) SignatureName(artifactName string) string {
+	return artifactName + p.SignatureExtension()
 }
 
 type InputDirParam struct {
 	Path    string
 	Exclude matcher.Matcher
+	// Templates are the glob patterns for the files in the input directory that are rendered as templates when they are
+	// copied. A pattern that contains a '/' is matched against the path of the file relative to the input directory and
+	// any other pattern is matched against the name of the file.
+	Templates []string
+	// PreserveSymlinks specifies that symlinks in the input directory are copied as symlinks with the same target.
+	PreserveSymlinks bool
+}
+
+// IsTemplate returns true if the file at the provided path relative to the input directory matches any of the template
+// patterns of the receiver.
+func (p InputDirParam) IsTemplate(relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	for _, pattern := range p.Templates {
+		name := relPath
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(relPath)
+		}
+		if match, err := path.Match(pattern, name); err == nil && match {
+			return true
+		}
+	}
+	return false
 }
 
 type DistOutputInfo struct {
 	DistNameTemplateRendered string   `json:"distNameTemplateRendered"`
 	DistArtifactNames        []string `json:"distArtifactNames"`
+	DistSidecarNames         []string `json:"distSidecarNames,omitempty"`
 	PackagingExtension       string   `json:"packagingExtension"`
 }
 
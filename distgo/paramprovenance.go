@@ -0,0 +1,151 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distgo
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/git"
+)
+
+// ProvenanceFileSuffix is the suffix of the name of the provenance file that is written next to an executable. The
+// provenance file for "out/build/foo/1.0.0/linux-amd64/foo" is "out/build/foo/1.0.0/linux-amd64/foo.provenance.json".
+const ProvenanceFileSuffix = ".provenance.json"
+
+type ProvenanceParam struct {
+	// CommitVar is the path to a string variable that is set to the hash of the HEAD commit of the project.
+	CommitVar string
+
+	// CommitDateVar is the path to a string variable that is set to the time of the HEAD commit of the project in RFC
+	// 3339 format.
+	CommitDateVar string
+
+	// BuildDateVar is the path to a string variable that is set to the time of the build in RFC 3339 format. If the
+	// SOURCE_DATE_EPOCH environment variable is set, its value is used as the time of the build. Otherwise, if the
+	// build cache is enabled, the time of the HEAD commit is used as the time of the build (the variable is part of the
+	// build arguments, which are part of the cache key, so using the current time would prevent any cache hits).
+	BuildDateVar string
+
+	// DirtyVar is the path to a string variable that is set to "true" if the project has uncommitted changes
+	// (including untracked files) and to "false" otherwise.
+	DirtyVar string
+
+	// BuilderVar is the path to a string variable that is set to the identity of the builder.
+	BuilderVar string
+
+	// Builder is the identity of the builder. If empty, "{{user}}@{{hostname}}" for the user running the build is used.
+	Builder string
+}
+
+// ProvenanceInfo is the provenance information of a build.
+type ProvenanceInfo struct {
+	Commit     string
+	CommitDate time.Time
+	BuildDate  time.Time
+	Dirty      bool
+	Builder    string
+	// Repository is the URL of the "origin" remote of the git repository of the project. Empty if the repository does
+	// not have such a remote.
+	Repository string
+}
+
+// Info returns the provenance information for a build of the project in the provided directory, which must be in a
+// git repository that has at least one commit. If the SOURCE_DATE_EPOCH environment variable is not set, the build date
+// is the time of the HEAD commit if commitTimeBuildDate is true and the current time otherwise.
+func (p *ProvenanceParam) Info(projectDir string, commitTimeBuildDate bool) (ProvenanceInfo, error) {
+	commit, err := git.CmdOutput(projectDir, "rev-parse", "HEAD")
+	if err != nil {
+		return ProvenanceInfo{}, errors.Wrapf(err, "failed to determine commit of project")
+	}
+	commitDate, err := git.CommitTime(projectDir)
+	if err != nil {
+		return ProvenanceInfo{}, errors.Wrapf(err, "failed to determine commit time of project")
+	}
+	dirtyFiles, err := git.CmdOutput(projectDir, "status", "--porcelain")
+	if err != nil {
+		return ProvenanceInfo{}, errors.Wrapf(err, "failed to determine whether project has uncommitted changes")
+	}
+	buildDate, ok, err := SourceDateEpoch()
+	if err != nil {
+		return ProvenanceInfo{}, err
+	}
+	if !ok {
+		if commitTimeBuildDate {
+			buildDate = commitDate
+		} else {
+			buildDate = time.Now().UTC().Truncate(time.Second)
+		}
+	}
+	builder := p.Builder
+	if builder == "" {
+		builder = defaultBuilder()
+	}
+	// the "origin" remote is optional, so failure to read it is not an error
+	repository, _ := git.CmdOutput(projectDir, "config", "--get", "remote.origin.url")
+	return ProvenanceInfo{
+		Commit:     commit,
+		CommitDate: commitDate,
+		BuildDate:  buildDate,
+		Dirty:      dirtyFiles != "",
+		Builder:    builder,
+		Repository: repository,
+	}, nil
+}
+
+// XVars returns a map from the configured variables to the values of the provided provenance information that they are
+// set to.
+func (p *ProvenanceParam) XVars(info ProvenanceInfo) map[string]string {
+	xVars := make(map[string]string)
+	for variable, value := range map[string]string{
+		p.CommitVar:     info.Commit,
+		p.CommitDateVar: info.CommitDate.Format(time.RFC3339),
+		p.BuildDateVar:  info.BuildDate.Format(time.RFC3339),
+		p.DirtyVar:      strconv.FormatBool(info.Dirty),
+		p.BuilderVar:    info.Builder,
+	} {
+		if variable != "" {
+			xVars[variable] = value
+		}
+	}
+	return xVars
+}
+
+func defaultBuilder() string {
+	username := os.Getenv("USER")
+	if currUser, err := user.Current(); err == nil {
+		username = currUser.Username
+	}
+	hostname, _ := os.Hostname()
+	switch {
+	case username != "" && hostname != "":
+		return username + "@" + hostname
+	case hostname != "":
+		return hostname
+	case username != "":
+		return username
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distgo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type SizeBudgetParam struct {
+	// MaxSize is the maximum size in bytes of every artifact. If 0, the size is not limited.
+	MaxSize int64
+
+	// MaxGrowth is the maximum percentage by which the size of an artifact may grow relative to the size of the same
+	// artifact of the previous version. If 0, the growth is not limited.
+	MaxGrowth float64
+
+	// Warn specifies that an artifact that exceeds the budget causes a warning to be printed rather than an error.
+	Warn bool
+}
+
+var sizeRegexp = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([a-zA-Z]*)$`)
+
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+}
+
+// ParseSize returns the number of bytes represented by the provided size, which is a number optionally followed by
+// one of the units "B", "KB", "MB", "GB" (powers of 1000) or "KiB", "MiB", "GiB" (powers of 1024). For example, "512",
+// "20MB" and "1.5 GiB" are valid sizes.
+func ParseSize(size string) (int64, error) {
+	match := sizeRegexp.FindStringSubmatch(strings.TrimSpace(size))
+	if match == nil {
+		return 0, errors.Errorf("invalid size %q: must be a number optionally followed by a unit", size)
+	}
+	unit, ok := sizeUnits[strings.ToLower(match[2])]
+	if !ok {
+		return 0, errors.Errorf("invalid unit %q in size %q: valid units are B, KB, MB, GB, KiB, MiB and GiB", match[2], size)
+	}
+	val, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid size %q", size)
+	}
+	return int64(val * float64(unit)), nil
+}
+
+// FormatSize returns a human-readable representation of the provided number of bytes using binary units.
+func FormatSize(bytes int64) string {
+	abs := bytes
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(bytes)/(1<<30))
+	case abs >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(bytes)/(1<<20))
+	case abs >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(bytes)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
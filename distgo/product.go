@@ -92,6 +92,10 @@ func (p *ProductTaskOutputInfo) ProductDistArtifactPaths() map[DistID][]string {
 	return ProductDistArtifactPaths(p.Project, p.Product)
 }
 
+func (p *ProductTaskOutputInfo) ProductDisterArtifactPaths() map[DistID][]string {
+	return ProductDisterArtifactPaths(p.Project, p.Product)
+}
+
 func (p *ProductTaskOutputInfo) ProductDistWorkDirsAndArtifactPaths() map[DistID][]string {
 	return ProductDistWorkDirsAndArtifactPaths(p.Project, p.Product)
 }
@@ -161,8 +165,25 @@ func ProductDistWorkDirs(projectInfo ProjectInfo, productOutputInfo ProductOutpu
 }
 
 // ProductDistArtifactPaths returns a map from DistID to the output paths for the dist, which is
-// "{{ProjectDir}}/{{OutputDir}}/{{ProductID}}/{{Version}}/{{DistID}}/{{Artifacts}}".
+// "{{ProjectDir}}/{{OutputDir}}/{{ProductID}}/{{Version}}/{{DistID}}/{{Artifacts}}". The output paths consist of the
+// artifacts generated by the Dister followed by the checksum, manifest and SBOM sidecar files for the dist (if any).
 func ProductDistArtifactPaths(projectInfo ProjectInfo, productOutputInfo ProductOutputInfo) map[DistID][]string {
+	paths := ProductDisterArtifactPaths(projectInfo, productOutputInfo)
+	if paths == nil {
+		return nil
+	}
+	for distID, distOutputInfo := range productOutputInfo.DistOutputInfos.DistInfos {
+		for _, currSidecarPath := range distOutputInfo.DistSidecarNames {
+			paths[distID] = append(paths[distID], path.Join(ProductDistOutputDir(projectInfo, productOutputInfo, distID), currSidecarPath))
+		}
+	}
+	return paths
+}
+
+// ProductDisterArtifactPaths returns a map from DistID to the output paths for the artifacts generated by the Dister
+// for the dist. The paths are the same as those returned by ProductDistArtifactPaths, but do not include the checksum
+// and manifest sidecar files. Disters should use these paths to determine the artifacts that they must generate.
+func ProductDisterArtifactPaths(projectInfo ProjectInfo, productOutputInfo ProductOutputInfo) map[DistID][]string {
 	if productOutputInfo.DistOutputInfos == nil {
 		return nil
 	}
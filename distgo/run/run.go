@@ -31,6 +31,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/palantir/godel/pkg/osarch"
 	"github.com/pkg/errors"
 
 	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
@@ -55,7 +56,21 @@ func Product(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam, r
 	if err != nil {
 		return errors.Wrapf(err, "failed to compute output info")
 	}
-	buildArgs, err := productParam.Build.BuildArgs(productTaskOutputInfo)
+	// the product is run on the host, so the build arguments and environment for the current OS/Arch are used
+	osArch := osarch.Current()
+	var provenanceInfo *distgo.ProvenanceInfo
+	if productParam.Build.Provenance != nil {
+		info, err := productParam.Build.Provenance.Info(projectInfo.ProjectDir, productParam.Build.Cache != nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine provenance information")
+		}
+		provenanceInfo = &info
+	}
+	scriptArgs, err := productParam.Build.ScriptArgs(productTaskOutputInfo)
+	if err != nil {
+		return err
+	}
+	buildArgs, err := productParam.Build.BuildArgsForOSArch(productTaskOutputInfo, osArch, scriptArgs, provenanceInfo)
 	if err != nil {
 		return err
 	}
@@ -70,6 +85,12 @@ func Product(projectInfo distgo.ProjectInfo, productParam distgo.ProductParam, r
 	args = append(args, runArgs...)
 	cmd.Args = args
 
+	var env []string
+	for k, v := range productParam.Build.EnvironmentFor(osArch) {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	cmd.Env = append(os.Environ(), env...)
+
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 	cmd.Stdin = os.Stdin
@@ -29,6 +29,7 @@ import (
 	"testing"
 
 	"github.com/nmiyake/pkg/dirs"
+	"github.com/palantir/godel/pkg/osarch"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -205,6 +206,50 @@ func main() {
 				assert.Equal(t, "0.1.0", string(bytes))
 			},
 		},
+		{
+			`"run" uses environment and build arguments of the override for the current OS/Arch`,
+			distgoconfig.ProductConfig{
+				Build: distgoconfig.ToBuildConfig(&distgoconfig.BuildConfig{
+					MainPkg: stringPtr("."),
+					Environment: &map[string]string{
+						"RUN_TEST_BASE_VAR": "base",
+					},
+					OSArchOverrides: distgoconfig.ToOSArchOverrides(&map[string]distgoconfig.OSArchBuildOverrideConfig{
+						osarch.Current().String(): {
+							Environment: map[string]string{
+								"RUN_TEST_OVERRIDE_VAR": "override",
+							},
+							BuildArgs: []string{"-ldflags", "-X main.testVar=override"},
+						},
+					}),
+				}),
+			},
+			nil,
+			func(projectDir string) {
+				currMainContent := `package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+var testVar = "default"
+
+func main() {
+	ioutil.WriteFile(path.Join("{{OUTPUT_PATH}}", "runTestMainOutput.txt"), []byte(testVar+" "+os.Getenv("RUN_TEST_BASE_VAR")+" "+os.Getenv("RUN_TEST_OVERRIDE_VAR")), 0644)
+}
+`
+				err := ioutil.WriteFile(path.Join(projectDir, "main.go"), []byte(strings.Replace(currMainContent, "{{OUTPUT_PATH}}", projectDir, -1)), 0644)
+				require.NoError(t, err)
+			},
+			func(runErr error, caseNum int, projectDir string) {
+				assert.NoError(t, runErr, "Case %d", caseNum)
+				bytes, err := ioutil.ReadFile(path.Join(projectDir, "runTestMainOutput.txt"))
+				require.NoError(t, err, "Case %d", caseNum)
+				assert.Equal(t, "override base override", string(bytes))
+			},
+		},
 		{
 			`"run" works with multiple main package files as long as there is a single main function`,
 			distgoconfig.ProductConfig{
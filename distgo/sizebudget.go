@@ -0,0 +1,232 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distgo
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ArtifactSize is the size of an artifact along with the size of the corresponding artifact of the previous version of
+// the product (if it exists).
+type ArtifactSize struct {
+	// Name is the name used to refer to the artifact in the report and in error messages.
+	Name string
+	Size int64
+
+	// PrevVersion is the version of the previous artifact. If empty, there is no previous artifact.
+	PrevVersion string
+	PrevSize    int64
+}
+
+// Growth returns the percentage by which the size of the artifact grew relative to the size of the previous artifact.
+// Returns 0 if there is no previous artifact or if the previous artifact is empty.
+func (s ArtifactSize) Growth() float64 {
+	if s.PrevVersion == "" || s.PrevSize == 0 {
+		return 0
+	}
+	return float64(s.Size-s.PrevSize) / float64(s.PrevSize) * 100
+}
+
+func (s ArtifactSize) String() string {
+	out := fmt.Sprintf("Size of %s: %s", s.Name, FormatSize(s.Size))
+	if s.PrevVersion != "" {
+		diff := FormatSize(s.Size - s.PrevSize)
+		if s.Size >= s.PrevSize {
+			diff = "+" + diff
+		}
+		out += fmt.Sprintf(" (%s, %+.1f%% compared to %s)", diff, s.Growth(), s.PrevVersion)
+	}
+	return out
+}
+
+// NewArtifactSize returns the ArtifactSize for the artifact at artifactPath. The previous artifact is the artifact
+// returned by prevArtifactPath for the greatest version in versionsDir that is lower than currVersion (in the order
+// described by compareVersions) and that contains the artifact. The size of the artifact is not compared to that of a
+// previous version if no such artifact exists.
+func NewArtifactSize(name, artifactPath, versionsDir, currVersion string, prevArtifactPath func(version string) (string, error)) (ArtifactSize, error) {
+	fi, err := os.Stat(artifactPath)
+	if err != nil {
+		return ArtifactSize{}, errors.Wrapf(err, "failed to determine size of %s", artifactPath)
+	}
+	artifactSize := ArtifactSize{
+		Name: name,
+		Size: fi.Size(),
+	}
+	versionDirs, err := ioutil.ReadDir(versionsDir)
+	if err != nil {
+		return artifactSize, nil
+	}
+	for _, versionDir := range versionDirs {
+		version := versionDir.Name()
+		if !versionDir.IsDir() || compareVersions(version, currVersion) >= 0 {
+			continue
+		}
+		if artifactSize.PrevVersion != "" && compareVersions(version, artifactSize.PrevVersion) <= 0 {
+			continue
+		}
+		prevPath, err := prevArtifactPath(version)
+		if err != nil {
+			return ArtifactSize{}, err
+		}
+		prevFi, err := os.Stat(prevPath)
+		if err != nil || !prevFi.Mode().IsRegular() {
+			continue
+		}
+		artifactSize.PrevVersion = version
+		artifactSize.PrevSize = prevFi.Size()
+	}
+	return artifactSize, nil
+}
+
+// versionRegexp matches the versions generated by the git project versioner: a release version, an optional
+// pre-release, the number of commits since the tag and the abbreviated commit hash (if the HEAD commit is not tagged)
+// and an optional ".dirty" suffix. For example, "1.2.0", "1.2.0-rc1", "1.2.0-3-gabcdef0" and "1.2.0-rc1-3-gabcdef0.dirty".
+var versionRegexp = regexp.MustCompile(`^v?([0-9]+(?:\.[0-9]+)*)(?:-([0-9A-Za-z.-]+?))??(?:-([0-9]+)-g[0-9a-f]+)?(\.dirty)?$`)
+
+// compareVersions returns a negative number if version a is lower than version b, a positive number if it is greater
+// and 0 if the versions are equal. The release versions are compared numerically, a release is greater than its
+// pre-releases (which are compared in the manner described by compareIdentifiers), a version with commits on top of a tag is
+// greater than the tagged version (the greater the number of commits, the greater the version) and a dirty version is
+// greater than the clean version. Versions that do not match this format are lower than all versions that do and are
+// compared lexically.
+func compareVersions(a, b string) int {
+	aMatch, bMatch := versionRegexp.FindStringSubmatch(a), versionRegexp.FindStringSubmatch(b)
+	switch {
+	case aMatch == nil && bMatch == nil:
+		return strings.Compare(a, b)
+	case aMatch == nil:
+		return -1
+	case bMatch == nil:
+		return 1
+	}
+	if cmp := compareIdentifiers(strings.Split(aMatch[1], "."), strings.Split(bMatch[1], ".")); cmp != 0 {
+		return cmp
+	}
+	// a pre-release is lower than the release
+	switch {
+	case aMatch[2] == "" && bMatch[2] != "":
+		return 1
+	case aMatch[2] != "" && bMatch[2] == "":
+		return -1
+	}
+	if cmp := compareIdentifiers(strings.Split(aMatch[2], "."), strings.Split(bMatch[2], ".")); cmp != 0 {
+		return cmp
+	}
+	aCommits, _ := strconv.Atoi(aMatch[3])
+	bCommits, _ := strconv.Atoi(bMatch[3])
+	if aCommits != bCommits {
+		return aCommits - bCommits
+	}
+	switch {
+	case aMatch[4] == bMatch[4]:
+		return 0
+	case aMatch[4] != "":
+		return 1
+	default:
+		return -1
+	}
+}
+
+// compareIdentifiers compares the provided version identifiers in order. Numeric identifiers are compared numerically
+// and are lower than non-numeric identifiers, which are compared in the manner described by compareAlphanumeric. If all
+// of the identifiers of one version are equal to the corresponding identifiers of the other, the version with fewer
+// identifiers is lower.
+func compareIdentifiers(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		aNum, aErr := strconv.ParseUint(a[i], 10, 64)
+		bNum, bErr := strconv.ParseUint(b[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum < bNum {
+				return -1
+			} else if aNum > bNum {
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if cmp := compareAlphanumeric(a[i], b[i]); cmp != 0 {
+				return cmp
+			}
+		}
+	}
+	return len(a) - len(b)
+}
+
+// alphanumericRunRegexp matches the runs of digits and of non-digits in an identifier.
+var alphanumericRunRegexp = regexp.MustCompile(`[0-9]+|[^0-9]+`)
+
+// compareAlphanumeric compares the provided identifiers by comparing their runs of digits numerically and their other
+// runs lexically so that, for example, "rc10" is greater than "rc2".
+func compareAlphanumeric(a, b string) int {
+	aRuns, bRuns := alphanumericRunRegexp.FindAllString(a, -1), alphanumericRunRegexp.FindAllString(b, -1)
+	for i := 0; i < len(aRuns) && i < len(bRuns); i++ {
+		aNum, aErr := strconv.ParseUint(aRuns[i], 10, 64)
+		bNum, bErr := strconv.ParseUint(bRuns[i], 10, 64)
+		if aErr == nil && bErr == nil {
+			if aNum < bNum {
+				return -1
+			} else if aNum > bNum {
+				return 1
+			}
+			continue
+		}
+		if cmp := strings.Compare(aRuns[i], bRuns[i]); cmp != 0 {
+			return cmp
+		}
+	}
+	return len(aRuns) - len(bRuns)
+}
+
+// CheckSizeBudget prints the size of every provided artifact to stdout and verifies that the artifacts are within the
+// budget specified by the receiver. If any artifact exceeds the budget, an error that describes every violation is
+// returned. If the receiver specifies that violations are warnings, the violations are printed instead.
+func (p *SizeBudgetParam) CheckSizeBudget(sizes []ArtifactSize, stdout io.Writer) error {
+	var violations []string
+	for _, currSize := range sizes {
+		fmt.Fprintln(stdout, currSize.String())
+		if p.MaxSize > 0 && currSize.Size > p.MaxSize {
+			violations = append(violations, fmt.Sprintf("%s is %s, which exceeds the maximum size of %s", currSize.Name, FormatSize(currSize.Size), FormatSize(p.MaxSize)))
+		}
+		if growth := currSize.Growth(); p.MaxGrowth > 0 && growth > p.MaxGrowth {
+			violations = append(violations, fmt.Sprintf("%s grew by %.1f%% compared to %s, which exceeds the maximum growth of %g%%", currSize.Name, growth, currSize.PrevVersion, p.MaxGrowth))
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	if p.Warn {
+		for _, violation := range violations {
+			fmt.Fprintf(stdout, "Warning: %s\n", violation)
+		}
+		return nil
+	}
+	return errors.Errorf("size budget exceeded: %s", strings.Join(violations, "; "))
+}
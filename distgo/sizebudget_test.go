@@ -0,0 +1,210 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distgo_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo"
+)
+
+func TestParseSize(t *testing.T) {
+	for i, tc := range []struct {
+		in        string
+		want      int64
+		wantError string
+	}{
+		{in: "512", want: 512},
+		{in: "512B", want: 512},
+		{in: "20KB", want: 20000},
+		{in: "20MB", want: 20000000},
+		{in: "20MiB", want: 20 << 20},
+		{in: "1.5 GiB", want: 3 << 29},
+		{in: "1gb", want: 1000000000},
+		{in: "MiB", wantError: `invalid size "MiB": must be a number optionally followed by a unit`},
+		{in: "20TB", wantError: `invalid unit "TB" in size "20TB": valid units are B, KB, MB, GB, KiB, MiB and GiB`},
+	} {
+		got, err := distgo.ParseSize(tc.in)
+		if tc.wantError == "" {
+			require.NoError(t, err, "Case %d", i)
+			assert.Equal(t, tc.want, got, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, tc.wantError, "Case %d", i)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "100 B", distgo.FormatSize(100))
+	assert.Equal(t, "1.5 KiB", distgo.FormatSize(1536))
+	assert.Equal(t, "20.0 MiB", distgo.FormatSize(20<<20))
+	assert.Equal(t, "-2.0 MiB", distgo.FormatSize(-2<<20))
+	assert.Equal(t, "1.0 GiB", distgo.FormatSize(1<<30))
+}
+
+func TestNewArtifactSize(t *testing.T) {
+	tmpDir, cleanup, err := dirs.TempDir("", "")
+	require.NoError(t, err)
+	defer cleanup()
+
+	artifactPath := func(version string) (string, error) {
+		return path.Join(tmpDir, version, "foo-"+version+".tgz"), nil
+	}
+	writeArtifact := func(version string, size int, modTime time.Time) {
+		p, _ := artifactPath(version)
+		require.NoError(t, os.MkdirAll(path.Dir(p), 0755))
+		require.NoError(t, ioutil.WriteFile(p, make([]byte, size), 0644))
+		require.NoError(t, os.Chtimes(p, modTime, modTime))
+	}
+	now := time.Now()
+	writeArtifact("1.0.0", 1200, now)
+	writeArtifact("0.9.0", 1000, now.Add(-time.Hour))
+	writeArtifact("0.8.0", 500, now.Add(-2*time.Hour))
+	// version directory without the artifact is ignored
+	require.NoError(t, os.MkdirAll(path.Join(tmpDir, "0.9.1"), 0755))
+
+	currPath, _ := artifactPath("1.0.0")
+	got, err := distgo.NewArtifactSize("foo-1.0.0.tgz", currPath, tmpDir, "1.0.0", artifactPath)
+	require.NoError(t, err)
+	assert.Equal(t, distgo.ArtifactSize{
+		Name:        "foo-1.0.0.tgz",
+		Size:        1200,
+		PrevVersion: "0.9.0",
+		PrevSize:    1000,
+	}, got)
+	assert.Equal(t, 20.0, got.Growth())
+	assert.Equal(t, "Size of foo-1.0.0.tgz: 1.2 KiB (+200 B, +20.0% compared to 0.9.0)", got.String())
+
+	got, err = distgo.NewArtifactSize("foo-1.0.0.tgz", currPath, path.Join(tmpDir, "does-not-exist"), "1.0.0", artifactPath)
+	require.NoError(t, err)
+	assert.Equal(t, "Size of foo-1.0.0.tgz: 1.2 KiB", got.String())
+}
+
+func TestNewArtifactSizePrevVersionOrder(t *testing.T) {
+	for i, tc := range []struct {
+		currVersion string
+		// versions are written from the most recently modified to the least recently modified
+		versions []string
+		want     string
+	}{
+		{
+			currVersion: "1.0.0",
+			versions:    []string{"1.1.0", "0.9.0", "0.10.0", "0.10.0-rc1"},
+			want:        "0.10.0",
+		},
+		{
+			currVersion: "1.0.0",
+			versions:    []string{"1.0.0-rc2", "0.9.0-5-gabcdef0", "1.0.0-rc10"},
+			want:        "1.0.0-rc10",
+		},
+		{
+			currVersion: "1.0.0-3-gabcdef0",
+			versions:    []string{"1.0.0-10-g1234567", "1.0.0", "1.0.0-2-g1234567"},
+			want:        "1.0.0-2-g1234567",
+		},
+		{
+			currVersion: "1.0.0.dirty",
+			versions:    []string{"1.0.1", "1.0.0-1-g1234567", "1.0.0"},
+			want:        "1.0.0",
+		},
+		{
+			currVersion: "1.0.0",
+			versions:    []string{"1.1.0", "2.0.0"},
+		},
+	} {
+		func() {
+			tmpDir, cleanup, err := dirs.TempDir("", "")
+			require.NoError(t, err, "Case %d", i)
+			defer cleanup()
+
+			artifactPath := func(version string) (string, error) {
+				return path.Join(tmpDir, version, "foo"), nil
+			}
+			now := time.Now()
+			for j, version := range append([]string{tc.currVersion}, tc.versions...) {
+				p, _ := artifactPath(version)
+				require.NoError(t, os.MkdirAll(path.Dir(p), 0755), "Case %d", i)
+				require.NoError(t, ioutil.WriteFile(p, []byte("foo"), 0644), "Case %d", i)
+				modTime := now.Add(-time.Duration(j) * time.Hour)
+				require.NoError(t, os.Chtimes(p, modTime, modTime), "Case %d", i)
+			}
+
+			currPath, _ := artifactPath(tc.currVersion)
+			got, err := distgo.NewArtifactSize("foo", currPath, tmpDir, tc.currVersion, artifactPath)
+			require.NoError(t, err, "Case %d", i)
+			assert.Equal(t, tc.want, got.PrevVersion, "Case %d", i)
+		}()
+	}
+}
+
+func TestCheckSizeBudget(t *testing.T) {
+	sizes := []distgo.ArtifactSize{
+		{Name: "foo for linux-amd64", Size: 3 << 20, PrevVersion: "0.9.0", PrevSize: 2 << 20},
+		{Name: "foo for darwin-amd64", Size: 1 << 20},
+	}
+	for i, tc := range []struct {
+		name       string
+		param      distgo.SizeBudgetParam
+		wantError  string
+		wantOutput string
+	}{
+		{
+			name:  "artifacts within budget",
+			param: distgo.SizeBudgetParam{MaxSize: 4 << 20, MaxGrowth: 60},
+			wantOutput: `Size of foo for linux-amd64: 3.0 MiB (+1.0 MiB, +50.0% compared to 0.9.0)
+Size of foo for darwin-amd64: 1.0 MiB
+`,
+		},
+		{
+			name:      "artifacts that exceed budget cause an error",
+			param:     distgo.SizeBudgetParam{MaxSize: 2 << 20, MaxGrowth: 10},
+			wantError: "size budget exceeded: foo for linux-amd64 is 3.0 MiB, which exceeds the maximum size of 2.0 MiB; foo for linux-amd64 grew by 50.0% compared to 0.9.0, which exceeds the maximum growth of 10%",
+			wantOutput: `Size of foo for linux-amd64: 3.0 MiB (+1.0 MiB, +50.0% compared to 0.9.0)
+Size of foo for darwin-amd64: 1.0 MiB
+`,
+		},
+		{
+			name:  "artifacts that exceed budget cause a warning",
+			param: distgo.SizeBudgetParam{MaxSize: 2 << 20, Warn: true},
+			wantOutput: `Size of foo for linux-amd64: 3.0 MiB (+1.0 MiB, +50.0% compared to 0.9.0)
+Size of foo for darwin-amd64: 1.0 MiB
+Warning: foo for linux-amd64 is 3.0 MiB, which exceeds the maximum size of 2.0 MiB
+`,
+		},
+	} {
+		buf := &bytes.Buffer{}
+		err := tc.param.CheckSizeBudget(sizes, buf)
+		if tc.wantError == "" {
+			assert.NoError(t, err, "Case %d: %s", i, tc.name)
+		} else {
+			assert.EqualError(t, err, tc.wantError, "Case %d: %s", i, tc.name)
+		}
+		assert.Equal(t, tc.wantOutput, buf.String(), "Case %d: %s", i, tc.name)
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FileName is the name of the state file written to the output directory of a build or dist.
+const FileName = ".distgo-state.json"
+
+// State records the digests of the inputs that were used to generate a set of outputs and the digests of the outputs.
+// It is used to determine whether outputs are up-to-date based on content rather than on modification times, which
+// are not reliable after operations such as checking out a different revision or restoring a cache.
+type State struct {
+	// Inputs is a map from the name of an input to its digest.
+	Inputs map[string]string `json:"inputs"`
+	// Outputs is a map from the path of an output (relative to the directory that contains the state file) to its
+	// digest.
+	Outputs map[string]string `json:"outputs"`
+}
+
+// Read reads the State stored in the file at the provided path. Returns nil if the file does not exist.
+func Read(stateFilePath string) (*State, error) {
+	bytes, err := ioutil.ReadFile(stateFilePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read state file %s", stateFilePath)
+	}
+	var state State
+	if err := json.Unmarshal(bytes, &state); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal state file %s", stateFilePath)
+	}
+	return &state, nil
+}
+
+// Write writes a State that records the provided inputs and the digests of the outputs at the provided paths to the
+// file at the provided path.
+func Write(stateFilePath string, inputs map[string]string, outputPaths []string) error {
+	outputs, err := outputDigests(path.Dir(stateFilePath), outputPaths)
+	if err != nil {
+		return err
+	}
+	bytes, err := json.MarshalIndent(State{
+		Inputs:  inputs,
+		Outputs: outputs,
+	}, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal state")
+	}
+	if err := ioutil.WriteFile(stateFilePath, append(bytes, '\n'), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write state file %s", stateFilePath)
+	}
+	return nil
+}
+
+// StaleReason returns a description of why the outputs at the provided paths are out-of-date with respect to the
+// State stored in the file at the provided path and the provided inputs. The outputs are considered up-to-date if the
+// state file exists, the recorded inputs are the same as the provided inputs, and all of the outputs exist and have the
+// recorded digests. Returns an empty string if the outputs are up-to-date.
+func StaleReason(stateFilePath string, inputs map[string]string, outputPaths []string) (string, error) {
+	stateDir := path.Dir(stateFilePath)
+	outputs := make(map[string]string)
+	for _, outputPath := range outputPaths {
+		relPath := relOutputPath(stateDir, outputPath)
+		digest, err := FileDigest(outputPath)
+		if os.IsNotExist(errors.Cause(err)) {
+			return fmt.Sprintf("output %s does not exist", relPath), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		outputs[relPath] = digest
+	}
+
+	state, err := Read(stateFilePath)
+	if err != nil {
+		return "", err
+	}
+	if state == nil {
+		return "no state was recorded for the previous run", nil
+	}
+
+	var changedInputs []string
+	for name, digest := range inputs {
+		if state.Inputs[name] != digest {
+			changedInputs = append(changedInputs, name)
+		}
+	}
+	for name := range state.Inputs {
+		if _, ok := inputs[name]; !ok {
+			changedInputs = append(changedInputs, name)
+		}
+	}
+	if len(changedInputs) > 0 {
+		sort.Strings(changedInputs)
+		return fmt.Sprintf("inputs changed: %s", strings.Join(changedInputs, ", ")), nil
+	}
+
+	for _, outputPath := range outputPaths {
+		relPath := relOutputPath(stateDir, outputPath)
+		if state.Outputs[relPath] != outputs[relPath] {
+			return fmt.Sprintf("output %s was modified", relPath), nil
+		}
+	}
+	return "", nil
+}
+
+func outputDigests(stateDir string, outputPaths []string) (map[string]string, error) {
+	digests := make(map[string]string)
+	for _, outputPath := range outputPaths {
+		digest, err := FileDigest(outputPath)
+		if err != nil {
+			return nil, err
+		}
+		digests[relOutputPath(stateDir, outputPath)] = digest
+	}
+	return digests, nil
+}
+
+func relOutputPath(stateDir, outputPath string) string {
+	if relPath, err := filepath.Rel(stateDir, outputPath); err == nil {
+		return relPath
+	}
+	return outputPath
+}
+
+// StringDigest returns the hex-encoded SHA-256 digest of the provided string.
+func StringDigest(in string) string {
+	sum := sha256.Sum256([]byte(in))
+	return hex.EncodeToString(sum[:])
+}
+
+// JSONDigest returns the hex-encoded SHA-256 digest of the JSON representation of the provided value.
+func JSONDigest(in interface{}) (string, error) {
+	bytes, err := json.Marshal(in)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal value as JSON")
+	}
+	return StringDigest(string(bytes)), nil
+}
+
+// FileDigest returns the hex-encoded SHA-256 digest of the content of the file at the provided path.
+func FileDigest(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", filePath)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", filePath)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// FilesDigest returns a digest of the files in the provided map, which is a map from the name of a file to its path.
+// The digest covers the name and content of every file, so it changes if a file is added, removed, renamed or
+// modified. Files that do not exist are recorded as missing rather than causing an error.
+func FilesDigest(files map[string]string) (string, error) {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		digest, err := FileDigest(files[name])
+		if os.IsNotExist(errors.Cause(err)) {
+			digest = "missing"
+		} else if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %s\n", digest, name)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,161 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/distgo/state"
+)
+
+func TestStaleReason(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	inputs := map[string]string{
+		"version": state.StringDigest("1.0.0"),
+		"script":  state.StringDigest("echo hello"),
+	}
+
+	for i, tc := range []struct {
+		name       string
+		modify     func(outDir string, inputs map[string]string)
+		wantReason string
+	}{
+		{
+			name: "up-to-date if inputs and outputs did not change",
+		},
+		{
+			name: "up-to-date if only modification time of output changed",
+			modify: func(outDir string, inputs map[string]string) {
+				future := time.Now().Add(time.Hour)
+				err := os.Chtimes(path.Join(outDir, "out", "artifact.txt"), future, future)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "stale if state file does not exist",
+			modify: func(outDir string, inputs map[string]string) {
+				err := os.Remove(path.Join(outDir, state.FileName))
+				require.NoError(t, err)
+			},
+			wantReason: "no state was recorded for the previous run",
+		},
+		{
+			name: "stale if output does not exist",
+			modify: func(outDir string, inputs map[string]string) {
+				err := os.Remove(path.Join(outDir, "out", "artifact.txt"))
+				require.NoError(t, err)
+			},
+			wantReason: "output out/artifact.txt does not exist",
+		},
+		{
+			name: "stale if output was modified",
+			modify: func(outDir string, inputs map[string]string) {
+				err := ioutil.WriteFile(path.Join(outDir, "out", "artifact.txt"), []byte("modified"), 0644)
+				require.NoError(t, err)
+			},
+			wantReason: "output out/artifact.txt was modified",
+		},
+		{
+			name: "stale if inputs changed, were added or were removed",
+			modify: func(outDir string, inputs map[string]string) {
+				inputs["version"] = state.StringDigest("1.0.1")
+				inputs["input-dir"] = state.StringDigest("")
+				delete(inputs, "script")
+			},
+			wantReason: "inputs changed: input-dir, script, version",
+		},
+	} {
+		outDir, err := ioutil.TempDir(tmp, "")
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		outputPath := path.Join(outDir, "out", "artifact.txt")
+		err = os.MkdirAll(path.Dir(outputPath), 0755)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		err = ioutil.WriteFile(outputPath, []byte("artifact"), 0644)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+
+		stateFilePath := path.Join(outDir, state.FileName)
+		err = state.Write(stateFilePath, inputs, []string{outputPath})
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+
+		currInputs := make(map[string]string)
+		for k, v := range inputs {
+			currInputs[k] = v
+		}
+		if tc.modify != nil {
+			tc.modify(outDir, currInputs)
+		}
+
+		reason, err := state.StaleReason(stateFilePath, currInputs, []string{outputPath})
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantReason, reason, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestFilesDigest(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(path.Join(tmp, "a.txt"), []byte("a"), 0644)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path.Join(tmp, "b.txt"), []byte("b"), 0644)
+	require.NoError(t, err)
+
+	digest := func(files map[string]string) string {
+		got, err := state.FilesDigest(files)
+		require.NoError(t, err)
+		return got
+	}
+
+	original := digest(map[string]string{
+		"a.txt": path.Join(tmp, "a.txt"),
+		"b.txt": path.Join(tmp, "b.txt"),
+	})
+	assert.Equal(t, original, digest(map[string]string{
+		"b.txt": path.Join(tmp, "b.txt"),
+		"a.txt": path.Join(tmp, "a.txt"),
+	}), "digest should not depend on map order")
+	assert.NotEqual(t, original, digest(map[string]string{
+		"a.txt": path.Join(tmp, "a.txt"),
+		"c.txt": path.Join(tmp, "b.txt"),
+	}), "digest should change if a file is renamed")
+	assert.NotEqual(t, original, digest(map[string]string{
+		"a.txt": path.Join(tmp, "a.txt"),
+		"b.txt": path.Join(tmp, "missing.txt"),
+	}), "digest should change if a file is missing")
+
+	err = ioutil.WriteFile(path.Join(tmp, "b.txt"), []byte("modified"), 0644)
+	require.NoError(t, err)
+	assert.NotEqual(t, original, digest(map[string]string{
+		"a.txt": path.Join(tmp, "a.txt"),
+		"b.txt": path.Join(tmp, "b.txt"),
+	}), "digest should change if content changes")
+}
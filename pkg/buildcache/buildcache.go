@@ -0,0 +1,315 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package buildcache provides caches that store build outputs by a key derived from the inputs of the build.
+package buildcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Cache stores files by key. The SHA-256 digest of each file is stored alongside it and the content of a file is
+// verified against the digest before it is written to its destination.
+type Cache interface {
+	// Get writes the file stored for the provided key to dst (with mode 0755) and returns true. Returns false if the
+	// cache does not contain a file for the key, in which case dst is not modified. Returns an error without modifying
+	// dst if the content of the stored file does not match its digest.
+	Get(key, dst string) (bool, error)
+
+	// Put stores the file at src for the provided key.
+	Put(key, src string) error
+}
+
+// digestSuffix is the suffix appended to the key of a file to form the key of its SHA-256 digest.
+const digestSuffix = ".sha256"
+
+// NewDirCache returns a cache that stores files in the provided directory, which is created if it does not exist.
+func NewDirCache(dir string) Cache {
+	return &dirCache{
+		dir: dir,
+	}
+}
+
+type dirCache struct {
+	dir string
+}
+
+func (c *dirCache) Get(key, dst string) (bool, error) {
+	// an entry is only complete once its digest has been written, so an entry without a digest is not in the cache
+	digest, err := ioutil.ReadFile(c.entryPath(key) + digestSuffix)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrapf(err, "failed to read digest of cache entry for %s", key)
+	}
+	f, err := os.Open(c.entryPath(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrapf(err, "failed to open cache entry for %s", key)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if err := writeFileAtomic(dst, f, 0755, verifyDigest(string(digest))); err != nil {
+		return false, errors.Wrapf(err, "failed to restore cache entry for %s", key)
+	}
+	return true, nil
+}
+
+func (c *dirCache) Put(key, src string) error {
+	digest, err := fileDigest(src)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", src)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	entryPath := c.entryPath(key)
+	if err := os.MkdirAll(path.Dir(entryPath), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", path.Dir(entryPath))
+	}
+	if err := writeFileAtomic(entryPath, f, 0644, nil); err != nil {
+		return err
+	}
+	return writeFileAtomic(entryPath+digestSuffix, strings.NewReader(digest), 0644, nil)
+}
+
+// entryPath returns the path of the file for the provided key. Entries are sharded into directories named after the
+// first 2 characters of the key so that no single directory contains too many entries.
+func (c *dirCache) entryPath(key string) string {
+	shard := key
+	if len(shard) > 2 {
+		shard = shard[:2]
+	}
+	return path.Join(c.dir, shard, key)
+}
+
+// NewHTTPCache returns a cache that stores files on an HTTP server. The file for a key is retrieved using a GET
+// request to "{{baseURL}}/{{key}}" and stored using a PUT request to the same URL. The hex-encoded SHA-256 digest of
+// the file is stored at "{{baseURL}}/{{key}}.sha256" in the same manner. The server must respond to a GET request for
+// a key that it does not have with a 404 status. If token is non-empty, it is provided to the server as a bearer
+// token.
+func NewHTTPCache(baseURL, token string, client *http.Client) Cache {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpCache{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  client,
+	}
+}
+
+type httpCache struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func (c *httpCache) Get(key, dst string) (bool, error) {
+	found, err := c.get(key, func(r io.Reader) error {
+		err := writeFileAtomic(dst, r, 0755, func(digest string) error {
+			var wantDigest []byte
+			found, err := c.get(key+digestSuffix, func(r io.Reader) error {
+				var err error
+				wantDigest, err = ioutil.ReadAll(r)
+				return errors.Wrapf(err, "failed to read digest")
+			})
+			if err != nil {
+				return err
+			}
+			if !found {
+				return errNoDigest
+			}
+			return verifyDigest(string(wantDigest))(digest)
+		})
+		if err == errNoDigest {
+			return err
+		}
+		return errors.Wrapf(err, "failed to restore cache entry for %s", key)
+	})
+	if err == errNoDigest {
+		// the digest is stored after the file, so a file without a digest is not in the cache
+		return false, nil
+	}
+	return found, err
+}
+
+// errNoDigest is returned when verifying a file for which no digest is stored.
+var errNoDigest = errors.New("no digest is stored")
+
+// get performs a GET request for the provided key and calls handleBody with the body of the response if the request
+// succeeds. Returns false if the server does not have the key.
+func (c *httpCache) get(key string, handleBody func(r io.Reader) error) (bool, error) {
+	resp, err := c.do(http.MethodGet, key, nil, 0)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := handleBody(resp.Body); err != nil {
+			return false, err
+		}
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, errors.Errorf("GET %s returned status %s", c.entryURL(key), resp.Status)
+	}
+}
+
+func (c *httpCache) Put(key, src string) error {
+	digest, err := fileDigest(src)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", src)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", src)
+	}
+	if err := c.put(key, f, fi.Size()); err != nil {
+		return err
+	}
+	return c.put(key+digestSuffix, strings.NewReader(digest), int64(len(digest)))
+}
+
+func (c *httpCache) put(key string, body io.Reader, contentLength int64) error {
+	resp, err := c.do(http.MethodPut, key, body, contentLength)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("PUT %s returned status %s", c.entryURL(key), resp.Status)
+	}
+	return nil
+}
+
+func (c *httpCache) do(method, key string, body io.Reader, contentLength int64) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.entryURL(key), body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create request")
+	}
+	if body != nil {
+		req.ContentLength = contentLength
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s failed", method, c.entryURL(key))
+	}
+	return resp, nil
+}
+
+func (c *httpCache) entryURL(key string) string {
+	return fmt.Sprintf("%s/%s", c.baseURL, key)
+}
+
+// fileDigest returns the hex-encoded SHA-256 digest of the file at the provided path.
+func fileDigest(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", filePath)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", filePath)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyDigest returns a function that returns an error if the digest provided to it is not wantDigest.
+func verifyDigest(wantDigest string) func(digest string) error {
+	wantDigest = strings.TrimSpace(wantDigest)
+	return func(digest string) error {
+		if digest != wantDigest {
+			return errors.Errorf("SHA-256 digest of content is %s, but expected %s", digest, wantDigest)
+		}
+		return nil
+	}
+}
+
+// writeFileAtomic writes the content of the provided reader to a temporary file in the directory of dst and renames
+// it to dst so that a partially written file is never observed at dst. If verify is non-nil, it is called with the
+// hex-encoded SHA-256 digest of the content before the rename and the content is not written to dst if it returns an
+// error.
+func writeFileAtomic(dst string, r io.Reader, perm os.FileMode, verify func(digest string) error) (rErr error) {
+	tmpFile, err := ioutil.TempFile(path.Dir(dst), "."+path.Base(dst)+"-")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary file")
+	}
+	defer func() {
+		if rErr != nil {
+			_ = os.Remove(tmpFile.Name())
+		}
+	}()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmpFile, h), r); err != nil {
+		_ = tmpFile.Close()
+		return errors.Wrapf(err, "failed to write %s", tmpFile.Name())
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close %s", tmpFile.Name())
+	}
+	if verify != nil {
+		if err := verify(hex.EncodeToString(h.Sum(nil))); err != nil {
+			return err
+		}
+	}
+	if err := os.Chmod(tmpFile.Name(), perm); err != nil {
+		return errors.Wrapf(err, "failed to set permissions of %s", tmpFile.Name())
+	}
+	if err := os.Rename(tmpFile.Name(), dst); err != nil {
+		return errors.Wrapf(err, "failed to rename %s to %s", tmpFile.Name(), dst)
+	}
+	return nil
+}
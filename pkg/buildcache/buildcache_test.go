@@ -0,0 +1,143 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildcache_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/buildcache"
+)
+
+func TestDirCache(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	cacheDir := path.Join(tmp, "cache")
+	testCache(t, tmp, buildcache.NewDirCache(cacheDir), func(key string, content []byte) {
+		err := ioutil.WriteFile(path.Join(cacheDir, key[:2], key), content, 0644)
+		require.NoError(t, err)
+	})
+}
+
+func TestHTTPCache(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	var mutex sync.Mutex
+	entries := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/cache/")
+		switch r.Method {
+		case http.MethodGet:
+			content, ok := entries[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(content)
+		case http.MethodPut:
+			content, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			entries[key] = content
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	testCache(t, tmp, buildcache.NewHTTPCache(server.URL+"/cache/", "test-token", nil), func(key string, content []byte) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		entries[key] = content
+	})
+
+	// requests without the token fail
+	_, err = buildcache.NewHTTPCache(server.URL+"/cache", "", nil).Get("key", path.Join(tmp, "out"))
+	assert.EqualError(t, err, "GET "+server.URL+"/cache/key returned status 401 Unauthorized")
+}
+
+// testCache verifies the behavior of the provided cache. tamper replaces the stored content for a key without updating
+// its digest.
+func testCache(t *testing.T, tmp string, cache buildcache.Cache, tamper func(key string, content []byte)) {
+	src := path.Join(tmp, "src")
+	err := ioutil.WriteFile(src, []byte("executable"), 0755)
+	require.NoError(t, err)
+	dst := path.Join(tmp, "dst")
+
+	found, err := cache.Get("0123", dst)
+	require.NoError(t, err)
+	assert.False(t, found)
+	_, err = os.Stat(dst)
+	assert.True(t, os.IsNotExist(err))
+
+	err = cache.Put("0123", src)
+	require.NoError(t, err)
+
+	found, err = cache.Get("0123", dst)
+	require.NoError(t, err)
+	assert.True(t, found)
+	content, err := ioutil.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "executable", string(content))
+	fi, err := os.Stat(dst)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+
+	found, err = cache.Get("4567", dst)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// content that does not match the stored digest is not written to the destination
+	tamper("0123", []byte("tampered"))
+	found, err = cache.Get("0123", path.Join(tmp, "tampered"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to restore cache entry for 0123: SHA-256 digest of content is")
+	assert.False(t, found)
+	_, err = os.Stat(path.Join(tmp, "tampered"))
+	assert.True(t, os.IsNotExist(err))
+	files, err := ioutil.ReadDir(tmp)
+	require.NoError(t, err)
+	for _, fi := range files {
+		assert.False(t, strings.HasPrefix(fi.Name(), ".tampered"), "temporary file %s was not removed", fi.Name())
+	}
+}
@@ -23,7 +23,9 @@ import (
 	"fmt"
 	"os/exec"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -87,6 +89,19 @@ func ProjectVersionWithPrefix(gitDir, tagPrefix string) (string, error) {
 	return result, nil
 }
 
+// CommitTime returns the committer time of the HEAD commit of the git repository that the provided directory is in.
+func CommitTime(gitDir string) (time.Time, error) {
+	result, err := CmdOutput(gitDir, "log", "-1", "--format=%ct")
+	if err != nil {
+		return time.Time{}, err
+	}
+	seconds, err := strconv.ParseInt(result, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to parse commit time %q", result)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
 func CmdOutput(gitDir string, args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = gitDir
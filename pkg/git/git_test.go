@@ -21,9 +21,11 @@ package git_test
 
 import (
 	"io/ioutil"
+	"os"
 	"path"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/nmiyake/pkg/dirs"
 	"github.com/palantir/pkg/gittest"
@@ -222,3 +224,18 @@ func TestProjectVersionWithPrefix(t *testing.T) {
 		assert.Regexp(t, currCase.want, got, "Case %d", i)
 	}
 }
+
+func TestCommitTime(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	gittest.InitGitDir(t, tmp)
+	require.NoError(t, os.Setenv("GIT_COMMITTER_DATE", "2018-05-01T12:00:00Z"))
+	gittest.CommitRandomFile(t, tmp, "dated commit")
+	require.NoError(t, os.Unsetenv("GIT_COMMITTER_DATE"))
+
+	got, err := git.CommitTime(tmp)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC), got)
+}
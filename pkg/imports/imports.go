@@ -17,23 +17,30 @@ Sniperkit-Bot
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package imports resolves the set of files that are inputs to building a main package. The files are determined using
+// "go list -deps -json", so the set reflects the build context (GOOS, GOARCH, build tags, cgo) and module mode (go.mod,
+// go.sum, replace directives and vendoring) in the same manner as "go build".
 package imports
 
 import (
-	"go/build"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
 )
 
-// GoFiles is a map from package paths to the names of the buildable .go source files (.go files excluding Cgo and test
-// files) in the package.
+// GoFiles maps the absolute path of the directory of a package to the paths of the input files of the package relative
+// to that directory.
 type GoFiles map[string][]string
 
-// NewerThan returns true if the modification time of any of the GoFiles is newer than that of the provided file.
 func (g GoFiles) NewerThan(fi os.FileInfo) (bool, error) {
 	for pkg, files := range g {
 		for _, goFile := range files {
@@ -50,51 +57,314 @@ func (g GoFiles) NewerThan(fi os.FileInfo) (bool, error) {
 	return false, nil
 }
 
-// AllFiles returns a map that contains all of the non-standard library Go files that are imported (and thus required to
-// build) the specified package (including the package itself). The keys in the returned map are the paths to the
-// packages and the values are a slice of the names of the .go source files in the package (excluding Cgo and test
-// files).
+// Options specifies the build context used to resolve the files of a package.
+type Options struct {
+	// GOOS is the target operating system. If empty, the value of the GOOS environment variable (or the host operating
+	// system) is used.
+	GOOS string
+
+	// GOARCH is the target architecture. If empty, the value of the GOARCH environment variable (or the host
+	// architecture) is used.
+	GOARCH string
+
+	// Env specifies additional environment variables for resolving the files: for example, "CGO_ENABLED" or "GOFLAGS"
+	// (which can specify build tags using "-tags").
+	Env map[string]string
+
+	// Tags are the build tags that are considered satisfied when resolving the files.
+	Tags []string
+}
+
+// Files is the set of files that are inputs to building a main package.
+type Files struct {
+	// Packages contains the input files of the main package and of every non-standard library package that it depends
+	// on. The input files of a package are its Go files (including cgo files and Go files excluded by build
+	// constraints, since editing the constraints of such a file can add it to the build), its non-Go source files (C,
+	// assembly, syso, etc.) and the files embedded using "//go:embed" directives.
+	Packages GoFiles
+
+	// ModuleFiles are the absolute paths of the "go.mod", "go.sum" and "vendor/modules.txt" files of the main module and
+	// of the "go.mod" files of modules that are replaced by local directories. Is empty if the package is not built
+	// in module mode.
+	ModuleFiles []string
+
+	// Names maps the absolute path of each file to a name that does not depend on where the file is located on disk.
+	// The name of a file that belongs to a module is the module path (followed by "@" and the module version for
+	// versioned modules) joined with the path of the file relative to the root directory of the module. The name of any
+	// other file is the import path of its package joined with the name of the file. Files of packages that have no
+	// import path (packages outside of GOPATH that are not in a module) have no name.
+	Names map[string]string
+
+	// ImportPaths maps the directory of each package in Packages to the import path of the package as reported by
+	// "go list". The import path of a package in a GOPATH vendor directory includes the path of the vendor directory.
+	ImportPaths map[string]string
+
+	// Modules maps the directory of each package in Packages that belongs to a module to the module. Is empty if the
+	// package is not built in module mode.
+	Modules map[string]Module
+}
+
+// Module is a module that contains packages that are inputs to building a main package.
+type Module struct {
+	// Path is the path of the module.
+	Path string
+	// Version is the version of the module. Empty for the main module and for modules that are replaced by local
+	// directories.
+	Version string
+	// Main is true if the module is the main module.
+	Main bool
+	// Replace is the module by which the module is replaced using a "replace" directive (if any).
+	Replace *Module
+}
+
+// Paths returns the sorted absolute paths of all of the files in the receiver.
+func (f Files) Paths() []string {
+	var paths []string
+	for pkgDir, files := range f.Packages {
+		for _, file := range files {
+			paths = append(paths, path.Join(pkgDir, file))
+		}
+	}
+	paths = append(paths, f.ModuleFiles...)
+	sort.Strings(paths)
+	return paths
+}
+
+// AllFiles returns the input files of the main package at pkgPath and of every non-standard library package it depends
+// on for the host build context. Equivalent to the Packages field of the Files returned by Resolve with empty options.
 func AllFiles(pkgPath string) (GoFiles, error) {
-	// package name to all non-test Go files in the package
-	pkgFiles := make(map[string][]string)
+	files, err := Resolve(pkgPath, Options{})
+	if err != nil {
+		return nil, err
+	}
+	return files.Packages, nil
+}
+
+// listPackage is the subset of the JSON output of "go list -json" used to determine input files.
+type listPackage struct {
+	Dir            string
+	ImportPath     string
+	Standard       bool
+	GoFiles        []string
+	CgoFiles       []string
+	IgnoredGoFiles []string
+	CFiles         []string
+	CXXFiles       []string
+	MFiles         []string
+	HFiles         []string
+	FFiles         []string
+	SFiles         []string
+	SwigFiles      []string
+	SwigCXXFiles   []string
+	SysoFiles      []string
+	EmbedFiles     []string
+	Module         *listModule
+	Error          *listError
+}
+
+type listModule struct {
+	Path    string
+	Version string
+	Dir     string
+	GoMod   string
+	Main    bool
+	Replace *listModule
+}
+
+func (m *listModule) toModule() Module {
+	module := Module{
+		Path:    m.Path,
+		Version: m.Version,
+		Main:    m.Main,
+	}
+	if m.Replace != nil {
+		replace := m.Replace.toModule()
+		module.Replace = &replace
+	}
+	return module
+}
 
+type listError struct {
+	Err string
+}
+
+func (p listPackage) inputFiles() []string {
+	var files []string
+	for _, currFiles := range [][]string{
+		p.GoFiles,
+		p.CgoFiles,
+		p.IgnoredGoFiles,
+		p.CFiles,
+		p.CXXFiles,
+		p.MFiles,
+		p.HFiles,
+		p.FFiles,
+		p.SFiles,
+		p.SwigFiles,
+		p.SwigCXXFiles,
+		p.SysoFiles,
+		p.EmbedFiles,
+	} {
+		files = append(files, currFiles...)
+	}
+	return files
+}
+
+// Resolve returns the input files for building the main package at pkgPath in the build context specified by opts. The
+// files are determined by running "go list -deps -json" in the directory of the package, so the "go" executable must
+// be on the PATH.
+func Resolve(pkgPath string, opts Options) (Files, error) {
 	absPkgPath, err := filepath.Abs(pkgPath)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Failed to convert %v to absolute path", pkgPath)
+		return Files{}, errors.Wrapf(err, "Failed to convert %v to absolute path", pkgPath)
 	}
-
-	pkgsToProcess := []string{
-		absPkgPath,
+	pkgs, err := listDeps(absPkgPath, opts)
+	if err != nil {
+		return Files{}, err
 	}
 
-	for len(pkgsToProcess) > 0 {
-		currPkg := pkgsToProcess[0]
-		pkgsToProcess = pkgsToProcess[1:]
-		if _, ok := pkgFiles[currPkg]; ok {
+	pkgFiles := make(map[string][]string)
+	moduleFiles := make(map[string]struct{})
+	names := make(map[string]string)
+	importPaths := make(map[string]string)
+	var modules map[string]Module
+	for _, pkg := range pkgs {
+		if pkg.Error != nil {
+			return Files{}, errors.Errorf("Failed to import package %v: %v", pkg.ImportPath, pkg.Error.Err)
+		}
+		if pkg.Standard {
 			continue
 		}
+		pkgFiles[pkg.Dir] = uniqueSorted(append(pkgFiles[pkg.Dir], pkg.inputFiles()...))
+		importPaths[pkg.Dir] = pkg.ImportPath
 
-		// parse current package
-		pkg, err := build.Import(".", currPkg, build.ImportComment)
-		if err != nil {
-			return nil, errors.Wrapf(err, "Failed to import package %v", currPkg)
+		for _, file := range pkg.inputFiles() {
+			if (pkg.Module == nil || !addModuleName(names, pkg.Module, path.Join(pkg.Dir, file))) && !strings.HasPrefix(pkg.ImportPath, "_/") {
+				names[path.Join(pkg.Dir, file)] = path.Join(pkg.ImportPath, file)
+			}
 		}
-
-		// add all files for the current package to output
-		pkgFiles[currPkg] = pkg.GoFiles
-
-		// convert all non-built-in imports into packages and add to packages to process
-		for importPath := range pkg.ImportPos {
-			if !strings.Contains(importPath, ".") {
-				// if import is a standard package, skip
-				continue
+		if pkg.Module == nil {
+			continue
+		}
+		if modules == nil {
+			modules = make(map[string]Module)
+		}
+		modules[pkg.Dir] = pkg.Module.toModule()
+		switch {
+		case pkg.Module.Main:
+			modDir := path.Dir(pkg.Module.GoMod)
+			for _, name := range []string{"go.mod", "go.sum", path.Join("vendor", "modules.txt")} {
+				if _, err := os.Stat(path.Join(modDir, name)); err == nil {
+					moduleFiles[path.Join(modDir, name)] = struct{}{}
+					addModuleName(names, pkg.Module, path.Join(modDir, name))
+				}
 			}
-			importPkg, err := build.Import(importPath, currPkg, build.ImportComment)
-			if err != nil {
-				return nil, errors.Wrapf(err, "Failed to import package %v using srcDir %v", importPath, currPkg)
+		case pkg.Module.Replace != nil && pkg.Module.Replace.Version == "" && pkg.Module.Replace.GoMod != "":
+			// module replaced by a local directory: unlike a versioned module, its content is not verified by go.sum
+			if _, err := os.Stat(pkg.Module.Replace.GoMod); err == nil {
+				moduleFiles[pkg.Module.Replace.GoMod] = struct{}{}
+				addModuleName(names, pkg.Module, pkg.Module.Replace.GoMod)
 			}
-			pkgsToProcess = append(pkgsToProcess, importPkg.Dir)
 		}
 	}
-	return GoFiles(pkgFiles), nil
+
+	var sortedModuleFiles []string
+	for moduleFile := range moduleFiles {
+		sortedModuleFiles = append(sortedModuleFiles, moduleFile)
+	}
+	sort.Strings(sortedModuleFiles)
+	return Files{
+		Packages:    GoFiles(pkgFiles),
+		ModuleFiles: sortedModuleFiles,
+		Names:       names,
+		ImportPaths: importPaths,
+		Modules:     modules,
+	}, nil
+}
+
+// addModuleName adds the location-independent name of the file at filePath, which is in the provided module, to names
+// and returns true. Returns false if the root directory of the module is not known (for example, for vendored modules)
+// or if the file is not in it.
+func addModuleName(names map[string]string, module *listModule, filePath string) bool {
+	id, modDir := module.Path, module.Dir
+	if module.Replace != nil {
+		if module.Replace.Version != "" {
+			id = module.Replace.Path + "@" + module.Replace.Version
+		}
+		if module.Replace.Dir != "" {
+			modDir = module.Replace.Dir
+		}
+	} else if module.Version != "" {
+		id += "@" + module.Version
+	}
+	if modDir == "" {
+		return false
+	}
+	relPath, err := filepath.Rel(modDir, filePath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return false
+	}
+	names[filePath] = path.Join(id, filepath.ToSlash(relPath))
+	return true
+}
+
+func listDeps(absPkgPath string, opts Options) ([]listPackage, error) {
+	args := []string{"list", "-deps", "-json"}
+	if len(opts.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(opts.Tags, ","))
+	}
+	cmd := exec.Command("go", append(args, ".")...)
+	cmd.Dir = absPkgPath
+
+	var env []string
+	if opts.GOOS != "" {
+		env = append(env, "GOOS="+opts.GOOS)
+	}
+	if opts.GOARCH != "" {
+		env = append(env, "GOARCH="+opts.GOARCH)
+	}
+	var keys []string
+	for k := range opts.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, fmt.Sprintf("%s=%s", k, opts.Env[k]))
+	}
+	cmd.Env = append(os.Environ(), env...)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Errorf("Failed to list dependencies of package %v: %v failed with output:\n%s", absPkgPath, cmd.Args, strings.TrimSpace(stderr.String()))
+	}
+
+	var pkgs []listPackage
+	decoder := json.NewDecoder(stdout)
+	for {
+		var pkg listPackage
+		if err := decoder.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse output of %v", cmd.Args)
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+func uniqueSorted(in []string) []string {
+	seen := make(map[string]struct{})
+	var out []string
+	for _, s := range in {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
 }
@@ -21,6 +21,7 @@ package imports_test
 
 import (
 	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
 	"testing"
@@ -173,6 +174,184 @@ func TestAllFiles(t *testing.T) {
 	}
 }
 
+func TestResolveModule(t *testing.T) {
+	tmpDir, cleanup, err := dirs.TempDir("", "")
+	require.NoError(t, err)
+	defer cleanup()
+	tmpDir, err = filepath.EvalSymlinks(tmpDir)
+	require.NoError(t, err)
+
+	modDir := path.Join(tmpDir, "m")
+	depDir := path.Join(tmpDir, "dep")
+	for relPath, content := range map[string]string{
+		"m/go.mod": `module example.com/m
+
+go 1.16
+
+require example.com/dep v0.0.0
+
+replace example.com/dep => ../dep
+`,
+		"m/go.sum": ``,
+		"m/main.go": `package main
+
+import (
+	_ "embed"
+
+	"example.com/m/lib"
+)
+
+//go:embed assets/banner.txt
+var banner string
+
+func main() { lib.F() }
+`,
+		"m/main_windows.go":     `package main; import _ "example.com/m/winonly"`,
+		"m/main_test.go":        `package main; import "testing"; func TestMain(t *testing.T) {}`,
+		"m/assets/banner.txt":   `banner`,
+		"m/assets/unused.txt":   `unused`,
+		"m/winonly/winonly.go":  `package winonly`,
+		"m/lib/lib.go":          `package lib; import "example.com/dep"; func F() { dep.G() }`,
+		"m/lib/lib_cgo.go":      `package lib; import "C"`,
+		"m/unused/unused.go":    `package unused`,
+		"dep/go.mod":            "module example.com/dep\n\ngo 1.16\n",
+		"dep/dep.go":            `package dep; func G() {}`,
+		"dep/internal/other.go": `package other`,
+	} {
+		err := os.MkdirAll(path.Dir(path.Join(tmpDir, relPath)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(tmpDir, relPath), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+
+	mainModule := imports.Module{Path: "example.com/m", Main: true}
+	depModule := imports.Module{Path: "example.com/dep", Version: "v0.0.0", Replace: &imports.Module{Path: "../dep"}}
+
+	// module mode is enabled explicitly so that the test does not depend on the environment
+	for i, tc := range []struct {
+		name string
+		opts imports.Options
+		want imports.Files
+	}{
+		{
+			name: "linux includes embedded files, cgo files, files excluded by build constraints and module files",
+			opts: imports.Options{GOOS: "linux", GOARCH: "amd64", Env: map[string]string{"CGO_ENABLED": "0", "GO111MODULE": "on"}},
+			want: imports.Files{
+				Packages: imports.GoFiles(map[string][]string{
+					modDir: {
+						"assets/banner.txt",
+						"main.go",
+						"main_windows.go",
+					},
+					path.Join(modDir, "lib"): {
+						"lib.go",
+						"lib_cgo.go",
+					},
+					depDir: {
+						"dep.go",
+					},
+				}),
+				ModuleFiles: []string{
+					path.Join(depDir, "go.mod"),
+					path.Join(modDir, "go.mod"),
+					path.Join(modDir, "go.sum"),
+				},
+				Names: map[string]string{
+					path.Join(modDir, "assets/banner.txt"): "example.com/m/assets/banner.txt",
+					path.Join(modDir, "main.go"):           "example.com/m/main.go",
+					path.Join(modDir, "main_windows.go"):   "example.com/m/main_windows.go",
+					path.Join(modDir, "lib", "lib.go"):     "example.com/m/lib/lib.go",
+					path.Join(modDir, "lib", "lib_cgo.go"): "example.com/m/lib/lib_cgo.go",
+					path.Join(modDir, "go.mod"):            "example.com/m/go.mod",
+					path.Join(modDir, "go.sum"):            "example.com/m/go.sum",
+					path.Join(depDir, "dep.go"):            "example.com/dep/dep.go",
+					path.Join(depDir, "go.mod"):            "example.com/dep/go.mod",
+				},
+				ImportPaths: map[string]string{
+					modDir:                   "example.com/m",
+					path.Join(modDir, "lib"): "example.com/m/lib",
+					depDir:                   "example.com/dep",
+				},
+				Modules: map[string]imports.Module{
+					modDir:                   mainModule,
+					path.Join(modDir, "lib"): mainModule,
+					depDir:                   depModule,
+				},
+			},
+		},
+		{
+			name: "windows includes dependencies that are only imported for windows",
+			opts: imports.Options{GOOS: "windows", GOARCH: "amd64", Env: map[string]string{"CGO_ENABLED": "0", "GO111MODULE": "on"}},
+			want: imports.Files{
+				Packages: imports.GoFiles(map[string][]string{
+					modDir: {
+						"assets/banner.txt",
+						"main.go",
+						"main_windows.go",
+					},
+					path.Join(modDir, "lib"): {
+						"lib.go",
+						"lib_cgo.go",
+					},
+					path.Join(modDir, "winonly"): {
+						"winonly.go",
+					},
+					depDir: {
+						"dep.go",
+					},
+				}),
+				ModuleFiles: []string{
+					path.Join(depDir, "go.mod"),
+					path.Join(modDir, "go.mod"),
+					path.Join(modDir, "go.sum"),
+				},
+				Names: map[string]string{
+					path.Join(modDir, "assets/banner.txt"):     "example.com/m/assets/banner.txt",
+					path.Join(modDir, "main.go"):               "example.com/m/main.go",
+					path.Join(modDir, "main_windows.go"):       "example.com/m/main_windows.go",
+					path.Join(modDir, "lib", "lib.go"):         "example.com/m/lib/lib.go",
+					path.Join(modDir, "lib", "lib_cgo.go"):     "example.com/m/lib/lib_cgo.go",
+					path.Join(modDir, "go.mod"):                "example.com/m/go.mod",
+					path.Join(modDir, "go.sum"):                "example.com/m/go.sum",
+					path.Join(depDir, "dep.go"):                "example.com/dep/dep.go",
+					path.Join(depDir, "go.mod"):                "example.com/dep/go.mod",
+					path.Join(modDir, "winonly", "winonly.go"): "example.com/m/winonly/winonly.go",
+				},
+				ImportPaths: map[string]string{
+					modDir:                       "example.com/m",
+					path.Join(modDir, "lib"):     "example.com/m/lib",
+					depDir:                       "example.com/dep",
+					path.Join(modDir, "winonly"): "example.com/m/winonly",
+				},
+				Modules: map[string]imports.Module{
+					modDir:                       mainModule,
+					path.Join(modDir, "lib"):     mainModule,
+					depDir:                       depModule,
+					path.Join(modDir, "winonly"): mainModule,
+				},
+			},
+		},
+	} {
+		got, err := imports.Resolve(modDir, tc.opts)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, got, "Case %d: %s", i, tc.name)
+	}
+
+	got, err := imports.Resolve(modDir, imports.Options{GOOS: "linux", GOARCH: "amd64", Env: map[string]string{"GO111MODULE": "on"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		path.Join(depDir, "dep.go"),
+		path.Join(depDir, "go.mod"),
+		path.Join(modDir, "assets/banner.txt"),
+		path.Join(modDir, "go.mod"),
+		path.Join(modDir, "go.sum"),
+		path.Join(modDir, "lib", "lib.go"),
+		path.Join(modDir, "lib", "lib_cgo.go"),
+		path.Join(modDir, "main.go"),
+		path.Join(modDir, "main_windows.go"),
+	}, got.Paths())
+}
+
 func TestNewerThanFileIsNewer(t *testing.T) {
 	tmpDir, cleanup, err := dirs.TempDir(".", "")
 	require.NoError(t, err)
@@ -0,0 +1,265 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"go/build"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	AGPL3      = "AGPL-3.0"
+	Apache2    = "Apache-2.0"
+	BSD2Clause = "BSD-2-Clause"
+	BSD3Clause = "BSD-3-Clause"
+	GPL2       = "GPL-2.0"
+	GPL3       = "GPL-3.0"
+	ISC        = "ISC"
+	LGPL21     = "LGPL-2.1"
+	LGPL3      = "LGPL-3.0"
+	MIT        = "MIT"
+	MPL2       = "MPL-2.0"
+	Unlicense  = "Unlicense"
+	// Unknown is the type of a license whose text is not recognized and the type used for modules that do not contain
+	// a license file.
+	Unknown = "unknown"
+)
+
+// Types returns the license types that can be detected.
+func Types() []string {
+	return []string{AGPL3, Apache2, BSD2Clause, BSD3Clause, GPL2, GPL3, ISC, LGPL21, LGPL3, MIT, MPL2, Unlicense, Unknown}
+}
+
+// Module is a directory that contains license or notice files and the packages in it or its subdirectories.
+type Module struct {
+	// Path is the path of the module relative to the directory that contains it: the vendor directory, the "src"
+	// directory of a GOPATH or the module cache. For example, "github.com/pkg/errors".
+	Path string
+	// Dir is the path to the directory of the module.
+	Dir string
+	// Files are the names of the license and notice files in Dir.
+	Files []string
+	// Types are the types of the licenses in the license files of the module. Contains Unknown if the module does not
+	// contain any license files.
+	Types []string
+}
+
+var licenseFilePrefixes = []string{"LICENSE", "LICENCE", "COPYING", "NOTICE"}
+
+// IsLicenseFile returns true if the provided file name is the name of a license or notice file: its upper-case form
+// starts with "LICENSE", "LICENCE", "COPYING" or "NOTICE" (for example, "LICENSE", "LICENSE.md", "COPYING.txt" or
+// "NOTICE").
+func IsLicenseFile(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, prefix := range licenseFilePrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNoticeFile(name string) bool {
+	return strings.HasPrefix(strings.ToUpper(name), "NOTICE")
+}
+
+// Find returns the modules that contain the packages in the provided directories. The module of a package is the
+// closest directory (starting with the directory of the package and moving up towards the vendor directory, GOPATH or
+// module cache that contains it) that contains a license or notice file. If no such directory exists, the directory
+// of the package is used as the module and the type of its license is Unknown. Packages that are in the project
+// directory but not in a vendor directory are part of the project and are skipped. The returned modules are sorted by
+// path.
+func Find(projectDir string, pkgDirs []string) ([]Module, error) {
+	absProjectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert %s to absolute path", projectDir)
+	}
+
+	modules := make(map[string]Module)
+	for _, pkgDir := range pkgDirs {
+		absPkgDir, err := filepath.Abs(pkgDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert %s to absolute path", pkgDir)
+		}
+		if relPath, err := filepath.Rel(absProjectDir, absPkgDir); err == nil && !strings.HasPrefix(relPath, "..") && !hasVendorElement(relPath) {
+			// package is part of the project
+			continue
+		}
+
+		root := searchRoot(absPkgDir)
+		moduleDir, files, err := findModuleDir(absPkgDir, root)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := modules[moduleDir]; ok {
+			continue
+		}
+		types, err := licenseTypes(moduleDir, files)
+		if err != nil {
+			return nil, err
+		}
+		modulePath := moduleDir
+		if relPath, err := filepath.Rel(root, moduleDir); err == nil {
+			modulePath = filepath.ToSlash(relPath)
+		}
+		modules[moduleDir] = Module{
+			Path:  modulePath,
+			Dir:   moduleDir,
+			Files: files,
+			Types: types,
+		}
+	}
+
+	var out []Module
+	for _, module := range modules {
+		out = append(out, module)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Path < out[j].Path
+	})
+	return out, nil
+}
+
+func hasVendorElement(relPath string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(relPath), "/") {
+		if part == "vendor" {
+			return true
+		}
+	}
+	return false
+}
+
+// searchRoot returns the directory that contains the import path of the package in the provided directory: the
+// innermost vendor directory, the "src" directory of a GOPATH entry or the module cache. If none of these contain the
+// directory, the parent directory of the package is returned.
+func searchRoot(pkgDir string) string {
+	slashDir := filepath.ToSlash(pkgDir)
+	if idx := strings.LastIndex(slashDir, "/vendor/"); idx != -1 {
+		return filepath.FromSlash(slashDir[:idx+len("/vendor")])
+	}
+	for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+		for _, root := range []string{filepath.Join(gopath, "src"), filepath.Join(gopath, "pkg", "mod")} {
+			if relPath, err := filepath.Rel(root, pkgDir); err == nil && !strings.HasPrefix(relPath, "..") {
+				return root
+			}
+		}
+	}
+	return filepath.Dir(pkgDir)
+}
+
+// findModuleDir returns the closest directory to pkgDir (inclusive) below root that contains license or notice files
+// along with the names of those files. Returns pkgDir and no files if no such directory exists.
+func findModuleDir(pkgDir, root string) (string, []string, error) {
+	for dir := pkgDir; dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		fis, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "failed to list files in %s", dir)
+		}
+		var files []string
+		for _, fi := range fis {
+			if fi.Mode().IsRegular() && IsLicenseFile(fi.Name()) {
+				files = append(files, fi.Name())
+			}
+		}
+		if len(files) > 0 {
+			return dir, files, nil
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	return pkgDir, nil, nil
+}
+
+func licenseTypes(dir string, files []string) ([]string, error) {
+	typesMap := make(map[string]struct{})
+	for _, file := range files {
+		if isNoticeFile(file) {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", filepath.Join(dir, file))
+		}
+		typesMap[Detect(string(content))] = struct{}{}
+	}
+	if len(typesMap) == 0 {
+		return []string{Unknown}, nil
+	}
+	var types []string
+	for licenseType := range typesMap {
+		types = append(types, licenseType)
+	}
+	sort.Strings(types)
+	return types, nil
+}
+
+var whitespaceRegexp = regexp.MustCompile(`\s+`)
+
+// Detect returns the type of the license with the provided text. The type is determined based on phrases that are
+// characteristic of the license, so it is a best-effort classification rather than an exact match of the text. Returns
+// Unknown if the license is not recognized.
+func Detect(text string) string {
+	normalized := whitespaceRegexp.ReplaceAllString(strings.ToLower(text), " ")
+	contains := func(phrases ...string) bool {
+		for _, phrase := range phrases {
+			if !strings.Contains(normalized, phrase) {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case contains("gnu affero general public license"):
+		return AGPL3
+	case contains("gnu lesser general public license", "version 3"):
+		return LGPL3
+	case contains("gnu lesser general public license"), contains("gnu library general public license"):
+		return LGPL21
+	case contains("gnu general public license", "version 3"):
+		return GPL3
+	case contains("gnu general public license"):
+		return GPL2
+	case contains("mozilla public license", "version 2.0"):
+		return MPL2
+	case contains("apache license", "version 2.0"):
+		return Apache2
+	case contains("redistribution and use in source and binary forms"):
+		if contains("neither the name") || contains("names of its contributors") {
+			return BSD3Clause
+		}
+		return BSD2Clause
+	case contains("permission is hereby granted, free of charge"):
+		return MIT
+	case contains("permission to use, copy, modify, and/or distribute this software for any purpose"),
+		contains("permission to use, copy, modify, and distribute this software for any purpose with or without fee"):
+		return ISC
+	case contains("this is free and unencumbered software released into the public domain"):
+		return Unlicense
+	default:
+		return Unknown
+	}
+}
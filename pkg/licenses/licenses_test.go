@@ -0,0 +1,131 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/licenses"
+)
+
+const (
+	mitLicense = `The MIT License (MIT)
+
+Copyright (c) 2015 Foo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction.`
+	bsd3License = `Copyright (c) 2009 The Foo Authors. All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are
+met:
+
+   * Neither the name of Foo nor the names of its
+contributors may be used to endorse or promote products derived from
+this software without specific prior written permission.`
+	gpl3License = `                    GNU GENERAL PUBLIC LICENSE
+                       Version 3, 29 June 2007`
+)
+
+func TestDetect(t *testing.T) {
+	for i, tc := range []struct {
+		text string
+		want string
+	}{
+		{mitLicense, licenses.MIT},
+		{bsd3License, licenses.BSD3Clause},
+		{`Redistribution and use in source and binary forms, with or without modification, are permitted.`, licenses.BSD2Clause},
+		{`                                 Apache License
+                           Version 2.0, January 2004`, licenses.Apache2},
+		{gpl3License, licenses.GPL3},
+		{`GNU GENERAL PUBLIC LICENSE Version 2, June 1991`, licenses.GPL2},
+		{`GNU LESSER GENERAL PUBLIC LICENSE Version 3, 29 June 2007`, licenses.LGPL3},
+		{`GNU AFFERO GENERAL PUBLIC LICENSE Version 3, 19 November 2007`, licenses.AGPL3},
+		{`Mozilla Public License Version 2.0`, licenses.MPL2},
+		{`Permission to use, copy, modify, and/or distribute this software for any purpose with or without fee is hereby granted`, licenses.ISC},
+		{`This is free and unencumbered software released into the public domain.`, licenses.Unlicense},
+		{`All rights reserved.`, licenses.Unknown},
+	} {
+		assert.Equal(t, tc.want, licenses.Detect(tc.text), "Case %d", i)
+	}
+}
+
+func TestFind(t *testing.T) {
+	projectDir, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for _, currFile := range []struct {
+		relPath string
+		content string
+	}{
+		{"foo/main.go", "package main"},
+		{"foo/LICENSE", mitLicense},
+		{"vendor/github.com/org/mit/LICENSE.txt", mitLicense},
+		{"vendor/github.com/org/mit/NOTICE", "Notice"},
+		{"vendor/github.com/org/mit/sub/sub.go", "package sub"},
+		{"vendor/github.com/org/mit/mit.go", "package mit"},
+		{"vendor/github.com/org/gpl/COPYING", gpl3License},
+		{"vendor/github.com/org/gpl/gpl.go", "package gpl"},
+		{"vendor/github.com/org/nolicense/nolicense.go", "package nolicense"},
+	} {
+		err := os.MkdirAll(path.Dir(path.Join(projectDir, currFile.relPath)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(projectDir, currFile.relPath), []byte(currFile.content), 0644)
+		require.NoError(t, err)
+	}
+
+	modules, err := licenses.Find(projectDir, []string{
+		path.Join(projectDir, "foo"),
+		path.Join(projectDir, "vendor", "github.com", "org", "mit"),
+		path.Join(projectDir, "vendor", "github.com", "org", "mit", "sub"),
+		path.Join(projectDir, "vendor", "github.com", "org", "gpl"),
+		path.Join(projectDir, "vendor", "github.com", "org", "nolicense"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []licenses.Module{
+		{
+			Path:  "github.com/org/gpl",
+			Dir:   path.Join(projectDir, "vendor", "github.com", "org", "gpl"),
+			Files: []string{"COPYING"},
+			Types: []string{licenses.GPL3},
+		},
+		{
+			Path:  "github.com/org/mit",
+			Dir:   path.Join(projectDir, "vendor", "github.com", "org", "mit"),
+			Files: []string{"LICENSE.txt", "NOTICE"},
+			Types: []string{licenses.MIT},
+		},
+		{
+			Path:  "github.com/org/nolicense",
+			Dir:   path.Join(projectDir, "vendor", "github.com", "org", "nolicense"),
+			Types: []string{licenses.Unknown},
+		},
+	}, modules)
+}
@@ -0,0 +1,185 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provenance
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	statementType = "https://in-toto.io/Statement/v0.1"
+	predicateType = "https://slsa.dev/provenance/v0.2"
+
+	// BuildType is the type of the builds described by the provenance documents.
+	BuildType = "https://github.com/palantir/distgo/build@v1"
+)
+
+// Document describes how an artifact was built.
+type Document struct {
+	// Subject is the artifact that was built.
+	Subject Artifact
+	// BuilderID identifies the entity that performed the build.
+	BuilderID string
+	// Repository is the URL of the git repository that contains the source of the build. May be empty if it is not known.
+	Repository string
+	// Commit is the hash of the commit of the git repository that was built.
+	Commit string
+	// EntryPoint is the main package that was built.
+	EntryPoint string
+	// Parameters are the parameters of the build, such as the version and OS/Arch.
+	Parameters map[string]interface{}
+	// Environment describes the environment of the build, such as the state of the git repository and the environment
+	// variables set for the build.
+	Environment map[string]interface{}
+	// BuildStartedOn is the time at which the build started.
+	BuildStartedOn time.Time
+	// Materials are the files that were inputs to the build.
+	Materials []Artifact
+}
+
+// Artifact is a file along with its SHA-256 digest.
+type Artifact struct {
+	// Name is the name of the artifact. For materials, this is the path of the file (relative to the project directory
+	// for files in the project).
+	Name string
+	// SHA256 is the hex-encoded SHA-256 digest of the artifact.
+	SHA256 string
+}
+
+type statement struct {
+	Type          string    `json:"_type"`
+	Subject       []subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     predicate `json:"predicate"`
+}
+
+type subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type predicate struct {
+	Builder    builder    `json:"builder"`
+	BuildType  string     `json:"buildType"`
+	Invocation invocation `json:"invocation"`
+	Metadata   metadata   `json:"metadata"`
+	Materials  []material `json:"materials"`
+}
+
+type builder struct {
+	ID string `json:"id"`
+}
+
+type invocation struct {
+	ConfigSource configSource           `json:"configSource"`
+	Parameters   map[string]interface{} `json:"parameters,omitempty"`
+	Environment  map[string]interface{} `json:"environment,omitempty"`
+}
+
+type configSource struct {
+	URI        string            `json:"uri,omitempty"`
+	Digest     map[string]string `json:"digest,omitempty"`
+	EntryPoint string            `json:"entryPoint,omitempty"`
+}
+
+type metadata struct {
+	BuildStartedOn string       `json:"buildStartedOn"`
+	Completeness   completeness `json:"completeness"`
+	Reproducible   bool         `json:"reproducible"`
+}
+
+type completeness struct {
+	Parameters  bool `json:"parameters"`
+	Environment bool `json:"environment"`
+	Materials   bool `json:"materials"`
+}
+
+type material struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+// InTotoStatement returns the JSON representation of an in-toto statement whose predicate is the SLSA provenance
+// (v0.2) described by the provided document. The materials are sorted by name.
+func InTotoStatement(doc Document) ([]byte, error) {
+	out := statement{
+		Type: statementType,
+		Subject: []subject{
+			{
+				Name: doc.Subject.Name,
+				Digest: map[string]string{
+					"sha256": doc.Subject.SHA256,
+				},
+			},
+		},
+		PredicateType: predicateType,
+		Predicate: predicate{
+			Builder: builder{
+				ID: doc.BuilderID,
+			},
+			BuildType: BuildType,
+			Invocation: invocation{
+				ConfigSource: configSource{
+					EntryPoint: doc.EntryPoint,
+				},
+				Parameters:  doc.Parameters,
+				Environment: doc.Environment,
+			},
+			Metadata: metadata{
+				BuildStartedOn: doc.BuildStartedOn.UTC().Format(time.RFC3339),
+				Completeness: completeness{
+					Materials: true,
+				},
+			},
+			Materials: []material{},
+		},
+	}
+	if doc.Repository != "" {
+		out.Predicate.Invocation.ConfigSource.URI = "git+" + doc.Repository
+	}
+	if doc.Commit != "" {
+		out.Predicate.Invocation.ConfigSource.Digest = map[string]string{
+			"sha1": doc.Commit,
+		}
+	}
+
+	materials := append([]Artifact(nil), doc.Materials...)
+	sort.Slice(materials, func(i, j int) bool {
+		return materials[i].Name < materials[j].Name
+	})
+	for _, currMaterial := range materials {
+		out.Predicate.Materials = append(out.Predicate.Materials, material{
+			URI: currMaterial.Name,
+			Digest: map[string]string{
+				"sha256": currMaterial.SHA256,
+			},
+		})
+	}
+
+	bytes, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal provenance as JSON")
+	}
+	return append(bytes, '\n'), nil
+}
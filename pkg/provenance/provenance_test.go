@@ -0,0 +1,118 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provenance_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/provenance"
+)
+
+func TestInTotoStatement(t *testing.T) {
+	got, err := provenance.InTotoStatement(provenance.Document{
+		Subject: provenance.Artifact{
+			Name:   "foo",
+			SHA256: "aaaa",
+		},
+		BuilderID:  "https://ci.example.com",
+		Repository: "git@github.com:palantir/foo.git",
+		Commit:     "0123456789abcdef0123456789abcdef01234567",
+		EntryPoint: "./foo",
+		Parameters: map[string]interface{}{
+			"version": "1.0.0",
+		},
+		Environment: map[string]interface{}{
+			"dirty": false,
+		},
+		BuildStartedOn: time.Unix(1514764800, 0),
+		Materials: []provenance.Artifact{
+			{
+				Name:   "main.go",
+				SHA256: "cccc",
+			},
+			{
+				Name:   "go.mod",
+				SHA256: "bbbb",
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "_type": "https://in-toto.io/Statement/v0.1",
+  "subject": [
+    {
+      "name": "foo",
+      "digest": {
+        "sha256": "aaaa"
+      }
+    }
+  ],
+  "predicateType": "https://slsa.dev/provenance/v0.2",
+  "predicate": {
+    "builder": {
+      "id": "https://ci.example.com"
+    },
+    "buildType": "https://github.com/palantir/distgo/build@v1",
+    "invocation": {
+      "configSource": {
+        "uri": "git+git@github.com:palantir/foo.git",
+        "digest": {
+          "sha1": "0123456789abcdef0123456789abcdef01234567"
+        },
+        "entryPoint": "./foo"
+      },
+      "parameters": {
+        "version": "1.0.0"
+      },
+      "environment": {
+        "dirty": false
+      }
+    },
+    "metadata": {
+      "buildStartedOn": "2018-01-01T00:00:00Z",
+      "completeness": {
+        "parameters": false,
+        "environment": false,
+        "materials": true
+      },
+      "reproducible": false
+    },
+    "materials": [
+      {
+        "uri": "go.mod",
+        "digest": {
+          "sha256": "bbbb"
+        }
+      },
+      {
+        "uri": "main.go",
+        "digest": {
+          "sha256": "cccc"
+        }
+      }
+    ]
+  }
+}
+`, string(got))
+}
@@ -0,0 +1,116 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Module is a versioned collection of Go packages: a Go module or a project in a "Gopkg.lock" file.
+type Module struct {
+	// Path is the import path of the root of the module.
+	Path string
+	// Version is the version of the module. For projects in a "Gopkg.lock" file that do not have a version, this is the
+	// revision of the project. May be empty if the version is not known.
+	Version string
+	// Replaces is the path of the module that is replaced by this module using a "replace" directive if it differs from
+	// Path. The import paths of the packages of the module are relative to Replaces rather than Path.
+	Replaces string `json:",omitempty"`
+}
+
+// ReadGopkgLock returns the projects in the provided "Gopkg.lock" content as modules. The version of a project is its
+// "version" if specified and its "revision" otherwise.
+func ReadGopkgLock(r io.Reader) ([]Module, error) {
+	var modules []Module
+	var current *Module
+	var revision string
+	flush := func() {
+		if current != nil && current.Path != "" {
+			if current.Version == "" {
+				current.Version = revision
+			}
+			modules = append(modules, *current)
+		}
+		current = nil
+		revision = ""
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			flush()
+			if line == "[[projects]]" {
+				current = &Module{}
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		if key != "name" && key != "version" && key != "revision" {
+			continue
+		}
+		value, err := strconv.Unquote(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse value of %s in line %q", key, line)
+		}
+		switch key {
+		case "name":
+			current.Path = value
+		case "version":
+			current.Version = value
+		case "revision":
+			revision = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read Gopkg.lock")
+	}
+	flush()
+	return modules, nil
+}
+
+// ModuleForPackage returns the module in the provided modules whose path is the longest prefix of the provided import
+// path. Returns false if no module contains the package.
+func ModuleForPackage(modules []Module, importPath string) (Module, bool) {
+	var match Module
+	found := false
+	for _, module := range modules {
+		if importPath != module.Path && !strings.HasPrefix(importPath, module.Path+"/") {
+			continue
+		}
+		if !found || len(module.Path) > len(match.Path) {
+			match = module
+			found = true
+		}
+	}
+	return match, found
+}
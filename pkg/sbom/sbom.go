@@ -0,0 +1,354 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Document describes the content of a software bill of materials for a product.
+type Document struct {
+	// Name is the name of the product.
+	Name string
+	// Version is the version of the product.
+	Version string
+	// Created is the time at which the document was created.
+	Created time.Time
+	// Packages are the Go packages (other than the packages of the product itself) that are compiled into the product.
+	Packages []Package
+	// Artifacts are the artifacts produced for the product.
+	Artifacts []Artifact
+}
+
+// Package is a Go package along with the module that contains it.
+type Package struct {
+	ImportPath string
+	Module     Module
+}
+
+// PURL returns the package URL of the package, which is "pkg:golang/{{ModulePath}}@{{Version}}#{{SubPath}}". The
+// version is omitted if it is not known and the subpath is omitted if the package is the root of its module.
+func (p Package) PURL() string {
+	modulePath := p.Module.Path
+	if modulePath == "" {
+		modulePath = p.ImportPath
+	}
+	importRoot := modulePath
+	if p.Module.Replaces != "" {
+		importRoot = p.Module.Replaces
+	}
+	purl := "pkg:golang/" + modulePath
+	if p.Module.Version != "" {
+		purl += "@" + p.Module.Version
+	}
+	if subPath := strings.TrimPrefix(strings.TrimPrefix(p.ImportPath, importRoot), "/"); subPath != "" {
+		purl += "#" + subPath
+	}
+	return purl
+}
+
+// Artifact is a file produced for a product.
+type Artifact struct {
+	// Name is the file name of the artifact.
+	Name string
+	// Digests is a map from algorithm ("sha256" or "sha512") to the hex-encoded digest of the artifact.
+	Digests map[string]string
+}
+
+const (
+	creator = "distgo"
+
+	spdxVersion          = "SPDX-2.3"
+	cycloneDXSpecVersion = "1.4"
+)
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	DocumentDescribes []string           `json:"documentDescribes"`
+	Packages          []spdxPackage      `json:"packages"`
+	Files             []spdxFile         `json:"files,omitempty"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxFile struct {
+	SPDXID    string         `json:"SPDXID"`
+	FileName  string         `json:"fileName"`
+	Checksums []spdxChecksum `json:"checksums"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// SPDX returns the SPDX 2.3 JSON representation of the provided document.
+func SPDX(doc Document) ([]byte, error) {
+	const productID = "SPDXRef-Product"
+	namespaceHash, err := contentHash(doc)
+	if err != nil {
+		return nil, err
+	}
+	out := spdxDocument{
+		SPDXVersion:       spdxVersion,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              fmt.Sprintf("%s-%s", doc.Name, doc.Version),
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/%s-%s-%s", doc.Name, doc.Version, namespaceHash),
+		CreationInfo: spdxCreationInfo{
+			Created:  doc.Created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + creator},
+		},
+		DocumentDescribes: []string{productID},
+		Packages: []spdxPackage{
+			{
+				SPDXID:           productID,
+				Name:             doc.Name,
+				VersionInfo:      doc.Version,
+				DownloadLocation: "NOASSERTION",
+			},
+		},
+		Relationships: []spdxRelationship{
+			{
+				SPDXElementID:      "SPDXRef-DOCUMENT",
+				RelationshipType:   "DESCRIBES",
+				RelatedSPDXElement: productID,
+			},
+		},
+	}
+	for i, pkg := range sortedPackages(doc.Packages) {
+		pkgID := fmt.Sprintf("SPDXRef-Package-%d", i)
+		out.Packages = append(out.Packages, spdxPackage{
+			SPDXID:           pkgID,
+			Name:             pkg.ImportPath,
+			VersionInfo:      pkg.Module.Version,
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs: []spdxExternalRef{
+				{
+					ReferenceCategory: "PACKAGE-MANAGER",
+					ReferenceType:     "purl",
+					ReferenceLocator:  pkg.PURL(),
+				},
+			},
+		})
+		out.Relationships = append(out.Relationships, spdxRelationship{
+			SPDXElementID:      productID,
+			RelationshipType:   "DEPENDS_ON",
+			RelatedSPDXElement: pkgID,
+		})
+	}
+	for i, artifact := range doc.Artifacts {
+		fileID := fmt.Sprintf("SPDXRef-File-%d", i)
+		file := spdxFile{
+			SPDXID:   fileID,
+			FileName: artifact.Name,
+		}
+		for _, algorithm := range sortedKeys(artifact.Digests) {
+			file.Checksums = append(file.Checksums, spdxChecksum{
+				Algorithm:     strings.ToUpper(algorithm),
+				ChecksumValue: artifact.Digests[algorithm],
+			})
+		}
+		out.Files = append(out.Files, file)
+		out.Relationships = append(out.Relationships, spdxRelationship{
+			SPDXElementID:      productID,
+			RelationshipType:   "GENERATES",
+			RelatedSPDXElement: fileID,
+		})
+	}
+	return marshal(out)
+}
+
+type cycloneDXDocument struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	Version      int                   `json:"version"`
+	Metadata     cycloneDXMetadata     `json:"metadata"`
+	Components   []cycloneDXComponent  `json:"components"`
+	Dependencies []cycloneDXDependency `json:"dependencies"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     []cycloneDXTool    `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXTool struct {
+	Name string `json:"name"`
+}
+
+type cycloneDXComponent struct {
+	Type    string          `json:"type"`
+	BOMRef  string          `json:"bom-ref"`
+	Name    string          `json:"name"`
+	Version string          `json:"version,omitempty"`
+	PURL    string          `json:"purl,omitempty"`
+	Hashes  []cycloneDXHash `json:"hashes,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// CycloneDX returns the CycloneDX 1.4 JSON representation of the provided document.
+func CycloneDX(doc Document) ([]byte, error) {
+	productRef := fmt.Sprintf("%s@%s", doc.Name, doc.Version)
+	out := cycloneDXDocument{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: cycloneDXSpecVersion,
+		Version:     1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: doc.Created.UTC().Format(time.RFC3339),
+			Tools: []cycloneDXTool{
+				{Name: creator},
+			},
+			Component: cycloneDXComponent{
+				Type:    "application",
+				BOMRef:  productRef,
+				Name:    doc.Name,
+				Version: doc.Version,
+			},
+		},
+		Components: []cycloneDXComponent{},
+	}
+	dependsOn := []string{}
+	for _, pkg := range sortedPackages(doc.Packages) {
+		purl := pkg.PURL()
+		out.Components = append(out.Components, cycloneDXComponent{
+			Type:    "library",
+			BOMRef:  purl,
+			Name:    pkg.ImportPath,
+			Version: pkg.Module.Version,
+			PURL:    purl,
+		})
+		dependsOn = append(dependsOn, purl)
+	}
+	for _, artifact := range doc.Artifacts {
+		component := cycloneDXComponent{
+			Type:   "file",
+			BOMRef: "file:" + artifact.Name,
+			Name:   artifact.Name,
+		}
+		for _, algorithm := range sortedKeys(artifact.Digests) {
+			component.Hashes = append(component.Hashes, cycloneDXHash{
+				Alg:     cycloneDXAlgorithm(algorithm),
+				Content: artifact.Digests[algorithm],
+			})
+		}
+		out.Components = append(out.Components, component)
+	}
+	out.Dependencies = []cycloneDXDependency{
+		{
+			Ref:       productRef,
+			DependsOn: dependsOn,
+		},
+	}
+	return marshal(out)
+}
+
+// cycloneDXAlgorithm returns the CycloneDX name of the provided algorithm: for example, "SHA-256" for "sha256".
+func cycloneDXAlgorithm(algorithm string) string {
+	upper := strings.ToUpper(algorithm)
+	if strings.HasPrefix(upper, "SHA") && !strings.HasPrefix(upper, "SHA-") {
+		return "SHA-" + strings.TrimPrefix(upper, "SHA")
+	}
+	return upper
+}
+
+// contentHash returns a hash of the content of the provided document that does not depend on its creation time.
+func contentHash(doc Document) (string, error) {
+	doc.Created = time.Time{}
+	bytes, err := json.Marshal(doc)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal document")
+	}
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+func sortedPackages(pkgs []Package) []Package {
+	sorted := append([]Package{}, pkgs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ImportPath < sorted[j].ImportPath
+	})
+	return sorted
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	bytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal SBOM as JSON")
+	}
+	return append(bytes, '\n'), nil
+}
@@ -0,0 +1,292 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sniperkit/snk.fork.palantir-distgo/pkg/sbom"
+)
+
+func TestReadGopkgLock(t *testing.T) {
+	modules, err := sbom.ReadGopkgLock(strings.NewReader(`# This file is autogenerated, do not edit; changes may be undone by the next 'dep ensure'.
+
+
+[[projects]]
+  name = "github.com/pkg/errors"
+  packages = ["."]
+  revision = "645ef00459ed84a119197bfb8d8205042c6df63d"
+  version = "v0.8.0"
+
+[[projects]]
+  branch = "master"
+  name = "golang.org/x/sys"
+  packages = ["unix"]
+  revision = "c4489faa6e5ab84c0ef40d6ee878f7a030281f0f"
+
+[solve-meta]
+  analyzer-name = "dep"
+  inputs-digest = "8b7d0fb0b3a8e6b0e5b4e0d3a2e0f1f6e7d8c9b0a1b2c3d4e5f6a7b8c9d0e1f2"
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []sbom.Module{
+		{Path: "github.com/pkg/errors", Version: "v0.8.0"},
+		{Path: "golang.org/x/sys", Version: "c4489faa6e5ab84c0ef40d6ee878f7a030281f0f"},
+	}, modules)
+}
+
+func TestModuleForPackage(t *testing.T) {
+	modules := []sbom.Module{
+		{Path: "github.com/org/repo", Version: "v1.0.0"},
+		{Path: "github.com/org/repo/sub", Version: "v2.0.0"},
+		{Path: "github.com/org/other", Version: "v3.0.0"},
+	}
+	for i, tc := range []struct {
+		importPath string
+		want       sbom.Module
+		wantOK     bool
+	}{
+		{"github.com/org/repo", modules[0], true},
+		{"github.com/org/repo/pkg", modules[0], true},
+		{"github.com/org/repo/sub/pkg", modules[1], true},
+		{"github.com/org/repository", sbom.Module{}, false},
+	} {
+		got, ok := sbom.ModuleForPackage(modules, tc.importPath)
+		assert.Equal(t, tc.wantOK, ok, "Case %d", i)
+		assert.Equal(t, tc.want, got, "Case %d", i)
+	}
+}
+
+func TestPackagePURL(t *testing.T) {
+	for i, tc := range []struct {
+		pkg  sbom.Package
+		want string
+	}{
+		{
+			sbom.Package{ImportPath: "github.com/org/repo", Module: sbom.Module{Path: "github.com/org/repo", Version: "v1.0.0"}},
+			"pkg:golang/github.com/org/repo@v1.0.0",
+		},
+		{
+			sbom.Package{ImportPath: "github.com/org/repo/sub/pkg", Module: sbom.Module{Path: "github.com/org/repo"}},
+			"pkg:golang/github.com/org/repo#sub/pkg",
+		},
+		{
+			sbom.Package{ImportPath: "github.com/org/repo/pkg", Module: sbom.Module{Path: "github.com/fork/repo", Version: "v1.1.0", Replaces: "github.com/org/repo"}},
+			"pkg:golang/github.com/fork/repo@v1.1.0#pkg",
+		},
+	} {
+		assert.Equal(t, tc.want, tc.pkg.PURL(), "Case %d", i)
+	}
+}
+
+var testDocument = sbom.Document{
+	Name:    "foo",
+	Version: "1.0.0",
+	Created: time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC),
+	Packages: []sbom.Package{
+		{ImportPath: "golang.org/x/sys/unix", Module: sbom.Module{Path: "golang.org/x/sys", Version: "c4489faa6e5a"}},
+		{ImportPath: "github.com/pkg/errors", Module: sbom.Module{Path: "github.com/pkg/errors", Version: "v0.8.0"}},
+	},
+	Artifacts: []sbom.Artifact{
+		{Name: "foo-1.0.0.tgz", Digests: map[string]string{"sha256": "aaaa", "sha512": "bbbb"}},
+	},
+}
+
+func TestSPDX(t *testing.T) {
+	got, err := sbom.SPDX(testDocument)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	err = json.Unmarshal(got, &doc)
+	require.NoError(t, err)
+	namespace := doc["documentNamespace"].(string)
+	assert.True(t, strings.HasPrefix(namespace, "https://spdx.org/spdxdocs/foo-1.0.0-"), namespace)
+
+	assert.Equal(t, `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "foo-1.0.0",
+  "documentNamespace": "`+namespace+`",
+  "creationInfo": {
+    "created": "2018-06-01T12:00:00Z",
+    "creators": [
+      "Tool: distgo"
+    ]
+  },
+  "documentDescribes": [
+    "SPDXRef-Product"
+  ],
+  "packages": [
+    {
+      "SPDXID": "SPDXRef-Product",
+      "name": "foo",
+      "versionInfo": "1.0.0",
+      "downloadLocation": "NOASSERTION",
+      "filesAnalyzed": false
+    },
+    {
+      "SPDXID": "SPDXRef-Package-0",
+      "name": "github.com/pkg/errors",
+      "versionInfo": "v0.8.0",
+      "downloadLocation": "NOASSERTION",
+      "filesAnalyzed": false,
+      "externalRefs": [
+        {
+          "referenceCategory": "PACKAGE-MANAGER",
+          "referenceType": "purl",
+          "referenceLocator": "pkg:golang/github.com/pkg/errors@v0.8.0"
+        }
+      ]
+    },
+    {
+      "SPDXID": "SPDXRef-Package-1",
+      "name": "golang.org/x/sys/unix",
+      "versionInfo": "c4489faa6e5a",
+      "downloadLocation": "NOASSERTION",
+      "filesAnalyzed": false,
+      "externalRefs": [
+        {
+          "referenceCategory": "PACKAGE-MANAGER",
+          "referenceType": "purl",
+          "referenceLocator": "pkg:golang/golang.org/x/sys@c4489faa6e5a#unix"
+        }
+      ]
+    }
+  ],
+  "files": [
+    {
+      "SPDXID": "SPDXRef-File-0",
+      "fileName": "foo-1.0.0.tgz",
+      "checksums": [
+        {
+          "algorithm": "SHA256",
+          "checksumValue": "aaaa"
+        },
+        {
+          "algorithm": "SHA512",
+          "checksumValue": "bbbb"
+        }
+      ]
+    }
+  ],
+  "relationships": [
+    {
+      "spdxElementId": "SPDXRef-DOCUMENT",
+      "relationshipType": "DESCRIBES",
+      "relatedSpdxElement": "SPDXRef-Product"
+    },
+    {
+      "spdxElementId": "SPDXRef-Product",
+      "relationshipType": "DEPENDS_ON",
+      "relatedSpdxElement": "SPDXRef-Package-0"
+    },
+    {
+      "spdxElementId": "SPDXRef-Product",
+      "relationshipType": "DEPENDS_ON",
+      "relatedSpdxElement": "SPDXRef-Package-1"
+    },
+    {
+      "spdxElementId": "SPDXRef-Product",
+      "relationshipType": "GENERATES",
+      "relatedSpdxElement": "SPDXRef-File-0"
+    }
+  ]
+}
+`, string(got))
+
+	// namespace does not depend on the creation time
+	laterDocument := testDocument
+	laterDocument.Created = laterDocument.Created.Add(time.Hour)
+	laterBytes, err := sbom.SPDX(laterDocument)
+	require.NoError(t, err)
+	assert.Contains(t, string(laterBytes), namespace)
+}
+
+func TestCycloneDX(t *testing.T) {
+	got, err := sbom.CycloneDX(testDocument)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.4",
+  "version": 1,
+  "metadata": {
+    "timestamp": "2018-06-01T12:00:00Z",
+    "tools": [
+      {
+        "name": "distgo"
+      }
+    ],
+    "component": {
+      "type": "application",
+      "bom-ref": "foo@1.0.0",
+      "name": "foo",
+      "version": "1.0.0"
+    }
+  },
+  "components": [
+    {
+      "type": "library",
+      "bom-ref": "pkg:golang/github.com/pkg/errors@v0.8.0",
+      "name": "github.com/pkg/errors",
+      "version": "v0.8.0",
+      "purl": "pkg:golang/github.com/pkg/errors@v0.8.0"
+    },
+    {
+      "type": "library",
+      "bom-ref": "pkg:golang/golang.org/x/sys@c4489faa6e5a#unix",
+      "name": "golang.org/x/sys/unix",
+      "version": "c4489faa6e5a",
+      "purl": "pkg:golang/golang.org/x/sys@c4489faa6e5a#unix"
+    },
+    {
+      "type": "file",
+      "bom-ref": "file:foo-1.0.0.tgz",
+      "name": "foo-1.0.0.tgz",
+      "hashes": [
+        {
+          "alg": "SHA-256",
+          "content": "aaaa"
+        },
+        {
+          "alg": "SHA-512",
+          "content": "bbbb"
+        }
+      ]
+    }
+  ],
+  "dependencies": [
+    {
+      "ref": "foo@1.0.0",
+      "dependsOn": [
+        "pkg:golang/github.com/pkg/errors@v0.8.0",
+        "pkg:golang/golang.org/x/sys@c4489faa6e5a#unix"
+      ]
+    }
+  ]
+}
+`, string(got))
+}